@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/internal/blob"
+	"payment-gateway-service/internal/dispute"
+	"text/tabwriter"
+	"time"
+)
+
+const disputesUsage = `Usage: main disputes <command>
+
+Commands:
+  expire    Mark the disputes still OPEN after their evidence deadline as LOST and debit them from the
+            ledger, meant to run every few minutes from cron`
+
+// runDisputes runs the disputes command
+func runDisputes(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(disputesUsage)
+	}
+
+	switch args[0] {
+	case "expire":
+		if len(args) != 1 {
+			exitWithUsage(disputesUsage)
+		}
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		service := dispute.NewDisputeService(db, blob.NewLocalStore(cfg.BlobDir), cfg.DisputeEvidenceWindowDuration, cfg.ChargebackSecrets)
+		disputes, err := service.ExpireOverdue(context.Background(), time.Now())
+		if err != nil {
+			log.Fatalf("Failed to expire disputes: %v", err)
+		}
+		printDisputes(disputes)
+		fmt.Printf("Lost %d dispute(s)\n", len(disputes))
+	default:
+		exitWithUsage(disputesUsage)
+	}
+}
+
+// printDisputes prints disputes as a table
+func printDisputes(disputes []dispute.Dispute) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMERCHANT\tPAYMENT\tSTATUS\tAMOUNT\tCURRENCY\tEVIDENCE DUE")
+	for _, d := range disputes {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%.2f\t%s\t%s\n", d.ID, d.MerchantID, d.PaymentID, d.Status, d.Amount, d.CurrencyCode, d.EvidenceDueAt.Format(time.RFC3339))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/internal/fx"
+	"text/tabwriter"
+	"time"
+)
+
+const fxUsage = `Usage: main fx <command>
+
+Commands:
+  sync [-file path]  Save the rates of the rates file, FX_RATES_FILE by default
+  rates              List the latest rate of every currency pair`
+
+// runFX runs the fx command
+func runFX(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(fxUsage)
+	}
+
+	switch args[0] {
+	case "sync":
+		flags := flag.NewFlagSet("fx sync", flag.ExitOnError)
+		file := flags.String("file", "", "rates file to sync instead of FX_RATES_FILE")
+		_ = flags.Parse(args[1:])
+
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		path := *file
+		if path == "" {
+			path = cfg.FXRatesFile
+		}
+		if path == "" {
+			log.Fatal("No rates file: set FX_RATES_FILE or pass -file")
+		}
+
+		count, err := fx.NewFXService(db, cfg.FXRateMaxAgeDuration).SyncRates(context.Background(), fx.NewFileRateSource(path))
+		if err != nil {
+			log.Fatalf("Failed to sync rates: %v", err)
+		}
+		fmt.Printf("Synced %d rate(s) from %s\n", count, path)
+	case "rates":
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		rates, err := fx.NewFXService(db, cfg.FXRateMaxAgeDuration).ListRates(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to list rates: %v", err)
+		}
+
+		// Rates older than FX_RATE_MAX_AGE are not used to convert payments
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FROM\tTO\tRATE\tSOURCE\tRATE AT\tSTALE")
+		for _, r := range rates {
+			stale := time.Since(r.RateAt) > cfg.FXRateMaxAgeDuration()
+			fmt.Fprintf(w, "%s\t%s\t%.8f\t%s\t%s\t%t\n", r.FromCurrency, r.ToCurrency, r.Rate, r.Source, r.RateAt.Format(time.RFC3339), stale)
+		}
+		_ = w.Flush()
+	default:
+		exitWithUsage(fxUsage)
+	}
+}
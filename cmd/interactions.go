@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"payment-gateway-service/internal/interaction"
+	"time"
+)
+
+const interactionsUsage = `Usage: main interactions <command>
+
+Commands:
+  list <payment id>          Show the provider interactions of a payment as JSON
+  purge [-older-than 2160h]  Delete the provider interactions older than INTERACTION_RETENTION or the given age`
+
+// runInteractions runs the interactions command
+func runInteractions(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(interactionsUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) != 2 {
+			exitWithUsage(interactionsUsage)
+		}
+		_, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		interactions, err := interaction.NewInteractionService(db).ListForPayment(context.Background(), args[1])
+		if err != nil {
+			log.Fatalf("Failed to list interactions: %v", err)
+		}
+		printJSON(interactions)
+	case "purge":
+		flags := flag.NewFlagSet("interactions purge", flag.ExitOnError)
+		olderThan := flags.Duration("older-than", 0, "delete interactions recorded longer ago than this instead of INTERACTION_RETENTION")
+		_ = flags.Parse(args[1:])
+
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		retention := *olderThan
+		if retention <= 0 {
+			retention = cfg.InteractionRetentionDuration()
+		}
+
+		deleted, err := interaction.NewInteractionService(db).DeleteOlderThan(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			log.Fatalf("Failed to purge interactions: %v", err)
+		}
+		fmt.Printf("Deleted %d interaction(s) older than %s\n", deleted, retention)
+	default:
+		exitWithUsage(interactionsUsage)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/batch"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/ratelimit"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// rateLimitPruneInterval is how often the unused rate limit buckets are deleted
+	rateLimitPruneInterval = time.Hour
+
+	// interactionPurgeInterval is how often the provider interactions past INTERACTION_RETENTION are deleted
+	interactionPurgeInterval = time.Hour
+
+	// authorizationVoidInterval is how often the authorizations past CAPTURE_DEADLINE are voided
+	authorizationVoidInterval = 5 * time.Minute
+
+	// batchResumeInterval is how often the payout batches interrupted by a restart are looked for
+	batchResumeInterval = time.Minute
+)
+
+// startJobs starts the maintenance jobs of the server in the background until ctx is done. Every replica
+// runs them, so each job must be safe to run concurrently.
+func startJobs(ctx context.Context, db *gorm.DB, configStore *config.Store, rateLimitStore ratelimit.Store) {
+	runEvery(ctx, "rate limit pruning", rateLimitPruneInterval, func(ctx context.Context) error {
+		// A bucket unused for longer than the longest period is full, the same as a missing one
+		deleted, err := rateLimitStore.Prune(ctx, time.Now().Add(-configStore.Current().RateLimitMaxPeriod()))
+		if err == nil && deleted > 0 {
+			log.Printf("Deleted %d unused rate limit bucket(s)", deleted)
+		}
+		return err
+	})
+
+	interactions := interaction.NewInteractionService(db)
+	runEvery(ctx, "interaction purge", interactionPurgeInterval, func(ctx context.Context) error {
+		retention := configStore.Current().InteractionRetentionDuration()
+		deleted, err := interactions.DeleteOlderThan(ctx, time.Now().Add(-retention))
+		if err == nil && deleted > 0 {
+			log.Printf("Deleted %d interaction(s) older than %s", deleted, retention)
+		}
+		return err
+	})
+
+	// Voiding claims each payment first, so replicas never void the same authorization twice
+	payments := newPaymentService(db, configStore.Current())
+	runEvery(ctx, "authorization void", authorizationVoidInterval, func(ctx context.Context) error {
+		deadline := configStore.Current().CaptureDeadlineDuration()
+		voided, err := payments.VoidExpiredAuthorizations(ctx, time.Now().Add(-deadline))
+		if err == nil && len(voided) > 0 {
+			log.Printf("Voided %d authorization(s) older than %s", len(voided), deadline)
+		}
+		return err
+	})
+
+	// Batches are claimed before they are processed, so replicas only take over the batches of stopped
+	// instances once their claim expired
+	cipher, _ := beneficiary.NewCipher(configStore.Current().BeneficiaryEncryptionKeyBytes())
+	batches := batch.NewBatchService(db, cipher, payments)
+	resumeBatches := func(ctx context.Context) error {
+		if err := batches.ResumeInterrupted(ctx); !errors.Is(err, batch.ErrBatchesNotConfigured) {
+			return err
+		}
+		return nil
+	}
+	// Batches interrupted by a restart carry on right away
+	go func() {
+		if err := resumeBatches(ctx); err != nil {
+			log.Printf("Background job payout batch resumption failed: %v", err)
+		}
+	}()
+	runEvery(ctx, "payout batch resumption", batchResumeInterval, resumeBatches)
+}
+
+// runEvery runs a job every interval until ctx is done. A failed run is logged and the job runs again at
+// the next interval.
+func runEvery(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil {
+					log.Printf("Background job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
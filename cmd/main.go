@@ -1,81 +1,72 @@
 package main
 
 import (
-	"context"
-	"log"
-	"net/http"
+	"fmt"
 	"os"
-	"os/signal"
-	"payment-gateway-service/config"
 	_ "payment-gateway-service/docs"
-	"payment-gateway-service/internal/database"
-	"payment-gateway-service/internal/middleware"
-	"payment-gateway-service/internal/routes"
-	"syscall"
-	"time"
-
-	"github.com/gin-gonic/gin"
 )
 
-func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
-
-	// Connect to the database with GORM
-	db, err := database.ConnectPostgres(cfg.DatabaseURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-
-	// Ensure the database connection is closed when the program exits
-	sqlDB, err := db.DB()
-	if err != nil {
-		log.Fatalf("Failed to get database object: %v", err)
-	}
-	defer sqlDB.Close()
-
-	// Initialize the Gin engine
-	router := gin.Default()
-
-	// Apply the RequestIDMiddleware globally
-	router.Use(middleware.RequestIDMiddleware())
+const usage = `Usage: main [command] [arguments]
 
-	// Register routes with the gorm.DB instance
-	routes.RegisterRoutes(router, db)
+Commands:
+  serve                     Start the HTTP server (default when no command is given)
+  migrate up|down|status    Manage the database schema
+  seed                      Create a merchant and issue an API key for it
+  operators create-key <name>
+                            Issue an operator key for the admin routes
+  payments get <id>         Show a payment
+  payments list             List payments
+  payments expire           Expire payments that never completed
+  payments poll-payouts     Complete the pending payouts the providers settled
+  payments void-authorizations
+                            Void the deposit authorizations past the capture deadline
+  providers list            List the provider routing configurations
+  providers test            Check that the configured providers are reachable
+  reconcile run             Report payment statuses per provider and stale pending payments
+  fx sync                   Save the exchange rates of the rates file
+  fx rates                  List the exchange rates
+  interactions list <id>    Show the provider interactions of a payment
+  interactions purge        Delete the provider interactions past their retention
+  subscriptions run         Charge the subscriptions that are due
+  disputes expire           Mark the disputes past their evidence deadline as LOST
 
-	// Construct the address with port
-	address := ":" + cfg.PORT
+Run "main <command> -h" for the options of a command.`
 
-	// Print the address to the logs
-	log.Printf("Starting server on %s", address)
+func main() {
+	args := os.Args[1:]
 
-	// Server settings
-	srv := &http.Server{
-		Addr:              address,
-		Handler:           router,
-		ReadHeaderTimeout: 5 * time.Second,
+	// Serve when started without a command, as in the Docker image
+	if len(args) == 0 {
+		args = []string{"serve"}
 	}
 
-	// Start the server in a goroutine so it doesn’t block
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s\n", err)
-		}
-	}()
-
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
-
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+	switch args[0] {
+	case "serve":
+		runServe(args[1:])
+	case "migrate":
+		runMigrate(args[1:])
+	case "seed":
+		runSeed(args[1:])
+	case "operators":
+		runOperators(args[1:])
+	case "payments":
+		runPayments(args[1:])
+	case "providers":
+		runProviders(args[1:])
+	case "reconcile":
+		runReconcile(args[1:])
+	case "fx":
+		runFX(args[1:])
+	case "interactions":
+		runInteractions(args[1:])
+	case "subscriptions":
+		runSubscriptions(args[1:])
+	case "disputes":
+		runDisputes(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s\n", args[0], usage)
+		os.Exit(2)
 	}
-
-	log.Println("Server exiting")
 }
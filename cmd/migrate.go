@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"payment-gateway-service/internal/database"
+	"strconv"
+)
+
+const migrateUsage = `Usage: main migrate <command>
+
+Commands:
+  up          Apply all pending migrations
+  down [N]    Roll back the last N migrations (default 1)
+  status      List the migrations and whether they are applied`
+
+// runMigrate runs the migrate subcommand with the embedded migrations
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(migrateUsage)
+	}
+
+	// Load configuration and connect to the database
+	_, _, sqlDB := setup()
+	defer sqlDB.Close()
+
+	migrator, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("Applied %d migration(s)", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid number of migrations to roll back: %s", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		log.Printf("Rolled back %d migration(s)", rolledBack)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read schema version: %v", err)
+		}
+		fmt.Printf("Schema version: %d (dirty: %t), latest: %d\n", version, dirty, migrator.LatestVersion())
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("  %06d_%-50s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		exitWithUsage(migrateUsage)
+	}
+}
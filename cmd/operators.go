@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/internal/operator"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const operatorsUsage = `Usage: main operators <command>
+
+Commands:
+  create-key <name>    Issue an operator key for the admin routes, printed once
+  revoke-key <id>      Revoke an operator key
+  list-keys            List the operator keys`
+
+// runOperators runs the operators command
+func runOperators(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(operatorsUsage)
+	}
+
+	switch args[0] {
+	case "create-key":
+		if len(args) != 2 {
+			exitWithUsage(operatorsUsage)
+		}
+		_, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		rawKey, apiKey, err := operator.NewOperatorService(db).CreateKey(context.Background(), args[1])
+		if err != nil {
+			log.Fatalf("Failed to create operator key: %v", err)
+		}
+
+		// The key is only shown once, it cannot be recovered from the database
+		fmt.Printf("Operator key ID: %d\n", apiKey.ID)
+		fmt.Printf("Operator key:    %s\n", rawKey)
+	case "revoke-key":
+		if len(args) != 2 {
+			exitWithUsage(operatorsUsage)
+		}
+		keyID, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			exitWithUsage(operatorsUsage)
+		}
+		_, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		if err := operator.NewOperatorService(db).RevokeKey(context.Background(), uint(keyID)); err != nil {
+			log.Fatalf("Failed to revoke operator key: %v", err)
+		}
+		fmt.Printf("Revoked operator key %d\n", keyID)
+	case "list-keys":
+		if len(args) != 1 {
+			exitWithUsage(operatorsUsage)
+		}
+		_, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		keys, err := operator.NewOperatorService(db).ListKeys(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to list operator keys: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tCREATED\tREVOKED")
+		for _, k := range keys {
+			revoked := ""
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.KeyPrefix, k.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		_ = w.Flush()
+	default:
+		exitWithUsage(operatorsUsage)
+	}
+}
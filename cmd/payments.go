@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/fx"
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/routing"
+	"payment-gateway-service/internal/savedmethod"
+	"payment-gateway-service/internal/utils"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const paymentsUsage = `Usage: main payments <command>
+
+Commands:
+  get <id>                                   Show a payment as JSON
+  list [-merchant ID] [-status S] [-limit N] [-reference R] [-needs-review]
+                                             List payments, newest first
+  expire -older-than 24h [-dry-run]          Mark INITIALIZED and PENDING payments older than the given age as EXPIRED, except payouts
+  poll-payouts [-pending-for 5m]             Ask the providers for the status of payouts pending for longer than the given time
+  void-authorizations                        Void the authorizations not captured before CAPTURE_DEADLINE`
+
+// runPayments runs the payments command
+func runPayments(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(paymentsUsage)
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) != 2 {
+			exitWithUsage(paymentsUsage)
+		}
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		payments, err := newPaymentService(db, cfg).ListPayments(context.Background(), payment.PaymentFilter{ID: args[1]})
+		if err != nil {
+			log.Fatalf("Failed to get payment: %v", err)
+		}
+		if len(payments) == 0 {
+			log.Fatalf("Payment %s not found", args[1])
+		}
+		printJSON(payments[0])
+	case "list":
+		flags := flag.NewFlagSet("payments list", flag.ExitOnError)
+		merchantID := flags.Uint("merchant", 0, "only list payments of this merchant ID")
+		status := flags.String("status", "", "only list payments in this status")
+		limit := flags.Int("limit", 50, "maximum number of payments listed")
+		reference := flags.String("reference", "", "only list the payment with this merchant reference")
+		needsReview := flags.Bool("needs-review", false, "only list payments flagged for manual review")
+		_ = flags.Parse(args[1:])
+
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		payments, err := newPaymentService(db, cfg).ListPayments(context.Background(), payment.PaymentFilter{
+			MerchantID:        *merchantID,
+			Status:            utils.PaymentStatus(strings.ToUpper(*status)),
+			MerchantReference: *reference,
+			NeedsReview:       *needsReview,
+			Limit:             *limit,
+		})
+		if err != nil {
+			log.Fatalf("Failed to list payments: %v", err)
+		}
+		printPayments(payments)
+	case "expire":
+		flags := flag.NewFlagSet("payments expire", flag.ExitOnError)
+		olderThan := flags.Duration("older-than", 24*time.Hour, "expire payments created longer ago than this")
+		dryRun := flags.Bool("dry-run", false, "only list the payments that would be expired")
+		_ = flags.Parse(args[1:])
+
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		payments, err := newPaymentService(db, cfg).ExpirePayments(context.Background(), time.Now().Add(-*olderThan), *dryRun)
+		if err != nil {
+			log.Fatalf("Failed to expire payments: %v", err)
+		}
+		printPayments(payments)
+		if *dryRun {
+			fmt.Printf("%d payment(s) would be expired\n", len(payments))
+		} else {
+			fmt.Printf("Expired %d payment(s)\n", len(payments))
+		}
+	case "poll-payouts":
+		flags := flag.NewFlagSet("payments poll-payouts", flag.ExitOnError)
+		pendingFor := flags.Duration("pending-for", 5*time.Minute, "only poll payouts pending for longer than this")
+		_ = flags.Parse(args[1:])
+
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		payments, err := newPaymentService(db, cfg).PollPayouts(context.Background(), time.Now().Add(-*pendingFor))
+		if err != nil {
+			log.Fatalf("Failed to poll payouts: %v", err)
+		}
+		printPayments(payments)
+		fmt.Printf("Completed %d payout(s)\n", len(payments))
+	case "void-authorizations":
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		payments, err := newPaymentService(db, cfg).VoidExpiredAuthorizations(context.Background(), time.Now().Add(-cfg.CaptureDeadlineDuration()))
+		if err != nil {
+			log.Fatalf("Failed to void authorizations: %v", err)
+		}
+		printPayments(payments)
+		fmt.Printf("Voided %d authorization(s)\n", len(payments))
+	default:
+		exitWithUsage(paymentsUsage)
+	}
+}
+
+// newPaymentService builds a PaymentService the same way the HTTP handler does
+func newPaymentService(db *gorm.DB, cfg *config.Config) *payment.PaymentService {
+	providerSvc := provider.NewProviderService(db)
+	adapterFactory := provider.NewAdapterFactory(providerSvc, cfg.ProviderCredentials(), cfg.ProviderTimeoutDuration, nil, interaction.NewInteractionService(db))
+	fxSvc := fx.NewFXService(db, cfg.FXRateMaxAgeDuration)
+	cipher, _ := beneficiary.NewCipher(cfg.BeneficiaryEncryptionKeyBytes())
+	return payment.NewPaymentService(db, providerSvc, adapterFactory, routing.NewEngine(routing.NewRoutingService(db), nil), fxSvc, beneficiary.NewBeneficiaryService(db, cipher), savedmethod.NewSavedMethodService(db))
+}
+
+// printPayments prints payments as a table
+func printPayments(payments []payment.Payment) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMERCHANT\tTYPE\tSTATUS\tAMOUNT\tCURRENCY\tPROVIDER\tCREATED")
+	for _, p := range payments {
+		status := string(p.Status)
+		if p.NeedsReview {
+			status += " (needs review)"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%.2f\t%s\t%s\t%s\n",
+			p.ID, p.MerchantID, p.PaymentType, status, p.Amount, p.CurrencyCode, p.Provider.Name, p.CreatedAt.Format(time.RFC3339))
+	}
+	_ = w.Flush()
+}
+
+// printJSON prints a value as indented JSON
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Fatalf("Failed to encode output: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/internal/provider"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const providersUsage = `Usage: main providers <command>
+
+Commands:
+  list                          List the provider routing configurations
+  test [-timeout 5s] [provider] Check that the base URL of every configuration, or of one provider, is reachable`
+
+// runProviders runs the providers command
+func runProviders(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(providersUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		_, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		configs, err := provider.NewProviderService(db).ListProviderConfigs(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to list provider configurations: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPROVIDER\tCOUNTRY ID\tCURRENCY ID\tPRIORITY\tWEIGHT\tBASE URL")
+		for _, c := range configs {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%s\n", c.ID, c.ProviderName, c.CountryID, c.CurrencyID, c.Priority, c.Weight, c.BaseURL)
+		}
+		_ = w.Flush()
+	case "test":
+		flags := flag.NewFlagSet("providers test", flag.ExitOnError)
+		timeout := flags.Duration("timeout", 5*time.Second, "timeout of each check")
+		_ = flags.Parse(args[1:])
+		name := flags.Arg(0)
+
+		_, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		configs, err := provider.NewProviderService(db).ListProviderConfigs(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to list provider configurations: %v", err)
+		}
+
+		// Several configurations usually share a base URL, check each one once
+		checked := make(map[string]bool)
+		failed := 0
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROVIDER\tBASE URL\tSTATUS\tLATENCY")
+		for _, c := range configs {
+			if name != "" && !strings.EqualFold(c.ProviderName, name) {
+				continue
+			}
+			if checked[c.BaseURL] {
+				continue
+			}
+			checked[c.BaseURL] = true
+
+			statusCode, latency, err := provider.CheckReachability(context.Background(), c.BaseURL, *timeout)
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "%s\t%s\tunreachable: %v\t%s\n", c.ProviderName, c.BaseURL, err, latency.Round(time.Millisecond))
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", c.ProviderName, c.BaseURL, statusCode, latency.Round(time.Millisecond))
+		}
+		_ = w.Flush()
+
+		if len(checked) == 0 {
+			log.Fatalf("No provider configurations found for %q", name)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	default:
+		exitWithUsage(providersUsage)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const reconcileUsage = `Usage: main reconcile run [-since 24h] [-stale-after 1h] [-json]
+
+Counts the payments created in the last -since per provider and status, and lists the
+PENDING payments that have been waiting for a provider callback for longer than -stale-after.`
+
+// runReconcile runs the reconcile command
+func runReconcile(args []string) {
+	if len(args) == 0 || args[0] != "run" {
+		exitWithUsage(reconcileUsage)
+	}
+
+	flags := flag.NewFlagSet("reconcile run", flag.ExitOnError)
+	since := flags.Duration("since", 24*time.Hour, "report on payments created within this period")
+	staleAfter := flags.Duration("stale-after", time.Hour, "age after which a PENDING payment is reported as stale")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	_ = flags.Parse(args[1:])
+
+	cfg, db, sqlDB := setup()
+	defer sqlDB.Close()
+
+	report, err := newPaymentService(db, cfg).Reconcile(context.Background(), time.Now().Add(-*since), *staleAfter)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if *asJSON {
+		printJSON(report)
+		return
+	}
+
+	fmt.Printf("Payments created since %s\n\n", report.Since.Format(time.RFC3339))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tSTATUS\tCOUNT")
+	for _, count := range report.StatusCounts {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", count.ProviderName, count.Status, count.Count)
+	}
+	_ = w.Flush()
+
+	fmt.Printf("\n%d payment(s) pending for longer than %s\n", len(report.StalePayments), *staleAfter)
+	if len(report.StalePayments) > 0 {
+		fmt.Println()
+		printPayments(report.StalePayments)
+	}
+}
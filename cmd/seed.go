@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"payment-gateway-service/internal/merchant"
+	"strings"
+)
+
+// runSeed runs the seed command, creating a merchant if needed and issuing an API key for it
+func runSeed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	merchantName := flags.String("merchant", "Default Merchant", "name of the merchant, created if it does not exist")
+	keyName := flags.String("key-name", "bootstrap", "name of the API key")
+	scopeList := flags.String("scopes", "payments:deposit,payments:withdrawal,payments:read", "comma separated scopes granted to the key")
+	authMode := flags.String("auth-mode", string(merchant.AuthModeToken), "authentication mode of the key (token or hmac)")
+	_ = flags.Parse(args)
+
+	scopes, err := merchant.ParseScopes(strings.Split(*scopeList, ","))
+	if err != nil {
+		log.Fatalf("Invalid scopes: %v", err)
+	}
+
+	// Load configuration and connect to the database
+	_, db, sqlDB := setup()
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	merchantSvc := merchant.NewMerchantService(db)
+
+	m, err := merchantSvc.FindOrCreateMerchant(ctx, *merchantName)
+	if err != nil {
+		log.Fatalf("Failed to create merchant: %v", err)
+	}
+
+	rawKey, apiKey, err := merchantSvc.CreateAPIKey(ctx, m.ID, *keyName, scopes, merchant.AuthMode(*authMode), nil)
+	if err != nil {
+		log.Fatalf("Failed to create API key: %v", err)
+	}
+
+	// The key is only shown once, it cannot be recovered from the database
+	fmt.Printf("Merchant:       %s (ID %d)\n", m.Name, m.ID)
+	fmt.Printf("API key ID:     %d\n", apiKey.ID)
+	fmt.Printf("API key:        %s\n", rawKey)
+	if apiKey.AuthMode == merchant.AuthModeHMAC {
+		fmt.Printf("Signing secret: %s\n", apiKey.SigningSecret)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/database"
+	"payment-gateway-service/internal/middleware"
+	"payment-gateway-service/internal/ratelimit"
+	"payment-gateway-service/internal/routes"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runServe runs the serve command, starting the HTTP server
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	autoMigrate := flags.Bool("auto-migrate", false, "apply pending migrations before starting (same as AUTO_MIGRATE=true)")
+	_ = flags.Parse(args)
+
+	// Load configuration and connect to the database
+	cfg, db, sqlDB := setup()
+
+	// Ensure the database connection is closed when the program exits
+	defer sqlDB.Close()
+
+	// Apply pending migrations if enabled, and refuse to start on an outdated schema
+	migrator, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if cfg.AutoMigrate || *autoMigrate {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		log.Printf("Applied %d migration(s)", applied)
+	}
+	if err := migrator.CheckVersion(context.Background()); err != nil {
+		log.Fatalf("Database schema check failed: %v", err)
+	}
+
+	// Initialize the Gin engine
+	router := gin.Default()
+
+	// Apply the RequestIDMiddleware globally
+	router.Use(middleware.RequestIDMiddleware())
+
+	// Map errors attached by handlers to API error responses
+	router.Use(middleware.ErrorHandlerMiddleware())
+
+	// Rate limits are shared between replicas unless the memory store is configured
+	var rateLimitStore ratelimit.Store = ratelimit.NewPostgresStore(db)
+	if cfg.RateLimitStore == "memory" {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+
+	// Register routes with the gorm.DB instance and the configuration, which is reloaded on SIGHUP
+	configStore := config.NewStore(cfg, configFile())
+	routes.RegisterRoutes(router, db, configStore, rateLimitStore)
+
+	// Run the maintenance jobs until the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	startJobs(jobsCtx, db, configStore, rateLimitStore)
+
+	// Construct the address with port
+	address := ":" + cfg.PORT
+
+	// Print the address to the logs
+	log.Printf("Starting server on %s", address)
+
+	// Server settings
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Start the server in a goroutine so it doesn’t block
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+
+	// Reload the configuration on SIGHUP until a shutdown signal is received
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	for running := true; running; {
+		select {
+		case <-reload:
+			reloadConfig(configStore)
+		case <-quit:
+			running = false
+		}
+	}
+
+	// Graceful shutdown
+	log.Println("Shutting down server...")
+	stopJobs()
+
+	// The context is used to inform the server it has 5 seconds to finish
+	// the request it is currently handling
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatal("Server forced to shutdown:", err)
+	}
+
+	log.Println("Server exiting")
+}
+
+// reloadConfig reloads the configuration and logs what changed. An invalid configuration is
+// logged and the current one is kept.
+func reloadConfig(configStore *config.Store) {
+	log.Println("Reloading configuration...")
+
+	applied, ignored, err := configStore.Reload()
+	if err != nil {
+		log.Printf("Configuration reload rejected, keeping the current configuration: %v", err)
+		return
+	}
+
+	if len(applied) == 0 {
+		log.Println("Configuration reloaded, nothing changed")
+	}
+	for _, change := range applied {
+		log.Printf("Configuration reloaded: %s", change)
+	}
+	for _, change := range ignored {
+		log.Printf("Configuration change ignored until restart: %s", change)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// setup loads the configuration and connects to the database, as shared by every command.
+// The configuration file, if any, is named by the CONFIG_FILE environment variable.
+// The returned *sql.DB must be closed by the caller.
+func setup() (*config.Config, *gorm.DB, *sql.DB) {
+	// Load configuration once, it is passed down to everything that needs it
+	cfg, err := config.Load(configFile())
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	log.Printf("Loaded config: %s", cfg)
+
+	// Connect to the database with GORM
+	db, err := database.ConnectPostgres(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Expose the underlying connection so callers can close it
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database object: %v", err)
+	}
+	// Bounds the connections of the requests and of the background work, such as payout batches
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConnsLimit())
+	sqlDB.SetMaxIdleConns(cfg.DBMaxOpenConnsLimit())
+
+	return cfg, db, sqlDB
+}
+
+// configFile returns the path of the configuration file, empty if there is none
+func configFile() string {
+	return os.Getenv("CONFIG_FILE")
+}
+
+// exitWithUsage prints a command usage to stderr and exits with status 2
+func exitWithUsage(usage string) {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
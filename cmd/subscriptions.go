@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"payment-gateway-service/internal/savedmethod"
+	"payment-gateway-service/internal/subscription"
+	"text/tabwriter"
+	"time"
+)
+
+const subscriptionsUsage = `Usage: main subscriptions <command>
+
+Commands:
+  run    Settle the charges whose payment completed and charge the subscriptions that are due,
+         meant to run every few minutes from cron`
+
+// runSubscriptions runs the subscriptions command
+func runSubscriptions(args []string) {
+	if len(args) == 0 {
+		exitWithUsage(subscriptionsUsage)
+	}
+
+	switch args[0] {
+	case "run":
+		if len(args) != 1 {
+			exitWithUsage(subscriptionsUsage)
+		}
+		cfg, db, sqlDB := setup()
+		defer sqlDB.Close()
+
+		service := subscription.NewSubscriptionService(db, newPaymentService(db, cfg), savedmethod.NewSavedMethodService(db), cfg.SubscriptionRetryDelays())
+		charges, err := service.Run(context.Background(), time.Now())
+		if err != nil {
+			log.Fatalf("Failed to run subscriptions: %v", err)
+		}
+		printCharges(charges)
+		fmt.Printf("Created or settled %d charge(s)\n", len(charges))
+	default:
+		exitWithUsage(subscriptionsUsage)
+	}
+}
+
+// printCharges prints subscription charges as a table
+func printCharges(charges []subscription.Charge) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUBSCRIPTION\tPERIOD\tATTEMPT\tSTATUS\tPAYMENT\tERROR")
+	for _, c := range charges {
+		paymentID := ""
+		if c.PaymentID != nil {
+			paymentID = *c.PaymentID
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", c.SubscriptionID, c.Period, c.Attempt, c.Status, paymentID, c.Error)
+	}
+	_ = w.Flush()
+}
@@ -1,59 +1,546 @@
 package config
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/ratelimit"
 
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
+// redacted replaces secrets when the configuration is printed
+const redacted = "******"
+
+// Config is the configuration of the service. It is assembled from the defaults, an optional
+// YAML or TOML file and environment variables, in increasing order of precedence.
 type Config struct {
-	PORT        string
-	DBHost      string
-	DBPort      string
-	DBUser      string
-	DBPassword  string
-	DBName      string
-	DBSSLMode   string
-	DatabaseURL string
-	AppHost     string
-	AuthToken   string
-}
-
-func LoadConfig() *Config {
-	// Load .env file if present
-	if err := godotenv.Load(); err != nil {
+	PORT        string `yaml:"port" toml:"port"`
+	DBHost      string `yaml:"db_host" toml:"db_host"`
+	DBPort      string `yaml:"db_port" toml:"db_port"`
+	DBUser      string `yaml:"db_user" toml:"db_user"`
+	DBPassword  string `yaml:"db_password" toml:"db_password"`
+	DBName      string `yaml:"db_name" toml:"db_name"`
+	DBSSLMode   string `yaml:"db_sslmode" toml:"db_sslmode"`
+	DatabaseURL string `yaml:"-" toml:"-"`
+	AppHost     string `yaml:"app_host" toml:"app_host"`
+
+	// DBMaxOpenConns bounds the connections to the database, shared by the requests and the background work
+	DBMaxOpenConns string `yaml:"db_max_open_conns" toml:"db_max_open_conns"`
+
+	// AutoMigrate applies pending migrations on startup
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+
+	// Rate limiting: the store ("postgres" or "memory") and the per route policies,
+	// e.g. "key=60/m,user=10/m,ip=120/m"
+	RateLimitStore      string `yaml:"rate_limit_store" toml:"rate_limit_store"`
+	RateLimitDeposit    string `yaml:"rate_limit_deposit" toml:"rate_limit_deposit"`
+	RateLimitWithdrawal string `yaml:"rate_limit_withdrawal" toml:"rate_limit_withdrawal"`
+	RateLimitRead       string `yaml:"rate_limit_read" toml:"rate_limit_read"`
+	RateLimitPublic     string `yaml:"rate_limit_public" toml:"rate_limit_public"`
+
+	// ProviderTimeout bounds every request to a provider, e.g. "30s"
+	ProviderTimeout string `yaml:"provider_timeout" toml:"provider_timeout"`
+
+	// FX: how long a quote locks its rate, the age after which a rate is no longer used
+	// and the JSON file the rates are synced from by the fx sync command
+	FXQuoteTTL   string `yaml:"fx_quote_ttl" toml:"fx_quote_ttl"`
+	FXRateMaxAge string `yaml:"fx_rate_max_age" toml:"fx_rate_max_age"`
+	FXRatesFile  string `yaml:"fx_rates_file" toml:"fx_rates_file"`
+
+	// InteractionRetention is how long the provider interactions are kept, e.g. "2160h"
+	InteractionRetention string `yaml:"interaction_retention" toml:"interaction_retention"`
+
+	// BeneficiaryEncryptionKey encrypts the bank accounts of saved beneficiaries: 32 bytes, base64 encoded.
+	// Beneficiaries are unavailable without it.
+	BeneficiaryEncryptionKey string `yaml:"beneficiary_encryption_key" toml:"beneficiary_encryption_key"`
+
+	// PayoutConcurrency is the number of payouts sent to each provider at the same time
+	PayoutConcurrency string `yaml:"payout_concurrency" toml:"payout_concurrency"`
+
+	// SubscriptionRetrySchedule is the delays after which a failed subscription charge is retried,
+	// comma separated, e.g. "24h,72h". The subscription is cancelled after the last retry fails.
+	SubscriptionRetrySchedule string `yaml:"subscription_retry_schedule" toml:"subscription_retry_schedule"`
+
+	// CaptureDeadline is how long the authorization of a deposit made with manual capture can be captured,
+	// e.g. "168h". Authorizations not captured in time are voided.
+	CaptureDeadline string `yaml:"capture_deadline" toml:"capture_deadline"`
+
+	// DisputeEvidenceWindow is how long merchants have to submit the evidence of a dispute when the provider
+	// gives no deadline, e.g. "168h". Disputes still open after their deadline are lost.
+	DisputeEvidenceWindow string `yaml:"dispute_evidence_window" toml:"dispute_evidence_window"`
+
+	// BlobDir is the directory the evidence files of disputes are stored in
+	BlobDir string `yaml:"blob_dir" toml:"blob_dir"`
+
+	// Provider credentials
+	HSBCUserID     string `yaml:"hsbc_user_id" toml:"hsbc_user_id"`
+	HSBCUserSecret string `yaml:"hsbc_user_secret" toml:"hsbc_user_secret"`
+	ADCBUserID     string `yaml:"adcb_user_id" toml:"adcb_user_id"`
+	ADCBUserSecret string `yaml:"adcb_user_secret" toml:"adcb_user_secret"`
+
+	// Secrets the providers sign their chargeback notifications with. Notifications of a provider without
+	// a secret are rejected.
+	HSBCChargebackSecret string `yaml:"hsbc_chargeback_secret" toml:"hsbc_chargeback_secret"`
+	ADCBChargebackSecret string `yaml:"adcb_chargeback_secret" toml:"adcb_chargeback_secret"`
+
+	// rateLimitPolicies are the parsed rate limit policies keyed by route, set by Load
+	rateLimitPolicies map[string]ratelimit.Policy
+}
+
+// setting describes a configuration value for the environment, reloads and printing
+type setting struct {
+	key        string
+	value      interface{} // *string or *bool
+	reloadable bool
+	secret     bool
+}
+
+// settings lists the values of the configuration under their environment variable names.
+// Reloadable settings take effect on a reload, the others require a restart.
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "PORT", value: &c.PORT},
+		{key: "DB_HOST", value: &c.DBHost},
+		{key: "DB_PORT", value: &c.DBPort},
+		{key: "DB_USER", value: &c.DBUser},
+		{key: "DB_PASSWORD", value: &c.DBPassword, secret: true},
+		{key: "DB_NAME", value: &c.DBName},
+		{key: "DB_SSLMODE", value: &c.DBSSLMode},
+		{key: "DB_MAX_OPEN_CONNS", value: &c.DBMaxOpenConns},
+		{key: "APP_HOST", value: &c.AppHost, reloadable: true},
+		{key: "AUTO_MIGRATE", value: &c.AutoMigrate},
+		{key: "RATE_LIMIT_STORE", value: &c.RateLimitStore},
+		{key: "RATE_LIMIT_DEPOSIT", value: &c.RateLimitDeposit, reloadable: true},
+		{key: "RATE_LIMIT_WITHDRAWAL", value: &c.RateLimitWithdrawal, reloadable: true},
+		{key: "RATE_LIMIT_READ", value: &c.RateLimitRead, reloadable: true},
+		{key: "RATE_LIMIT_PUBLIC", value: &c.RateLimitPublic, reloadable: true},
+		{key: "PROVIDER_TIMEOUT", value: &c.ProviderTimeout, reloadable: true},
+		{key: "FX_QUOTE_TTL", value: &c.FXQuoteTTL, reloadable: true},
+		{key: "FX_RATE_MAX_AGE", value: &c.FXRateMaxAge, reloadable: true},
+		{key: "FX_RATES_FILE", value: &c.FXRatesFile},
+		{key: "INTERACTION_RETENTION", value: &c.InteractionRetention, reloadable: true},
+		{key: "BENEFICIARY_ENCRYPTION_KEY", value: &c.BeneficiaryEncryptionKey, secret: true},
+		{key: "PAYOUT_CONCURRENCY", value: &c.PayoutConcurrency},
+		{key: "SUBSCRIPTION_RETRY_SCHEDULE", value: &c.SubscriptionRetrySchedule},
+		{key: "CAPTURE_DEADLINE", value: &c.CaptureDeadline, reloadable: true},
+		{key: "DISPUTE_EVIDENCE_WINDOW", value: &c.DisputeEvidenceWindow, reloadable: true},
+		{key: "BLOB_DIR", value: &c.BlobDir},
+		{key: "HSBC_USER_ID", value: &c.HSBCUserID},
+		{key: "HSBC_USER_SECRET", value: &c.HSBCUserSecret, secret: true},
+		{key: "ADCB_USER_ID", value: &c.ADCBUserID},
+		{key: "ADCB_USER_SECRET", value: &c.ADCBUserSecret, secret: true},
+		{key: "HSBC_CHARGEBACK_SECRET", value: &c.HSBCChargebackSecret, reloadable: true, secret: true},
+		{key: "ADCB_CHARGEBACK_SECRET", value: &c.ADCBChargebackSecret, reloadable: true, secret: true},
+	}
+}
+
+// Defaults returns the configuration used for every setting that is neither in the file nor in the environment
+func Defaults() *Config {
+	return &Config{
+		PORT:      "8080",
+		DBHost:    "localhost",
+		DBPort:    "5432",
+		DBSSLMode: "disable",
+		AppHost:   "http://localhost:8080",
+
+		DBMaxOpenConns: "25",
+
+		RateLimitStore:      "postgres",
+		RateLimitDeposit:    "key=60/m,user=10/m,ip=120/m",
+		RateLimitWithdrawal: "key=30/m,user=5/m,ip=60/m",
+		RateLimitRead:       "key=300/m,ip=600/m",
+		RateLimitPublic:     "ip=300/m",
+
+		ProviderTimeout: "30s",
+
+		FXQuoteTTL:   "10m",
+		FXRateMaxAge: "24h",
+
+		InteractionRetention: "2160h",
+
+		PayoutConcurrency: "4",
+
+		SubscriptionRetrySchedule: "24h,72h,168h",
+
+		CaptureDeadline: "168h",
+
+		DisputeEvidenceWindow: "168h",
+		BlobDir:               "blobs",
+	}
+}
+
+// Load assembles the configuration from the defaults, the file at path (skipped when empty) and
+// the environment, including a .env file if present, and validates it. All invalid settings are
+// reported together.
+func Load(path string) (*Config, error) {
+	// Read .env file if present. It is read again on every load rather than copied into the environment
+	// of the process, so that a reload picks up its changes.
+	dotenv, err := godotenv.Read()
+	if err != nil {
 		log.Println("No .env file found")
 	}
 
-	config := &Config{
-		PORT:       getEnv("PORT"),
-		DBHost:     getEnv("DB_HOST"),
-		DBPort:     getEnv("DB_PORT"),
-		DBUser:     getEnv("DB_USER"),
-		DBPassword: getEnv("DB_PASSWORD"),
-		DBName:     getEnv("DB_NAME"),
-		DBSSLMode:  getEnv("DB_SSLMODE"),
-		AppHost:    getEnv("APP_HOST"),
-		AuthToken:  getEnv("AUTH_TOKEN"),
+	config := Defaults()
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.loadEnv(dotenv); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Loaded config: %+v\n", config)
+	config.rateLimitPolicies = make(map[string]ratelimit.Policy)
+	for route, value := range map[string]string{"deposit": config.RateLimitDeposit, "withdrawal": config.RateLimitWithdrawal, "read": config.RateLimitRead, "public": config.RateLimitPublic} {
+		// Validated above
+		config.rateLimitPolicies[route], _ = ratelimit.ParsePolicy(value)
+	}
 
-	config.DatabaseURL = fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		config.DBUser, config.DBPassword, config.DBHost, config.DBPort, config.DBName, config.DBSSLMode,
-	)
+	// url.URL escapes every part, including user names and passwords with reserved characters
+	config.DatabaseURL = (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.DBUser, config.DBPassword),
+		Host:     net.JoinHostPort(config.DBHost, config.DBPort),
+		Path:     "/" + config.DBName,
+		RawQuery: url.Values{"sslmode": {config.DBSSLMode}}.Encode(),
+	}).String()
 
-	return config
+	return config, nil
 }
 
-// Helper function to get environment variables without fallback
-func getEnv(key string) string {
-	value, exists := os.LookupEnv(key)
-	if !exists {
-		log.Fatalf("Environment variable %s is required but was not set", key)
+// loadFile overrides the configuration with the settings of a YAML or TOML file. Unknown settings are rejected.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		// An empty file decodes to io.EOF and leaves the configuration unchanged
+		if err := decoder.Decode(c); err != nil && len(bytes.TrimSpace(data)) > 0 {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(c); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", filepath.Ext(path))
 	}
-	return value
+
+	return nil
+}
+
+// loadEnv overrides the configuration with the environment variables that are set, and then with the
+// variables of the .env file that the environment does not set
+func (c *Config) loadEnv(dotenv map[string]string) error {
+	for _, setting := range c.settings() {
+		value, exists := os.LookupEnv(setting.key)
+		if !exists {
+			value, exists = dotenv[setting.key]
+		}
+		if !exists {
+			continue
+		}
+
+		switch field := setting.value.(type) {
+		case *string:
+			*field = value
+		case *bool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false, got %q", setting.key, value)
+			}
+			*field = parsed
+		}
+	}
+
+	return nil
+}
+
+// Validate checks every setting and returns all the problems found joined in one error
+func (c *Config) Validate() error {
+	var errs []error
+
+	required := []struct {
+		key   string
+		value string
+	}{
+		{"PORT", c.PORT},
+		{"DB_HOST", c.DBHost},
+		{"DB_PORT", c.DBPort},
+		{"DB_USER", c.DBUser},
+		{"DB_NAME", c.DBName},
+		{"APP_HOST", c.AppHost},
+		{"BLOB_DIR", c.BlobDir},
+	}
+	for _, setting := range required {
+		if setting.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", setting.key))
+		}
+	}
+
+	ports := []struct {
+		key   string
+		value string
+	}{
+		{"PORT", c.PORT},
+		{"DB_PORT", c.DBPort},
+	}
+	for _, setting := range ports {
+		if setting.value == "" {
+			continue
+		}
+		if port, err := strconv.Atoi(setting.value); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("%s must be a port number, got %q", setting.key, setting.value))
+		}
+	}
+
+	switch c.DBSSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("DB_SSLMODE must be one of disable, allow, prefer, require, verify-ca or verify-full, got %q", c.DBSSLMode))
+	}
+
+	if c.AppHost != "" {
+		if u, err := url.Parse(c.AppHost); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("APP_HOST must be an http or https URL, got %q", c.AppHost))
+		}
+	}
+
+	if c.RateLimitStore != "postgres" && c.RateLimitStore != "memory" {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be postgres or memory, got %q", c.RateLimitStore))
+	}
+
+	policies := []struct {
+		key   string
+		value string
+	}{
+		{"RATE_LIMIT_DEPOSIT", c.RateLimitDeposit},
+		{"RATE_LIMIT_WITHDRAWAL", c.RateLimitWithdrawal},
+		{"RATE_LIMIT_READ", c.RateLimitRead},
+		{"RATE_LIMIT_PUBLIC", c.RateLimitPublic},
+	}
+	for _, policy := range policies {
+		if _, err := ratelimit.ParsePolicy(policy.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s is invalid: %w", policy.key, err))
+		}
+	}
+
+	durations := []struct {
+		key   string
+		value string
+	}{
+		{"PROVIDER_TIMEOUT", c.ProviderTimeout},
+		{"FX_QUOTE_TTL", c.FXQuoteTTL},
+		{"FX_RATE_MAX_AGE", c.FXRateMaxAge},
+		{"INTERACTION_RETENTION", c.InteractionRetention},
+		{"CAPTURE_DEADLINE", c.CaptureDeadline},
+		{"DISPUTE_EVIDENCE_WINDOW", c.DisputeEvidenceWindow},
+	}
+	for _, setting := range durations {
+		if duration, err := time.ParseDuration(setting.value); err != nil || duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be a positive duration such as 30s, got %q", setting.key, setting.value))
+		}
+	}
+
+	if c.BeneficiaryEncryptionKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.BeneficiaryEncryptionKey); err != nil || len(key) != 32 {
+			errs = append(errs, errors.New("BENEFICIARY_ENCRYPTION_KEY must be 32 bytes encoded in base64"))
+		}
+	}
+
+	if conns, err := strconv.Atoi(c.DBMaxOpenConns); err != nil || conns < 1 || conns > 1000 {
+		errs = append(errs, fmt.Errorf("DB_MAX_OPEN_CONNS must be a number between 1 and 1000, got %q", c.DBMaxOpenConns))
+	}
+
+	if concurrency, err := strconv.Atoi(c.PayoutConcurrency); err != nil || concurrency < 1 || concurrency > 100 {
+		errs = append(errs, fmt.Errorf("PAYOUT_CONCURRENCY must be a number between 1 and 100, got %q", c.PayoutConcurrency))
+	}
+
+	// An empty schedule cancels subscriptions on their first failed charge
+	if strings.TrimSpace(c.SubscriptionRetrySchedule) != "" {
+		for _, delay := range strings.Split(c.SubscriptionRetrySchedule, ",") {
+			if duration, err := time.ParseDuration(strings.TrimSpace(delay)); err != nil || duration <= 0 {
+				errs = append(errs, fmt.Errorf("SUBSCRIPTION_RETRY_SCHEDULE must be positive durations separated by commas such as 24h,72h, got %q", c.SubscriptionRetrySchedule))
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// DBMaxOpenConnsLimit returns the number of connections opened to the database at most
+func (c *Config) DBMaxOpenConnsLimit() int {
+	conns, _ := strconv.Atoi(c.DBMaxOpenConns)
+	return conns
+}
+
+// ProviderCredentials returns the credentials of each provider, keyed by provider name
+func (c *Config) ProviderCredentials() map[string]provider.Credentials {
+	return map[string]provider.Credentials{
+		"HSBC": {UserID: c.HSBCUserID, UserSecret: c.HSBCUserSecret},
+		"ADCB": {UserID: c.ADCBUserID, UserSecret: c.ADCBUserSecret},
+	}
+}
+
+// ChargebackSecrets returns the secret each provider signs its chargeback notifications with, keyed by provider name
+func (c *Config) ChargebackSecrets() map[string]string {
+	return map[string]string{
+		"HSBC": c.HSBCChargebackSecret,
+		"ADCB": c.ADCBChargebackSecret,
+	}
+}
+
+// RateLimitPolicy returns the rate limit policy of a route ("deposit", "withdrawal", "read" or "public")
+func (c *Config) RateLimitPolicy(route string) ratelimit.Policy {
+	return c.rateLimitPolicies[route]
+}
+
+// RateLimitMaxPeriod returns the longest period of the rate limits, after which an unused bucket is full
+func (c *Config) RateLimitMaxPeriod() time.Duration {
+	var longest time.Duration
+	for _, policy := range c.rateLimitPolicies {
+		for _, limit := range policy {
+			if limit.Per > longest {
+				longest = limit.Per
+			}
+		}
+	}
+	return longest
+}
+
+// ProviderTimeoutDuration returns the timeout of requests to providers, or 0 for no timeout
+func (c *Config) ProviderTimeoutDuration() time.Duration {
+	timeout, _ := time.ParseDuration(c.ProviderTimeout)
+	return timeout
+}
+
+// FXQuoteTTLDuration returns how long an FX quote locks its rate
+func (c *Config) FXQuoteTTLDuration() time.Duration {
+	ttl, _ := time.ParseDuration(c.FXQuoteTTL)
+	return ttl
+}
+
+// FXRateMaxAgeDuration returns the age after which an FX rate is no longer used
+func (c *Config) FXRateMaxAgeDuration() time.Duration {
+	maxAge, _ := time.ParseDuration(c.FXRateMaxAge)
+	return maxAge
+}
+
+// InteractionRetentionDuration returns how long the provider interactions are kept
+func (c *Config) InteractionRetentionDuration() time.Duration {
+	retention, _ := time.ParseDuration(c.InteractionRetention)
+	return retention
+}
+
+// BeneficiaryEncryptionKeyBytes returns the decoded encryption key of beneficiaries, or nil if there is none
+func (c *Config) BeneficiaryEncryptionKeyBytes() []byte {
+	key, err := base64.StdEncoding.DecodeString(c.BeneficiaryEncryptionKey)
+	if err != nil || len(key) == 0 {
+		return nil
+	}
+	return key
+}
+
+// PayoutConcurrencyLimit returns the number of payouts sent to each provider at the same time
+func (c *Config) PayoutConcurrencyLimit() int {
+	concurrency, _ := strconv.Atoi(c.PayoutConcurrency)
+	return concurrency
+}
+
+// SubscriptionRetryDelays returns the delays after which failed subscription charges are retried, or
+// nil when they are not retried
+func (c *Config) SubscriptionRetryDelays() []time.Duration {
+	var delays []time.Duration
+	for _, delay := range strings.Split(c.SubscriptionRetrySchedule, ",") {
+		if duration, err := time.ParseDuration(strings.TrimSpace(delay)); err == nil {
+			delays = append(delays, duration)
+		}
+	}
+	return delays
+}
+
+// CaptureDeadlineDuration returns how long the authorization of a deposit made with manual capture can be captured
+func (c *Config) CaptureDeadlineDuration() time.Duration {
+	deadline, _ := time.ParseDuration(c.CaptureDeadline)
+	return deadline
+}
+
+// DisputeEvidenceWindowDuration returns how long merchants have to submit the evidence of a dispute
+func (c *Config) DisputeEvidenceWindowDuration() time.Duration {
+	window, _ := time.ParseDuration(c.DisputeEvidenceWindow)
+	return window
+}
+
+// Diff lists the settings that differ between two configurations, one line per setting.
+// The values of secrets are not included.
+func Diff(previous, next *Config) []string {
+	var changes []string
+
+	nextSettings := next.settings()
+	for i, setting := range previous.settings() {
+		before := reflect.ValueOf(setting.value).Elem().Interface()
+		after := reflect.ValueOf(nextSettings[i].value).Elem().Interface()
+		if before == after {
+			continue
+		}
+
+		if setting.secret {
+			changes = append(changes, fmt.Sprintf("%s changed", setting.key))
+		} else {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", setting.key, fmt.Sprint(before), fmt.Sprint(after)))
+		}
+	}
+
+	return changes
+}
+
+// String formats the configuration with its secrets redacted, so it can be logged
+func (c *Config) String() string {
+	// The alias type has no String method, which avoids recursing into this one
+	type plain Config
+	safe := plain(*c)
+
+	for _, secret := range []*string{&safe.DBPassword, &safe.BeneficiaryEncryptionKey, &safe.HSBCUserSecret, &safe.ADCBUserSecret, &safe.HSBCChargebackSecret, &safe.ADCBChargebackSecret} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	if c.DBPassword != "" {
+		credentials := url.UserPassword(c.DBUser, c.DBPassword).String() + "@"
+		safe.DatabaseURL = strings.Replace(safe.DatabaseURL, credentials, url.User(c.DBUser).String()+":"+redacted+"@", 1)
+	}
+
+	return fmt.Sprintf("%+v", safe)
 }
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the current configuration and swaps it atomically on reload, so that readers
+// always see a complete configuration. Readers must call Current for every use rather than
+// keeping the returned configuration around.
+type Store struct {
+	path    string
+	current atomic.Pointer[Config]
+
+	// reloadMu serializes reloads
+	reloadMu sync.Mutex
+}
+
+// NewStore creates a Store with the configuration loaded at startup and the file it was loaded from
+func NewStore(cfg *Config, path string) *Store {
+	store := &Store{path: path}
+	store.current.Store(cfg)
+	return store
+}
+
+// Current returns the current configuration
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// Reload loads the configuration again and swaps in its reloadable settings. Changes to the
+// other settings are ignored until the next restart. If the new configuration is invalid the
+// current one is kept and the error is returned. It returns the applied and the ignored changes.
+func (s *Store) Reload() (applied, ignored []string, err error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	next, err := Load(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previous := s.Current()
+
+	// Keep the settings that require a restart as they were
+	restart := Diff(previous, next)
+	previousSettings := previous.settings()
+	for i, setting := range next.settings() {
+		if !setting.reloadable {
+			reflect.ValueOf(setting.value).Elem().Set(reflect.ValueOf(previousSettings[i].value).Elem())
+		}
+	}
+	next.DatabaseURL = previous.DatabaseURL
+
+	applied = Diff(previous, next)
+	ignored = subtract(restart, applied)
+
+	s.current.Store(next)
+	return applied, ignored, nil
+}
+
+// subtract returns the entries of all that are not in some
+func subtract(all, some []string) []string {
+	seen := make(map[string]bool, len(some))
+	for _, entry := range some {
+		seen[entry] = true
+	}
+
+	var rest []string
+	for _, entry := range all {
+		if !seen[entry] {
+			rest = append(rest, entry)
+		}
+	}
+	return rest
+}
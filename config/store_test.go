@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"payment-gateway-service/internal/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupStore loads a configuration file into a Store and returns the store and the file path
+func setupStore(t *testing.T, content string) (*Store, string) {
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", content)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	return NewStore(cfg, path), path
+}
+
+func TestStore_Reload(t *testing.T) {
+	store, path := setupStore(t, "db_user: user\ndb_name: payments\napp_host: http://old.example.com\n")
+	previous := store.Current()
+
+	require.NoError(t, os.WriteFile(path, []byte("db_user: user\ndb_name: other\napp_host: http://new.example.com\nrate_limit_read: key=5/m\nprovider_timeout: 10s\n"), 0o600))
+
+	applied, ignored, err := store.Reload()
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		`APP_HOST: "http://old.example.com" -> "http://new.example.com"`,
+		`RATE_LIMIT_READ: "key=300/m,ip=600/m" -> "key=5/m"`,
+		`PROVIDER_TIMEOUT: "30s" -> "10s"`,
+	}, applied)
+	assert.Equal(t, []string{`DB_NAME: "payments" -> "other"`}, ignored)
+
+	current := store.Current()
+	assert.Equal(t, "http://new.example.com", current.AppHost)
+	assert.Equal(t, "payments", current.DBName, "settings requiring a restart are kept")
+	assert.Equal(t, previous.DatabaseURL, current.DatabaseURL)
+	assert.Equal(t, 5, current.RateLimitPolicy("read")[ratelimit.DimensionAPIKey].Requests)
+	assert.Equal(t, "http://old.example.com", previous.AppHost, "readers holding the previous configuration are not affected")
+}
+
+func TestStore_ReloadDotEnv(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	require.NoError(t, os.WriteFile(".env", []byte("DB_USER=user\nDB_NAME=payments\nAPP_HOST=http://old.example.com\n"), 0o600))
+	cfg, err := Load("")
+	require.NoError(t, err)
+	store := NewStore(cfg, "")
+
+	// The .env file is read again, it was not copied into the environment on the first load
+	require.NoError(t, os.WriteFile(".env", []byte("DB_USER=user\nDB_NAME=payments\nAPP_HOST=http://new.example.com\n"), 0o600))
+
+	applied, ignored, err := store.Reload()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{`APP_HOST: "http://old.example.com" -> "http://new.example.com"`}, applied)
+	assert.Empty(t, ignored)
+	assert.Equal(t, "http://new.example.com", store.Current().AppHost)
+
+	// The environment still takes precedence over the .env file
+	t.Setenv("APP_HOST", "http://env.example.com")
+	_, _, err = store.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, "http://env.example.com", store.Current().AppHost)
+}
+
+func TestStore_ReloadInvalid(t *testing.T) {
+	store, path := setupStore(t, "db_user: user\ndb_name: payments\n")
+	previous := store.Current()
+
+	require.NoError(t, os.WriteFile(path, []byte("db_user: user\ndb_name: payments\napp_host: not a url\n"), 0o600))
+
+	applied, ignored, err := store.Reload()
+
+	assert.ErrorContains(t, err, "APP_HOST")
+	assert.Nil(t, applied)
+	assert.Nil(t, ignored)
+	assert.Same(t, previous, store.Current())
+}
+
+func TestDiff_HidesSecrets(t *testing.T) {
+	previous := Defaults()
+	next := Defaults()
+	next.DBPassword = "new-secret"
+
+	assert.Equal(t, []string{"DB_PASSWORD changed"}, Diff(previous, next))
+}
@@ -15,95 +15,158 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/payment/callback/failure": {
-            "get": {
-                "description": "Processes a failed payment callback and redirects to a status URL.",
+        "/admin/beneficiaries/{id}/verify": {
+            "post": {
+                "description": "Marks a beneficiary of any merchant as VERIFIED once an operator checked the account, so payouts can be sent to it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "admin"
                 ],
-                "summary": "Handles failed payment provider callbacks",
+                "summary": "Verify a beneficiary",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "External ID",
-                        "name": "external_id",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Beneficiary ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirects to status URL",
+                    "200": {
+                        "description": "Verified beneficiary",
                         "schema": {
-                            "type": "string"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/beneficiary.Beneficiary"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Error extracting external ID",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Failed to handle callback",
+                    "404": {
+                        "description": "Beneficiary not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Beneficiary is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
                 }
             }
         },
-        "/payment/callback/success": {
-            "get": {
-                "description": "Processes a successful payment callback and redirects to a status URL.",
+        "/admin/disputes": {
+            "post": {
+                "description": "Opens a dispute of a completed deposit of any merchant, such as a chargeback a bank reported outside of the provider notifications. The amount defaults to the amount of the payment and the evidence deadline to DISPUTE_EVIDENCE_WINDOW from now.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "admin"
                 ],
-                "summary": "Handles successful payment provider callbacks",
+                "summary": "Open a dispute",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "External ID",
-                        "name": "external_id",
-                        "in": "path",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Dispute",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dispute.CreateDisputeRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirects to status URL",
+                    "201": {
+                        "description": "Opened dispute",
                         "schema": {
-                            "type": "string"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dispute.Dispute"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Error extracting external ID",
+                        "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Failed to handle callback",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not a completed deposit or the provider reference is already used",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Dispute amount exceeds the undisputed amount of the payment",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
                 }
             }
         },
-        "/payment/deposit": {
+        "/admin/disputes/{id}/resolve": {
             "post": {
-                "description": "Processes a deposit request and returns a URL for payment.",
+                "description": "Records the decision of the provider on an OPEN or EVIDENCE_SUBMITTED dispute of any merchant, for providers that do not notify it. A LOST dispute debits its amount from the ledger of the merchant.",
                 "consumes": [
                     "application/json"
                 ],
@@ -111,64 +174,199 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "admin"
                 ],
-                "summary": "Handles deposit requests",
+                "summary": "Resolve a dispute",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Authorization token",
-                        "name": "X-AUTH-TOKEN",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "Validated Payment Request",
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Outcome",
                         "name": "validatedBody",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payment.PaymentRequest"
+                            "$ref": "#/definitions/dispute.ResolveDisputeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resolved dispute",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dispute.Dispute"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
+                    "409": {
+                        "description": "Dispute was already decided",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/fx/rates": {
+            "get": {
+                "description": "Lists the latest rate of every currency pair with its source and time.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List FX rates",
+                "parameters": [
                     {
-                        "description": "Example request",
-                        "name": "exampleRequest",
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "FX rates",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/fx.Rate"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the rates of the uploaded currency pairs, stamped with the current time and the manual source.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Upload FX rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Rates",
+                        "name": "validatedBody",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payment.PaymentRequest"
+                            "$ref": "#/definitions/fx.UploadRatesRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "url",
+                        "description": "Saved FX rates",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/fx.Rate"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Failed to process request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
                 }
             }
         },
-        "/payment/withdrawal": {
+        "/admin/merchants/{id}/api-keys": {
             "post": {
-                "description": "Processes a withdrawal request and returns a URL for payment.",
+                "description": "Issues a new API key with the given scopes. The raw key, and the signing secret of hmac keys, are only returned in this response.",
                 "consumes": [
                     "application/json"
                 ],
@@ -176,85 +374,5128 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "payment"
+                    "admin"
                 ],
-                "summary": "Handles withdrawal requests",
+                "summary": "Create a merchant API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Authorization token",
-                        "name": "X-AUTH-TOKEN",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "Validated Payment Request",
+                        "type": "integer",
+                        "description": "Merchant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API key details",
                         "name": "validatedBody",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/payment.PaymentRequest"
+                            "$ref": "#/definitions/merchant.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created API key",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown scope",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
+                    "404": {
+                        "description": "Merchant not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/merchants/{id}/api-keys/{key_id}": {
+            "delete": {
+                "description": "Revokes an API key. Requests made with a revoked key are rejected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke a merchant API key",
+                "parameters": [
                     {
-                        "description": "Example request",
-                        "name": "exampleRequest",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Merchant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key revoked",
                         "schema": {
-                            "$ref": "#/definitions/payment.PaymentRequest"
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
+                }
+            }
+        },
+        "/admin/merchants/{id}/ledger": {
+            "get": {
+                "description": "Lists the movements of the balance of a merchant, newest first, such as the chargebacks of the disputes it lost. Debits are negative.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List the ledger entries of a merchant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Merchant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "url",
+                        "description": "Ledger entries",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/ledger.Entry"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid merchant ID",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
-                    "500": {
-                        "description": "Failed to process request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "payment.PaymentRequest": {
-            "type": "object",
-            "required": [
-                "amount",
-                "country_code",
-                "currency_code",
-                "user_id"
-            ],
-            "properties": {
-                "amount": {
-                    "type": "number"
-                },
+        },
+        "/admin/merchants/{id}/redirect-domains": {
+            "put": {
+                "description": "Replaces the domains, including their subdomains, that the success, failure and cancel URLs of the merchant's payments may point to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update the redirect domains of a merchant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Merchant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Allowed domains",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/merchant.UpdateRedirectDomainsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated merchant",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/merchant.Merchant"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Merchant not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/payments/{id}/interactions": {
+            "get": {
+                "description": "Lists the requests sent to providers for a payment and the callbacks received for it, oldest first, with redacted headers and bodies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List the provider interactions of a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Provider interactions",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/interaction.Interaction"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid payment ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/provider-configurations": {
+            "get": {
+                "description": "Lists every provider configuration used to route payments by currency, country and priority.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List provider configurations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Provider configurations",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/provider.ProviderConfiguration"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/provider-configurations/{id}": {
+            "put": {
+                "description": "Changes the routing priority and optionally the base URL and weight of a provider configuration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update a provider configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Provider configuration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Provider configuration update",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/provider.UpdateProviderConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated provider configuration",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/provider.ProviderConfiguration"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider configuration not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/routing-rules": {
+            "get": {
+                "description": "Lists the routing rules by priority. The first rule matching a payment sends it to its provider, before the provider configuration weights are considered.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List routing rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Routing rules",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/routing.Rule"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a rule sending the payments that match its currency, country, payment type, amount band, user segment and UTC time window to a provider.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create a routing rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Routing rule",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routing.CreateRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created routing rule",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/routing.Rule"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/routing-rules/{id}": {
+            "delete": {
+                "description": "Deletes a routing rule. Payments created afterwards are no longer routed by it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a routing rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Operator API key",
+                        "name": "X-OPERATOR-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Routing rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Routing rule deleted",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Routing rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/beneficiaries": {
+            "get": {
+                "description": "Lists the beneficiaries saved for a user of the authenticated merchant, newest first, with their accounts masked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "beneficiaries"
+                ],
+                "summary": "List the beneficiaries of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Beneficiaries",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/beneficiary.Beneficiary"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Missing user ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Saves the bank account of a user for later payouts. The account is validated for the country like the beneficiary of a payout, stored encrypted and returned masked. New beneficiaries are UNVERIFIED and cannot be paid out to until an operator verifies them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "beneficiaries"
+                ],
+                "summary": "Save a beneficiary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Beneficiary",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/beneficiary.CreateBeneficiaryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Saved beneficiary",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/beneficiary.Beneficiary"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:withdrawal",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid bank account",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Beneficiaries are not configured",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/beneficiaries/{id}/disable": {
+            "post": {
+                "description": "Disables a beneficiary of the authenticated merchant for good, payouts can no longer be sent to it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "beneficiaries"
+                ],
+                "summary": "Disable a beneficiary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Beneficiary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Disabled beneficiary",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/beneficiary.Beneficiary"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:withdrawal",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Beneficiary not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/checkout/{id}": {
+            "get": {
+                "description": "Renders the payment summary and the providers the customer can pay with. Payments already sent to a provider redirect to the result page. The language is taken from the lang query parameter or the Accept-Language header.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "checkout"
+                ],
+                "summary": "Hosted checkout page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language (en or ar)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Checkout page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "303": {
+                        "description": "Redirects to the result page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sends the payment to the chosen provider configuration and redirects the customer to the provider.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "checkout"
+                ],
+                "summary": "Start a hosted checkout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Provider configuration ID",
+                        "name": "provider_config_id",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "Redirects to the provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Checkout already started page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Provider not eligible page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes": {
+            "get": {
+                "description": "Lists the disputes of the payments of the authenticated merchant, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "List disputes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "OPEN",
+                            "EVIDENCE_SUBMITTED",
+                            "WON",
+                            "LOST"
+                        ],
+                        "type": "string",
+                        "description": "Only disputes in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only disputes of this payment",
+                        "name": "payment_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Disputes",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dispute.Dispute"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes/{id}": {
+            "get": {
+                "description": "Returns a dispute of the authenticated merchant with the evidence attached to it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Get a dispute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dispute",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dispute.Dispute"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes/{id}/evidence": {
+            "post": {
+                "description": "Attaches a file of up to 10 MB, such as a receipt or a proof of delivery, to an OPEN dispute of the authenticated merchant before its evidence deadline. The evidence is sent to the provider once submitted.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Attach evidence to a dispute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Evidence file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "What the file shows",
+                        "name": "description",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Attached evidence",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dispute.Evidence"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Missing file",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Dispute is no longer open",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Evidence file too large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Evidence deadline passed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes/{id}/evidence/{evidence_id}": {
+            "get": {
+                "description": "Returns the file of an evidence attached to a dispute of the authenticated merchant.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Download an evidence file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Evidence ID",
+                        "name": "evidence_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Evidence file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute or evidence not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/disputes/{id}/submit": {
+            "post": {
+                "description": "Submits the evidence attached to an OPEN dispute of the authenticated merchant before its deadline. The dispute is then EVIDENCE_SUBMITTED until the provider decides it, and no more evidence can be attached.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "disputes"
+                ],
+                "summary": "Submit the evidence of a dispute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Dispute ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dispute",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dispute.Dispute"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dispute not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Dispute is no longer open",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No evidence attached or evidence deadline passed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/fx/quotes": {
+            "post": {
+                "description": "Locks the latest rate of a currency pair for the configured quote TTL. The first payment passing the quote ID as fx_quote_id is converted at the locked rate, and uses the quote up. Requires the payments:deposit or payments:withdrawal scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fx"
+                ],
+                "summary": "Create an FX quote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Currency pair",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/fx.QuoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "FX quote",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/fx.QuoteResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key is missing the required scope",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate available",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment": {
+            "get": {
+                "description": "Renders the current status of a payment. Customers are redirected here after the provider callback unless the payment has its own redirect URLs.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "checkout"
+                ],
+                "summary": "Payment result page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language (en or ar)",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Result page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/callback/failure": {
+            "get": {
+                "description": "Processes a failed payment callback and redirects to a status URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Handles failed payment provider callbacks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External ID",
+                        "name": "external_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirects to status URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error extracting external ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not pending, or payout not settled by the provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to handle callback",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/callback/success": {
+            "get": {
+                "description": "Processes a successful payment callback and redirects to a status URL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Handles successful payment provider callbacks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External ID",
+                        "name": "external_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirects to status URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error extracting external ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not pending, or payout not settled by the provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to handle callback",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/callbacks/cancel": {
+            "get": {
+                "description": "Marks the payment as failed and redirects to its cancel URL, falling back to its failure URL and then to the status page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Handles cancelled payment provider callbacks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "External ID",
+                        "name": "external_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirects to cancel URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error extracting external ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not pending, or payout not settled by the provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to handle callback",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/callbacks/chargeback/{external_id}": {
+            "post": {
+                "description": "Opens a dispute of the payment with the external ID when the provider notifies a chargeback, or records the decision of the provider on it when notified again under the same reference as WON or LOST. A lost dispute debits its amount from the ledger of the merchant. Repeated notifications have no effect.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Handles chargeback provider callbacks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 of the request with the chargeback secret of the provider, hex encoded",
+                        "name": "X-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unix time the request was signed at",
+                        "name": "X-Signature-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "External ID",
+                        "name": "external_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Chargeback notification",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dispute.ChargebackNotification"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dispute",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dispute.Dispute"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Signature missing, invalid or expired, or unknown payment",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not a completed deposit or the dispute was already decided otherwise",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Dispute amount exceeds the undisputed amount of the payment",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/deposit": {
+            "post": {
+                "description": "Processes a deposit request and returns a URL for payment. With save_method the provider saves the payment method once the deposit succeeded, and it is listed with GET /saved-methods. With saved_method_id a method the user saved is charged right away with its provider, with no redirect, and the payment is returned instead of a URL: SUCCESS or FAILED, or PENDING until the provider calls back. With capture_mode manual the provider only authorizes the deposit, which becomes AUTHORIZED instead of SUCCESS, and the merchant captures it with POST /payment/{id}/capture or voids it with POST /payment/{id}/void.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Handles deposit requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Validated Payment Request",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.PaymentRequest"
+                        }
+                    },
+                    {
+                        "description": "Example request",
+                        "name": "exampleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.PaymentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "url, or the payment charging a saved method",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Saved method not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No route for currency/country, saved method disabled or payment rejected by provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to process request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/methods": {
+            "get": {
+                "description": "Lists the providers a payment in the currency and country can be sent to, by priority. Their names can be used as the provider preference or exclusions of a payment.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "List payment methods",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency code",
+                        "name": "currency",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Country code",
+                        "name": "country",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payment methods",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/payment.PaymentMethod"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid currency or country",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/payout": {
+            "post": {
+                "description": "Sends a withdrawal to the beneficiary's bank account, server to server, with no redirect. The account is given as beneficiary, or is the verified saved beneficiary of the user referenced by beneficiary_id. It is validated for the country: an IBAN in IBAN countries, otherwise an account number and the bank code of the country. The payout is PENDING until the provider settles it and then SUCCESS or FAILED; its status is read with GET /payment/{id}. The beneficiary is returned and stored masked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Pay out to a bank account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Validated Payout Request",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.PayoutRequest"
+                        }
+                    },
+                    {
+                        "description": "Example request",
+                        "name": "exampleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.PayoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Pending payout",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/payment.Payment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:withdrawal",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Duplicate merchant reference",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid or unverified beneficiary, no route for currency/country or payout rejected by provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to process request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/reference/{reference}": {
+            "get": {
+                "description": "Returns the payment the authenticated merchant created with the merchant reference.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Get a payment by merchant reference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merchant reference",
+                        "name": "reference",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payment",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/payment.Payment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/withdrawal": {
+            "post": {
+                "description": "Processes a withdrawal request and returns a URL for payment.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Handles withdrawal requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Validated Payment Request",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.PaymentRequest"
+                        }
+                    },
+                    {
+                        "description": "Example request",
+                        "name": "exampleRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.PaymentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "url",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No route for currency/country or payment rejected by provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to process request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}": {
+            "get": {
+                "description": "Returns a single payment owned by the authenticated merchant.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Get a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payment",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/payment.Payment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}/cancel": {
+            "post": {
+                "description": "Cancels an INITIALIZED or PENDING payment, for example when the customer abandoned the checkout. The provider of a PENDING payment is notified. A provider reporting the payment as successful afterwards flags it for manual review. Cancelling a deposit requires the payments:deposit scope and a withdrawal the payments:withdrawal scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Cancel a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled payment",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/payment.Payment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope for the payment type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is already completed",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}/capture": {
+            "post": {
+                "description": "Captures an AUTHORIZED deposit made with capture_mode manual, in full or in part. Without an amount the full amount is captured, and the rest of a partial capture is released by the provider. Authorizations not captured before CAPTURE_DEADLINE are voided.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Capture an authorized payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Amount to capture, the full amount if not set",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/payment.CaptureRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Captured payment",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/payment.Payment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing payments:deposit scope",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not authorized, or is being captured or voided",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Amount exceeds the authorized amount or capture rejected by provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payment/{id}/void": {
+            "post": {
+                "description": "Voids an AUTHORIZED deposit made with capture_mode manual, and the provider releases the authorized amount.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payment"
+                ],
+                "summary": "Void an authorized payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Voided payment",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/payment.Payment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing payments:deposit scope",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Payment not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Payment is not authorized, or is being captured or voided",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Void rejected by provider",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Payment provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payouts/batches": {
+            "post": {
+                "description": "Accepts up to 1000 payouts, as JSON with the payouts listed like single payout requests, as a CSV body or as a CSV file in the multipart field \"file\". CSV files start with a header naming their columns among user_id, amount, currency_code, country_code, merchant_reference, description, beneficiary_id, holder_name, iban, account_number and bank_code; the first four are required. Every payout is validated before the batch is accepted, and a batch with any invalid payout is rejected with the errors of each line. The payouts are then sent in the background; the batch is PROCESSING until each of them was submitted to its provider or failed, and then COMPLETED.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payouts"
+                ],
+                "summary": "Create a payout batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Payouts, when sent as JSON",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/batch.CreateBatchRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file, when sent as multipart",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted batch",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/batch.Batch"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid payouts, keyed by line",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:withdrawal",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Payout batches are not configured",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payouts/batches/{id}": {
+            "get": {
+                "description": "Returns a payout batch of the authenticated merchant with its progress: the number of payouts processed so far, and how many of them were submitted or failed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payouts"
+                ],
+                "summary": "Get a payout batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Batch",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/batch.Batch"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payouts/batches/{id}/items": {
+            "get": {
+                "description": "Lists the payouts of a batch of the authenticated merchant in the order of their lines, with their result and the current status of their payment.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payouts"
+                ],
+                "summary": "List the payouts of a batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Payouts of the batch",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/batch.Item"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/payouts/batches/{id}/results.csv": {
+            "get": {
+                "description": "Returns the payouts of a batch of the authenticated merchant as CSV, one line per payout with its line, merchant reference, user, amount, currency, country, status, payment ID, payment status and error.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "payouts"
+                ],
+                "summary": "Download the results of a batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Result CSV",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Batch not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-methods": {
+            "get": {
+                "description": "Lists the payment methods a user of the authenticated merchant saved with deposits made with save_method, newest first. The provider tokens are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-methods"
+                ],
+                "summary": "List the saved payment methods of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved methods",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/savedmethod.SavedMethod"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Missing user ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/saved-methods/{id}/disable": {
+            "post": {
+                "description": "Disables a saved payment method of the authenticated merchant for good, it can no longer be charged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saved-methods"
+                ],
+                "summary": "Disable a saved payment method",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Saved method ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Disabled saved method",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/savedmethod.SavedMethod"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Saved method not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Lists the subscriptions of a user of the authenticated merchant with their plan, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "List the subscriptions of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/subscription.Subscription"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Missing user ID",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes a user to a plan of the authenticated merchant from start_at, now by default. Each period is charged when it starts with a deposit of the plan's amount, whose checkout URL is recorded on the charge. A failed charge is retried on the configured retry schedule, during which the subscription is PAST_DUE, and the subscription is CANCELLED when the last retry fails.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create a subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.CreateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created subscription",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/subscription.Subscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Plan not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Start in the past",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/plans": {
+            "get": {
+                "description": "Lists the plans of the authenticated merchant, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "List the plans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Plans",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/subscription.Plan"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a plan billing an amount every interval_count intervals of a day, week, month or year, every interval by default. Monthly and yearly periods keep the day of the month the subscription started on, or the last day of shorter months.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create a plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Plan",
+                        "name": "validatedBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/subscription.CreatePlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created plan",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/subscription.Plan"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Returns a subscription of the authenticated merchant with its plan and its charges, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get a subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/subscription.Subscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:read",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "Stops charging a subscription of the authenticated merchant for good. A charge in progress still completes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel a subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled subscription",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/subscription.Subscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "description": "Stops charging a subscription of the authenticated merchant until it is resumed. A charge in progress still completes. Pausing a paused subscription has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Pause a subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paused subscription",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/subscription.Subscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Subscription is cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "description": "Charges a paused subscription of the authenticated merchant again from the first period starting after now. The periods that started while it was paused are not charged. Resuming an active subscription has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Resume a subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Merchant API key",
+                        "name": "X-AUTH-TOKEN",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resumed subscription",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/utils.APIResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/subscription.Subscription"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing scope payments:deposit",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Subscription is cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "bankaccount.BankAccount": {
+            "type": "object",
+            "required": [
+                "holder_name"
+            ],
+            "properties": {
+                "account_number": {
+                    "type": "string",
+                    "maxLength": 34
+                },
+                "bank_code": {
+                    "type": "string",
+                    "maxLength": 11
+                },
+                "holder_name": {
+                    "type": "string",
+                    "maxLength": 140
+                },
+                "iban": {
+                    "type": "string",
+                    "maxLength": 42
+                }
+            }
+        },
+        "batch.Batch": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failed_items": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "processed_items": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/batch.Status"
+                },
+                "submitted_items": {
+                    "type": "integer"
+                },
+                "total_items": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "batch.CreateBatchRequest": {
+            "type": "object",
+            "properties": {
+                "payouts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/payment.PayoutRequest"
+                    }
+                }
+            }
+        },
+        "batch.Item": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "batch_id": {
+                    "type": "string"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "merchant_reference": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "payment_status": {
+                    "description": "PaymentStatus is the current status of the payment of the payout, read with the items",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/utils.PaymentStatus"
+                        }
+                    ]
+                },
+                "processed_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/batch.ItemStatus"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "batch.ItemStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "SENDING",
+                "SUBMITTED",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "ItemStatusPending",
+                "ItemStatusSending",
+                "ItemStatusSubmitted",
+                "ItemStatusFailed"
+            ]
+        },
+        "batch.Status": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "PROCESSING",
+                "COMPLETED"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusProcessing",
+                "StatusCompleted"
+            ]
+        },
+        "beneficiary.Beneficiary": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "description": "MaskedAccount is returned in responses, EncryptedAccount is the full account sent to providers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/bankaccount.BankAccount"
+                        }
+                    ]
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/beneficiary.Status"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "verified_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "beneficiary.CreateBeneficiaryRequest": {
+            "type": "object",
+            "required": [
+                "account",
+                "country_code",
+                "user_id"
+            ],
+            "properties": {
+                "account": {
+                    "$ref": "#/definitions/bankaccount.BankAccount"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "beneficiary.Status": {
+            "type": "string",
+            "enum": [
+                "UNVERIFIED",
+                "VERIFIED",
+                "DISABLED"
+            ],
+            "x-enum-varnames": [
+                "StatusUnverified",
+                "StatusVerified",
+                "StatusDisabled"
+            ]
+        },
+        "dispute.ChargebackNotification": {
+            "type": "object",
+            "required": [
+                "reference"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "evidence_due_at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "status": {
+                    "enum": [
+                        "OPEN",
+                        "WON",
+                        "LOST"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dispute.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "dispute.CreateDisputeRequest": {
+            "type": "object",
+            "required": [
+                "payment_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "evidence_due_at": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "provider_reference": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "dispute.Dispute": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "evidence": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dispute.Evidence"
+                    }
+                },
+                "evidence_due_at": {
+                    "type": "string"
+                },
+                "evidence_submitted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "provider_reference": {
+                    "description": "ProviderReference is the provider's identifier of the dispute, empty for disputes entered by an operator",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "source": {
+                    "$ref": "#/definitions/dispute.Source"
+                },
+                "status": {
+                    "$ref": "#/definitions/dispute.Status"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dispute.Evidence": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dispute_id": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dispute.ResolveDisputeRequest": {
+            "type": "object",
+            "required": [
+                "outcome"
+            ],
+            "properties": {
+                "outcome": {
+                    "enum": [
+                        "WON",
+                        "LOST"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dispute.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "dispute.Source": {
+            "type": "string",
+            "enum": [
+                "PROVIDER",
+                "ADMIN"
+            ],
+            "x-enum-varnames": [
+                "SourceProvider",
+                "SourceAdmin"
+            ]
+        },
+        "dispute.Status": {
+            "type": "string",
+            "enum": [
+                "OPEN",
+                "EVIDENCE_SUBMITTED",
+                "WON",
+                "LOST"
+            ],
+            "x-enum-varnames": [
+                "StatusOpen",
+                "StatusEvidenceSubmitted",
+                "StatusWon",
+                "StatusLost"
+            ]
+        },
+        "fx.QuoteRequest": {
+            "type": "object",
+            "required": [
+                "from_currency",
+                "to_currency"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "Amount is optionally converted at the quoted rate in the response",
+                    "type": "number"
+                },
+                "from_currency": {
+                    "type": "string"
+                },
+                "to_currency": {
+                    "type": "string"
+                }
+            }
+        },
+        "fx.QuoteResponse": {
+            "type": "object",
+            "properties": {
+                "converted_amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "from_currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "rate_at": {
+                    "type": "string"
+                },
+                "to_currency": {
+                    "type": "string"
+                },
+                "used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "fx.Rate": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "from_currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "rate_at": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "to_currency": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "fx.RateInput": {
+            "type": "object",
+            "required": [
+                "from_currency",
+                "rate",
+                "to_currency"
+            ],
+            "properties": {
+                "from_currency": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "to_currency": {
+                    "type": "string"
+                }
+            }
+        },
+        "fx.UploadRatesRequest": {
+            "type": "object",
+            "required": [
+                "rates"
+            ],
+            "properties": {
+                "rates": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/fx.RateInput"
+                    }
+                }
+            }
+        },
+        "interaction.Direction": {
+            "type": "string",
+            "enum": [
+                "OUTBOUND",
+                "INBOUND"
+            ],
+            "x-enum-varnames": [
+                "DirectionOutbound",
+                "DirectionInbound"
+            ]
+        },
+        "interaction.Interaction": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "direction": {
+                    "$ref": "#/definitions/interaction.Direction"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "request_body": {
+                    "type": "string"
+                },
+                "request_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "response_body": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ledger.Entry": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dispute_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/ledger.EntryType"
+                }
+            }
+        },
+        "ledger.EntryType": {
+            "type": "string",
+            "enum": [
+                "CHARGEBACK"
+            ],
+            "x-enum-varnames": [
+                "EntryTypeChargeback"
+            ]
+        },
+        "merchant.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "auth_mode": {
+                    "type": "string",
+                    "enum": [
+                        "token",
+                        "hmac"
+                    ]
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "merchant.Merchant": {
+            "type": "object",
+            "properties": {
+                "allowed_redirect_domains": {
+                    "description": "AllowedRedirectDomains is the space separated list of domains payments may redirect to",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "merchant.UpdateRedirectDomainsRequest": {
+            "type": "object",
+            "required": [
+                "domains"
+            ],
+            "properties": {
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "payment.CaptureRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                }
+            }
+        },
+        "payment.Customer": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "locale": {
+                    "type": "string",
+                    "maxLength": 35
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "payment.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "authorized_at": {
+                    "type": "string"
+                },
+                "beneficiary": {
+                    "$ref": "#/definitions/bankaccount.BankAccount"
+                },
+                "beneficiary_id": {
+                    "description": "Beneficiary is the bank account a payout is sent to, with its identifiers masked, and BeneficiaryID the\nsaved beneficiary it was taken from, if any. Both are nil for other payments.",
+                    "type": "string"
+                },
+                "cancel_url": {
+                    "type": "string"
+                },
+                "capture_mode": {
+                    "description": "CaptureMode is manual for a deposit the provider only authorizes: it is AUTHORIZED as of AuthorizedAt,\nthen CAPTURED for CapturedAmount, up to its Amount, or VOIDED",
+                    "type": "string"
+                },
+                "captured_amount": {
+                    "type": "number"
+                },
+                "captured_at": {
+                    "type": "string"
+                },
+                "checkout_url": {
+                    "type": "string"
+                },
+                "converted_amount": {
+                    "description": "A payment settled in another currency keeps its original Amount and CurrencyCode; the provider is\nsent the converted amount, converted at FXRate as of FXRateAt",
+                    "type": "number"
+                },
+                "converted_currency": {
+                    "type": "string"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "customer": {
+                    "$ref": "#/definitions/payment.Customer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "failure_url": {
+                    "type": "string"
+                },
+                "fx_rate": {
+                    "type": "number"
+                },
+                "fx_rate_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "merchant_reference": {
+                    "description": "MerchantReference is the merchant's own identifier of the payment, unique per merchant",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "needs_review": {
+                    "description": "NeedsReview flags a payment an operator has to look at, such as one the provider completed after it was cancelled",
+                    "type": "boolean"
+                },
+                "payment_type": {
+                    "$ref": "#/definitions/utils.PaymentType"
+                },
+                "provider": {
+                    "$ref": "#/definitions/provider.Provider"
+                },
+                "provider_configuration_id": {
+                    "description": "ProviderConfigurationID and ProviderPriority are the configuration the payment was sent to the provider with\nand its priority at the time. They are nil until the payment is routed.",
+                    "type": "integer"
+                },
+                "provider_id": {
+                    "type": "integer"
+                },
+                "provider_priority": {
+                    "type": "integer"
+                },
+                "review_reason": {
+                    "type": "string"
+                },
+                "routing_decision": {
+                    "description": "RoutingDecision records how the provider of the payment was chosen, for later analysis",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/routing.Decision"
+                        }
+                    ]
+                },
+                "save_method": {
+                    "description": "SaveMethod asks the provider of a deposit for a reusable token of the payment method once it succeeded,\nand SavedMethodID is the saved method the token is stored as, or the saved method the deposit charged",
+                    "type": "boolean"
+                },
+                "saved_method_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/utils.PaymentStatus"
+                },
+                "success_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "payment.PaymentMethod": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "payment.PaymentRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "country_code",
+                "currency_code",
+                "exclude_providers",
+                "user_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "cancel_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "capture_mode": {
+                    "description": "CaptureMode manual only has the provider authorize a deposit, for the merchant to capture or void it later",
+                    "type": "string",
+                    "enum": [
+                        "automatic",
+                        "manual"
+                    ]
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "customer": {
+                    "$ref": "#/definitions/payment.Customer"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "exclude_providers": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "failure_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "fx_quote_id": {
+                    "type": "string"
+                },
+                "hosted_checkout": {
+                    "description": "HostedCheckout returns a gateway checkout page where the customer picks the provider,\ninstead of the URL of the highest priority provider",
+                    "type": "boolean"
+                },
+                "merchant_reference": {
+                    "description": "MerchantReference is the merchant's own identifier of the payment, unique per merchant, and the\npayment can be looked up by it. Metadata is stored as is and returned with the payment.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "provider": {
+                    "description": "Provider routes the payment to the named provider instead of the highest priority one, and\nExcludeProviders never routes it to the named providers. The customer picks the provider on\nthe hosted checkout, so neither can be combined with it.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "save_method": {
+                    "description": "SaveMethod saves the payment method of a deposit with its provider once it succeeded, and\nSavedMethodID charges a method the user saved before, server to server with no redirect, with\nthe provider the method was saved with",
+                    "type": "boolean"
+                },
+                "saved_method_id": {
+                    "type": "string"
+                },
+                "settlement_currency": {
+                    "description": "SettlementCurrency converts the payment at the latest rate and routes it to the providers of that\ncurrency, and FXQuoteID does the same at the rate locked by a quote of CurrencyCode to the\nsettlement currency",
+                    "type": "string"
+                },
+                "success_url": {
+                    "description": "Optional pages the customer is sent to after the payment, on one of the merchant's allowed redirect domains",
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_segment": {
+                    "description": "UserSegment is the merchant's label for the customer, such as \"vip\", that routing rules can match",
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "payment.PayoutRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "country_code",
+                "currency_code",
+                "exclude_providers",
+                "user_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "beneficiary": {
+                    "$ref": "#/definitions/bankaccount.BankAccount"
+                },
+                "beneficiary_id": {
+                    "type": "string"
+                },
+                "cancel_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "capture_mode": {
+                    "description": "CaptureMode manual only has the provider authorize a deposit, for the merchant to capture or void it later",
+                    "type": "string",
+                    "enum": [
+                        "automatic",
+                        "manual"
+                    ]
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "customer": {
+                    "$ref": "#/definitions/payment.Customer"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "exclude_providers": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "failure_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "fx_quote_id": {
+                    "type": "string"
+                },
+                "hosted_checkout": {
+                    "description": "HostedCheckout returns a gateway checkout page where the customer picks the provider,\ninstead of the URL of the highest priority provider",
+                    "type": "boolean"
+                },
+                "merchant_reference": {
+                    "description": "MerchantReference is the merchant's own identifier of the payment, unique per merchant, and the\npayment can be looked up by it. Metadata is stored as is and returned with the payment.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "provider": {
+                    "description": "Provider routes the payment to the named provider instead of the highest priority one, and\nExcludeProviders never routes it to the named providers. The customer picks the provider on\nthe hosted checkout, so neither can be combined with it.",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "save_method": {
+                    "description": "SaveMethod saves the payment method of a deposit with its provider once it succeeded, and\nSavedMethodID charges a method the user saved before, server to server with no redirect, with\nthe provider the method was saved with",
+                    "type": "boolean"
+                },
+                "saved_method_id": {
+                    "type": "string"
+                },
+                "settlement_currency": {
+                    "description": "SettlementCurrency converts the payment at the latest rate and routes it to the providers of that\ncurrency, and FXQuoteID does the same at the rate locked by a quote of CurrencyCode to the\nsettlement currency",
+                    "type": "string"
+                },
+                "success_url": {
+                    "description": "Optional pages the customer is sent to after the payment, on one of the merchant's allowed redirect domains",
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_segment": {
+                    "description": "UserSegment is the merchant's label for the customer, such as \"vip\", that routing rules can match",
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "provider.Provider": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "provider.ProviderConfiguration": {
+            "type": "object",
+            "properties": {
+                "base_url": {
+                    "type": "string"
+                },
+                "country_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "provider_display_name": {
+                    "description": "ProviderDisplayName is the name of the provider shown to merchants and customers",
+                    "type": "string"
+                },
+                "provider_id": {
+                    "type": "integer"
+                },
+                "provider_name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "provider.UpdateProviderConfigRequest": {
+            "type": "object",
+            "required": [
+                "priority"
+            ],
+            "properties": {
+                "base_url": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "weight": {
+                    "description": "Weight is the share of traffic among the configurations of the same priority, 0 stops routing to it",
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                }
+            }
+        },
+        "routing.Candidate": {
+            "type": "object",
+            "properties": {
+                "average_latency_ms": {
+                    "type": "integer"
+                },
+                "calls": {
+                    "type": "integer"
+                },
+                "effective_weight": {
+                    "type": "number"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "provider_configuration_id": {
+                    "type": "integer"
+                },
+                "success_rate": {
+                    "type": "number"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routing.CreateRuleRequest": {
+            "type": "object",
+            "required": [
+                "priority",
+                "provider"
+            ],
+            "properties": {
+                "active_from": {
+                    "description": "ActiveFrom and ActiveUntil limit the rule to a UTC time of day window, such as 22:00 to 06:00",
+                    "type": "string"
+                },
+                "active_until": {
+                    "type": "string"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "max_amount": {
+                    "type": "number"
+                },
+                "min_amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "payment_type": {
+                    "type": "string",
+                    "enum": [
+                        "DEPOSIT",
+                        "WITHDRAWAL"
+                    ]
+                },
+                "priority": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "provider": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "user_segment": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "routing.Decision": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routing.Candidate"
+                    }
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "provider_configuration_id": {
+                    "type": "integer"
+                },
+                "rule_id": {
+                    "type": "integer"
+                },
+                "strategy": {
+                    "$ref": "#/definitions/routing.Strategy"
+                }
+            }
+        },
+        "routing.Rule": {
+            "type": "object",
+            "properties": {
+                "active_from": {
+                    "type": "string"
+                },
+                "active_until": {
+                    "type": "string"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_amount": {
+                    "type": "number"
+                },
+                "min_amount": {
+                    "type": "number"
+                },
+                "payment_type": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "$ref": "#/definitions/provider.Provider"
+                },
+                "provider_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_segment": {
+                    "type": "string"
+                }
+            }
+        },
+        "routing.Strategy": {
+            "type": "string",
+            "enum": [
+                "preferred",
+                "rule",
+                "weighted",
+                "priority",
+                "checkout",
+                "saved_method"
+            ],
+            "x-enum-varnames": [
+                "StrategyPreferred",
+                "StrategyRule",
+                "StrategyWeighted",
+                "StrategyPriority",
+                "StrategyCheckout",
+                "StrategySavedMethod"
+            ]
+        },
+        "savedmethod.SavedMethod": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "$ref": "#/definitions/provider.Provider"
+                },
+                "provider_id": {
+                    "type": "integer"
+                },
+                "source_payment_id": {
+                    "description": "SourcePaymentID is the payment the user saved the method with",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/savedmethod.Status"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "savedmethod.Status": {
+            "type": "string",
+            "enum": [
+                "ACTIVE",
+                "DISABLED"
+            ],
+            "x-enum-varnames": [
+                "StatusActive",
+                "StatusDisabled"
+            ]
+        },
+        "subscription.Charge": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer"
+                },
+                "checkout_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "period": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/subscription.ChargeStatus"
+                },
+                "subscription_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.ChargeStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "SUCCEEDED",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "ChargeStatusPending",
+                "ChargeStatusSucceeded",
+                "ChargeStatusFailed"
+            ]
+        },
+        "subscription.CreatePlanRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "currency_code",
+                "interval",
+                "name"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "currency_code": {
+                    "type": "string"
+                },
+                "interval": {
+                    "enum": [
+                        "DAY",
+                        "WEEK",
+                        "MONTH",
+                        "YEAR"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/subscription.Interval"
+                        }
+                    ]
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "subscription.CreateSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "country_code",
+                "plan_id",
+                "user_id"
+            ],
+            "properties": {
                 "country_code": {
                     "type": "string"
                 },
+                "customer": {
+                    "$ref": "#/definitions/payment.Customer"
+                },
+                "plan_id": {
+                    "type": "string"
+                },
+                "saved_method_id": {
+                    "description": "SavedMethodID charges the subscription with a saved payment method of the user, with no redirect",
+                    "type": "string"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "subscription.Interval": {
+            "type": "string",
+            "enum": [
+                "DAY",
+                "WEEK",
+                "MONTH",
+                "YEAR"
+            ],
+            "x-enum-varnames": [
+                "IntervalDay",
+                "IntervalWeek",
+                "IntervalMonth",
+                "IntervalYear"
+            ]
+        },
+        "subscription.Plan": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
                 "currency_code": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "string"
+                },
+                "interval": {
+                    "$ref": "#/definitions/subscription.Interval"
+                },
+                "interval_count": {
+                    "type": "integer"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "subscription.Status": {
+            "type": "string",
+            "enum": [
+                "ACTIVE",
+                "PAST_DUE",
+                "PAUSED",
+                "CANCELLED"
+            ],
+            "x-enum-varnames": [
+                "StatusActive",
+                "StatusPastDue",
+                "StatusPaused",
+                "StatusCancelled"
+            ]
+        },
+        "subscription.Subscription": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "charges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/subscription.Charge"
+                    }
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "customer": {
+                    "$ref": "#/definitions/payment.Customer"
+                },
+                "failed_attempts": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merchant_id": {
+                    "type": "integer"
+                },
+                "next_charge_at": {
+                    "description": "NextChargeAt is the start of the next period, or the next retry of a PAST_DUE subscription.\nFailedAttempts counts the failed charges of the period since its last success.",
+                    "type": "string"
+                },
+                "next_period": {
+                    "type": "integer"
+                },
+                "paused_at": {
+                    "type": "string"
+                },
+                "pending_payment_id": {
+                    "description": "PendingPaymentID is the payment of the charge in progress, the subscription is not charged again until it completes",
+                    "type": "string"
+                },
+                "plan": {
+                    "$ref": "#/definitions/subscription.Plan"
+                },
+                "plan_id": {
+                    "type": "string"
+                },
+                "saved_method_id": {
+                    "description": "SavedMethodID is the saved payment method the subscription is charged with, with no redirect. Without\none each charge records a checkout URL the customer pays at.",
+                    "type": "string"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/subscription.Status"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
                 "user_id": {
                     "type": "integer"
                 }
             }
+        },
+        "utils.APIResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "data": {},
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "INITIALIZED",
+                "PENDING",
+                "SUCCESS",
+                "FAILED",
+                "EXPIRED",
+                "CANCELLED",
+                "AUTHORIZED",
+                "CAPTURED",
+                "VOIDED"
+            ],
+            "x-enum-varnames": [
+                "PaymentStatusInitialized",
+                "PaymentStatusPending",
+                "PaymentStatusSuccess",
+                "PaymentStatusFailed",
+                "PaymentStatusExpired",
+                "PaymentStatusCancelled",
+                "PaymentStatusAuthorized",
+                "PaymentStatusCaptured",
+                "PaymentStatusVoided"
+            ]
+        },
+        "utils.PaymentType": {
+            "type": "string",
+            "enum": [
+                "DEPOSIT",
+                "WITHDRAWAL"
+            ],
+            "x-enum-varnames": [
+                "PaymentTypeDeposit",
+                "PaymentTypeWithdrawal"
+            ]
         }
     }
 }`
@@ -0,0 +1,20 @@
+package bankaccount
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrIBANRequired is returned when the account is in a country identifying accounts by IBAN and has none
+	ErrIBANRequired = utils.NewAPIError(http.StatusUnprocessableEntity, "invalid_bank_account", "Bank accounts in this country are identified by an IBAN")
+
+	// ErrInvalidIBAN is returned when the IBAN is not one of the country of the payout or its check digits are wrong
+	ErrInvalidIBAN = utils.NewAPIError(http.StatusUnprocessableEntity, "invalid_bank_account", "IBAN is not valid for the country")
+
+	// ErrInvalidAccountNumber is returned when the account number is missing or not in the format of the country
+	ErrInvalidAccountNumber = utils.NewAPIError(http.StatusUnprocessableEntity, "invalid_bank_account", "Account number is missing or not valid for the country")
+
+	// ErrInvalidBankCode is returned when the bank code is missing or not in the format of the country
+	ErrInvalidBankCode = utils.NewAPIError(http.StatusUnprocessableEntity, "invalid_bank_account", "Bank code is missing or not valid for the country")
+)
@@ -0,0 +1,48 @@
+package bankaccount
+
+import "strings"
+
+// BankAccount identifies the bank account money is paid out to. Depending on the country it is
+// identified by an IBAN, or by an account number and a bank code, see Validate.
+type BankAccount struct {
+	HolderName    string `json:"holder_name" binding:"required,max=140"`
+	IBAN          string `json:"iban,omitempty" binding:"omitempty,max=42"`
+	AccountNumber string `json:"account_number,omitempty" binding:"omitempty,max=34"`
+	BankCode      string `json:"bank_code,omitempty" binding:"omitempty,max=11"`
+}
+
+// Normalized returns the account with the spaces removed from its identifiers and the letters uppercased
+func (a BankAccount) Normalized() BankAccount {
+	return BankAccount{
+		HolderName:    strings.TrimSpace(a.HolderName),
+		IBAN:          compact(a.IBAN),
+		AccountNumber: compact(a.AccountNumber),
+		BankCode:      compact(a.BankCode),
+	}
+}
+
+// Masked returns the account with all but the last four characters of its IBAN and account number
+// hidden. The country code of the IBAN is kept.
+func (a BankAccount) Masked() BankAccount {
+	masked := a
+	if a.IBAN != "" {
+		masked.IBAN = mask(a.IBAN, 2)
+	}
+	if a.AccountNumber != "" {
+		masked.AccountNumber = mask(a.AccountNumber, 0)
+	}
+	return masked
+}
+
+// compact removes the spaces and dashes of an identifier and uppercases it
+func compact(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+}
+
+// mask replaces the characters of s with * except the first keep and the last four
+func mask(s string, keep int) string {
+	if len(s) <= keep+4 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:keep] + strings.Repeat("*", len(s)-keep-4) + s[len(s)-4:]
+}
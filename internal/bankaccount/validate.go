@@ -0,0 +1,102 @@
+package bankaccount
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// countryRule is how the bank accounts of a country are identified: by an IBAN of the given length,
+// or by an account number and a bank code in the country's formats
+type countryRule struct {
+	ibanLength    int
+	accountNumber *regexp.Regexp
+	bankCode      *regexp.Regexp
+	bankCodeName  string
+	checkBankCode func(string) bool
+}
+
+// countryRules are the countries with their own account formats. Accounts in other countries are
+// identified by an account number and the SWIFT BIC of their bank.
+var countryRules = map[string]countryRule{
+	"AE": {ibanLength: 23},
+	"BH": {ibanLength: 22},
+	"DE": {ibanLength: 22},
+	"FR": {ibanLength: 27},
+	"GB": {ibanLength: 22},
+	"KW": {ibanLength: 30},
+	"QA": {ibanLength: 29},
+	"SA": {ibanLength: 24},
+	"US": {accountNumber: regexp.MustCompile(`^\d{4,17}$`), bankCode: regexp.MustCompile(`^\d{9}$`), bankCodeName: "ABA routing number", checkBankCode: validABA},
+	"CA": {accountNumber: regexp.MustCompile(`^\d{7,12}$`), bankCode: regexp.MustCompile(`^\d{8,9}$`), bankCodeName: "institution and transit number"},
+	"AU": {accountNumber: regexp.MustCompile(`^\d{6,9}$`), bankCode: regexp.MustCompile(`^\d{6}$`), bankCodeName: "BSB"},
+	"IN": {accountNumber: regexp.MustCompile(`^\d{9,18}$`), bankCode: regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`), bankCodeName: "IFSC"},
+	"JP": {accountNumber: regexp.MustCompile(`^\d{7}$`), bankCode: regexp.MustCompile(`^\d{7}$`), bankCodeName: "bank and branch code"},
+	"CN": {accountNumber: regexp.MustCompile(`^\d{12,19}$`), bankCode: regexp.MustCompile(`^\d{12}$`), bankCodeName: "CNAPS code"},
+}
+
+// defaultRule applies to the countries without a rule of their own
+var defaultRule = countryRule{
+	accountNumber: regexp.MustCompile(`^[A-Z0-9]{4,34}$`),
+	bankCode:      regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`),
+	bankCodeName:  "SWIFT BIC",
+}
+
+// Validate checks that the account is identified the way accounts are in the country. It expects a
+// normalized account, see Normalized.
+func (a BankAccount) Validate(countryCode string) error {
+	countryCode = strings.ToUpper(countryCode)
+	rule, ok := countryRules[countryCode]
+	if !ok {
+		rule = defaultRule
+	}
+
+	if rule.ibanLength > 0 {
+		if a.IBAN == "" {
+			return fmt.Errorf("%w: %s", ErrIBANRequired, countryCode)
+		}
+		if len(a.IBAN) != rule.ibanLength || !strings.HasPrefix(a.IBAN, countryCode) || !validIBANChecksum(a.IBAN) {
+			return fmt.Errorf("%w: %s IBANs have %d characters and valid check digits", ErrInvalidIBAN, countryCode, rule.ibanLength)
+		}
+		return nil
+	}
+
+	if !rule.accountNumber.MatchString(a.AccountNumber) {
+		return fmt.Errorf("%w: %s", ErrInvalidAccountNumber, countryCode)
+	}
+	if !rule.bankCode.MatchString(a.BankCode) || (rule.checkBankCode != nil && !rule.checkBankCode(a.BankCode)) {
+		return fmt.Errorf("%w: expected the %s of the bank", ErrInvalidBankCode, rule.bankCodeName)
+	}
+	return nil
+}
+
+// validIBANChecksum verifies the check digits of an IBAN with the ISO 7064 mod 97-10 algorithm
+func validIBANChecksum(iban string) bool {
+	rearranged := iban[4:] + iban[:4]
+
+	var digits strings.Builder
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		default:
+			return false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validABA verifies the check digit of a US ABA routing number
+func validABA(routingNumber string) bool {
+	weights := []int{3, 7, 1}
+	sum := 0
+	for i, r := range routingNumber {
+		sum += int(r-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,50 @@
+package bankaccount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		country string
+		account BankAccount
+		wantErr error
+	}{
+		{name: "GB IBAN", country: "GB", account: BankAccount{IBAN: "GB82WEST12345698765432"}},
+		{name: "AE IBAN", country: "ae", account: BankAccount{IBAN: "AE070331234567890123456"}},
+		{name: "IBAN missing", country: "DE", account: BankAccount{AccountNumber: "0532013000", BankCode: "37040044"}, wantErr: ErrIBANRequired},
+		{name: "IBAN of another country", country: "FR", account: BankAccount{IBAN: "DE89370400440532013000"}, wantErr: ErrInvalidIBAN},
+		{name: "IBAN with wrong check digits", country: "GB", account: BankAccount{IBAN: "GB83WEST12345698765432"}, wantErr: ErrInvalidIBAN},
+		{name: "IBAN with wrong length", country: "GB", account: BankAccount{IBAN: "GB82WEST1234569876543"}, wantErr: ErrInvalidIBAN},
+		{name: "US account", country: "US", account: BankAccount{AccountNumber: "123456789", BankCode: "021000021"}},
+		{name: "US routing number with wrong checksum", country: "US", account: BankAccount{AccountNumber: "123456789", BankCode: "021000022"}, wantErr: ErrInvalidBankCode},
+		{name: "US account number missing", country: "US", account: BankAccount{BankCode: "021000021"}, wantErr: ErrInvalidAccountNumber},
+		{name: "IN account", country: "IN", account: BankAccount{AccountNumber: "50100012345678", BankCode: "HDFC0000001"}},
+		{name: "IN invalid IFSC", country: "IN", account: BankAccount{AccountNumber: "50100012345678", BankCode: "HDFC1000001"}, wantErr: ErrInvalidBankCode},
+		{name: "other country with SWIFT BIC", country: "EG", account: BankAccount{AccountNumber: "12345678", BankCode: "NBEGEGCX"}},
+		{name: "other country without SWIFT BIC", country: "EG", account: BankAccount{AccountNumber: "12345678", BankCode: "123"}, wantErr: ErrInvalidBankCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.account.HolderName = "Jane Doe"
+			err := tt.account.Validate(tt.country)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizedAndMasked(t *testing.T) {
+	account := BankAccount{HolderName: " Jane Doe ", IBAN: "gb82 west 1234 5698 7654 32", AccountNumber: "1234-5678"}.Normalized()
+
+	assert.Equal(t, BankAccount{HolderName: "Jane Doe", IBAN: "GB82WEST12345698765432", AccountNumber: "12345678"}, account)
+	assert.Equal(t, BankAccount{HolderName: "Jane Doe", IBAN: "GB****************5432", AccountNumber: "****5678"}, account.Masked())
+	assert.Equal(t, "***", BankAccount{AccountNumber: "123"}.Masked().AccountNumber)
+}
@@ -0,0 +1,41 @@
+package batch
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrBatchNotFound is returned when no batch of the merchant has the ID
+	ErrBatchNotFound = utils.NewAPIError(http.StatusNotFound, "payout_batch_not_found", "Payout batch not found")
+
+	// ErrUnsupportedContentType is returned when a batch is neither JSON nor CSV
+	ErrUnsupportedContentType = utils.NewAPIError(http.StatusUnsupportedMediaType, "unsupported_content_type", "Payout batches must be sent as JSON, CSV or a multipart CSV file")
+
+	// ErrBatchesNotConfigured is returned when batches are used without BENEFICIARY_ENCRYPTION_KEY, which encrypts their payouts
+	ErrBatchesNotConfigured = utils.NewAPIError(http.StatusServiceUnavailable, "payout_batches_unavailable", "Payout batches are not available")
+)
+
+// LineErrors are the errors of the lines of a batch, keyed by "line N". A batch with any of them is
+// rejected as a whole.
+type LineErrors map[string][]string
+
+// add records an error of a line. Line 0 is the batch itself.
+func (e LineErrors) add(line int, message string) {
+	key := "batch"
+	if line > 0 {
+		key = "line " + strconv.Itoa(line)
+	}
+	e[key] = append(e[key], message)
+}
+
+// Error implements the error interface
+func (e LineErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for key, errs := range e {
+		messages = append(messages, key+": "+strings.Join(errs, ", "))
+	}
+	return "invalid payout batch: " + strings.Join(messages, "; ")
+}
@@ -0,0 +1,178 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBodyBytes is the largest batch accepted, enough for MaxItems payouts
+const maxBodyBytes = 5 << 20
+
+// BatchHandler handles the payout batch requests
+type BatchHandler struct {
+	service BatchServiceInterface
+}
+
+// NewBatchHandler initializes a new BatchHandler. The payouts of batches are created with payouts.
+func NewBatchHandler(db *gorm.DB, configStore *config.Store, payouts PayoutServiceInterface) *BatchHandler {
+	// Nil without BENEFICIARY_ENCRYPTION_KEY, whose length is validated with the configuration
+	cipher, _ := beneficiary.NewCipher(configStore.Current().BeneficiaryEncryptionKeyBytes())
+	return &BatchHandler{service: NewBatchService(db, cipher, payouts)}
+}
+
+// Create creates a payout batch
+// @Summary Create a payout batch
+// @Description Accepts up to 1000 payouts, as JSON with the payouts listed like single payout requests, as a CSV body or as a CSV file in the multipart field "file". CSV files start with a header naming their columns among user_id, amount, currency_code, country_code, merchant_reference, description, beneficiary_id, holder_name, iban, account_number and bank_code; the first four are required. Every payout is validated before the batch is accepted, and a batch with any invalid payout is rejected with the errors of each line. The payouts are then sent in the background; the batch is PROCESSING until each of them was submitted to its provider or failed, and then COMPLETED.
+// @Tags payouts
+// @Accept json
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param request body CreateBatchRequest false "Payouts, when sent as JSON"
+// @Param file formData file false "CSV file, when sent as multipart"
+// @Success 202 {object} utils.APIResponse{data=Batch} "Accepted batch"
+// @Failure 400 {object} utils.APIResponse "Invalid payouts, keyed by line"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:withdrawal"
+// @Failure 415 {object} utils.APIResponse "Unsupported content type"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Failure 503 {object} utils.APIResponse "Payout batches are not configured"
+// @Router /payouts/batches [post]
+func (h *BatchHandler) Create(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+
+	lines, err := parseBody(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	batch, err := h.service.Create(c, lines)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	go func() {
+		// The request context ends with the response, the batch is processed on behalf of its merchant
+		_ = h.service.Process(context.Background(), batch.ID)
+	}()
+	utils.SuccessResponse(c, http.StatusAccepted, "Payout batch accepted", batch)
+}
+
+// respondError responds with the errors of each line of a rejected batch, or attaches any other error
+func respondError(c *gin.Context, err error) {
+	var lineErrs LineErrors
+	if errors.As(err, &lineErrs) {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", lineErrs)
+		return
+	}
+	_ = c.Error(err)
+}
+
+// parseBody reads the payouts of a batch according to the content type of the request
+func parseBody(c *gin.Context) ([]Line, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return ParseJSON(c.Request.Body)
+	case "text/csv":
+		return ParseCSV(c.Request.Body)
+	case "multipart/form-data":
+		header, err := c.FormFile("file")
+		if err != nil {
+			errs := LineErrors{}
+			errs.add(0, "the CSV file is expected in the field \"file\"")
+			return nil, errs
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ParseCSV(file)
+	default:
+		return nil, ErrUnsupportedContentType
+	}
+}
+
+// Get returns a payout batch
+// @Summary Get a payout batch
+// @Description Returns a payout batch of the authenticated merchant with its progress: the number of payouts processed so far, and how many of them were submitted or failed.
+// @Tags payouts
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Batch ID"
+// @Success 200 {object} utils.APIResponse{data=Batch} "Batch"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Failure 404 {object} utils.APIResponse "Batch not found"
+// @Router /payouts/batches/{id} [get]
+func (h *BatchHandler) Get(c *gin.Context) {
+	batch, err := h.service.Find(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payout batch", batch)
+}
+
+// ListItems lists the payouts of a batch
+// @Summary List the payouts of a batch
+// @Description Lists the payouts of a batch of the authenticated merchant in the order of their lines, with their result and the current status of their payment.
+// @Tags payouts
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Batch ID"
+// @Success 200 {object} utils.APIResponse{data=[]Item} "Payouts of the batch"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Failure 404 {object} utils.APIResponse "Batch not found"
+// @Router /payouts/batches/{id}/items [get]
+func (h *BatchHandler) ListItems(c *gin.Context) {
+	items, err := h.service.Items(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payout batch items", items)
+}
+
+// Results downloads the results of a batch
+// @Summary Download the results of a batch
+// @Description Returns the payouts of a batch of the authenticated merchant as CSV, one line per payout with its line, merchant reference, user, amount, currency, country, status, payment ID, payment status and error.
+// @Tags payouts
+// @Produce text/csv
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Batch ID"
+// @Success 200 {string} string "Result CSV"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Failure 404 {object} utils.APIResponse "Batch not found"
+// @Router /payouts/batches/{id}/results.csv [get]
+func (h *BatchHandler) Results(c *gin.Context) {
+	items, err := h.service.Items(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"payout-batch-%s.csv\"", c.Param("id")))
+	c.Status(http.StatusOK)
+	if err := WriteResultsCSV(c.Writer, items); err != nil {
+		utils.LogWithRequestID(c, fmt.Sprintf("BatchHandler: Failed to write the results of batch %s: %v", c.Param("id"), err))
+	}
+}
@@ -0,0 +1,77 @@
+package batch
+
+import (
+	"payment-gateway-service/internal/utils"
+	"time"
+)
+
+// Status is the processing status of a batch
+type Status string
+
+const (
+	// StatusPending batches were accepted and wait for their payouts to be sent
+	StatusPending Status = "PENDING"
+	// StatusProcessing batches are sending their payouts
+	StatusProcessing Status = "PROCESSING"
+	// StatusCompleted batches sent every payout, or failed to
+	StatusCompleted Status = "COMPLETED"
+)
+
+// ItemStatus is the status of a payout of a batch
+type ItemStatus string
+
+const (
+	// ItemStatusPending payouts were not sent yet
+	ItemStatusPending ItemStatus = "PENDING"
+	// ItemStatusSending payouts are being sent by the run processing the batch
+	ItemStatusSending ItemStatus = "SENDING"
+	// ItemStatusSubmitted payouts were created and accepted by their provider, their payment tells whether they settled
+	ItemStatusSubmitted ItemStatus = "SUBMITTED"
+	// ItemStatusFailed payouts could not be created or were refused by their provider
+	ItemStatusFailed ItemStatus = "FAILED"
+)
+
+// Batch is a set of payouts of a merchant sent in the background. Its counters tell the progress
+// of the batch while it is PROCESSING.
+type Batch struct {
+	ID             string     `gorm:"type:uuid;primaryKey" json:"id"`
+	MerchantID     uint       `gorm:"not null" json:"merchant_id"`
+	Status         Status     `gorm:"type:varchar(20);not null" json:"status"`
+	TotalItems     int        `gorm:"not null" json:"total_items"`
+	ProcessedItems int        `gorm:"not null" json:"processed_items"`
+	SubmittedItems int        `gorm:"not null" json:"submitted_items"`
+	FailedItems    int        `gorm:"not null" json:"failed_items"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+func (Batch) TableName() string {
+	return "payout_batches"
+}
+
+// Item is a payout of a batch. The payout request is stored encrypted, as it may carry a bank
+// account, and the columns needed for the results are kept in clear.
+type Item struct {
+	ID                uint    `gorm:"primaryKey" json:"-"`
+	BatchID           string  `gorm:"type:uuid;not null" json:"batch_id"`
+	Line              int     `gorm:"not null" json:"line"`
+	UserID            int     `gorm:"not null" json:"user_id"`
+	Amount            float64 `gorm:"not null" json:"amount"`
+	CurrencyCode      string  `gorm:"type:varchar(3);not null" json:"currency_code"`
+	CountryCode       string  `gorm:"type:varchar(2);not null" json:"country_code"`
+	MerchantReference string  `gorm:"type:varchar(255);not null" json:"merchant_reference"`
+	EncryptedRequest  []byte  `gorm:"type:bytea;not null" json:"-"`
+
+	Status      ItemStatus `gorm:"type:varchar(20);not null" json:"status"`
+	PaymentID   *string    `gorm:"type:uuid" json:"payment_id,omitempty"`
+	Error       string     `gorm:"type:text;not null" json:"error,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+
+	// PaymentStatus is the current status of the payment of the payout, read with the items
+	PaymentStatus utils.PaymentStatus `gorm:"->" json:"payment_status,omitempty"`
+}
+
+func (Item) TableName() string {
+	return "payout_batch_items"
+}
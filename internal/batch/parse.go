@@ -0,0 +1,181 @@
+package batch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/payment"
+	"strconv"
+	"strings"
+)
+
+// MaxItems is the largest number of payouts in a batch
+const MaxItems = 1000
+
+// Line is a payout of a batch with the line it was read from: the line of the CSV file, or
+// the position of the payout in the JSON list starting at 1
+type Line struct {
+	Number  int
+	Request payment.PayoutRequest
+}
+
+// CreateBatchRequest is a batch of payouts sent as JSON
+type CreateBatchRequest struct {
+	Payouts []payment.PayoutRequest `json:"payouts"`
+}
+
+// ParseJSON reads a batch sent as JSON
+func ParseJSON(r io.Reader) ([]Line, error) {
+	var request CreateBatchRequest
+	if err := json.NewDecoder(r).Decode(&request); err != nil {
+		errs := LineErrors{}
+		errs.add(0, "invalid JSON: "+err.Error())
+		return nil, errs
+	}
+
+	lines := make([]Line, len(request.Payouts))
+	for i, payout := range request.Payouts {
+		lines[i] = Line{Number: i + 1, Request: payout}
+	}
+	return lines, checkSize(lines)
+}
+
+// csvColumns are the columns of a batch sent as CSV. The first four are required, the account
+// columns give the beneficiary unless beneficiary_id is set.
+var csvColumns = []string{
+	"user_id", "amount", "currency_code", "country_code",
+	"merchant_reference", "description", "beneficiary_id",
+	"holder_name", "iban", "account_number", "bank_code",
+}
+
+// ParseCSV reads a batch sent as CSV. The first line names the columns, in any order. Values
+// that cannot be read are reported with their line.
+func ParseCSV(r io.Reader) ([]Line, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	errs := LineErrors{}
+
+	header, err := reader.Read()
+	if err != nil {
+		errs.add(0, "invalid CSV header: "+err.Error())
+		return nil, errs
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !knownColumn(name) {
+			errs.add(1, fmt.Sprintf("unknown column %q", name))
+			continue
+		}
+		columns[name] = i
+	}
+	for _, name := range csvColumns[:4] {
+		if _, ok := columns[name]; !ok {
+			errs.add(1, fmt.Sprintf("missing column %q", name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var lines []Line
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+				errs.add(parseErr.StartLine, fmt.Sprintf("expected %d columns, got %d", len(header), len(record)))
+				continue
+			}
+			errs.add(0, "invalid CSV: "+err.Error())
+			return nil, errs
+		}
+
+		number, _ := reader.FieldPos(0)
+		line, lineErrs := parseRecord(record, columns)
+		for _, message := range lineErrs {
+			errs.add(number, message)
+		}
+		line.Number = number
+		lines = append(lines, line)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return lines, checkSize(lines)
+}
+
+// parseRecord reads the payout of a CSV line
+func parseRecord(record []string, columns map[string]int) (Line, []string) {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var errs []string
+	request := payment.PayoutRequest{}
+	if userID := value("user_id"); userID != "" {
+		var err error
+		if request.UserID, err = strconv.Atoi(userID); err != nil {
+			errs = append(errs, "user_id must be a number")
+		}
+	}
+	if amount := value("amount"); amount != "" {
+		var err error
+		if request.Amount, err = strconv.ParseFloat(amount, 64); err != nil {
+			errs = append(errs, "amount must be a number")
+		}
+	}
+	request.CurrencyCode = value("currency_code")
+	request.CountryCode = value("country_code")
+	request.MerchantReference = value("merchant_reference")
+	request.Description = value("description")
+	request.BeneficiaryID = value("beneficiary_id")
+
+	account := bankaccount.BankAccount{
+		HolderName:    value("holder_name"),
+		IBAN:          value("iban"),
+		AccountNumber: value("account_number"),
+		BankCode:      value("bank_code"),
+	}
+	if account != (bankaccount.BankAccount{}) {
+		request.Beneficiary = &account
+	}
+
+	return Line{Request: request}, errs
+}
+
+// knownColumn tells whether name is a column of batches sent as CSV
+func knownColumn(name string) bool {
+	for _, column := range csvColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSize rejects empty batches and batches of more than MaxItems payouts
+func checkSize(lines []Line) error {
+	errs := LineErrors{}
+	switch {
+	case len(lines) == 0:
+		errs.add(0, "no payouts")
+	case len(lines) > MaxItems:
+		errs.add(0, fmt.Sprintf("at most %d payouts per batch, got %d", MaxItems, len(lines)))
+	default:
+		return nil
+	}
+	return errs
+}
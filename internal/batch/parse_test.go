@@ -0,0 +1,111 @@
+package batch
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSON(t *testing.T) {
+	lines, err := ParseJSON(strings.NewReader(`{"payouts": [
+		{"user_id": 1, "amount": 40, "currency_code": "GBP", "country_code": "GB", "beneficiary": {"holder_name": "Jane Doe", "iban": "GB82WEST12345698765432"}},
+		{"user_id": 2, "amount": 25.5, "currency_code": "GBP", "country_code": "GB", "beneficiary_id": "3f1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"}
+	]}`))
+
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, 1, lines[0].Number)
+	assert.Equal(t, "GB82WEST12345698765432", lines[0].Request.Beneficiary.IBAN)
+	assert.Equal(t, 2, lines[1].Number)
+	assert.Equal(t, 25.5, lines[1].Request.Amount)
+	assert.Equal(t, "3f1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21", lines[1].Request.BeneficiaryID)
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "\ufeffAmount,user_id,currency_code,country_code,merchant_reference,holder_name,iban,beneficiary_id\n" +
+		"40,1,GBP,GB,payday-1,Jane Doe,GB82 WEST 1234 5698 7654 32,\n" +
+		"\n" +
+		"\"25.50\",2,GBP,GB,payday-2,,,3f1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21\n"
+
+	lines, err := ParseCSV(strings.NewReader(csv))
+
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, 2, lines[0].Number)
+	assert.Equal(t, 1, lines[0].Request.UserID)
+	assert.Equal(t, 40.0, lines[0].Request.Amount)
+	assert.Equal(t, "payday-1", lines[0].Request.MerchantReference)
+	require.NotNil(t, lines[0].Request.Beneficiary)
+	assert.Equal(t, "Jane Doe", lines[0].Request.Beneficiary.HolderName)
+	assert.Equal(t, 4, lines[1].Number, "blank lines are skipped but counted")
+	assert.Equal(t, 25.5, lines[1].Request.Amount)
+	assert.Nil(t, lines[1].Request.Beneficiary, "no account columns are set")
+	assert.Equal(t, "3f1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21", lines[1].Request.BeneficiaryID)
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		expected LineErrors
+	}{
+		{
+			name:     "unknown and missing columns",
+			csv:      "user_id,amount,currency,country_code\n1,40,GBP,GB\n",
+			expected: LineErrors{"line 1": {`unknown column "currency"`, `missing column "currency_code"`}},
+		},
+		{
+			name: "unreadable values",
+			csv:  "user_id,amount,currency_code,country_code\n1,40,GBP,GB\njane,forty,GBP,GB\n1,40,GBP\n",
+			expected: LineErrors{
+				"line 3": {"user_id must be a number", "amount must be a number"},
+				"line 4": {"expected 4 columns, got 3"},
+			},
+		},
+		{
+			name:     "no payouts",
+			csv:      "user_id,amount,currency_code,country_code\n",
+			expected: LineErrors{"batch": {"no payouts"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := ParseCSV(strings.NewReader(tt.csv))
+
+			assert.Nil(t, lines)
+			assert.Equal(t, tt.expected, err)
+		})
+	}
+}
+
+func TestParseCSV_TooManyPayouts(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("user_id,amount,currency_code,country_code\n")
+	for i := 0; i <= MaxItems; i++ {
+		fmt.Fprintf(&csv, "%d,40,GBP,GB\n", i+1)
+	}
+
+	_, err := ParseCSV(strings.NewReader(csv.String()))
+
+	assert.Equal(t, LineErrors{"batch": {"at most 1000 payouts per batch, got 1001"}}, err)
+}
+
+func TestWriteResultsCSV(t *testing.T) {
+	paymentID := "9b2f6a4e-1c3d-4e5f-8a7b-6c5d4e3f2a1b"
+	items := []Item{
+		{Line: 2, MerchantReference: "payday-1", UserID: 1, Amount: 40, CurrencyCode: "GBP", CountryCode: "GB", Status: ItemStatusSubmitted, PaymentID: &paymentID, PaymentStatus: "SUCCESS"},
+		{Line: 3, MerchantReference: "payday-2", UserID: 2, Amount: 25.5, CurrencyCode: "GBP", CountryCode: "GB", Status: ItemStatusFailed, Error: "Payment provider rejected the payment, with a comma"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteResultsCSV(&buf, items))
+
+	assert.Equal(t, "line,merchant_reference,user_id,amount,currency_code,country_code,status,payment_id,payment_status,error\n"+
+		"2,payday-1,1,40,GBP,GB,SUBMITTED,9b2f6a4e-1c3d-4e5f-8a7b-6c5d4e3f2a1b,SUCCESS,\n"+
+		"3,payday-2,2,25.5,GBP,GB,FAILED,,,\"Payment provider rejected the payment, with a comma\"\n", buf.String())
+}
@@ -0,0 +1,45 @@
+package batch
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// resultColumns are the columns of the result CSV of a batch
+var resultColumns = []string{
+	"line", "merchant_reference", "user_id", "amount", "currency_code", "country_code",
+	"status", "payment_id", "payment_status", "error",
+}
+
+// WriteResultsCSV writes the result of every payout of a batch as CSV, one line per item
+func WriteResultsCSV(w io.Writer, items []Item) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(resultColumns); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var paymentID string
+		if item.PaymentID != nil {
+			paymentID = *item.PaymentID
+		}
+		if err := writer.Write([]string{
+			strconv.Itoa(item.Line),
+			item.MerchantReference,
+			strconv.Itoa(item.UserID),
+			strconv.FormatFloat(item.Amount, 'f', -1, 64),
+			item.CurrencyCode,
+			item.CountryCode,
+			string(item.Status),
+			paymentID,
+			string(item.PaymentStatus),
+			item.Error,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,392 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/middleware"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultWorkers is the number of payouts of a batch created at the same time. The payouts sent to
+// each provider are further bounded by PAYOUT_CONCURRENCY.
+const DefaultWorkers = 16
+
+// claimTimeout is how long a PROCESSING batch goes without progress before another run takes it over,
+// such as when the instance processing it stopped
+const claimTimeout = 5 * time.Minute
+
+// BatchServiceInterface defines the methods that the BatchService must implement.
+type BatchServiceInterface interface {
+	Create(ctx context.Context, lines []Line) (*Batch, error)
+	Find(ctx context.Context, id string) (*Batch, error)
+	Items(ctx context.Context, id string) ([]Item, error)
+	Process(ctx context.Context, id string) error
+	ResumeInterrupted(ctx context.Context) error
+}
+
+// PayoutServiceInterface defines the methods of the PaymentService used to send the payouts of batches.
+type PayoutServiceInterface interface {
+	CreatePayout(ctx context.Context, payoutRequest *payment.PayoutRequest) (*payment.Payment, error)
+	CheckPayout(ctx context.Context, payoutRequest *payment.PayoutRequest) error
+	FindPaymentByReference(ctx context.Context, reference string) (*payment.Payment, error)
+}
+
+// BatchService stores payout batches and sends their payouts.
+type BatchService struct {
+	db      *gorm.DB
+	cipher  *beneficiary.Cipher
+	payouts PayoutServiceInterface
+	workers int
+}
+
+// NewBatchService initializes a new BatchService. The payouts of batches are stored encrypted with
+// cipher, without which batches cannot be created nor processed.
+func NewBatchService(db *gorm.DB, cipher *beneficiary.Cipher, payouts PayoutServiceInterface) *BatchService {
+	return &BatchService{db: db, cipher: cipher, payouts: payouts, workers: DefaultWorkers}
+}
+
+var _ BatchServiceInterface = (*BatchService)(nil)
+
+// Create validates the payouts of a batch of the authenticated merchant and saves the batch as PENDING.
+// The batch is rejected with LineErrors if any of its payouts is invalid. Payouts without a merchant
+// reference are given one, so a batch resumed after a restart never sends a payout twice.
+func (s *BatchService) Create(ctx context.Context, lines []Line) (*Batch, error) {
+	if s.cipher == nil {
+		return nil, ErrBatchesNotConfigured
+	}
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(ctx, merchantID, lines); err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Batch rejected: %v", err))
+		return nil, err
+	}
+
+	batch := &Batch{
+		ID:         uuid.NewString(),
+		MerchantID: merchantID,
+		Status:     StatusPending,
+		TotalItems: len(lines),
+	}
+
+	items := make([]Item, len(lines))
+	for i, line := range lines {
+		request := line.Request
+		if request.MerchantReference == "" {
+			request.MerchantReference = fmt.Sprintf("batch-%s-%d", batch.ID, line.Number)
+		}
+
+		plaintext, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := s.cipher.Encrypt(plaintext)
+		if err != nil {
+			utils.LogWithRequestID(ctx, "BatchService: Failed to encrypt payout")
+			return nil, err
+		}
+
+		items[i] = Item{
+			BatchID:           batch.ID,
+			Line:              line.Number,
+			UserID:            request.UserID,
+			Amount:            request.Amount,
+			CurrencyCode:      request.CurrencyCode,
+			CountryCode:       request.CountryCode,
+			MerchantReference: request.MerchantReference,
+			EncryptedRequest:  encrypted,
+			Status:            ItemStatusPending,
+		}
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&items, 100).Error
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to save batch: %v", err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Batch %s created with %d payout(s)", batch.ID, batch.TotalItems))
+	return batch, nil
+}
+
+// validate checks every payout of a batch like a single payout request, their bank accounts or saved
+// beneficiaries, that a provider can send them, and that their merchant references are used once
+func (s *BatchService) validate(ctx context.Context, merchantID uint, lines []Line) error {
+	errs := LineErrors{}
+	references := make(map[string]int)
+
+	for i := range lines {
+		line := &lines[i]
+		request := &line.Request
+
+		if err := binding.Validator.ValidateStruct(request); err != nil {
+			var validationErrs validator.ValidationErrors
+			if !errors.As(err, &validationErrs) {
+				errs.add(line.Number, err.Error())
+				continue
+			}
+			for _, validationErr := range validationErrs {
+				errs.add(line.Number, validationErr.Field()+" "+middleware.ValidationMessage(validationErr))
+			}
+			continue
+		}
+
+		if request.Beneficiary != nil {
+			if err := request.Beneficiary.Normalized().Validate(request.CountryCode); err != nil {
+				errs.add(line.Number, err.Error())
+			}
+		}
+		if _, invalid := errs["line "+strconv.Itoa(line.Number)]; !invalid {
+			if err := s.payouts.CheckPayout(ctx, request); err != nil {
+				var apiErr *utils.APIError
+				if !errors.As(err, &apiErr) {
+					return err
+				}
+				errs.add(line.Number, err.Error())
+			}
+		}
+
+		if reference := request.MerchantReference; reference != "" {
+			if first, ok := references[reference]; ok {
+				errs.add(line.Number, fmt.Sprintf("MerchantReference is already used on line %d", first))
+				continue
+			}
+			references[reference] = line.Number
+		}
+	}
+
+	if len(references) > 0 {
+		list := make([]string, 0, len(references))
+		for reference := range references {
+			list = append(list, reference)
+		}
+
+		var used []string
+		if err := s.db.WithContext(ctx).Model(&payment.Payment{}).
+			Where("merchant_id = ? AND merchant_reference IN ?", merchantID, list).
+			Pluck("merchant_reference", &used).Error; err != nil {
+			return err
+		}
+		for _, reference := range used {
+			errs.add(references[reference], "MerchantReference is already used by another payment")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Find finds a batch of the authenticated merchant
+func (s *BatchService) Find(ctx context.Context, id string) (*Batch, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrBatchNotFound
+	}
+
+	var batch Batch
+	if err := s.db.WithContext(ctx).Where("id = ? AND merchant_id = ?", id, merchantID).First(&batch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBatchNotFound
+		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to find batch %s: %v", id, err))
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Items lists the payouts of a batch of the authenticated merchant in the order of their lines,
+// with the current status of their payments
+func (s *BatchService) Items(ctx context.Context, id string) ([]Item, error) {
+	batch, err := s.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	if err := s.db.WithContext(ctx).Model(&Item{}).
+		Select("payout_batch_items.*, payments.status AS payment_status").
+		Joins("LEFT JOIN payments ON payments.id = payout_batch_items.payment_id").
+		Where("payout_batch_items.batch_id = ?", batch.ID).
+		Order("payout_batch_items.line").
+		Find(&items).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to list the items of batch %s: %v", id, err))
+		return nil, err
+	}
+	return items, nil
+}
+
+// Process sends the PENDING payouts of a batch on behalf of its merchant and marks the batch COMPLETED
+// once each of them was submitted or failed. Processing a batch again after an interruption only
+// sends the payouts that were not processed. The batch is claimed first, so that a single run sends
+// its payouts: a PROCESSING batch is only taken over after it made no progress for claimTimeout.
+func (s *BatchService) Process(ctx context.Context, id string) error {
+	if s.cipher == nil {
+		return ErrBatchesNotConfigured
+	}
+
+	var batch Batch
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&batch).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to load batch %s: %v", id, err))
+		return err
+	}
+	if batch.Status == StatusCompleted {
+		return nil
+	}
+
+	// The payouts are created on behalf of the merchant of the batch
+	ctx = context.WithValue(ctx, utils.ContextKeyMerchantID, batch.MerchantID)
+
+	claim := s.db.WithContext(ctx).Model(&batch).
+		Where("status = ? OR (status = ? AND updated_at < ?)", StatusPending, StatusProcessing, time.Now().Add(-claimTimeout)).
+		Updates(map[string]interface{}{"status": StatusProcessing})
+	if claim.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to start batch %s: %v", id, claim.Error))
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Batch %s is processed by another run", id))
+		return nil
+	}
+
+	// Payouts left SENDING were being sent by a run that stopped, their merchant reference prevents sending them twice
+	var items []Item
+	if err := s.db.WithContext(ctx).Where("batch_id = ? AND status IN ?", id, []ItemStatus{ItemStatusPending, ItemStatusSending}).Order("line").Find(&items).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to list the pending items of batch %s: %v", id, err))
+		return err
+	}
+	utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Processing %d payout(s) of batch %s", len(items), id))
+
+	jobs := make(chan *Item)
+	var wg sync.WaitGroup
+	for i := 0; i < min(s.workers, len(items)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				s.processItem(ctx, item)
+			}
+		}()
+	}
+	for i := range items {
+		jobs <- &items[i]
+	}
+	close(jobs)
+	wg.Wait()
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&batch).Updates(map[string]interface{}{"status": StatusCompleted, "completed_at": now}).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to complete batch %s: %v", id, err))
+		return err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Batch %s completed", id))
+	return nil
+}
+
+// processItem claims a payout of a batch, sends it and records its result and the progress of the batch
+func (s *BatchService) processItem(ctx context.Context, item *Item) {
+	claim := s.db.WithContext(ctx).Model(&Item{}).Where("id = ? AND status = ?", item.ID, item.Status).Updates(map[string]interface{}{"status": ItemStatusSending})
+	if claim.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to claim line %d of batch %s: %v", item.Line, item.BatchID, claim.Error))
+		return
+	}
+	if claim.RowsAffected == 0 {
+		// Sent by another run of the batch
+		return
+	}
+
+	paymentID, err := s.sendPayout(ctx, item)
+
+	updates := map[string]interface{}{"status": ItemStatusSubmitted, "payment_id": paymentID, "processed_at": time.Now()}
+	counter := "submitted_items"
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Payout of line %d of batch %s failed: %v", item.Line, item.BatchID, err))
+		updates["status"] = ItemStatusFailed
+		updates["error"] = err.Error()
+		counter = "failed_items"
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Item{}).Where("id = ? AND status = ?", item.ID, ItemStatusSending).Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			// Already processed by another run of the batch
+			return result.Error
+		}
+		return tx.Model(&Batch{}).Where("id = ?", item.BatchID).Updates(map[string]interface{}{
+			"processed_items": gorm.Expr("processed_items + 1"),
+			counter:           gorm.Expr(counter + " + 1"),
+		}).Error
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to record the result of line %d of batch %s: %v", item.Line, item.BatchID, err))
+	}
+}
+
+// sendPayout creates the payout of an item and returns the ID of its payment
+func (s *BatchService) sendPayout(ctx context.Context, item *Item) (*string, error) {
+	plaintext, err := s.cipher.Decrypt(item.EncryptedRequest)
+	if err != nil {
+		return nil, err
+	}
+	var request payment.PayoutRequest
+	if err := json.Unmarshal(plaintext, &request); err != nil {
+		return nil, err
+	}
+
+	payout, err := s.payouts.CreatePayout(ctx, &request)
+	if errors.Is(err, payment.ErrDuplicateMerchantReference) {
+		// The payout may have been created before the batch was interrupted
+		existing, findErr := s.payouts.FindPaymentByReference(ctx, request.MerchantReference)
+		if findErr == nil && existing.UserID == request.UserID && existing.Amount == request.Amount {
+			return &existing.ID, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payout.ID, nil
+}
+
+// ResumeInterrupted processes the batches left PENDING or PROCESSING, such as by a restart, oldest first.
+// Batches another run is processing are skipped.
+func (s *BatchService) ResumeInterrupted(ctx context.Context) error {
+	var ids []string
+	if err := s.db.WithContext(ctx).Model(&Batch{}).
+		Where("status IN ?", []Status{StatusPending, StatusProcessing}).
+		Order("created_at").
+		Pluck("id", &ids).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BatchService: Failed to list interrupted batches: %v", err))
+		return err
+	}
+
+	for _, id := range ids {
+		if err := s.Process(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, sqlMock, func() {
+		db.Close()
+	}
+}
+
+// MockPayoutService is a mock implementation of the PayoutServiceInterface
+type MockPayoutService struct {
+	mock.Mock
+}
+
+func (m *MockPayoutService) CreatePayout(ctx context.Context, payoutRequest *payment.PayoutRequest) (*payment.Payment, error) {
+	args := m.Called(ctx, payoutRequest)
+	if args.Get(0) != nil {
+		return args.Get(0).(*payment.Payment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPayoutService) CheckPayout(ctx context.Context, payoutRequest *payment.PayoutRequest) error {
+	args := m.Called(ctx, payoutRequest)
+	return args.Error(0)
+}
+
+func (m *MockPayoutService) FindPaymentByReference(ctx context.Context, reference string) (*payment.Payment, error) {
+	args := m.Called(ctx, reference)
+	if args.Get(0) != nil {
+		return args.Get(0).(*payment.Payment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// merchantCtx carries the authenticated merchant the way the auth middleware sets it
+var merchantCtx = context.WithValue(context.TODO(), utils.ContextKeyMerchantID, uint(1))
+
+const batchID = "5d8e2c1a-7b3f-4a6e-9c2d-1e0f3a4b5c6d"
+
+func testCipher(t *testing.T) *beneficiary.Cipher {
+	cipher, err := beneficiary.NewCipher(bytes.Repeat([]byte{7}, beneficiary.KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+// payoutLine returns a valid payout to a GB bank account
+func payoutLine(number int, reference string) Line {
+	request := payment.PayoutRequest{Beneficiary: &bankaccount.BankAccount{HolderName: "Jane Doe", IBAN: "GB82 WEST 1234 5698 7654 32"}}
+	request.UserID = number
+	request.Amount = 40
+	request.CurrencyCode = "GBP"
+	request.CountryCode = "GB"
+	request.MerchantReference = reference
+	return Line{Number: number, Request: request}
+}
+
+// encryptedItem returns a PENDING item of the batch carrying the encrypted payout of line
+func encryptedItem(t *testing.T, cipher *beneficiary.Cipher, id uint, line Line) Item {
+	plaintext, err := json.Marshal(line.Request)
+	require.NoError(t, err)
+	encrypted, err := cipher.Encrypt(plaintext)
+	require.NoError(t, err)
+	return Item{ID: id, BatchID: batchID, Line: line.Number, EncryptedRequest: encrypted, Status: ItemStatusPending, MerchantReference: line.Request.MerchantReference}
+}
+
+func TestCreate_Success(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payouts := new(MockPayoutService)
+	payouts.On("CheckPayout", merchantCtx, mock.Anything).Return(nil)
+	service := NewBatchService(db, testCipher(t), payouts)
+
+	sqlMock.ExpectQuery(`^SELECT "merchant_reference" FROM "payments" WHERE merchant_id = \$1 AND merchant_reference IN \(\$2\)`).
+		WithArgs(1, "payday-1").
+		WillReturnRows(sqlmock.NewRows([]string{"merchant_reference"}))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`^INSERT INTO "payout_batches"`).
+		WithArgs(sqlmock.AnyArg(), 1, StatusPending, 2, 0, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectQuery(`^INSERT INTO "payout_batch_items" .* VALUES \(.*\),\(.*\) RETURNING "id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	sqlMock.ExpectCommit()
+
+	batch, err := service.Create(merchantCtx, []Line{payoutLine(2, "payday-1"), payoutLine(3, "")})
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, batch.Status)
+	assert.Equal(t, uint(1), batch.MerchantID)
+	assert.Equal(t, 2, batch.TotalItems)
+	payouts.AssertNumberOfCalls(t, "CheckPayout", 2)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_InvalidLines(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payouts := new(MockPayoutService)
+	payouts.On("CheckPayout", merchantCtx, mock.Anything).Return(nil)
+	service := NewBatchService(db, testCipher(t), payouts)
+
+	invalidAmount := payoutLine(3, "payday-2")
+	invalidAmount.Request.Amount = 0
+	invalidAmount.Request.CurrencyCode = "GB"
+	invalidAccount := payoutLine(4, "payday-3")
+	invalidAccount.Request.Beneficiary.IBAN = "GB00WEST12345698765432"
+	noAccount := payoutLine(6, "payday-5")
+	noAccount.Request.Beneficiary = nil
+
+	sqlMock.ExpectQuery(`^SELECT "merchant_reference" FROM "payments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"merchant_reference"}).AddRow("payday-4"))
+
+	_, err := service.Create(merchantCtx, []Line{
+		payoutLine(2, "payday-1"),
+		invalidAmount,
+		invalidAccount,
+		payoutLine(5, "payday-4"),
+		noAccount,
+		payoutLine(7, "payday-1"),
+	})
+
+	var lineErrs LineErrors
+	require.ErrorAs(t, err, &lineErrs)
+	assert.Equal(t, []string{"Amount is required", "CurrencyCode must be exactly 3 characters"}, lineErrs["line 3"])
+	assert.Len(t, lineErrs["line 4"], 1)
+	assert.Equal(t, []string{"MerchantReference is already used by another payment"}, lineErrs["line 5"])
+	assert.Equal(t, []string{"BeneficiaryID is required unless Beneficiary is set", "Beneficiary is required unless BeneficiaryID is set"}, lineErrs["line 6"])
+	assert.Equal(t, []string{"MerchantReference is already used on line 2"}, lineErrs["line 7"])
+	assert.NotContains(t, lineErrs, "line 2")
+	// Only the payouts valid on their own are checked against the beneficiaries and the routes
+	payouts.AssertNumberOfCalls(t, "CheckPayout", 3)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_CannotBeSent(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payouts := new(MockPayoutService)
+	service := NewBatchService(db, testCipher(t), payouts)
+
+	unverified := payoutLine(2, "payday-1")
+	unverified.Request.Beneficiary = nil
+	unverified.Request.BeneficiaryID = "7c1d9e2a-3b4f-4a5e-8d6c-2f1e0a9b8c7d"
+	noRoute := payoutLine(3, "payday-2")
+	noRoute.Request.CurrencyCode = "JPY"
+
+	withReference := func(reference string) interface{} {
+		return mock.MatchedBy(func(request *payment.PayoutRequest) bool { return request.MerchantReference == reference })
+	}
+	payouts.On("CheckPayout", merchantCtx, withReference("payday-1")).Return(fmt.Errorf("%w: status is UNVERIFIED", beneficiary.ErrBeneficiaryNotVerified))
+	payouts.On("CheckPayout", merchantCtx, withReference("payday-2")).Return(provider.ErrNoRouteForCurrencyCountry)
+	sqlMock.ExpectQuery(`^SELECT "merchant_reference" FROM "payments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"merchant_reference"}))
+
+	_, err := service.Create(merchantCtx, []Line{unverified, noRoute})
+
+	var lineErrs LineErrors
+	require.ErrorAs(t, err, &lineErrs)
+	assert.Len(t, lineErrs["line 2"], 1)
+	assert.Equal(t, []string{provider.ErrNoRouteForCurrencyCountry.Message}, lineErrs["line 3"])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_NoMerchant(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewBatchService(db, testCipher(t), nil)
+
+	_, err := service.Create(context.TODO(), []Line{payoutLine(2, "")})
+
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_NotConfigured(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewBatchService(db, nil, nil)
+
+	_, err := service.Create(merchantCtx, []Line{payoutLine(2, "")})
+
+	assert.ErrorIs(t, err, ErrBatchesNotConfigured)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestFind_NotFound(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewBatchService(db, nil, nil)
+
+	sqlMock.ExpectQuery(`^SELECT \* FROM "payout_batches" WHERE id = \$1 AND merchant_id = \$2`).
+		WithArgs(batchID, 1, 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	_, err := service.Find(merchantCtx, batchID)
+	assert.ErrorIs(t, err, ErrBatchNotFound)
+
+	_, err = service.Find(merchantCtx, "not-a-uuid")
+	assert.ErrorIs(t, err, ErrBatchNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+const batchClaim = `^UPDATE "payout_batches" SET "status"=\$1,"updated_at"=\$2 WHERE \(status = \$3 OR \(status = \$4 AND updated_at < \$5\)\) AND "id" = \$6`
+
+func TestProcess(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	cipher := testCipher(t)
+	payouts := new(MockPayoutService)
+	service := NewBatchService(db, cipher, payouts)
+	// One worker keeps the queries in the order of the lines
+	service.workers = 1
+
+	submitted := payoutLine(2, "payday-1")
+	rejected := payoutLine(3, "payday-2")
+	resumed := payoutLine(4, "payday-3")
+
+	sqlMock.ExpectQuery(`^SELECT \* FROM "payout_batches" WHERE id = \$1`).
+		WithArgs(batchID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "status", "total_items"}).AddRow(batchID, 1, StatusPending, 3))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(batchClaim).
+		WithArgs(StatusProcessing, sqlmock.AnyArg(), StatusPending, StatusProcessing, sqlmock.AnyArg(), batchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	rows := sqlmock.NewRows([]string{"id", "batch_id", "line", "merchant_reference", "encrypted_request", "status"})
+	for i, line := range []Line{submitted, rejected, resumed} {
+		item := encryptedItem(t, cipher, uint(i+1), line)
+		rows.AddRow(item.ID, item.BatchID, item.Line, item.MerchantReference, item.EncryptedRequest, item.Status)
+	}
+	sqlMock.ExpectQuery(`^SELECT \* FROM "payout_batch_items" WHERE batch_id = \$1 AND status IN \(\$2,\$3\) ORDER BY line`).
+		WithArgs(batchID, ItemStatusPending, ItemStatusSending).
+		WillReturnRows(rows)
+
+	expectClaimed := func(itemID int) {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`^UPDATE "payout_batch_items" SET "status"=\$1 WHERE id = \$2 AND status = \$3`).
+			WithArgs(ItemStatusSending, itemID, ItemStatusPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+	}
+	expectSubmitted := func(itemID int, paymentID string) {
+		expectClaimed(itemID)
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`^UPDATE "payout_batch_items" SET "payment_id"=\$1,"processed_at"=\$2,"status"=\$3 WHERE id = \$4 AND status = \$5`).
+			WithArgs(paymentID, sqlmock.AnyArg(), ItemStatusSubmitted, itemID, ItemStatusSending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectExec(`^UPDATE "payout_batches" SET "processed_items"=processed_items \+ 1,"submitted_items"=submitted_items \+ 1,"updated_at"=\$1 WHERE id = \$2`).
+			WithArgs(sqlmock.AnyArg(), batchID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+	}
+	expectSubmitted(1, "payment-1")
+	expectClaimed(2)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`^UPDATE "payout_batch_items" SET "error"=\$1,"payment_id"=\$2,"processed_at"=\$3,"status"=\$4 WHERE id = \$5 AND status = \$6`).
+		WithArgs(provider.ErrProviderRejected.Error(), nil, sqlmock.AnyArg(), ItemStatusFailed, 2, ItemStatusSending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`^UPDATE "payout_batches" SET "failed_items"=failed_items \+ 1,"processed_items"=processed_items \+ 1,"updated_at"=\$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), batchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	expectSubmitted(3, "payment-3")
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`^UPDATE "payout_batches" SET "completed_at"=\$1,"status"=\$2,"updated_at"=\$3 WHERE "id" = \$4`).
+		WithArgs(sqlmock.AnyArg(), StatusCompleted, sqlmock.AnyArg(), batchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	onBehalfOfMerchant := mock.MatchedBy(func(ctx context.Context) bool {
+		merchantID, ok := utils.MerchantIDFromContext(ctx)
+		return ok && merchantID == 1
+	})
+	withReference := func(reference string) interface{} {
+		return mock.MatchedBy(func(request *payment.PayoutRequest) bool {
+			return request.MerchantReference == reference && request.Beneficiary.IBAN == "GB82 WEST 1234 5698 7654 32"
+		})
+	}
+	payouts.On("CreatePayout", onBehalfOfMerchant, withReference("payday-1")).Return(&payment.Payment{ID: "payment-1"}, nil)
+	payouts.On("CreatePayout", onBehalfOfMerchant, withReference("payday-2")).Return(nil, provider.ErrProviderRejected)
+	// The payout of line 4 was created before the batch was interrupted
+	payouts.On("CreatePayout", onBehalfOfMerchant, withReference("payday-3")).Return(nil, payment.ErrDuplicateMerchantReference)
+	payouts.On("FindPaymentByReference", onBehalfOfMerchant, "payday-3").Return(&payment.Payment{ID: "payment-3", UserID: 4, Amount: 40}, nil)
+
+	err := service.Process(context.Background(), batchID)
+
+	assert.NoError(t, err)
+	payouts.AssertExpectations(t)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestProcess_ClaimedByAnotherRun(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payouts := new(MockPayoutService)
+	service := NewBatchService(db, testCipher(t), payouts)
+
+	// The batch is PROCESSING and made progress recently, so its payouts are left to the run sending them
+	sqlMock.ExpectQuery(`^SELECT \* FROM "payout_batches" WHERE id = \$1`).
+		WithArgs(batchID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "status", "total_items"}).AddRow(batchID, 1, StatusProcessing, 3))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(batchClaim).WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	err := service.Process(context.Background(), batchID)
+
+	assert.NoError(t, err)
+	payouts.AssertNotCalled(t, "CreatePayout", mock.Anything, mock.Anything)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSendPayout_DuplicateReferenceOfAnotherPayment(t *testing.T) {
+	cipher := testCipher(t)
+	payouts := new(MockPayoutService)
+	service := NewBatchService(nil, cipher, payouts)
+	item := encryptedItem(t, cipher, 1, payoutLine(2, "payday-1"))
+
+	payouts.On("CreatePayout", merchantCtx, mock.Anything).Return(nil, payment.ErrDuplicateMerchantReference)
+	payouts.On("FindPaymentByReference", merchantCtx, "payday-1").Return(&payment.Payment{ID: "payment-1", UserID: 9, Amount: 40}, nil)
+
+	paymentID, err := service.sendPayout(merchantCtx, &item)
+
+	assert.Nil(t, paymentID)
+	assert.True(t, errors.Is(err, payment.ErrDuplicateMerchantReference))
+}
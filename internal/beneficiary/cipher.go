@@ -0,0 +1,56 @@
+package beneficiary
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of the encryption key of beneficiaries, for AES-256
+const KeySize = 32
+
+// Cipher encrypts the bank accounts of beneficiaries with AES-256-GCM. Every ciphertext starts with
+// its random nonce.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher returns the Cipher of a key of KeySize bytes
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) == 0 {
+		return nil, ErrEncryptionNotConfigured
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt encrypts and authenticates plaintext
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts a ciphertext of Encrypt. It fails if the ciphertext was altered or encrypted with another key.
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < c.aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+	return c.aead.Open(nil, nonce, sealed, nil)
+}
@@ -0,0 +1,23 @@
+package beneficiary
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrBeneficiaryNotFound is returned when no beneficiary of the merchant, or of the user, has the ID
+	ErrBeneficiaryNotFound = utils.NewAPIError(http.StatusNotFound, "beneficiary_not_found", "Beneficiary not found")
+
+	// ErrBeneficiaryNotVerified is returned when paying out to a beneficiary that is not verified
+	ErrBeneficiaryNotVerified = utils.NewAPIError(http.StatusUnprocessableEntity, "beneficiary_not_verified", "Beneficiary is not verified")
+
+	// ErrCountryMismatch is returned when paying out to a beneficiary in another country than the payout
+	ErrCountryMismatch = utils.NewAPIError(http.StatusUnprocessableEntity, "beneficiary_country_mismatch", "Beneficiary is in another country than the payout")
+
+	// ErrBeneficiaryDisabled is returned when verifying a disabled beneficiary
+	ErrBeneficiaryDisabled = utils.NewAPIError(http.StatusConflict, "beneficiary_disabled", "Beneficiary is disabled")
+
+	// ErrEncryptionNotConfigured is returned when beneficiaries are used without BENEFICIARY_ENCRYPTION_KEY
+	ErrEncryptionNotConfigured = utils.NewAPIError(http.StatusServiceUnavailable, "beneficiaries_unavailable", "Beneficiaries are not available")
+)
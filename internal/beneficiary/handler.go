@@ -0,0 +1,126 @@
+package beneficiary
+
+import (
+	"net/http"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BeneficiaryHandler handles the beneficiary requests
+type BeneficiaryHandler struct {
+	service BeneficiaryServiceInterface
+}
+
+// NewBeneficiaryHandler initializes a new BeneficiaryHandler
+func NewBeneficiaryHandler(db *gorm.DB, configStore *config.Store) *BeneficiaryHandler {
+	// Nil without BENEFICIARY_ENCRYPTION_KEY, whose length is validated with the configuration
+	cipher, _ := NewCipher(configStore.Current().BeneficiaryEncryptionKeyBytes())
+	return &BeneficiaryHandler{service: NewBeneficiaryService(db, cipher)}
+}
+
+// Create saves a beneficiary
+// @Summary Save a beneficiary
+// @Description Saves the bank account of a user for later payouts. The account is validated for the country like the beneficiary of a payout, stored encrypted and returned masked. New beneficiaries are UNVERIFIED and cannot be paid out to until an operator verifies them.
+// @Tags beneficiaries
+// @Accept json
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param validatedBody body CreateBeneficiaryRequest true "Beneficiary"
+// @Success 201 {object} utils.APIResponse{data=Beneficiary} "Saved beneficiary"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:withdrawal"
+// @Failure 422 {object} utils.APIResponse "Invalid bank account"
+// @Failure 503 {object} utils.APIResponse "Beneficiaries are not configured"
+// @Router /beneficiaries [post]
+func (h *BeneficiaryHandler) Create(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CreateBeneficiaryRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	beneficiary, err := h.service.Create(c, request)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Beneficiary created", beneficiary)
+}
+
+// List lists the beneficiaries of a user
+// @Summary List the beneficiaries of a user
+// @Description Lists the beneficiaries saved for a user of the authenticated merchant, newest first, with their accounts masked.
+// @Tags beneficiaries
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param user_id query int true "User ID"
+// @Success 200 {object} utils.APIResponse{data=[]Beneficiary} "Beneficiaries"
+// @Failure 400 {object} utils.APIResponse "Missing user ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Router /beneficiaries [get]
+func (h *BeneficiaryHandler) List(c *gin.Context) {
+	var query ListBeneficiariesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", map[string][]string{"validation": {err.Error()}})
+		return
+	}
+
+	beneficiaries, err := h.service.List(c, query.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Beneficiaries", beneficiaries)
+}
+
+// Disable disables a beneficiary
+// @Summary Disable a beneficiary
+// @Description Disables a beneficiary of the authenticated merchant for good, payouts can no longer be sent to it.
+// @Tags beneficiaries
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Beneficiary ID"
+// @Success 200 {object} utils.APIResponse{data=Beneficiary} "Disabled beneficiary"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:withdrawal"
+// @Failure 404 {object} utils.APIResponse "Beneficiary not found"
+// @Router /beneficiaries/{id}/disable [post]
+func (h *BeneficiaryHandler) Disable(c *gin.Context) {
+	beneficiary, err := h.service.Disable(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Beneficiary disabled", beneficiary)
+}
+
+// Verify verifies a beneficiary
+// @Summary Verify a beneficiary
+// @Description Marks a beneficiary of any merchant as VERIFIED once an operator checked the account, so payouts can be sent to it.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path string true "Beneficiary ID"
+// @Success 200 {object} utils.APIResponse{data=Beneficiary} "Verified beneficiary"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Beneficiary not found"
+// @Failure 409 {object} utils.APIResponse "Beneficiary is disabled"
+// @Router /admin/beneficiaries/{id}/verify [post]
+func (h *BeneficiaryHandler) Verify(c *gin.Context) {
+	beneficiary, err := h.service.Verify(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Beneficiary verified", beneficiary)
+}
@@ -0,0 +1,52 @@
+package beneficiary
+
+import (
+	"payment-gateway-service/internal/bankaccount"
+	"time"
+)
+
+// Status is the verification status of a beneficiary
+type Status string
+
+const (
+	// StatusUnverified beneficiaries were saved but cannot be paid out to yet
+	StatusUnverified Status = "UNVERIFIED"
+	// StatusVerified beneficiaries were checked by an operator and can be paid out to
+	StatusVerified Status = "VERIFIED"
+	// StatusDisabled beneficiaries were disabled by the merchant and can no longer be paid out to
+	StatusDisabled Status = "DISABLED"
+)
+
+// Beneficiary is a bank account saved for the payouts of a user of a merchant. The account is
+// stored encrypted, and only its masked form is returned.
+type Beneficiary struct {
+	ID          string `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MerchantID  uint   `gorm:"not null" json:"merchant_id"`
+	UserID      int    `gorm:"not null" json:"user_id"`
+	CountryCode string `gorm:"type:varchar(2);not null" json:"country_code"`
+	Label       string `gorm:"type:varchar(100);not null;default:''" json:"label,omitempty"`
+
+	// MaskedAccount is returned in responses, EncryptedAccount is the full account sent to providers
+	MaskedAccount    bankaccount.BankAccount `gorm:"type:jsonb;serializer:json;not null" json:"account"`
+	EncryptedAccount []byte                  `gorm:"type:bytea;not null" json:"-"`
+
+	Status     Status     `gorm:"type:varchar(20);not null;default:UNVERIFIED" json:"status"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CreateBeneficiaryRequest saves the bank account of a user. The account is validated for the country
+// like the beneficiary of a payout.
+type CreateBeneficiaryRequest struct {
+	UserID      int                     `json:"user_id" binding:"required"`
+	CountryCode string                  `json:"country_code" binding:"required,len=2"`
+	Label       string                  `json:"label" binding:"omitempty,max=100"`
+	Account     bankaccount.BankAccount `json:"account" binding:"required"`
+}
+
+// ListBeneficiariesQuery is the user to list the beneficiaries of
+type ListBeneficiariesQuery struct {
+	UserID int `form:"user_id" binding:"required"`
+}
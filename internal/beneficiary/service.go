@@ -0,0 +1,215 @@
+package beneficiary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BeneficiaryServiceInterface defines the methods that the BeneficiaryService must implement.
+type BeneficiaryServiceInterface interface {
+	Create(ctx context.Context, request *CreateBeneficiaryRequest) (*Beneficiary, error)
+	List(ctx context.Context, userID int) ([]Beneficiary, error)
+	Verify(ctx context.Context, id string) (*Beneficiary, error)
+	Disable(ctx context.Context, id string) (*Beneficiary, error)
+	AccountForPayout(ctx context.Context, id string, userID int, countryCode string) (*bankaccount.BankAccount, error)
+}
+
+// BeneficiaryService stores the beneficiaries of the users of merchants.
+type BeneficiaryService struct {
+	db     *gorm.DB
+	cipher *Cipher
+}
+
+// NewBeneficiaryService initializes a new BeneficiaryService. Without a cipher, beneficiaries can be listed
+// and disabled but neither created nor paid out to.
+func NewBeneficiaryService(db *gorm.DB, cipher *Cipher) *BeneficiaryService {
+	return &BeneficiaryService{db: db, cipher: cipher}
+}
+
+// Create saves an UNVERIFIED beneficiary for a user of the authenticated merchant
+func (s *BeneficiaryService) Create(ctx context.Context, request *CreateBeneficiaryRequest) (*Beneficiary, error) {
+	if s.cipher == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account := request.Account.Normalized()
+	countryCode := strings.ToUpper(request.CountryCode)
+	if err := account.Validate(countryCode); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(account)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(plaintext)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "BeneficiaryService: Failed to encrypt account")
+		return nil, err
+	}
+
+	beneficiary := &Beneficiary{
+		MerchantID:       merchantID,
+		UserID:           request.UserID,
+		CountryCode:      countryCode,
+		Label:            request.Label,
+		MaskedAccount:    account.Masked(),
+		EncryptedAccount: encrypted,
+		Status:           StatusUnverified,
+	}
+	if err := s.db.WithContext(ctx).Create(beneficiary).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Failed to save beneficiary: %v", err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Beneficiary %s created for user %d", beneficiary.ID, beneficiary.UserID))
+	return beneficiary, nil
+}
+
+// List lists the beneficiaries of a user of the authenticated merchant, newest first
+func (s *BeneficiaryService) List(ctx context.Context, userID int) ([]Beneficiary, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var beneficiaries []Beneficiary
+	if err := s.db.WithContext(ctx).
+		Where("merchant_id = ? AND user_id = ?", merchantID, userID).
+		Order("created_at DESC").
+		Find(&beneficiaries).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Failed to list beneficiaries of user %d: %v", userID, err))
+		return nil, err
+	}
+	return beneficiaries, nil
+}
+
+// Verify marks a beneficiary of any merchant as VERIFIED, so payouts can be sent to it. Verifying a
+// verified beneficiary has no effect, and disabled beneficiaries cannot be verified.
+func (s *BeneficiaryService) Verify(ctx context.Context, id string) (*Beneficiary, error) {
+	return s.update(ctx, id, nil, func(beneficiary *Beneficiary) error {
+		switch beneficiary.Status {
+		case StatusVerified:
+			return nil
+		case StatusDisabled:
+			return ErrBeneficiaryDisabled
+		}
+		now := time.Now()
+		beneficiary.Status = StatusVerified
+		beneficiary.VerifiedAt = &now
+		return nil
+	})
+}
+
+// Disable marks a beneficiary of the authenticated merchant as DISABLED for good. Disabling a disabled
+// beneficiary has no effect.
+func (s *BeneficiaryService) Disable(ctx context.Context, id string) (*Beneficiary, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, id, &merchantID, func(beneficiary *Beneficiary) error {
+		if beneficiary.Status == StatusDisabled {
+			return nil
+		}
+		now := time.Now()
+		beneficiary.Status = StatusDisabled
+		beneficiary.DisabledAt = &now
+		return nil
+	})
+}
+
+// update applies change to a beneficiary, of the merchant if merchantID is set, and saves it
+func (s *BeneficiaryService) update(ctx context.Context, id string, merchantID *uint, change func(*Beneficiary) error) (*Beneficiary, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrBeneficiaryNotFound
+	}
+
+	var beneficiary Beneficiary
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id)
+		if merchantID != nil {
+			query = query.Where("merchant_id = ?", *merchantID)
+		}
+		if err := query.First(&beneficiary).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBeneficiaryNotFound
+			}
+			return err
+		}
+
+		previous := beneficiary.Status
+		if err := change(&beneficiary); err != nil {
+			return err
+		}
+		if beneficiary.Status == previous {
+			return nil
+		}
+		return tx.Save(&beneficiary).Error
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Failed to update beneficiary %s: %v", id, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Beneficiary %s is %s", id, beneficiary.Status))
+	return &beneficiary, nil
+}
+
+// AccountForPayout returns the decrypted bank account of a VERIFIED beneficiary of a user of the
+// authenticated merchant, for a payout to the country
+func (s *BeneficiaryService) AccountForPayout(ctx context.Context, id string, userID int, countryCode string) (*bankaccount.BankAccount, error) {
+	if s.cipher == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrBeneficiaryNotFound
+	}
+
+	var beneficiary Beneficiary
+	if err := s.db.WithContext(ctx).Where("id = ? AND merchant_id = ? AND user_id = ?", id, merchantID, userID).First(&beneficiary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, err
+	}
+
+	if beneficiary.Status != StatusVerified {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Payout to beneficiary %s refused (status: %s)", id, beneficiary.Status))
+		return nil, fmt.Errorf("%w: status is %s", ErrBeneficiaryNotVerified, beneficiary.Status)
+	}
+	if !strings.EqualFold(beneficiary.CountryCode, countryCode) {
+		return nil, fmt.Errorf("%w: beneficiary is in %s", ErrCountryMismatch, beneficiary.CountryCode)
+	}
+
+	plaintext, err := s.cipher.Decrypt(beneficiary.EncryptedAccount)
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("BeneficiaryService: Failed to decrypt the account of beneficiary %s: %v", id, err))
+		return nil, err
+	}
+	var account bankaccount.BankAccount
+	if err := json.Unmarshal(plaintext, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+var _ BeneficiaryServiceInterface = (*BeneficiaryService)(nil)
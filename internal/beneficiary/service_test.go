@@ -0,0 +1,249 @@
+package beneficiary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+// merchantCtx carries the authenticated merchant the way the auth middleware sets it
+var merchantCtx = context.WithValue(context.TODO(), utils.ContextKeyMerchantID, uint(1))
+
+const beneficiaryID = "3f1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"
+
+const payoutQuery = `^SELECT \* FROM "beneficiaries" WHERE id = \$1 AND merchant_id = \$2 AND user_id = \$3 ORDER BY "beneficiaries"."id" LIMIT \$4$`
+
+func testCipher(t *testing.T) *Cipher {
+	cipher, err := NewCipher(bytes.Repeat([]byte{7}, KeySize))
+	require.NoError(t, err)
+	return cipher
+}
+
+func TestCipher(t *testing.T) {
+	cipher := testCipher(t)
+
+	ciphertext, err := cipher.Encrypt([]byte("GB82WEST12345698765432"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "GB82WEST")
+
+	plaintext, err := cipher.Decrypt(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "GB82WEST12345698765432", string(plaintext))
+
+	// Altered ciphertexts and other keys are rejected
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = cipher.Decrypt(ciphertext)
+	assert.Error(t, err)
+
+	_, err = NewCipher(nil)
+	assert.ErrorIs(t, err, ErrEncryptionNotConfigured)
+	_, err = NewCipher([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestCreate_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "beneficiaries" .* RETURNING "id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(beneficiaryID))
+	mock.ExpectCommit()
+
+	cipher := testCipher(t)
+	service := NewBeneficiaryService(gormDB, cipher)
+
+	// Call the method under test
+	beneficiary, err := service.Create(merchantCtx, &CreateBeneficiaryRequest{
+		UserID:      7,
+		CountryCode: "gb",
+		Account:     bankaccount.BankAccount{HolderName: "Jane Doe", IBAN: "GB82 WEST 1234 5698 7654 32"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), beneficiary.MerchantID)
+	assert.Equal(t, "GB", beneficiary.CountryCode)
+	assert.Equal(t, StatusUnverified, beneficiary.Status)
+	assert.Equal(t, "GB****************5432", beneficiary.MaskedAccount.IBAN)
+
+	// The full account is only stored encrypted
+	plaintext, err := cipher.Decrypt(beneficiary.EncryptedAccount)
+	require.NoError(t, err)
+	var account bankaccount.BankAccount
+	require.NoError(t, json.Unmarshal(plaintext, &account))
+	assert.Equal(t, "GB82WEST12345698765432", account.IBAN)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_InvalidAccount(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	service := NewBeneficiaryService(gormDB, testCipher(t))
+
+	beneficiary, err := service.Create(merchantCtx, &CreateBeneficiaryRequest{
+		UserID:      7,
+		CountryCode: "US",
+		Account:     bankaccount.BankAccount{HolderName: "Jane Doe", AccountNumber: "123456789", BankCode: "021000022"},
+	})
+
+	assert.Nil(t, beneficiary)
+	assert.ErrorIs(t, err, bankaccount.ErrInvalidBankCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_NotConfigured(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	service := NewBeneficiaryService(gormDB, nil)
+
+	_, err := service.Create(merchantCtx, &CreateBeneficiaryRequest{UserID: 7, CountryCode: "GB"})
+
+	assert.ErrorIs(t, err, ErrEncryptionNotConfigured)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_NoMerchant(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	service := NewBeneficiaryService(gormDB, testCipher(t))
+
+	// Nothing is saved for merchant 0 when the context has no authenticated merchant
+	_, err := service.Create(context.TODO(), &CreateBeneficiaryRequest{UserID: 7, CountryCode: "GB"})
+
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerify_Disabled(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "beneficiaries" WHERE id = \$1 ORDER BY "beneficiaries"."id" LIMIT \$2 FOR UPDATE$`).
+		WithArgs(beneficiaryID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(beneficiaryID, "DISABLED"))
+	mock.ExpectRollback()
+
+	service := NewBeneficiaryService(gormDB, nil)
+
+	beneficiary, err := service.Verify(context.TODO(), beneficiaryID)
+
+	assert.Nil(t, beneficiary)
+	assert.ErrorIs(t, err, ErrBeneficiaryDisabled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDisable_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "beneficiaries" WHERE id = \$1 AND merchant_id = \$2 ORDER BY "beneficiaries"."id" LIMIT \$3 FOR UPDATE$`).
+		WithArgs(beneficiaryID, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "status"}).AddRow(beneficiaryID, 1, "VERIFIED"))
+	mock.ExpectExec(`^UPDATE "beneficiaries" SET .*"status"=\$7,.* WHERE "id" = \$12$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	service := NewBeneficiaryService(gormDB, nil)
+
+	beneficiary, err := service.Disable(merchantCtx, beneficiaryID)
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusDisabled, beneficiary.Status)
+	assert.NotNil(t, beneficiary.DisabledAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountForPayout(t *testing.T) {
+	cipher := testCipher(t)
+	plaintext, _ := json.Marshal(bankaccount.BankAccount{HolderName: "Jane Doe", IBAN: "GB82WEST12345698765432"})
+	encrypted, err := cipher.Encrypt(plaintext)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		status      string
+		countryCode string
+		wantErr     error
+	}{
+		{name: "verified", status: "VERIFIED", countryCode: "GB"},
+		{name: "unverified", status: "UNVERIFIED", countryCode: "GB", wantErr: ErrBeneficiaryNotVerified},
+		{name: "disabled", status: "DISABLED", countryCode: "GB", wantErr: ErrBeneficiaryNotVerified},
+		{name: "other country", status: "VERIFIED", countryCode: "DE", wantErr: ErrCountryMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			mock.ExpectQuery(payoutQuery).
+				WithArgs(beneficiaryID, 1, 7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "user_id", "country_code", "status", "encrypted_account"}).
+					AddRow(beneficiaryID, 1, 7, "GB", tt.status, encrypted))
+
+			service := NewBeneficiaryService(gormDB, cipher)
+
+			account, err := service.AccountForPayout(merchantCtx, beneficiaryID, 7, tt.countryCode)
+
+			if tt.wantErr != nil {
+				assert.Nil(t, account)
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "GB82WEST12345698765432", account.IBAN)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAccountForPayout_OtherUser(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectQuery(payoutQuery).
+		WithArgs(beneficiaryID, 1, 8, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewBeneficiaryService(gormDB, testCipher(t))
+
+	account, err := service.AccountForPayout(merchantCtx, beneficiaryID, 8, "GB")
+
+	assert.Nil(t, account)
+	assert.ErrorIs(t, err, ErrBeneficiaryNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
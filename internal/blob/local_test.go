@@ -0,0 +1,45 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+	ctx := context.TODO()
+
+	require.NoError(t, store.Put(ctx, "disputes/1/receipt", strings.NewReader("first")))
+	require.NoError(t, store.Put(ctx, "disputes/1/receipt", strings.NewReader("second")))
+
+	file, err := store.Get(ctx, "disputes/1/receipt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, "second", string(content))
+
+	require.NoError(t, store.Delete(ctx, "disputes/1/receipt"))
+	_, err = store.Get(ctx, "disputes/1/receipt")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting a missing blob is not an error
+	assert.NoError(t, store.Delete(ctx, "disputes/1/receipt"))
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "disputes/../../secret", "disputes//receipt", `disputes\receipt`} {
+		t.Run(key, func(t *testing.T) {
+			assert.ErrorIs(t, store.Put(context.TODO(), key, strings.NewReader("content")), ErrInvalidKey)
+			_, err := store.Get(context.TODO(), key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+		})
+	}
+}
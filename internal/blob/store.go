@@ -0,0 +1,24 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob is stored under the key
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned for keys that are empty or escape the store, such as "../secret"
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store keeps files such as dispute evidence out of the database. Keys are slash separated paths
+// chosen by the caller, e.g. "disputes/{id}/{evidence id}".
+type Store interface {
+	// Put stores the content read from r under the key, replacing any blob stored under it
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob stored under the key, which the caller closes
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under the key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	// Versions are sorted and have no gaps
+	for i, migration := range migrations {
+		assert.Equal(t, uint(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+func TestLoadMigrations_MissingDown(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/000001_create_things.up.sql": {Data: []byte("CREATE TABLE things ();")},
+	}
+
+	_, err := loadMigrations(files)
+
+	assert.Error(t, err)
+}
+
+func TestLoadMigrations_InvalidName(t *testing.T) {
+	files := fstest.MapFS{
+		"migrations/create_things.sql": {Data: []byte("CREATE TABLE things ();")},
+	}
+
+	_, err := loadMigrations(files)
+
+	assert.Error(t, err)
+}
+
+// setupMigrator creates a Migrator with two migrations on a mocked database
+func setupMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "first", Up: "SELECT 1", Down: "SELECT 1"},
+		{Version: 2, Name: "second", Up: "SELECT 2", Down: "SELECT 2"},
+	}}
+
+	return migrator, mock, func() {
+		db.Close()
+	}
+}
+
+func expectVersion(mock sqlmock.Sqlmock, version int, dirty bool) {
+	mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`^SELECT version, dirty FROM schema_migrations LIMIT 1$`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty))
+}
+
+func TestCheckVersion_UpToDate(t *testing.T) {
+	migrator, mock, teardown := setupMigrator(t)
+	defer teardown()
+
+	expectVersion(mock, 2, false)
+
+	assert.NoError(t, migrator.CheckVersion(context.TODO()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckVersion_Behind(t *testing.T) {
+	migrator, mock, teardown := setupMigrator(t)
+	defer teardown()
+
+	expectVersion(mock, 1, false)
+
+	assert.ErrorIs(t, migrator.CheckVersion(context.TODO()), ErrSchemaBehind)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckVersion_Dirty(t *testing.T) {
+	migrator, mock, teardown := setupMigrator(t)
+	defer teardown()
+
+	expectVersion(mock, 2, true)
+
+	assert.ErrorIs(t, migrator.CheckVersion(context.TODO()), ErrSchemaDirty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUp_AppliesPendingMigrations(t *testing.T) {
+	migrator, mock, teardown := setupMigrator(t)
+	defer teardown()
+
+	mock.ExpectExec(`^SELECT pg_advisory_lock\(\$1\)$`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 1, false)
+	mock.ExpectBegin()
+	mock.ExpectExec(`^SELECT 2$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^DELETE FROM schema_migrations$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO schema_migrations`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`^SELECT pg_advisory_unlock\(\$1\)$`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.TODO())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,9 @@
+-- Detach payments from merchants and drop the merchant tables
+DROP INDEX IF EXISTS idx_payments_merchant_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS merchant_id;
+
+DROP TRIGGER IF EXISTS set_timestamp ON merchant_api_keys;
+DROP TABLE IF EXISTS merchant_api_keys;
+
+DROP TRIGGER IF EXISTS set_timestamp ON merchants;
+DROP TABLE IF EXISTS merchants;
@@ -0,0 +1,43 @@
+-- Create the merchants table
+CREATE TABLE merchants (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON merchants
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- Create the merchant_api_keys table. Only the SHA-256 hash of a key is stored,
+-- the key_prefix is the public part of the key used to look it up.
+CREATE TABLE merchant_api_keys (
+    id SERIAL PRIMARY KEY,
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    key_prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_merchant_api_keys_merchant_id ON merchant_api_keys(merchant_id);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON merchant_api_keys
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- Seed a default merchant that owns every payment created before merchants existed
+INSERT INTO merchants (name, created_at, updated_at) VALUES ('Default Merchant', NOW(), NOW());
+
+-- Attach payments to the merchant that created them
+ALTER TABLE payments ADD COLUMN merchant_id INT REFERENCES merchants(id) ON DELETE RESTRICT;
+UPDATE payments SET merchant_id = (SELECT id FROM merchants WHERE name = 'Default Merchant');
+ALTER TABLE payments ALTER COLUMN merchant_id SET NOT NULL;
+
+CREATE INDEX idx_payments_merchant_id ON payments(merchant_id);
@@ -0,0 +1,2 @@
+-- Remove scopes from merchant API keys
+ALTER TABLE merchant_api_keys DROP COLUMN IF EXISTS scopes;
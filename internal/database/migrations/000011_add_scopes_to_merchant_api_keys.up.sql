@@ -0,0 +1,5 @@
+-- Add space separated scopes to merchant API keys
+ALTER TABLE merchant_api_keys ADD COLUMN scopes VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Existing keys keep the access they had before scopes were introduced
+UPDATE merchant_api_keys SET scopes = 'payments:deposit payments:withdrawal payments:read';
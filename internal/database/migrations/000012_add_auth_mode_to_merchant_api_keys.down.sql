@@ -0,0 +1,3 @@
+-- Remove request signing from merchant API keys
+ALTER TABLE merchant_api_keys DROP COLUMN IF EXISTS signing_secret;
+ALTER TABLE merchant_api_keys DROP COLUMN IF EXISTS auth_mode;
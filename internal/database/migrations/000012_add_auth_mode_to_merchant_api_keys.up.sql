@@ -0,0 +1,5 @@
+-- Keys either authenticate with the static token alone or must also sign requests with HMAC
+ALTER TABLE merchant_api_keys ADD COLUMN auth_mode VARCHAR(16) NOT NULL DEFAULT 'token' CHECK (auth_mode IN ('token', 'hmac'));
+
+-- Shared secret used to verify request signatures of keys in hmac mode
+ALTER TABLE merchant_api_keys ADD COLUMN signing_secret VARCHAR(128);
@@ -0,0 +1,2 @@
+-- Drop the rate limit buckets table
+DROP TABLE IF EXISTS rate_limit_buckets;
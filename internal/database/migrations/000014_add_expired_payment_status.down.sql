@@ -0,0 +1,9 @@
+-- PostgreSQL cannot drop a value from an enum, so the type is recreated without it
+UPDATE payments SET status = 'FAILED' WHERE status = 'EXPIRED';
+
+ALTER TABLE payments ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE payment_status RENAME TO payment_status_old;
+CREATE TYPE payment_status AS ENUM ('INITIALIZED', 'PENDING', 'SUCCESS', 'FAILED');
+ALTER TABLE payments ALTER COLUMN status TYPE payment_status USING status::text::payment_status;
+ALTER TABLE payments ALTER COLUMN status SET DEFAULT 'INITIALIZED';
+DROP TYPE payment_status_old;
@@ -0,0 +1,2 @@
+-- Payments that never completed can be expired by operators
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'EXPIRED';
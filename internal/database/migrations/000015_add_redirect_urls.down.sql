@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP COLUMN cancel_url;
+ALTER TABLE payments DROP COLUMN failure_url;
+ALTER TABLE payments DROP COLUMN success_url;
+
+ALTER TABLE merchants DROP COLUMN allowed_redirect_domains;
@@ -0,0 +1,7 @@
+-- Domains a merchant may redirect its customers to after a payment, space separated
+ALTER TABLE merchants ADD COLUMN allowed_redirect_domains TEXT NOT NULL DEFAULT '';
+
+-- Where the customer is sent after the provider callback, empty to use the gateway page
+ALTER TABLE payments ADD COLUMN success_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD COLUMN failure_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE payments ADD COLUMN cancel_url TEXT NOT NULL DEFAULT '';
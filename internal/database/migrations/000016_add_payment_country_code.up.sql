@@ -0,0 +1,2 @@
+-- The country of a payment, needed to route it again when the customer picks a provider at checkout
+ALTER TABLE payments ADD COLUMN country_code VARCHAR(2) NOT NULL DEFAULT '';
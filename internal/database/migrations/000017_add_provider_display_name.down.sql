@@ -0,0 +1 @@
+ALTER TABLE payment_providers DROP COLUMN display_name;
@@ -0,0 +1,5 @@
+-- The name of a provider shown to merchants and customers
+ALTER TABLE payment_providers ADD COLUMN display_name VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE payment_providers SET display_name = 'HSBC' WHERE name = 'HSBC';
+UPDATE payment_providers SET display_name = 'Abu Dhabi Commercial Bank' WHERE name = 'ADCB';
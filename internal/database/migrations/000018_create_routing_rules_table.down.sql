@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP COLUMN routing_decision;
+
+DROP TABLE routing_rules;
+
+ALTER TABLE provider_configurations DROP COLUMN weight;
@@ -0,0 +1,30 @@
+-- The share of traffic a configuration gets among the configurations of the same priority
+ALTER TABLE provider_configurations ADD COLUMN weight INT NOT NULL DEFAULT 1 CHECK (weight >= 0);
+
+-- Rules sending the payments they match to a provider, evaluated by priority before the weights.
+-- Empty and NULL conditions match every payment.
+CREATE TABLE routing_rules (
+    id SERIAL PRIMARY KEY,
+    currency_code VARCHAR(3) NOT NULL DEFAULT '',
+    country_code VARCHAR(2) NOT NULL DEFAULT '',
+    payment_type VARCHAR(20) NOT NULL DEFAULT '',
+    min_amount NUMERIC(12, 2),
+    max_amount NUMERIC(12, 2),
+    user_segment VARCHAR(50) NOT NULL DEFAULT '',
+    active_from VARCHAR(5) NOT NULL DEFAULT '',
+    active_until VARCHAR(5) NOT NULL DEFAULT '',
+    provider_id INT NOT NULL REFERENCES payment_providers(id) ON DELETE CASCADE,
+    priority INT NOT NULL CHECK (priority >= 1),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_routing_rules_route ON routing_rules (currency_code, country_code);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON routing_rules
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- How each payment was routed, for later analysis
+ALTER TABLE payments ADD COLUMN routing_decision JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,9 @@
+ALTER TABLE payments
+    DROP COLUMN converted_amount,
+    DROP COLUMN converted_currency,
+    DROP COLUMN fx_rate,
+    DROP COLUMN fx_rate_at;
+
+DROP TABLE fx_quotes;
+
+DROP TABLE fx_rates;
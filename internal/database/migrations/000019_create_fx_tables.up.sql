@@ -0,0 +1,36 @@
+-- The latest exchange rate of each currency pair: 1 from_currency = rate to_currency
+CREATE TABLE fx_rates (
+    id SERIAL PRIMARY KEY,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    source VARCHAR(50) NOT NULL,
+    rate_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (from_currency, to_currency)
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON fx_rates
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- Rates locked for a merchant until they expire
+CREATE TABLE fx_quotes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(18, 8) NOT NULL,
+    rate_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Payments converted to the currency of the provider they are settled with
+ALTER TABLE payments
+    ADD COLUMN converted_amount NUMERIC(12, 2),
+    ADD COLUMN converted_currency VARCHAR(3) NOT NULL DEFAULT '',
+    ADD COLUMN fx_rate NUMERIC(18, 8),
+    ADD COLUMN fx_rate_at TIMESTAMPTZ;
@@ -0,0 +1,13 @@
+ALTER TABLE payments
+    DROP COLUMN needs_review,
+    DROP COLUMN review_reason;
+
+-- PostgreSQL cannot drop a value from an enum, so the type is recreated without it
+UPDATE payments SET status = 'FAILED' WHERE status = 'CANCELLED';
+
+ALTER TABLE payments ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE payment_status RENAME TO payment_status_old;
+CREATE TYPE payment_status AS ENUM ('INITIALIZED', 'PENDING', 'SUCCESS', 'FAILED', 'EXPIRED');
+ALTER TABLE payments ALTER COLUMN status TYPE payment_status USING status::text::payment_status;
+ALTER TABLE payments ALTER COLUMN status SET DEFAULT 'INITIALIZED';
+DROP TYPE payment_status_old;
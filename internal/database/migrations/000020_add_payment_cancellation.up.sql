@@ -0,0 +1,7 @@
+-- Payments can be cancelled by the merchant before they complete
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'CANCELLED';
+
+-- Provider callbacks that arrive for cancelled payments are kept for manual review
+ALTER TABLE payments
+    ADD COLUMN needs_review BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN review_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_payments_merchant_reference;
+
+ALTER TABLE payments
+    DROP COLUMN merchant_reference,
+    DROP COLUMN description,
+    DROP COLUMN metadata,
+    DROP COLUMN customer_email,
+    DROP COLUMN customer_name,
+    DROP COLUMN customer_locale;
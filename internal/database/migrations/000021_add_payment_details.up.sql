@@ -0,0 +1,11 @@
+-- The merchant's own reference and description of a payment, free-form metadata and the customer paying
+ALTER TABLE payments
+    ADD COLUMN merchant_reference VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN customer_email VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN customer_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN customer_locale VARCHAR(35) NOT NULL DEFAULT '';
+
+-- A merchant reference identifies a single payment of the merchant
+CREATE UNIQUE INDEX idx_payments_merchant_reference ON payments (merchant_id, merchant_reference) WHERE merchant_reference <> '';
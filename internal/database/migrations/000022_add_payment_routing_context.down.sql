@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP COLUMN checkout_url;
+ALTER TABLE payments DROP COLUMN provider_priority;
+ALTER TABLE payments DROP COLUMN provider_configuration_id;
@@ -0,0 +1,12 @@
+-- The provider configuration a payment was routed with and its priority at the time, so the route can be
+-- explained after the configuration changed. NULL for payments not routed yet and for older payments.
+ALTER TABLE payments ADD COLUMN provider_configuration_id INT REFERENCES provider_configurations(id) ON DELETE SET NULL;
+ALTER TABLE payments ADD COLUMN provider_priority INT;
+-- The URL the provider sent the customer to
+ALTER TABLE payments ADD COLUMN checkout_url TEXT NOT NULL DEFAULT '';
+
+-- Older payments recorded the configuration in their routing decision; its priority then is unknown
+UPDATE payments p
+SET provider_configuration_id = (p.routing_decision->>'provider_configuration_id')::INT
+WHERE p.routing_decision ? 'provider_configuration_id'
+  AND EXISTS (SELECT 1 FROM provider_configurations pc WHERE pc.id = (p.routing_decision->>'provider_configuration_id')::INT);
@@ -0,0 +1,23 @@
+-- Every request sent to a provider and every callback received from one, with redacted headers and bodies.
+-- Rows are not tied to the payment with a foreign key: a request is recorded even when the payment it was
+-- made for is rolled back, and a callback even when no payment matches its external ID.
+CREATE TABLE provider_interactions (
+    id BIGSERIAL PRIMARY KEY,
+    payment_id UUID,
+    external_id VARCHAR(255) NOT NULL DEFAULT '',
+    provider VARCHAR(50) NOT NULL DEFAULT '',
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('OUTBOUND', 'INBOUND')),
+    method VARCHAR(10) NOT NULL,
+    endpoint TEXT NOT NULL,
+    request_headers JSONB NOT NULL DEFAULT '{}',
+    request_body TEXT NOT NULL DEFAULT '',
+    response_body TEXT NOT NULL DEFAULT '',
+    status_code INT NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_provider_interactions_payment_id ON provider_interactions (payment_id);
+CREATE INDEX idx_provider_interactions_external_id ON provider_interactions (external_id) WHERE external_id <> '';
+CREATE INDEX idx_provider_interactions_created_at ON provider_interactions (created_at);
@@ -0,0 +1,2 @@
+DROP INDEX idx_payments_pending_payouts;
+ALTER TABLE payments DROP COLUMN beneficiary;
@@ -0,0 +1,5 @@
+-- The bank account a payout is sent to, with its identifiers masked. NULL for other payments.
+ALTER TABLE payments ADD COLUMN beneficiary JSONB;
+
+-- Pending payouts are polled for their status
+CREATE INDEX idx_payments_pending_payouts ON payments (updated_at) WHERE status = 'PENDING' AND beneficiary IS NOT NULL;
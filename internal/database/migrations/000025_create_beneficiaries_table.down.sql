@@ -0,0 +1,2 @@
+ALTER TABLE payments DROP COLUMN beneficiary_id;
+DROP TABLE beneficiaries;
@@ -0,0 +1,21 @@
+-- Bank accounts saved for the payouts of the users of merchants. The account is stored encrypted with
+-- AES-256-GCM, and masked for responses.
+CREATE TABLE beneficiaries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    user_id INT NOT NULL,
+    country_code VARCHAR(2) NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    masked_account JSONB NOT NULL,
+    encrypted_account BYTEA NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'UNVERIFIED' CHECK (status IN ('UNVERIFIED', 'VERIFIED', 'DISABLED')),
+    verified_at TIMESTAMPTZ,
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_beneficiaries_merchant_user ON beneficiaries (merchant_id, user_id);
+
+-- The saved beneficiary a payout was sent to
+ALTER TABLE payments ADD COLUMN beneficiary_id UUID REFERENCES beneficiaries(id) ON DELETE SET NULL;
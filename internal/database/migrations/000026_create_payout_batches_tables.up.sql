@@ -0,0 +1,35 @@
+-- Batches of payouts sent in the background, and their payouts. The payout requests are stored
+-- encrypted with AES-256-GCM, as they may carry bank accounts.
+CREATE TABLE payout_batches (
+    id UUID PRIMARY KEY,
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PROCESSING', 'COMPLETED')),
+    total_items INT NOT NULL,
+    processed_items INT NOT NULL DEFAULT 0,
+    submitted_items INT NOT NULL DEFAULT 0,
+    failed_items INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+-- Batches left unfinished by a restart are resumed on startup
+CREATE INDEX idx_payout_batches_unfinished ON payout_batches (created_at) WHERE status <> 'COMPLETED';
+
+CREATE TABLE payout_batch_items (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id UUID NOT NULL REFERENCES payout_batches(id) ON DELETE CASCADE,
+    line INT NOT NULL,
+    user_id INT NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL,
+    currency_code VARCHAR(3) NOT NULL,
+    country_code VARCHAR(2) NOT NULL,
+    merchant_reference VARCHAR(255) NOT NULL,
+    encrypted_request BYTEA NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SUBMITTED', 'FAILED')),
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    error TEXT NOT NULL DEFAULT '',
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_payout_batch_items_batch_line ON payout_batch_items (batch_id, line);
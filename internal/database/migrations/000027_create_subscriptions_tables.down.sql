@@ -0,0 +1,3 @@
+DROP TABLE subscription_charges;
+DROP TABLE subscriptions;
+DROP TABLE subscription_plans;
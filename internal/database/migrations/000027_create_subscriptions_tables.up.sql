@@ -0,0 +1,58 @@
+-- Plans billed by merchants, the subscriptions of their users to them, and each attempt to charge
+-- a period of a subscription
+CREATE TABLE subscription_plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    currency_code VARCHAR(3) NOT NULL,
+    "interval" VARCHAR(10) NOT NULL CHECK ("interval" IN ('DAY', 'WEEK', 'MONTH', 'YEAR')),
+    interval_count INT NOT NULL DEFAULT 1 CHECK (interval_count > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscription_plans_merchant ON subscription_plans (merchant_id, created_at);
+
+CREATE TABLE subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    plan_id UUID NOT NULL REFERENCES subscription_plans(id),
+    user_id INT NOT NULL,
+    country_code VARCHAR(2) NOT NULL,
+    customer_email VARCHAR(255) NOT NULL DEFAULT '',
+    customer_name VARCHAR(255) NOT NULL DEFAULT '',
+    customer_locale VARCHAR(35) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'PAST_DUE', 'PAUSED', 'CANCELLED')),
+    start_at TIMESTAMPTZ NOT NULL,
+    next_period INT NOT NULL DEFAULT 0,
+    next_charge_at TIMESTAMPTZ NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    pending_payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    paused_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscriptions_merchant_user ON subscriptions (merchant_id, user_id);
+
+-- The scheduler charges the active and past due subscriptions without a charge in progress, and
+-- settles those with one
+CREATE INDEX idx_subscriptions_due ON subscriptions (next_charge_at)
+    WHERE status IN ('ACTIVE', 'PAST_DUE') AND pending_payment_id IS NULL;
+CREATE INDEX idx_subscriptions_pending ON subscriptions (pending_payment_id) WHERE pending_payment_id IS NOT NULL;
+
+CREATE TABLE subscription_charges (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    period INT NOT NULL,
+    attempt INT NOT NULL,
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    checkout_url TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED')),
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, period, attempt)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP COLUMN saved_method_id;
+ALTER TABLE payments DROP COLUMN saved_method_id;
+ALTER TABLE payments DROP COLUMN save_method;
+DROP TABLE saved_methods;
@@ -0,0 +1,24 @@
+-- Payment methods saved with providers by the users of merchants, charged server to server with the
+-- reusable token the provider issued once a payment made with them succeeded
+CREATE TABLE saved_methods (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    user_id INT NOT NULL,
+    provider_id INT NOT NULL REFERENCES payment_providers(id),
+    token VARCHAR(255) NOT NULL,
+    source_payment_id UUID NOT NULL REFERENCES payments(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'DISABLED')),
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider_id, token)
+);
+
+CREATE INDEX idx_saved_methods_merchant_user ON saved_methods (merchant_id, user_id);
+
+-- Whether a deposit saves its payment method, and the saved method it was saved as or charged
+ALTER TABLE payments ADD COLUMN save_method BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE payments ADD COLUMN saved_method_id UUID REFERENCES saved_methods(id) ON DELETE SET NULL;
+
+-- The saved method a subscription is charged with
+ALTER TABLE subscriptions ADD COLUMN saved_method_id UUID REFERENCES saved_methods(id) ON DELETE SET NULL;
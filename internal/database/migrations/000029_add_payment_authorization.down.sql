@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS idx_payments_authorized_at;
+
+ALTER TABLE payments
+    DROP COLUMN capture_mode,
+    DROP COLUMN authorized_at,
+    DROP COLUMN captured_amount,
+    DROP COLUMN captured_at;
+
+-- PostgreSQL cannot drop a value from an enum, so the type is recreated without them, and the
+-- partial index comparing the status is recreated for the new type
+DROP INDEX IF EXISTS idx_payments_pending_payouts;
+UPDATE payments SET status = 'PENDING' WHERE status = 'AUTHORIZED';
+UPDATE payments SET status = 'SUCCESS' WHERE status = 'CAPTURED';
+UPDATE payments SET status = 'CANCELLED' WHERE status = 'VOIDED';
+
+ALTER TABLE payments ALTER COLUMN status DROP DEFAULT;
+ALTER TYPE payment_status RENAME TO payment_status_old;
+CREATE TYPE payment_status AS ENUM ('INITIALIZED', 'PENDING', 'SUCCESS', 'FAILED', 'EXPIRED', 'CANCELLED');
+ALTER TABLE payments ALTER COLUMN status TYPE payment_status USING status::text::payment_status;
+ALTER TABLE payments ALTER COLUMN status SET DEFAULT 'INITIALIZED';
+DROP TYPE payment_status_old;
+CREATE INDEX idx_payments_pending_payouts ON payments (updated_at) WHERE status = 'PENDING' AND beneficiary IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Deposits made with manual capture are AUTHORIZED by the provider, then CAPTURED or VOIDED by the merchant
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'AUTHORIZED';
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'CAPTURED';
+ALTER TYPE payment_status ADD VALUE IF NOT EXISTS 'VOIDED';
+
+ALTER TABLE payments
+    ADD COLUMN capture_mode VARCHAR(10) NOT NULL DEFAULT 'automatic' CHECK (capture_mode IN ('automatic', 'manual')),
+    ADD COLUMN authorized_at TIMESTAMPTZ,
+    ADD COLUMN captured_amount NUMERIC(12,2),
+    ADD COLUMN captured_at TIMESTAMPTZ;
+
+-- Authorizations not captured before the capture deadline are voided
+CREATE INDEX idx_payments_authorized_at ON payments (authorized_at) WHERE authorized_at IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE ledger_entries;
+DROP TABLE dispute_evidence;
+DROP TABLE disputes;
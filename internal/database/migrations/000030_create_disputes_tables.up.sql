@@ -0,0 +1,57 @@
+-- Chargebacks and other disputes of payments, opened by a provider notification or by an operator. The
+-- merchant attaches evidence until evidence_due_at, and a lost dispute debits its amount from the ledger.
+CREATE TABLE disputes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    source VARCHAR(10) NOT NULL CHECK (source IN ('PROVIDER', 'ADMIN')),
+    provider_reference VARCHAR(255) NOT NULL DEFAULT '',
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    currency_code VARCHAR(3) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'EVIDENCE_SUBMITTED', 'WON', 'LOST')),
+    evidence_due_at TIMESTAMPTZ NOT NULL,
+    evidence_submitted_at TIMESTAMPTZ,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_disputes_merchant_id ON disputes (merchant_id, created_at DESC);
+CREATE INDEX idx_disputes_payment_id ON disputes (payment_id);
+CREATE INDEX idx_disputes_open_due ON disputes (evidence_due_at) WHERE status = 'OPEN';
+
+-- A provider notifies each of its disputes of a payment under its own reference
+CREATE UNIQUE INDEX idx_disputes_provider_reference ON disputes (payment_id, provider_reference) WHERE provider_reference <> '';
+
+-- The files attached as evidence of disputes, whose content is kept in the blob store under blob_key
+CREATE TABLE dispute_evidence (
+    id UUID PRIMARY KEY,
+    dispute_id UUID NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    blob_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_dispute_evidence_dispute_id ON dispute_evidence (dispute_id);
+
+-- Movements of the balances of merchants, negative for debits
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    merchant_id INT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    amount NUMERIC(12,2) NOT NULL,
+    currency_code VARCHAR(3) NOT NULL,
+    payment_id UUID REFERENCES payments(id),
+    dispute_id UUID REFERENCES disputes(id),
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ledger_entries_merchant_id ON ledger_entries (merchant_id, created_at DESC);
+
+-- A dispute is debited at most once
+CREATE UNIQUE INDEX idx_ledger_entries_dispute_id ON ledger_entries (dispute_id, type) WHERE dispute_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- The admin scope of the merchant keys it replaced cannot be told apart from the merchant scopes, so it is not restored
+DROP TABLE operator_api_keys;
@@ -0,0 +1,21 @@
+-- Create the operator_api_keys table. Operator keys belong to no merchant and are the only keys
+-- accepted by the admin routes. As for merchant keys, only the SHA-256 hash of a key is stored.
+CREATE TABLE operator_api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    key_prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON operator_api_keys
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+-- The admin scope no longer grants the admin routes. Merchant keys that had it keep every merchant scope.
+UPDATE merchant_api_keys
+SET scopes = 'payments:deposit payments:withdrawal payments:read'
+WHERE 'admin' = ANY (string_to_array(scopes, ' '));
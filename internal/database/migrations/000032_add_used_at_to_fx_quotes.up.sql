@@ -0,0 +1,2 @@
+-- A quote converts a single payment, the first one created with it
+ALTER TABLE fx_quotes ADD COLUMN used_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+UPDATE payout_batch_items SET status = 'PENDING' WHERE status = 'SENDING';
+ALTER TABLE payout_batch_items DROP CONSTRAINT payout_batch_items_status_check;
+ALTER TABLE payout_batch_items ADD CONSTRAINT payout_batch_items_status_check CHECK (status IN ('PENDING', 'SUBMITTED', 'FAILED'));
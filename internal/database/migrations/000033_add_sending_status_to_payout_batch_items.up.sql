@@ -0,0 +1,3 @@
+-- Payouts of a batch are claimed as SENDING before they are sent, so that a single run sends each of them
+ALTER TABLE payout_batch_items DROP CONSTRAINT payout_batch_items_status_check;
+ALTER TABLE payout_batch_items ADD CONSTRAINT payout_batch_items_status_check CHECK (status IN ('PENDING', 'SENDING', 'SUBMITTED', 'FAILED'));
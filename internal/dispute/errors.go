@@ -0,0 +1,38 @@
+package dispute
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrDisputeNotFound is returned when no dispute of the merchant has the ID
+	ErrDisputeNotFound = utils.NewAPIError(http.StatusNotFound, "dispute_not_found", "Dispute not found")
+
+	// ErrEvidenceNotFound is returned when no evidence of the dispute has the ID
+	ErrEvidenceNotFound = utils.NewAPIError(http.StatusNotFound, "evidence_not_found", "Evidence not found")
+
+	// ErrPaymentNotDisputable is returned when disputing a payment that is not a completed deposit
+	ErrPaymentNotDisputable = utils.NewAPIError(http.StatusConflict, "payment_not_disputable", "Only completed deposits can be disputed")
+
+	// ErrDuplicateDispute is returned when the payment already has a dispute with the provider reference
+	ErrDuplicateDispute = utils.NewAPIError(http.StatusConflict, "duplicate_dispute", "The payment already has a dispute with this provider reference")
+
+	// ErrDisputeAmountExceeded is returned when a dispute is for more than the amount of its payment its other disputes leave
+	ErrDisputeAmountExceeded = utils.NewAPIError(http.StatusUnprocessableEntity, "dispute_amount_exceeded", "Dispute amount exceeds the undisputed amount of the payment")
+
+	// ErrInvalidDisputeTransition is returned when a dispute cannot move from its current status to the requested one
+	ErrInvalidDisputeTransition = utils.NewAPIError(http.StatusConflict, "invalid_dispute_transition", "Dispute status transition is not allowed")
+
+	// ErrEvidenceDeadlinePassed is returned when evidence is added or submitted after the deadline of the dispute
+	ErrEvidenceDeadlinePassed = utils.NewAPIError(http.StatusUnprocessableEntity, "evidence_deadline_passed", "The evidence deadline of the dispute has passed")
+
+	// ErrNoEvidence is returned when submitting the evidence of a dispute that has none
+	ErrNoEvidence = utils.NewAPIError(http.StatusUnprocessableEntity, "no_evidence", "Attach evidence to the dispute before submitting it")
+
+	// ErrEvidenceFileRequired is returned when evidence is uploaded without a file in the multipart field "file"
+	ErrEvidenceFileRequired = utils.NewAPIError(http.StatusBadRequest, "evidence_file_required", "The evidence file is expected in the multipart field \"file\"")
+
+	// ErrEvidenceTooLarge is returned when an evidence file is larger than maxEvidenceBytes
+	ErrEvidenceTooLarge = utils.NewAPIError(http.StatusRequestEntityTooLarge, "evidence_too_large", "Evidence files are limited to 10 MB")
+)
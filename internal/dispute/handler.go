@@ -0,0 +1,320 @@
+package dispute
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/blob"
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxEvidenceBytes is the largest evidence file accepted
+const maxEvidenceBytes = 10 << 20
+
+// DisputeHandler handles the dispute requests and the chargeback notifications of providers
+type DisputeHandler struct {
+	service DisputeServiceInterface
+}
+
+// NewDisputeHandler initializes a new DisputeHandler, keeping the evidence files under BLOB_DIR
+func NewDisputeHandler(db *gorm.DB, configStore *config.Store) *DisputeHandler {
+	evidenceWindow := func() time.Duration { return configStore.Current().DisputeEvidenceWindowDuration() }
+	chargebackSecrets := func() map[string]string { return configStore.Current().ChargebackSecrets() }
+	store := blob.NewLocalStore(configStore.Current().BlobDir)
+	return &DisputeHandler{service: NewDisputeService(db, store, evidenceWindow, chargebackSecrets)}
+}
+
+// Service returns the DisputeService of the handler, which also looks up the secrets of chargeback notifications
+func (h *DisputeHandler) Service() DisputeServiceInterface {
+	return h.service
+}
+
+// HandleChargebackCallback handles the dispute notifications of providers
+// @Summary Handles chargeback provider callbacks
+// @Description Opens a dispute of the payment with the external ID when the provider notifies a chargeback, or records the decision of the provider on it when notified again under the same reference as WON or LOST. A lost dispute debits its amount from the ledger of the merchant. Repeated notifications have no effect.
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param X-Signature header string true "HMAC-SHA256 of the request with the chargeback secret of the provider, hex encoded"
+// @Param X-Signature-Timestamp header string true "Unix time the request was signed at"
+// @Param external_id path string true "External ID"
+// @Param validatedBody body ChargebackNotification true "Chargeback notification"
+// @Success 200 {object} utils.APIResponse{data=Dispute} "Dispute"
+// @Failure 400 {object} utils.APIResponse "Invalid notification"
+// @Failure 401 {object} utils.APIResponse "Signature missing, invalid or expired, or unknown payment"
+// @Failure 409 {object} utils.APIResponse "Payment is not a completed deposit or the dispute was already decided otherwise"
+// @Failure 422 {object} utils.APIResponse "Dispute amount exceeds the undisputed amount of the payment"
+// @Router /payment/callbacks/chargeback/{external_id} [post]
+func (h *DisputeHandler) HandleChargebackCallback(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	notification, ok := req.(*ChargebackNotification)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	externalID, err := payment.ExtractExternalID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Set(interaction.ContextKeyExternalID, externalID)
+
+	dispute, err := h.service.HandleNotification(c, externalID, notification)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Set(interaction.ContextKeyPaymentID, dispute.PaymentID)
+	utils.SuccessResponse(c, http.StatusOK, "Chargeback notification handled", dispute)
+}
+
+// List lists the disputes of the merchant
+// @Summary List disputes
+// @Description Lists the disputes of the payments of the authenticated merchant, newest first.
+// @Tags disputes
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param status query string false "Only disputes in this status" Enums(OPEN, EVIDENCE_SUBMITTED, WON, LOST)
+// @Param payment_id query string false "Only disputes of this payment"
+// @Success 200 {object} utils.APIResponse{data=[]Dispute} "Disputes"
+// @Failure 400 {object} utils.APIResponse "Invalid filter"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Router /disputes [get]
+func (h *DisputeHandler) List(c *gin.Context) {
+	var query ListDisputesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", map[string][]string{"validation": {err.Error()}})
+		return
+	}
+
+	disputes, err := h.service.List(c, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Disputes", disputes)
+}
+
+// Get returns a dispute of the merchant
+// @Summary Get a dispute
+// @Description Returns a dispute of the authenticated merchant with the evidence attached to it.
+// @Tags disputes
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} utils.APIResponse{data=Dispute} "Dispute"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Failure 404 {object} utils.APIResponse "Dispute not found"
+// @Router /disputes/{id} [get]
+func (h *DisputeHandler) Get(c *gin.Context) {
+	dispute, err := h.service.Get(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dispute found", dispute)
+}
+
+// AddEvidence attaches an evidence file to a dispute
+// @Summary Attach evidence to a dispute
+// @Description Attaches a file of up to 10 MB, such as a receipt or a proof of delivery, to an OPEN dispute of the authenticated merchant before its evidence deadline. The evidence is sent to the provider once submitted.
+// @Tags disputes
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Dispute ID"
+// @Param file formData file true "Evidence file"
+// @Param description formData string false "What the file shows"
+// @Success 201 {object} utils.APIResponse{data=Evidence} "Attached evidence"
+// @Failure 400 {object} utils.APIResponse "Missing file"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Dispute not found"
+// @Failure 409 {object} utils.APIResponse "Dispute is no longer open"
+// @Failure 413 {object} utils.APIResponse "Evidence file too large"
+// @Failure 422 {object} utils.APIResponse "Evidence deadline passed"
+// @Router /disputes/{id}/evidence [post]
+func (h *DisputeHandler) AddEvidence(c *gin.Context) {
+	// The form fields and the multipart headers come on top of the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxEvidenceBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			_ = c.Error(ErrEvidenceTooLarge)
+			return
+		}
+		_ = c.Error(ErrEvidenceFileRequired)
+		return
+	}
+	if header.Size > maxEvidenceBytes {
+		_ = c.Error(ErrEvidenceTooLarge)
+		return
+	}
+
+	description := c.PostForm("description")
+	if len(description) > 1000 {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", map[string][]string{"description": {"must be at most 1000 characters"}})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	defer file.Close()
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	fileName := filepath.Base(header.Filename)
+	if len(fileName) > 255 {
+		fileName = fileName[len(fileName)-255:]
+	}
+
+	evidence, err := h.service.AddEvidence(c, c.Param("id"), &EvidenceUpload{
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        header.Size,
+		Description: description,
+		Content:     file,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Evidence attached", evidence)
+}
+
+// DownloadEvidence returns an evidence file of a dispute
+// @Summary Download an evidence file
+// @Description Returns the file of an evidence attached to a dispute of the authenticated merchant.
+// @Tags disputes
+// @Produce octet-stream
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Dispute ID"
+// @Param evidence_id path string true "Evidence ID"
+// @Success 200 {file} file "Evidence file"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Failure 404 {object} utils.APIResponse "Dispute or evidence not found"
+// @Router /disputes/{id}/evidence/{evidence_id} [get]
+func (h *DisputeHandler) DownloadEvidence(c *gin.Context) {
+	evidence, file, err := h.service.OpenEvidence(c, c.Param("id"), c.Param("evidence_id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	defer file.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": evidence.FileName})
+	c.DataFromReader(http.StatusOK, evidence.Size, evidence.ContentType, file, map[string]string{"Content-Disposition": disposition})
+}
+
+// SubmitEvidence submits the evidence of a dispute
+// @Summary Submit the evidence of a dispute
+// @Description Submits the evidence attached to an OPEN dispute of the authenticated merchant before its deadline. The dispute is then EVIDENCE_SUBMITTED until the provider decides it, and no more evidence can be attached.
+// @Tags disputes
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} utils.APIResponse{data=Dispute} "Dispute"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Dispute not found"
+// @Failure 409 {object} utils.APIResponse "Dispute is no longer open"
+// @Failure 422 {object} utils.APIResponse "No evidence attached or evidence deadline passed"
+// @Router /disputes/{id}/submit [post]
+func (h *DisputeHandler) SubmitEvidence(c *gin.Context) {
+	dispute, err := h.service.SubmitEvidence(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Evidence submitted", dispute)
+}
+
+// Create opens a dispute entered by an operator
+// @Summary Open a dispute
+// @Description Opens a dispute of a completed deposit of any merchant, such as a chargeback a bank reported outside of the provider notifications. The amount defaults to the amount of the payment and the evidence deadline to DISPUTE_EVIDENCE_WINDOW from now.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param validatedBody body CreateDisputeRequest true "Dispute"
+// @Success 201 {object} utils.APIResponse{data=Dispute} "Opened dispute"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is not a completed deposit or the provider reference is already used"
+// @Failure 422 {object} utils.APIResponse "Dispute amount exceeds the undisputed amount of the payment"
+// @Router /admin/disputes [post]
+func (h *DisputeHandler) Create(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CreateDisputeRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Opening dispute of payment %s", request.PaymentID))
+	dispute, err := h.service.Create(c, request)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Dispute opened", dispute)
+}
+
+// Resolve records the decision of the provider on a dispute
+// @Summary Resolve a dispute
+// @Description Records the decision of the provider on an OPEN or EVIDENCE_SUBMITTED dispute of any merchant, for providers that do not notify it. A LOST dispute debits its amount from the ledger of the merchant.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path string true "Dispute ID"
+// @Param validatedBody body ResolveDisputeRequest true "Outcome"
+// @Success 200 {object} utils.APIResponse{data=Dispute} "Resolved dispute"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Dispute not found"
+// @Failure 409 {object} utils.APIResponse "Dispute was already decided"
+// @Router /admin/disputes/{id}/resolve [post]
+func (h *DisputeHandler) Resolve(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*ResolveDisputeRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	dispute, err := h.service.Resolve(c, c.Param("id"), request.Outcome)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dispute resolved", dispute)
+}
@@ -0,0 +1,107 @@
+package dispute
+
+import (
+	"time"
+)
+
+// Status is the status of a dispute
+type Status string
+
+const (
+	// StatusOpen disputes wait for the evidence of the merchant until their deadline
+	StatusOpen Status = "OPEN"
+	// StatusEvidenceSubmitted disputes wait for the decision of the provider
+	StatusEvidenceSubmitted Status = "EVIDENCE_SUBMITTED"
+	// StatusWon disputes were decided for the merchant, who keeps the funds
+	StatusWon Status = "WON"
+	// StatusLost disputes were decided for the customer, and their amount is debited from the merchant's ledger
+	StatusLost Status = "LOST"
+)
+
+// Source is where a dispute was opened from
+type Source string
+
+const (
+	// SourceProvider disputes were notified by the provider of the payment
+	SourceProvider Source = "PROVIDER"
+	// SourceAdmin disputes were entered by an operator, such as those a bank reported by email
+	SourceAdmin Source = "ADMIN"
+)
+
+// Dispute is a chargeback or other claim of a customer against a payment. The merchant attaches evidence
+// and submits it before EvidenceDueAt, and the dispute is WON or LOST once the provider decided.
+type Dispute struct {
+	ID         string `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	PaymentID  string `gorm:"type:uuid;not null" json:"payment_id"`
+	MerchantID uint   `gorm:"not null" json:"merchant_id"`
+	Source     Source `gorm:"type:varchar(10);not null" json:"source"`
+
+	// ProviderReference is the provider's identifier of the dispute, empty for disputes entered by an operator
+	ProviderReference string `gorm:"type:varchar(255);not null;default:''" json:"provider_reference,omitempty"`
+
+	Amount       float64 `gorm:"type:numeric(12,2);not null" json:"amount"`
+	CurrencyCode string  `gorm:"type:varchar(3);not null" json:"currency_code"`
+	Reason       string  `gorm:"type:text;not null;default:''" json:"reason,omitempty"`
+
+	Status              Status     `gorm:"type:varchar(20);not null;default:OPEN" json:"status"`
+	EvidenceDueAt       time.Time  `gorm:"not null" json:"evidence_due_at"`
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	Evidence []Evidence `gorm:"foreignKey:DisputeID" json:"evidence,omitempty"`
+}
+
+// Evidence is a file the merchant attached to a dispute, whose content is kept in the blob store
+type Evidence struct {
+	ID          string    `gorm:"type:uuid;primaryKey" json:"id"`
+	DisputeID   string    `gorm:"type:uuid;not null" json:"dispute_id"`
+	FileName    string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	Description string    `gorm:"type:text;not null;default:''" json:"description,omitempty"`
+	BlobKey     string    `gorm:"type:varchar(255);not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Evidence) TableName() string {
+	return "dispute_evidence"
+}
+
+// final reports whether the dispute was decided
+func (d *Dispute) final() bool {
+	return d.Status == StatusWon || d.Status == StatusLost
+}
+
+// CreateDisputeRequest is a dispute an operator enters, such as one a bank reported outside of the
+// provider notifications. Amount, in the settlement currency of the payment, defaults to the undisputed
+// amount of the payment and EvidenceDueAt to DISPUTE_EVIDENCE_WINDOW from now.
+type CreateDisputeRequest struct {
+	PaymentID         string     `json:"payment_id" binding:"required,uuid"`
+	Amount            float64    `json:"amount" binding:"omitempty,gt=0"`
+	Reason            string     `json:"reason" binding:"omitempty,max=1000"`
+	ProviderReference string     `json:"provider_reference" binding:"omitempty,max=255"`
+	EvidenceDueAt     *time.Time `json:"evidence_due_at"`
+}
+
+// ResolveDisputeRequest is the decision of the provider on a dispute
+type ResolveDisputeRequest struct {
+	Outcome Status `json:"outcome" binding:"required,oneof=WON LOST"`
+}
+
+// ChargebackNotification is a dispute notified by the provider of a payment. The provider notifies a new
+// dispute as OPEN and notifies it again under the same reference once it decided it, as WON or LOST.
+type ChargebackNotification struct {
+	Reference     string     `json:"reference" binding:"required,max=255"`
+	Status        Status     `json:"status" binding:"omitempty,oneof=OPEN WON LOST"`
+	Amount        float64    `json:"amount" binding:"omitempty,gt=0"`
+	Reason        string     `json:"reason" binding:"omitempty,max=1000"`
+	EvidenceDueAt *time.Time `json:"evidence_due_at"`
+}
+
+// ListDisputesQuery filters the disputes of the merchant
+type ListDisputesQuery struct {
+	Status    Status `form:"status" binding:"omitempty,oneof=OPEN EVIDENCE_SUBMITTED WON LOST"`
+	PaymentID string `form:"payment_id" binding:"omitempty,uuid"`
+}
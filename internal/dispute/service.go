@@ -0,0 +1,446 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"payment-gateway-service/internal/blob"
+	"payment-gateway-service/internal/ledger"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DisputeServiceInterface defines the methods that the DisputeService must implement.
+type DisputeServiceInterface interface {
+	Create(ctx context.Context, request *CreateDisputeRequest) (*Dispute, error)
+	HandleNotification(ctx context.Context, externalID string, notification *ChargebackNotification) (*Dispute, error)
+	List(ctx context.Context, query ListDisputesQuery) ([]Dispute, error)
+	Get(ctx context.Context, id string) (*Dispute, error)
+	AddEvidence(ctx context.Context, id string, upload *EvidenceUpload) (*Evidence, error)
+	OpenEvidence(ctx context.Context, id, evidenceID string) (*Evidence, io.ReadCloser, error)
+	SubmitEvidence(ctx context.Context, id string) (*Dispute, error)
+	Resolve(ctx context.Context, id string, outcome Status) (*Dispute, error)
+	ExpireOverdue(ctx context.Context, now time.Time) ([]Dispute, error)
+	NotificationSecret(ctx context.Context, externalID string) (string, error)
+}
+
+// EvidenceUpload is a file attached to a dispute, read from Content
+type EvidenceUpload struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Description string
+	Content     io.Reader
+}
+
+// DisputeService keeps the disputes of payments. Their evidence files are kept in a blob store, and the
+// disputes that are lost are debited from the ledger of their merchant.
+type DisputeService struct {
+	db                *gorm.DB
+	store             blob.Store
+	evidenceWindow    func() time.Duration
+	chargebackSecrets func() map[string]string
+}
+
+// NewDisputeService initializes a new DisputeService. evidenceWindow returns how long merchants have to
+// submit evidence when the dispute comes with no deadline, and chargebackSecrets the secret each provider
+// signs its chargeback notifications with, keyed by provider name.
+func NewDisputeService(db *gorm.DB, store blob.Store, evidenceWindow func() time.Duration, chargebackSecrets func() map[string]string) *DisputeService {
+	return &DisputeService{db: db, store: store, evidenceWindow: evidenceWindow, chargebackSecrets: chargebackSecrets}
+}
+
+var _ DisputeServiceInterface = (*DisputeService)(nil)
+
+// Create opens a dispute an operator entered for a payment of any merchant
+func (s *DisputeService) Create(ctx context.Context, request *CreateDisputeRequest) (*Dispute, error) {
+	var dispute *Dispute
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The payment is locked so that concurrent disputes cannot exceed its amount together
+		var p payment.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", request.PaymentID).First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return payment.ErrPaymentNotFound
+			}
+			return err
+		}
+
+		// Providers notify their disputes under their reference, which an operator may enter as well
+		if request.ProviderReference != "" {
+			var count int64
+			if err := tx.Model(&Dispute{}).Where("payment_id = ? AND provider_reference = ?", p.ID, request.ProviderReference).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrDuplicateDispute
+			}
+		}
+
+		var err error
+		dispute, err = s.open(tx, &p, SourceAdmin, request.ProviderReference, request.Amount, request.Reason, request.EvidenceDueAt)
+		return err
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to open dispute of payment %s: %v", request.PaymentID, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Dispute %s opened for payment %s", dispute.ID, dispute.PaymentID))
+	return dispute, nil
+}
+
+// HandleNotification records a dispute notified by the provider of the payment with the external ID. The
+// first notification of a reference opens the dispute, and a later one with the decision of the provider
+// resolves it. Notifications the dispute already reflects have no effect, so providers can repeat them.
+func (s *DisputeService) HandleNotification(ctx context.Context, externalID string, notification *ChargebackNotification) (*Dispute, error) {
+	var dispute Dispute
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var p payment.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("external_id = ?", externalID).First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return payment.ErrPaymentNotFound
+			}
+			return err
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payment_id = ? AND provider_reference = ?", p.ID, notification.Reference).
+			First(&dispute).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			opened, err := s.open(tx, &p, SourceProvider, notification.Reference, notification.Amount, notification.Reason, notification.EvidenceDueAt)
+			if err != nil {
+				return err
+			}
+			dispute = *opened
+		} else if err != nil {
+			return err
+		}
+
+		// OPEN notifications only open the dispute, and a repeated decision has no effect
+		if (notification.Status != StatusWon && notification.Status != StatusLost) || dispute.Status == notification.Status {
+			return nil
+		}
+		if dispute.final() {
+			return ErrInvalidDisputeTransition
+		}
+		return s.resolve(ctx, tx, &dispute, notification.Status, time.Now())
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to handle dispute %s of payment %s: %v", notification.Reference, externalID, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Dispute %s of payment %s is %s", dispute.ID, dispute.PaymentID, dispute.Status))
+	return &dispute, nil
+}
+
+// open creates an OPEN dispute of a completed deposit, for what its other disputes leave of its amount unless
+// amount is set. Disputes the merchant won leave the amount of the payment to dispute again. The payment must be
+// locked by tx.
+func (s *DisputeService) open(tx *gorm.DB, p *payment.Payment, source Source, reference string, amount float64, reason string, evidenceDueAt *time.Time) (*Dispute, error) {
+	if p.PaymentType != utils.PaymentTypeDeposit || (p.Status != utils.PaymentStatusSuccess && p.Status != utils.PaymentStatusCaptured) {
+		return nil, ErrPaymentNotDisputable
+	}
+
+	// Disputes are in the currency the provider settled the payment in, which converted payments changed
+	disputable := p.SettledAmount()
+
+	var disputed float64
+	if err := tx.Model(&Dispute{}).
+		Where("payment_id = ? AND status <> ?", p.ID, StatusWon).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&disputed).Error; err != nil {
+		return nil, err
+	}
+	disputable -= disputed
+	if disputable <= 0 {
+		return nil, ErrDisputeAmountExceeded
+	}
+	if amount == 0 {
+		amount = disputable
+	}
+	if amount > disputable {
+		return nil, ErrDisputeAmountExceeded
+	}
+
+	now := time.Now()
+	dueAt := now.Add(s.evidenceWindow())
+	if evidenceDueAt != nil {
+		dueAt = *evidenceDueAt
+	}
+
+	dispute := &Dispute{
+		PaymentID:         p.ID,
+		MerchantID:        p.MerchantID,
+		Source:            source,
+		ProviderReference: reference,
+		Amount:            amount,
+		CurrencyCode:      p.SettlementCurrency(),
+		Reason:            reason,
+		Status:            StatusOpen,
+		EvidenceDueAt:     dueAt,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := tx.Create(dispute).Error; err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// List lists the disputes of the authenticated merchant, newest first
+func (s *DisputeService) List(ctx context.Context, query ListDisputesQuery) ([]Dispute, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.db.WithContext(ctx).Where("merchant_id = ?", merchantID)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.PaymentID != "" {
+		db = db.Where("payment_id = ?", query.PaymentID)
+	}
+
+	var disputes []Dispute
+	if err := db.Order("created_at DESC").Find(&disputes).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to list disputes: %v", err))
+		return nil, err
+	}
+	return disputes, nil
+}
+
+// Get returns a dispute of the authenticated merchant with its evidence
+func (s *DisputeService) Get(ctx context.Context, id string) (*Dispute, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrDisputeNotFound
+	}
+
+	var dispute Dispute
+	if err := s.db.WithContext(ctx).
+		Preload("Evidence", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("id = ? AND merchant_id = ?", id, merchantID).
+		First(&dispute).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// AddEvidence attaches a file to an OPEN dispute of the authenticated merchant before its deadline
+func (s *DisputeService) AddEvidence(ctx context.Context, id string, upload *EvidenceUpload) (*Evidence, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence := &Evidence{
+		ID:          uuid.NewString(),
+		DisputeID:   id,
+		FileName:    upload.FileName,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		Description: upload.Description,
+		CreatedAt:   time.Now(),
+	}
+	evidence.BlobKey = fmt.Sprintf("disputes/%s/%s", id, evidence.ID)
+
+	// The file is written before the dispute is locked, and only kept with its evidence
+	if err := s.store.Put(ctx, evidence.BlobKey, upload.Content); err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to store evidence file %s: %v", evidence.BlobKey, err))
+		return nil, err
+	}
+	_, err = s.update(ctx, id, func(tx *gorm.DB, dispute *Dispute) error {
+		if err := checkEvidenceOpen(dispute, evidence.CreatedAt); err != nil {
+			return err
+		}
+		return tx.Create(evidence).Error
+	}, "id = ? AND merchant_id = ?", id, merchantID)
+	if err != nil {
+		if err := s.store.Delete(context.WithoutCancel(ctx), evidence.BlobKey); err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to delete evidence file %s: %v", evidence.BlobKey, err))
+		}
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Evidence %s attached to dispute %s", evidence.ID, id))
+	return evidence, nil
+}
+
+// OpenEvidence returns an evidence of a dispute of the authenticated merchant and its file, which the caller closes
+func (s *DisputeService) OpenEvidence(ctx context.Context, id, evidenceID string) (*Evidence, io.ReadCloser, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	if _, err := uuid.Parse(evidenceID); err != nil {
+		return nil, nil, ErrEvidenceNotFound
+	}
+
+	var evidence Evidence
+	if err := s.db.WithContext(ctx).Where("id = ? AND dispute_id = ?", evidenceID, id).First(&evidence).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrEvidenceNotFound
+		}
+		return nil, nil, err
+	}
+
+	file, err := s.store.Get(ctx, evidence.BlobKey)
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to open evidence file %s: %v", evidence.BlobKey, err))
+		return nil, nil, err
+	}
+	return &evidence, file, nil
+}
+
+// SubmitEvidence submits the evidence attached to an OPEN dispute of the authenticated merchant before its
+// deadline, which then waits for the decision of the provider
+func (s *DisputeService) SubmitEvidence(ctx context.Context, id string) (*Dispute, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, id, func(tx *gorm.DB, dispute *Dispute) error {
+		now := time.Now()
+		if err := checkEvidenceOpen(dispute, now); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&Evidence{}).Where("dispute_id = ?", dispute.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNoEvidence
+		}
+
+		dispute.Status = StatusEvidenceSubmitted
+		dispute.EvidenceSubmittedAt = &now
+		dispute.UpdatedAt = now
+		return tx.Save(dispute).Error
+	}, "id = ? AND merchant_id = ?", id, merchantID)
+}
+
+// Resolve records the decision of the provider on a dispute of any merchant, entered by an operator
+func (s *DisputeService) Resolve(ctx context.Context, id string, outcome Status) (*Dispute, error) {
+	return s.update(ctx, id, func(tx *gorm.DB, dispute *Dispute) error {
+		if dispute.final() {
+			return ErrInvalidDisputeTransition
+		}
+		return s.resolve(ctx, tx, dispute, outcome, time.Now())
+	}, "id = ?", id)
+}
+
+// NotificationSecret returns the secret the provider of the payment with the external ID signs its chargeback
+// notifications with, empty when none is configured
+func (s *DisputeService) NotificationSecret(ctx context.Context, externalID string) (string, error) {
+	var p payment.Payment
+	if err := s.db.WithContext(ctx).Preload("Provider").Where("external_id = ?", externalID).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", payment.ErrPaymentNotFound
+		}
+		return "", err
+	}
+	return s.chargebackSecrets()[p.Provider.Name], nil
+}
+
+// ExpireOverdue marks the disputes still OPEN after their evidence deadline as LOST, and returns them.
+// Disputes that fail are logged and left OPEN for the next run.
+func (s *DisputeService) ExpireOverdue(ctx context.Context, now time.Time) ([]Dispute, error) {
+	var ids []string
+	if err := s.db.WithContext(ctx).Model(&Dispute{}).
+		Where("status = ? AND evidence_due_at < ?", StatusOpen, now).
+		Order("evidence_due_at").
+		Pluck("id", &ids).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to list the overdue disputes: %v", err))
+		return nil, err
+	}
+
+	var lost []Dispute
+	for _, id := range ids {
+		dispute, err := s.update(ctx, id, func(tx *gorm.DB, dispute *Dispute) error {
+			// The merchant may have submitted evidence since the disputes were listed
+			if dispute.Status != StatusOpen {
+				return ErrInvalidDisputeTransition
+			}
+			return s.resolve(ctx, tx, dispute, StatusLost, now)
+		}, "id = ?", id)
+		if err != nil {
+			continue
+		}
+		lost = append(lost, *dispute)
+	}
+	return lost, nil
+}
+
+// update applies change to the dispute with the ID, locked in a transaction. The dispute is looked up
+// with query and args, which scope it to the authenticated merchant for the merchant's own requests.
+func (s *DisputeService) update(ctx context.Context, id string, change func(tx *gorm.DB, dispute *Dispute) error, query string, args ...interface{}) (*Dispute, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrDisputeNotFound
+	}
+
+	var dispute Dispute
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&dispute).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDisputeNotFound
+			}
+			return err
+		}
+		return change(tx, &dispute)
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Failed to update dispute %s: %v", id, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("DisputeService: Dispute %s is %s", id, dispute.Status))
+	return &dispute, nil
+}
+
+// resolve records the outcome of a dispute, and debits the ledger of the merchant if it was lost
+func (s *DisputeService) resolve(ctx context.Context, tx *gorm.DB, dispute *Dispute, outcome Status, now time.Time) error {
+	dispute.Status = outcome
+	dispute.ResolvedAt = &now
+	dispute.UpdatedAt = now
+	if err := tx.Omit("Evidence").Save(dispute).Error; err != nil {
+		return err
+	}
+	if outcome != StatusLost {
+		return nil
+	}
+
+	return ledger.Record(ctx, tx, &ledger.Entry{
+		MerchantID:   dispute.MerchantID,
+		Type:         ledger.EntryTypeChargeback,
+		Amount:       -dispute.Amount,
+		CurrencyCode: dispute.CurrencyCode,
+		PaymentID:    &dispute.PaymentID,
+		DisputeID:    &dispute.ID,
+		Description:  fmt.Sprintf("Chargeback of lost dispute %s", dispute.ID),
+		CreatedAt:    now,
+	})
+}
+
+// checkEvidenceOpen rejects evidence for disputes that are no longer OPEN or past their deadline
+func checkEvidenceOpen(dispute *Dispute, now time.Time) error {
+	if dispute.Status != StatusOpen {
+		return ErrInvalidDisputeTransition
+	}
+	if now.After(dispute.EvidenceDueAt) {
+		return ErrEvidenceDeadlinePassed
+	}
+	return nil
+}
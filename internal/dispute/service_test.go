@@ -0,0 +1,567 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-gateway-service/internal/blob"
+	"payment-gateway-service/internal/ledger"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+// merchantCtx carries the authenticated merchant the way the auth middleware sets it
+var merchantCtx = context.WithValue(context.TODO(), utils.ContextKeyMerchantID, uint(1))
+
+const disputeID = "3f6b9c2d-7e1a-4b8c-9d5e-0a2f4c6e8b1d"
+
+const paymentID = "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"
+
+const paymentByIDQuery = `^SELECT \* FROM "payments" WHERE id = \$1 ORDER BY "payments"."id" LIMIT \$2 FOR UPDATE$`
+
+const paymentByExternalIDQuery = `^SELECT \* FROM "payments" WHERE external_id = \$1 ORDER BY "payments"."id" LIMIT \$2 FOR UPDATE$`
+
+// disputedAmountQuery sums the amounts of the disputes of the payment the merchant did not win
+const disputedAmountQuery = `^SELECT COALESCE\(SUM\(amount\), 0\) FROM "disputes" WHERE payment_id = \$1 AND status <> \$2$`
+
+const notifiedDisputeQuery = `^SELECT \* FROM "disputes" WHERE payment_id = \$1 AND provider_reference = \$2 ORDER BY "disputes"."id" LIMIT \$3 FOR UPDATE$`
+
+const merchantDisputeQuery = `^SELECT \* FROM "disputes" WHERE id = \$1 AND merchant_id = \$2 ORDER BY "disputes"."id" LIMIT \$3 FOR UPDATE$`
+
+const disputeQuery = `^SELECT \* FROM "disputes" WHERE id = \$1 ORDER BY "disputes"."id" LIMIT \$2 FOR UPDATE$`
+
+var paymentColumns = []string{"id", "merchant_id", "payment_type", "status", "amount", "captured_amount", "currency_code", "external_id"}
+
+var disputeColumns = []string{"id", "payment_id", "merchant_id", "source", "provider_reference", "amount", "currency_code", "status", "evidence_due_at"}
+
+// evidenceWindow is the DISPUTE_EVIDENCE_WINDOW of the tests
+func evidenceWindow() time.Duration {
+	return 168 * time.Hour
+}
+
+// expectDisputed expects the amount of the other disputes of the payment to be summed
+func expectDisputed(mock sqlmock.Sqlmock, disputed float64) {
+	mock.ExpectQuery(disputedAmountQuery).WithArgs(paymentID, "WON").
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(disputed))
+}
+
+func TestCreate_DefaultsToPaymentAmount(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByIDQuery).WithArgs(paymentID, 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, "DEPOSIT", "SUCCESS", 100, nil, "USD", "external-id"))
+	expectDisputed(mock, 0)
+	mock.ExpectQuery(`^INSERT INTO "disputes" .* RETURNING "id"$`).
+		WithArgs(paymentID, 1, "ADMIN", "", float64(100), "USD", "fraudulent", "OPEN", sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(disputeID))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.Create(merchantCtx, &CreateDisputeRequest{PaymentID: paymentID, Reason: "fraudulent"})
+
+	require.NoError(t, err)
+	assert.Equal(t, disputeID, dispute.ID)
+	assert.Equal(t, uint(1), dispute.MerchantID)
+	assert.Equal(t, StatusOpen, dispute.Status)
+	assert.WithinDuration(t, time.Now().Add(evidenceWindow()), dispute.EvidenceDueAt, time.Minute)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_Rejected(t *testing.T) {
+	tests := []struct {
+		name           string
+		paymentType    string
+		status         string
+		capturedAmount interface{}
+		disputed       float64
+		amount         float64
+		wantErr        error
+	}{
+		{name: "pending deposit", paymentType: "DEPOSIT", status: "PENDING", wantErr: ErrPaymentNotDisputable},
+		{name: "withdrawal", paymentType: "WITHDRAWAL", status: "SUCCESS", wantErr: ErrPaymentNotDisputable},
+		{name: "more than captured", paymentType: "DEPOSIT", status: "CAPTURED", capturedAmount: 40, amount: 50, wantErr: ErrDisputeAmountExceeded},
+		{name: "more than undisputed", paymentType: "DEPOSIT", status: "SUCCESS", disputed: 70, amount: 40, wantErr: ErrDisputeAmountExceeded},
+		{name: "fully disputed", paymentType: "DEPOSIT", status: "SUCCESS", disputed: 100, wantErr: ErrDisputeAmountExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(paymentByIDQuery).WithArgs(paymentID, 1).
+				WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, tt.paymentType, tt.status, 100, tt.capturedAmount, "USD", "external-id"))
+			if tt.wantErr == ErrDisputeAmountExceeded {
+				expectDisputed(mock, tt.disputed)
+			}
+			mock.ExpectRollback()
+
+			service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+			dispute, err := service.Create(merchantCtx, &CreateDisputeRequest{PaymentID: paymentID, Amount: tt.amount})
+
+			assert.Nil(t, dispute)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreate_PaymentNotFound(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByIDQuery).WithArgs(paymentID, 1).WillReturnRows(sqlmock.NewRows(paymentColumns))
+	mock.ExpectRollback()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	_, err := service.Create(merchantCtx, &CreateDisputeRequest{PaymentID: paymentID})
+
+	assert.ErrorIs(t, err, payment.ErrPaymentNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_SubtractsOtherDisputes(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// 70 of the 100 are disputed already, the won disputes not counting
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByIDQuery).WithArgs(paymentID, 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, "DEPOSIT", "SUCCESS", 100, nil, "USD", "external-id"))
+	expectDisputed(mock, 70)
+	mock.ExpectQuery(`^INSERT INTO "disputes" .* RETURNING "id"$`).
+		WithArgs(paymentID, 1, "ADMIN", "", float64(30), "USD", "", "OPEN", sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(disputeID))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.Create(merchantCtx, &CreateDisputeRequest{PaymentID: paymentID})
+
+	require.NoError(t, err)
+	assert.Equal(t, float64(30), dispute.Amount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreate_ConvertedPayment(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// 100 USD were settled as 8312 INR, and 50 USD were captured at the same rate
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByIDQuery).WithArgs(paymentID, 1).
+		WillReturnRows(sqlmock.NewRows(append(paymentColumns, "converted_amount", "converted_currency", "fx_rate")).
+			AddRow(paymentID, 1, "DEPOSIT", "CAPTURED", 100, 50, "USD", "external-id", 8312, "INR", 83.12))
+	expectDisputed(mock, 1000)
+	mock.ExpectQuery(`^INSERT INTO "disputes" .* RETURNING "id"$`).
+		WithArgs(paymentID, 1, "ADMIN", "", float64(3156), "INR", "", "OPEN", sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(disputeID))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.Create(merchantCtx, &CreateDisputeRequest{PaymentID: paymentID})
+
+	require.NoError(t, err)
+	assert.Equal(t, float64(3156), dispute.Amount)
+	assert.Equal(t, "INR", dispute.CurrencyCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleNotification_OpensDispute(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The provider gives its own deadline
+	dueAt := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByExternalIDQuery).WithArgs("external-id", 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, "DEPOSIT", "SUCCESS", 100, nil, "USD", "external-id"))
+	mock.ExpectQuery(notifiedDisputeQuery).WithArgs(paymentID, "cb-1", 1).WillReturnRows(sqlmock.NewRows(disputeColumns))
+	expectDisputed(mock, 0)
+	mock.ExpectQuery(`^INSERT INTO "disputes" .* RETURNING "id"$`).
+		WithArgs(paymentID, 1, "PROVIDER", "cb-1", float64(30), "USD", "", "OPEN", dueAt, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(disputeID))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.HandleNotification(context.TODO(), "external-id", &ChargebackNotification{Reference: "cb-1", Status: StatusOpen, Amount: 30, EvidenceDueAt: &dueAt})
+
+	require.NoError(t, err)
+	assert.Equal(t, disputeID, dispute.ID)
+	assert.Equal(t, StatusOpen, dispute.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleNotification_LostDebitsLedger(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByExternalIDQuery).WithArgs("external-id", 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, "DEPOSIT", "SUCCESS", 100, nil, "USD", "external-id"))
+	mock.ExpectQuery(notifiedDisputeQuery).WithArgs(paymentID, "cb-1", 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "EVIDENCE_SUBMITTED", time.Now()))
+	mock.ExpectExec(`^UPDATE "disputes" SET .*"status"=\$8,.* WHERE "id" = \$14$`).
+		WithArgs(paymentID, 1, "PROVIDER", "cb-1", float64(30), "USD", "", "LOST", sqlmock.AnyArg(), nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), disputeID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`^INSERT INTO "ledger_entries" .* RETURNING "id"$`).
+		WithArgs(1, "CHARGEBACK", float64(-30), "USD", paymentID, disputeID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.HandleNotification(context.TODO(), "external-id", &ChargebackNotification{Reference: "cb-1", Status: StatusLost})
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusLost, dispute.Status)
+	assert.NotNil(t, dispute.ResolvedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleNotification_RepeatedDecision(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The ledger is not debited twice
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByExternalIDQuery).WithArgs("external-id", 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, "DEPOSIT", "SUCCESS", 100, nil, "USD", "external-id"))
+	mock.ExpectQuery(notifiedDisputeQuery).WithArgs(paymentID, "cb-1", 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "LOST", time.Now()))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.HandleNotification(context.TODO(), "external-id", &ChargebackNotification{Reference: "cb-1", Status: StatusLost})
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusLost, dispute.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleNotification_ConflictingDecision(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(paymentByExternalIDQuery).WithArgs("external-id", 1).
+		WillReturnRows(sqlmock.NewRows(paymentColumns).AddRow(paymentID, 1, "DEPOSIT", "SUCCESS", 100, nil, "USD", "external-id"))
+	mock.ExpectQuery(notifiedDisputeQuery).WithArgs(paymentID, "cb-1", 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "WON", time.Now()))
+	mock.ExpectRollback()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	_, err := service.HandleNotification(context.TODO(), "external-id", &ChargebackNotification{Reference: "cb-1", Status: StatusLost})
+
+	assert.ErrorIs(t, err, ErrInvalidDisputeTransition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddEvidence_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(merchantDisputeQuery).WithArgs(disputeID, 1, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "OPEN", time.Now().Add(time.Hour)))
+	mock.ExpectExec(`^INSERT INTO "dispute_evidence" `).
+		WithArgs(sqlmock.AnyArg(), disputeID, "receipt.pdf", "application/pdf", int64(7), "Signed receipt", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	store := blob.NewLocalStore(t.TempDir())
+	service := NewDisputeService(gormDB, store, evidenceWindow, nil)
+
+	evidence, err := service.AddEvidence(merchantCtx, disputeID, &EvidenceUpload{
+		FileName: "receipt.pdf", ContentType: "application/pdf", Size: 7, Description: "Signed receipt", Content: strings.NewReader("receipt"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "disputes/"+disputeID+"/"+evidence.ID, evidence.BlobKey)
+	file, err := store.Get(context.TODO(), evidence.BlobKey)
+	require.NoError(t, err)
+	defer file.Close()
+	content, _ := io.ReadAll(file)
+	assert.Equal(t, "receipt", string(content))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddEvidence_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		dueAt   time.Time
+		wantErr error
+	}{
+		{name: "deadline passed", status: "OPEN", dueAt: time.Now().Add(-time.Minute), wantErr: ErrEvidenceDeadlinePassed},
+		{name: "already submitted", status: "EVIDENCE_SUBMITTED", dueAt: time.Now().Add(time.Hour), wantErr: ErrInvalidDisputeTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(merchantDisputeQuery).WithArgs(disputeID, 1, 1).
+				WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", tt.status, tt.dueAt))
+			mock.ExpectRollback()
+
+			dir := t.TempDir()
+			service := NewDisputeService(gormDB, blob.NewLocalStore(dir), evidenceWindow, nil)
+
+			evidence, err := service.AddEvidence(merchantCtx, disputeID, &EvidenceUpload{FileName: "receipt.pdf", Content: strings.NewReader("receipt")})
+
+			assert.Nil(t, evidence)
+			assert.ErrorIs(t, err, tt.wantErr)
+			files, _ := filepath.Glob(filepath.Join(dir, "disputes", disputeID, "*"))
+			assert.Empty(t, files)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddEvidence_RecordFailedDeletesFile(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(merchantDisputeQuery).WithArgs(disputeID, 1, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "OPEN", time.Now().Add(time.Hour)))
+	mock.ExpectExec(`^INSERT INTO "dispute_evidence" `).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	dir := t.TempDir()
+	service := NewDisputeService(gormDB, blob.NewLocalStore(dir), evidenceWindow, nil)
+
+	_, err := service.AddEvidence(merchantCtx, disputeID, &EvidenceUpload{FileName: "receipt.pdf", Content: strings.NewReader("receipt")})
+
+	assert.Error(t, err)
+	files, _ := filepath.Glob(filepath.Join(dir, "disputes", disputeID, "*"))
+	assert.Empty(t, files)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitEvidence_NoEvidence(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(merchantDisputeQuery).WithArgs(disputeID, 1, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "OPEN", time.Now().Add(time.Hour)))
+	mock.ExpectQuery(`^SELECT count\(\*\) FROM "dispute_evidence" WHERE dispute_id = \$1$`).WithArgs(disputeID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	_, err := service.SubmitEvidence(merchantCtx, disputeID)
+
+	assert.ErrorIs(t, err, ErrNoEvidence)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitEvidence_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(merchantDisputeQuery).WithArgs(disputeID, 1, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "OPEN", time.Now().Add(time.Hour)))
+	mock.ExpectQuery(`^SELECT count\(\*\) FROM "dispute_evidence" WHERE dispute_id = \$1$`).WithArgs(disputeID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectExec(`^UPDATE "disputes" SET .*"status"=\$8,.* WHERE "id" = \$14$`).
+		WithArgs(paymentID, 1, "PROVIDER", "cb-1", float64(30), "USD", "", "EVIDENCE_SUBMITTED", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg(), sqlmock.AnyArg(), disputeID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	dispute, err := service.SubmitEvidence(merchantCtx, disputeID)
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusEvidenceSubmitted, dispute.Status)
+	assert.NotNil(t, dispute.EvidenceSubmittedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolve_AlreadyDecided(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(disputeQuery).WithArgs(disputeID, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "ADMIN", "", 30, "USD", "WON", time.Now()))
+	mock.ExpectRollback()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	_, err := service.Resolve(merchantCtx, disputeID, StatusLost)
+
+	assert.ErrorIs(t, err, ErrInvalidDisputeTransition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolve_LostDebitsLedger(t *testing.T) {
+	tests := []struct {
+		name      string
+		insertErr error
+		wantErr   error
+	}{
+		{name: "debited", wantErr: nil},
+		// The unique index on the dispute and the type keeps a dispute from being debited twice
+		{name: "already debited", insertErr: &pgconn.PgError{Code: "23505", ConstraintName: "idx_ledger_entries_dispute_id"}, wantErr: ledger.ErrDuplicateEntry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(disputeQuery).WithArgs(disputeID, 1).
+				WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "ADMIN", "", 30, "USD", "EVIDENCE_SUBMITTED", time.Now()))
+			mock.ExpectExec(`^UPDATE "disputes" SET .*"status"=\$8,.* WHERE "id" = \$14$`).WillReturnResult(sqlmock.NewResult(1, 1))
+			insert := mock.ExpectQuery(`^INSERT INTO "ledger_entries" .* RETURNING "id"$`).
+				WithArgs(1, "CHARGEBACK", float64(-30), "USD", paymentID, disputeID, "Chargeback of lost dispute "+disputeID, sqlmock.AnyArg())
+			if tt.insertErr != nil {
+				insert.WillReturnError(tt.insertErr)
+				mock.ExpectRollback()
+			} else {
+				insert.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			}
+
+			service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+			dispute, err := service.Resolve(context.TODO(), disputeID, StatusLost)
+
+			if tt.wantErr != nil {
+				assert.Nil(t, dispute)
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, StatusLost, dispute.Status)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExpireOverdue(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The second dispute was submitted since the overdue disputes were listed
+	const submittedID = "6a0d3e5f-2b4c-4d6e-8f1a-3c5e7a9b1d2f"
+	now := time.Now()
+	mock.ExpectQuery(`^SELECT "id" FROM "disputes" WHERE status = \$1 AND evidence_due_at < \$2 ORDER BY evidence_due_at$`).
+		WithArgs("OPEN", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(disputeID).AddRow(submittedID))
+	mock.ExpectBegin()
+	mock.ExpectQuery(disputeQuery).WithArgs(disputeID, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(disputeID, paymentID, 1, "PROVIDER", "cb-1", 30, "USD", "OPEN", now.Add(-time.Hour)))
+	mock.ExpectExec(`^UPDATE "disputes" SET .*"status"=\$8,.* WHERE "id" = \$14$`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`^INSERT INTO "ledger_entries" .* RETURNING "id"$`).
+		WithArgs(1, "CHARGEBACK", float64(-30), "USD", paymentID, disputeID, sqlmock.AnyArg(), now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(disputeQuery).WithArgs(submittedID, 1).
+		WillReturnRows(sqlmock.NewRows(disputeColumns).AddRow(submittedID, paymentID, 1, "PROVIDER", "cb-2", 20, "USD", "EVIDENCE_SUBMITTED", now.Add(-time.Hour)))
+	mock.ExpectRollback()
+
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	disputes, err := service.ExpireOverdue(context.TODO(), now)
+
+	require.NoError(t, err)
+	require.Len(t, disputes, 1)
+	assert.Equal(t, disputeID, disputes[0].ID)
+	assert.Equal(t, StatusLost, disputes[0].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchantRequests_NoMerchant(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Without an authenticated merchant nothing is read nor changed
+	service := NewDisputeService(gormDB, nil, evidenceWindow, nil)
+
+	_, err := service.List(context.TODO(), ListDisputesQuery{})
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	_, err = service.Get(context.TODO(), disputeID)
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	_, err = service.AddEvidence(context.TODO(), disputeID, &EvidenceUpload{FileName: "receipt.pdf", Content: strings.NewReader("receipt")})
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	_, err = service.SubmitEvidence(context.TODO(), disputeID)
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationSecret(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The secret is the one of the provider of the payment
+	mock.ExpectQuery(`^SELECT \* FROM "payments" WHERE external_id = \$1 ORDER BY "payments"."id" LIMIT \$2$`).WithArgs("external-id", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "provider_id", "external_id"}).AddRow(paymentID, 2, "external-id"))
+	mock.ExpectQuery(`^SELECT \* FROM "payment_providers" WHERE "payment_providers"."id" = \$1$`).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "ADCB"))
+	mock.ExpectQuery(`^SELECT \* FROM "payments" WHERE external_id = \$1 ORDER BY "payments"."id" LIMIT \$2$`).WithArgs("unknown", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	secrets := func() map[string]string { return map[string]string{"HSBC": "hsbc-secret", "ADCB": "adcb-secret"} }
+	service := NewDisputeService(gormDB, nil, evidenceWindow, secrets)
+
+	secret, err := service.NotificationSecret(context.TODO(), "external-id")
+	require.NoError(t, err)
+	assert.Equal(t, "adcb-secret", secret)
+
+	_, err = service.NotificationSecret(context.TODO(), "unknown")
+	assert.ErrorIs(t, err, payment.ErrPaymentNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
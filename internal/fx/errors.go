@@ -0,0 +1,20 @@
+package fx
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrRateUnavailable is returned when there is no recent enough rate for a currency pair
+	ErrRateUnavailable = utils.NewAPIError(http.StatusUnprocessableEntity, "fx_rate_unavailable", "No exchange rate available for the currency pair")
+
+	// ErrQuoteNotFound is returned when no quote of the merchant matches the lookup
+	ErrQuoteNotFound = utils.NewAPIError(http.StatusNotFound, "fx_quote_not_found", "FX quote not found")
+
+	// ErrQuoteExpired is returned when a quote is used after the time its rate was locked for
+	ErrQuoteExpired = utils.NewAPIError(http.StatusUnprocessableEntity, "fx_quote_expired", "FX quote has expired")
+
+	// ErrQuoteUsed is returned when a quote is used by a second payment
+	ErrQuoteUsed = utils.NewAPIError(http.StatusConflict, "fx_quote_used", "FX quote was already used by a payment")
+)
@@ -0,0 +1,120 @@
+package fx
+
+import (
+	"fmt"
+	"net/http"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FXHandler handles the exchange rate and quote requests
+type FXHandler struct {
+	service FXServiceInterface
+	config  *config.Store
+}
+
+// NewFXHandler initializes a new FXHandler
+func NewFXHandler(db *gorm.DB, configStore *config.Store) *FXHandler {
+	maxRateAge := func() time.Duration { return configStore.Current().FXRateMaxAgeDuration() }
+	return &FXHandler{service: NewFXService(db, maxRateAge), config: configStore}
+}
+
+// CreateQuote locks the rate of a currency pair
+// @Summary Create an FX quote
+// @Description Locks the latest rate of a currency pair for the configured quote TTL. The first payment passing the quote ID as fx_quote_id is converted at the locked rate, and uses the quote up. Requires the payments:deposit or payments:withdrawal scope.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param validatedBody body QuoteRequest true "Currency pair"
+// @Success 201 {object} utils.APIResponse{data=QuoteResponse} "FX quote"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "API key is missing the required scope"
+// @Failure 422 {object} utils.APIResponse "No exchange rate available"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /fx/quotes [post]
+func (h *FXHandler) CreateQuote(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*QuoteRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	merchantID, err := utils.RequireMerchantID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	quote, err := h.service.CreateQuote(c, merchantID, request.FromCurrency, request.ToCurrency, h.config.Current().FXQuoteTTLDuration())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := QuoteResponse{Quote: *quote}
+	if request.Amount != nil {
+		response.ConvertedAmount = &quote.Convert(*request.Amount).Amount
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "FX quote created", response)
+}
+
+// ListRates lists the exchange rates
+// @Summary List FX rates
+// @Description Lists the latest rate of every currency pair with its source and time.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Success 200 {object} utils.APIResponse{data=[]Rate} "FX rates"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /admin/fx/rates [get]
+func (h *FXHandler) ListRates(c *gin.Context) {
+	rates, err := h.service.ListRates(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "FX rates", rates)
+}
+
+// UploadRates uploads exchange rates manually
+// @Summary Upload FX rates
+// @Description Replaces the rates of the uploaded currency pairs, stamped with the current time and the manual source.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param validatedBody body UploadRatesRequest true "Rates"
+// @Success 200 {object} utils.APIResponse{data=[]Rate} "Saved FX rates"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /admin/fx/rates [put]
+func (h *FXHandler) UploadRates(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*UploadRatesRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	rates := make([]Rate, len(request.Rates))
+	for i, input := range request.Rates {
+		rates[i] = Rate{FromCurrency: input.FromCurrency, ToCurrency: input.ToCurrency, Rate: input.Rate}
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Uploading %d FX rate(s)", len(rates)))
+
+	if err := h.service.SaveRates(c, rates, SourceManual); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "FX rates saved", rates)
+}
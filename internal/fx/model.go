@@ -0,0 +1,68 @@
+package fx
+
+import (
+	"math"
+	"time"
+)
+
+// Rate is the latest exchange rate of a currency pair: 1 FromCurrency is worth Rate ToCurrency
+type Rate struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	FromCurrency string    `gorm:"type:varchar(3);not null" json:"from_currency"`
+	ToCurrency   string    `gorm:"type:varchar(3);not null" json:"to_currency"`
+	Rate         float64   `gorm:"type:numeric(18,8);not null" json:"rate"`
+	Source       string    `gorm:"type:varchar(50);not null" json:"source"`
+	RateAt       time.Time `gorm:"not null" json:"rate_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Rate) TableName() string {
+	return "fx_rates"
+}
+
+// Quote locks the rate of a currency pair for a merchant until it expires. A quote converts a single
+// payment: it is used up by the first payment created with it.
+type Quote struct {
+	ID           string     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MerchantID   uint       `gorm:"not null" json:"merchant_id"`
+	FromCurrency string     `gorm:"type:varchar(3);not null" json:"from_currency"`
+	ToCurrency   string     `gorm:"type:varchar(3);not null" json:"to_currency"`
+	Rate         float64    `gorm:"type:numeric(18,8);not null" json:"rate"`
+	RateAt       time.Time  `gorm:"not null" json:"rate_at"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (Quote) TableName() string {
+	return "fx_quotes"
+}
+
+// Convert converts an amount at the rate locked by the quote
+func (q *Quote) Convert(amount float64) *Conversion {
+	conversion := newConversion(amount, q.ToCurrency, q.Rate, q.RateAt)
+	conversion.QuoteID = q.ID
+	return conversion
+}
+
+// Conversion is an amount converted to another currency and the rate it was converted at
+type Conversion struct {
+	Amount   float64
+	Currency string
+	Rate     float64
+	RateAt   time.Time
+
+	// QuoteID is the quote the amount was converted with, empty for the latest rate
+	QuoteID string
+}
+
+// newConversion converts an amount at a rate, rounded to cents
+func newConversion(amount float64, currency string, rate float64, rateAt time.Time) *Conversion {
+	return &Conversion{
+		Amount:   math.Round(amount*rate*100) / 100,
+		Currency: currency,
+		Rate:     rate,
+		RateAt:   rateAt,
+	}
+}
@@ -0,0 +1,202 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FXServiceInterface defines the methods that the FXService must implement.
+type FXServiceInterface interface {
+	ListRates(ctx context.Context) ([]Rate, error)
+	SaveRates(ctx context.Context, rates []Rate, source string) error
+	SyncRates(ctx context.Context, source RateSource) (int, error)
+	Convert(ctx context.Context, amount float64, fromCurrency, toCurrency string) (*Conversion, error)
+	CreateQuote(ctx context.Context, merchantID uint, fromCurrency, toCurrency string, ttl time.Duration) (*Quote, error)
+	FindQuote(ctx context.Context, merchantID uint, id string) (*Quote, error)
+}
+
+// FXService handles exchange rates and quotes.
+type FXService struct {
+	db         *gorm.DB
+	maxRateAge func() time.Duration
+}
+
+// NewFXService initializes a new FXService with the provided database connection and a function
+// returning the current age after which a rate is no longer used.
+func NewFXService(db *gorm.DB, maxRateAge func() time.Duration) *FXService {
+	return &FXService{db: db, maxRateAge: maxRateAge}
+}
+
+// ListRates returns the latest rate of every currency pair.
+func (s *FXService) ListRates(ctx context.Context) ([]Rate, error) {
+	var rates []Rate
+
+	if err := s.db.WithContext(ctx).Order("from_currency, to_currency").Find(&rates).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Error listing rates: %v", err))
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// SaveRates replaces the rates of the given currency pairs. Rates without a time are stamped with the current time.
+func (s *FXService) SaveRates(ctx context.Context, rates []Rate, source string) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i := range rates {
+		rates[i].ID = 0
+		rates[i].FromCurrency = strings.ToUpper(rates[i].FromCurrency)
+		rates[i].ToCurrency = strings.ToUpper(rates[i].ToCurrency)
+		rates[i].Source = source
+		if rates[i].RateAt.IsZero() {
+			rates[i].RateAt = now
+		}
+	}
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "rate_at", "updated_at"}),
+	}).Create(&rates).Error
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Failed to save rates: %v", err))
+		return err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Saved %d rate(s) from %s", len(rates), source))
+	return nil
+}
+
+// SyncRates saves the rates fetched from a rate source and returns how many were saved.
+func (s *FXService) SyncRates(ctx context.Context, source RateSource) (int, error) {
+	rates, err := source.FetchRates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.SaveRates(ctx, rates, source.Name()); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// Convert converts an amount at the latest rate of a currency pair.
+func (s *FXService) Convert(ctx context.Context, amount float64, fromCurrency, toCurrency string) (*Conversion, error) {
+	rate, rateAt, err := s.findRate(ctx, fromCurrency, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+	return newConversion(amount, toCurrency, rate, rateAt), nil
+}
+
+// CreateQuote locks the latest rate of a currency pair for a merchant for ttl.
+func (s *FXService) CreateQuote(ctx context.Context, merchantID uint, fromCurrency, toCurrency string, ttl time.Duration) (*Quote, error) {
+	fromCurrency, toCurrency = strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency)
+
+	rate, rateAt, err := s.findRate(ctx, fromCurrency, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &Quote{
+		MerchantID:   merchantID,
+		FromCurrency: fromCurrency,
+		ToCurrency:   toCurrency,
+		Rate:         rate,
+		RateAt:       rateAt,
+		ExpiresAt:    time.Now().Add(ttl),
+	}
+	if err := s.db.WithContext(ctx).Create(quote).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Failed to create quote: %v", err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Quote %s locks %s/%s at %f until %s", quote.ID, fromCurrency, toCurrency, rate, quote.ExpiresAt.Format(time.RFC3339)))
+	return quote, nil
+}
+
+// FindQuote retrieves an unexpired and unused quote of a merchant.
+func (s *FXService) FindQuote(ctx context.Context, merchantID uint, id string) (*Quote, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrQuoteNotFound
+	}
+
+	var quote Quote
+	if err := s.db.WithContext(ctx).Where("id = ? AND merchant_id = ?", id, merchantID).First(&quote).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+
+	if time.Now().After(quote.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+	if quote.UsedAt != nil {
+		return nil, ErrQuoteUsed
+	}
+	return &quote, nil
+}
+
+// UseQuote marks an unexpired quote of a merchant as used, so that no other payment is converted with it.
+// It runs in tx, the transaction that saves the payment the quote converts, so that the quote is only used
+// up by a saved payment. It fails with ErrQuoteUsed if another payment used the quote first.
+func UseQuote(ctx context.Context, tx *gorm.DB, merchantID uint, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrQuoteNotFound
+	}
+
+	now := time.Now()
+	result := tx.WithContext(ctx).Model(&Quote{}).
+		Where("id = ? AND merchant_id = ? AND used_at IS NULL AND expires_at > ?", id, merchantID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Failed to use quote %s: %v", id, result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Quote %s was already used", id))
+		return ErrQuoteUsed
+	}
+	return nil
+}
+
+// findRate returns the latest rate of a currency pair, derived from the rate of the reverse pair
+// if needed. Rates older than the maximum rate age are not used.
+func (s *FXService) findRate(ctx context.Context, fromCurrency, toCurrency string) (float64, time.Time, error) {
+	var rates []Rate
+	err := s.db.WithContext(ctx).
+		Where("(from_currency = ? AND to_currency = ?) OR (from_currency = ? AND to_currency = ?)", fromCurrency, toCurrency, toCurrency, fromCurrency).
+		Order("rate_at DESC").
+		Find(&rates).Error
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: Error retrieving rate %s/%s: %v", fromCurrency, toCurrency, err))
+		return 0, time.Time{}, err
+	}
+
+	for _, rate := range rates {
+		if s.maxRateAge != nil && time.Since(rate.RateAt) > s.maxRateAge() {
+			continue
+		}
+		if rate.FromCurrency == fromCurrency {
+			return rate.Rate, rate.RateAt, nil
+		}
+		return 1 / rate.Rate, rate.RateAt, nil
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("FXService: No recent rate for %s/%s", fromCurrency, toCurrency))
+	return 0, time.Time{}, fmt.Errorf("%w: %s/%s", ErrRateUnavailable, fromCurrency, toCurrency)
+}
+
+// Ensure FXService implements FXServiceInterface.
+var _ FXServiceInterface = (*FXService)(nil)
@@ -0,0 +1,219 @@
+package fx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+const rateQuery = `^SELECT \* FROM "fx_rates" WHERE \(from_currency = \$1 AND to_currency = \$2\) OR \(from_currency = \$3 AND to_currency = \$4\) ORDER BY rate_at DESC$`
+
+func maxRateAge() time.Duration {
+	return 24 * time.Hour
+}
+
+func TestConvert(t *testing.T) {
+	rateAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		from, to     string
+		rows         *sqlmock.Rows
+		wantAmount   float64
+		wantRate     float64
+		wantErrorIs  error
+		wantCurrency string
+	}{
+		{
+			name: "direct rate",
+			from: "USD", to: "INR",
+			rows:       sqlmock.NewRows([]string{"id", "from_currency", "to_currency", "rate", "rate_at"}).AddRow(1, "USD", "INR", 83.12, rateAt),
+			wantAmount: 8312, wantRate: 83.12, wantCurrency: "INR",
+		},
+		{
+			name: "inverse rate",
+			from: "INR", to: "USD",
+			rows:       sqlmock.NewRows([]string{"id", "from_currency", "to_currency", "rate", "rate_at"}).AddRow(1, "USD", "INR", 80.0, rateAt),
+			wantAmount: 1.25, wantRate: 0.0125, wantCurrency: "USD",
+		},
+		{
+			name: "stale rate",
+			from: "USD", to: "INR",
+			rows:        sqlmock.NewRows([]string{"id", "from_currency", "to_currency", "rate", "rate_at"}).AddRow(1, "USD", "INR", 83.12, time.Now().Add(-48*time.Hour)),
+			wantErrorIs: ErrRateUnavailable,
+		},
+		{
+			name: "no rate",
+			from: "USD", to: "JPY",
+			rows:        sqlmock.NewRows([]string{"id", "from_currency", "to_currency", "rate", "rate_at"}),
+			wantErrorIs: ErrRateUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			fxService := NewFXService(gormDB, maxRateAge)
+
+			mock.ExpectQuery(rateQuery).
+				WithArgs(tt.from, tt.to, tt.to, tt.from).
+				WillReturnRows(tt.rows)
+
+			conversion, err := fxService.Convert(context.TODO(), 100, tt.from, tt.to)
+
+			if tt.wantErrorIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrorIs)
+				assert.Nil(t, conversion)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantAmount, conversion.Amount)
+				assert.InDelta(t, tt.wantRate, conversion.Rate, 1e-9)
+				assert.Equal(t, tt.wantCurrency, conversion.Currency)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateQuote(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	fxService := NewFXService(gormDB, maxRateAge)
+	rateAt := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery(rateQuery).
+		WithArgs("USD", "AED", "AED", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from_currency", "to_currency", "rate", "rate_at"}).AddRow(1, "USD", "AED", 3.6725, rateAt))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "fx_quotes" \("merchant_id","from_currency","to_currency","rate","rate_at","expires_at","used_at","created_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8\) RETURNING "id"$`).
+		WithArgs(uint(7), "USD", "AED", 3.6725, rateAt, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("9a0f3c1e-3b0c-4f4e-8b1f-0c2b7c1d2e3f"))
+	mock.ExpectCommit()
+
+	quote, err := fxService.CreateQuote(context.TODO(), 7, "usd", "aed", 10*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "9a0f3c1e-3b0c-4f4e-8b1f-0c2b7c1d2e3f", quote.ID)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), quote.ExpiresAt, time.Minute)
+	assert.Equal(t, 367.25, quote.Convert(100).Amount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindQuote(t *testing.T) {
+	quoteID := "9a0f3c1e-3b0c-4f4e-8b1f-0c2b7c1d2e3f"
+
+	tests := []struct {
+		name        string
+		id          string
+		rows        *sqlmock.Rows
+		wantErrorIs error
+	}{
+		{
+			name: "valid quote",
+			id:   quoteID,
+			rows: sqlmock.NewRows([]string{"id", "merchant_id", "rate", "expires_at"}).AddRow(quoteID, 7, 3.6725, time.Now().Add(5*time.Minute)),
+		},
+		{
+			name:        "expired quote",
+			id:          quoteID,
+			rows:        sqlmock.NewRows([]string{"id", "merchant_id", "rate", "expires_at"}).AddRow(quoteID, 7, 3.6725, time.Now().Add(-time.Minute)),
+			wantErrorIs: ErrQuoteExpired,
+		},
+		{
+			name:        "used quote",
+			id:          quoteID,
+			rows:        sqlmock.NewRows([]string{"id", "merchant_id", "rate", "expires_at", "used_at"}).AddRow(quoteID, 7, 3.6725, time.Now().Add(5*time.Minute), time.Now()),
+			wantErrorIs: ErrQuoteUsed,
+		},
+		{
+			name:        "unknown quote",
+			id:          quoteID,
+			rows:        sqlmock.NewRows([]string{"id", "merchant_id", "rate", "expires_at"}),
+			wantErrorIs: ErrQuoteNotFound,
+		},
+		{
+			name:        "invalid quote ID",
+			id:          "not-a-uuid",
+			wantErrorIs: ErrQuoteNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			fxService := NewFXService(gormDB, maxRateAge)
+
+			if tt.rows != nil {
+				mock.ExpectQuery(`^SELECT \* FROM "fx_quotes" WHERE id = \$1 AND merchant_id = \$2 ORDER BY "fx_quotes"."id" LIMIT \$3$`).
+					WithArgs(tt.id, uint(7), 1).
+					WillReturnRows(tt.rows)
+			}
+
+			quote, err := fxService.FindQuote(context.TODO(), 7, tt.id)
+
+			if tt.wantErrorIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrorIs)
+				assert.Nil(t, quote)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, quoteID, quote.ID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseQuote(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	quoteID := "9a0f3c1e-3b0c-4f4e-8b1f-0c2b7c1d2e3f"
+
+	// Setup mock expectations: the first payment uses the quote, the second finds it used and is not saved
+	useQuery := `^UPDATE "fx_quotes" SET "used_at"=\$1 WHERE id = \$2 AND merchant_id = \$3 AND used_at IS NULL AND expires_at > \$4$`
+	mock.ExpectBegin()
+	mock.ExpectExec(useQuery).WithArgs(sqlmock.AnyArg(), quoteID, uint(7), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(useQuery).WithArgs(sqlmock.AnyArg(), quoteID, uint(7), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	useQuote := func(id string) error {
+		return gormDB.Transaction(func(tx *gorm.DB) error {
+			return UseQuote(context.TODO(), tx, 7, id)
+		})
+	}
+	assert.NoError(t, useQuote(quoteID))
+	assert.ErrorIs(t, useQuote(quoteID), ErrQuoteUsed)
+	assert.ErrorIs(t, UseQuote(context.TODO(), gormDB, 7, "not-a-uuid"), ErrQuoteNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SourceManual is the source of the rates uploaded through the admin API
+const SourceManual = "manual"
+
+// RateSource provides exchange rates, such as a market data API. Rates without a time are
+// stamped with the time they are synced at.
+type RateSource interface {
+	// Name identifies the source on the rates it provides
+	Name() string
+	FetchRates(ctx context.Context) ([]Rate, error)
+}
+
+// FileRateSource reads the rates from a JSON file, a list of objects with from_currency,
+// to_currency, rate and an optional rate_at. It stands in for a market data API.
+type FileRateSource struct {
+	path string
+}
+
+// NewFileRateSource initializes a new FileRateSource reading the file at path
+func NewFileRateSource(path string) *FileRateSource {
+	return &FileRateSource{path: path}
+}
+
+// Name implements RateSource
+func (s *FileRateSource) Name() string {
+	return "file"
+}
+
+// FetchRates implements RateSource
+func (s *FileRateSource) FetchRates(_ context.Context) ([]Rate, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file: %w", err)
+	}
+
+	var rates []Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("invalid rates file %s: %w", s.path, err)
+	}
+
+	for i, rate := range rates {
+		if len(rate.FromCurrency) != 3 || len(rate.ToCurrency) != 3 || rate.Rate <= 0 {
+			return nil, fmt.Errorf("invalid rates file %s: rate %d needs 3 letter currencies and a positive rate", s.path, i+1)
+		}
+		rates[i].FromCurrency = strings.ToUpper(rate.FromCurrency)
+		rates[i].ToCurrency = strings.ToUpper(rate.ToCurrency)
+	}
+
+	return rates, nil
+}
+
+// Ensure FileRateSource implements RateSource.
+var _ RateSource = (*FileRateSource)(nil)
@@ -0,0 +1,60 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileRateSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantRates int
+		wantErr   bool
+	}{
+		{
+			name:      "valid rates",
+			content:   `[{"from_currency": "usd", "to_currency": "inr", "rate": 83.12}, {"from_currency": "EUR", "to_currency": "USD", "rate": 1.0842, "rate_at": "2026-10-19T08:00:00Z"}]`,
+			wantRates: 2,
+		},
+		{
+			name:    "invalid currency",
+			content: `[{"from_currency": "US", "to_currency": "INR", "rate": 83.12}]`,
+			wantErr: true,
+		},
+		{
+			name:    "zero rate",
+			content: `[{"from_currency": "USD", "to_currency": "INR", "rate": 0}]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rates.json")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			rates, err := NewFileRateSource(path).FetchRates(context.TODO())
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, rates, tt.wantRates)
+			assert.Equal(t, "USD", rates[0].FromCurrency)
+			assert.Equal(t, "INR", rates[0].ToCurrency)
+			assert.True(t, rates[0].RateAt.IsZero())
+			assert.False(t, rates[1].RateAt.IsZero())
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package fx
+
+// QuoteRequest represents the request payload for locking the rate of a currency pair
+type QuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,len=3"`
+	ToCurrency   string `json:"to_currency" binding:"required,len=3,nefield=FromCurrency"`
+
+	// Amount is optionally converted at the quoted rate in the response
+	Amount *float64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// UploadRatesRequest represents the request payload for uploading rates manually
+type UploadRatesRequest struct {
+	Rates []RateInput `json:"rates" binding:"required,min=1,max=500,dive"`
+}
+
+// RateInput is the rate of a currency pair: 1 FromCurrency is worth Rate ToCurrency
+type RateInput struct {
+	FromCurrency string  `json:"from_currency" binding:"required,len=3"`
+	ToCurrency   string  `json:"to_currency" binding:"required,len=3,nefield=FromCurrency"`
+	Rate         float64 `json:"rate" binding:"required,gt=0"`
+}
+
+// QuoteResponse is a quote and the amount of the request converted at its rate
+type QuoteResponse struct {
+	Quote
+	ConvertedAmount *float64 `json:"converted_amount,omitempty"`
+}
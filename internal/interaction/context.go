@@ -0,0 +1,24 @@
+package interaction
+
+import "context"
+
+// Context keys of the payment an interaction belongs to. They are plain strings so they can be set
+// both on the gin context of a callback and on the context handed down to the provider adapters.
+const (
+	ContextKeyPaymentID  = "InteractionPaymentID"
+	ContextKeyExternalID = "InteractionExternalID"
+)
+
+// WithPaymentID returns a context recording the interactions made with it for the payment
+func WithPaymentID(ctx context.Context, paymentID string) context.Context {
+	return context.WithValue(ctx, ContextKeyPaymentID, paymentID)
+}
+
+// paymentFromContext returns the payment ID and external ID stored in the context, if any
+func paymentFromContext(ctx context.Context) (*string, string) {
+	externalID, _ := ctx.Value(ContextKeyExternalID).(string)
+	if paymentID, _ := ctx.Value(ContextKeyPaymentID).(string); paymentID != "" {
+		return &paymentID, externalID
+	}
+	return nil, externalID
+}
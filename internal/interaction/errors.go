@@ -0,0 +1,9 @@
+package interaction
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+// ErrInvalidPaymentID is returned when the interactions of a payment are requested with an ID that is not a UUID
+var ErrInvalidPaymentID = utils.NewAPIError(http.StatusBadRequest, "invalid_payment_id", "Payment ID must be a UUID")
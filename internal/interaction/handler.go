@@ -0,0 +1,47 @@
+package interaction
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InteractionHandler handles the provider interaction admin requests
+type InteractionHandler struct {
+	service InteractionServiceInterface
+}
+
+// NewInteractionHandler initializes a new InteractionHandler
+func NewInteractionHandler(db *gorm.DB) *InteractionHandler {
+	return &InteractionHandler{service: NewInteractionService(db)}
+}
+
+// ListForPayment lists the provider interactions of a payment
+// @Summary List the provider interactions of a payment
+// @Description Lists the requests sent to providers for a payment and the callbacks received for it, oldest first, with redacted headers and bodies.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path string true "Payment ID"
+// @Success 200 {object} utils.APIResponse{data=[]Interaction} "Provider interactions"
+// @Failure 400 {object} utils.APIResponse "Invalid payment ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /admin/payments/{id}/interactions [get]
+func (h *InteractionHandler) ListForPayment(c *gin.Context) {
+	paymentID := c.Param("id")
+	if _, err := uuid.Parse(paymentID); err != nil {
+		_ = c.Error(ErrInvalidPaymentID)
+		return
+	}
+
+	interactions, err := h.service.ListForPayment(c, paymentID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Provider interactions", interactions)
+}
@@ -0,0 +1,36 @@
+package interaction
+
+import "time"
+
+// Direction tells which side started an interaction
+type Direction string
+
+const (
+	// DirectionOutbound is a request of the gateway to a provider
+	DirectionOutbound Direction = "OUTBOUND"
+	// DirectionInbound is a callback of a provider to the gateway
+	DirectionInbound Direction = "INBOUND"
+)
+
+// Interaction is a request exchanged with a provider, kept for disputes. Headers and bodies are
+// redacted before they are stored.
+type Interaction struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	PaymentID      *string           `gorm:"type:uuid" json:"payment_id,omitempty"`
+	ExternalID     string            `gorm:"type:varchar(255);not null;default:''" json:"external_id,omitempty"`
+	Provider       string            `gorm:"type:varchar(50);not null;default:''" json:"provider,omitempty"`
+	Direction      Direction         `gorm:"type:varchar(10);not null" json:"direction"`
+	Method         string            `gorm:"type:varchar(10);not null" json:"method"`
+	Endpoint       string            `gorm:"type:text;not null" json:"endpoint"`
+	RequestHeaders map[string]string `gorm:"type:jsonb;serializer:json;not null" json:"request_headers"`
+	RequestBody    string            `gorm:"type:text;not null;default:''" json:"request_body,omitempty"`
+	ResponseBody   string            `gorm:"type:text;not null;default:''" json:"response_body,omitempty"`
+	StatusCode     int               `gorm:"not null;default:0" json:"status_code,omitempty"`
+	LatencyMs      int64             `gorm:"not null;default:0" json:"latency_ms"`
+	Error          string            `gorm:"type:text;not null;default:''" json:"error,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+func (Interaction) TableName() string {
+	return "provider_interactions"
+}
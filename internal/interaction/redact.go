@@ -0,0 +1,197 @@
+package interaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the value of every sensitive header, field and query parameter
+const redacted = "[REDACTED]"
+
+// maxBodySize is the number of bytes of a body stored, longer bodies are truncated
+const maxBodySize = 64 << 10
+
+// sensitiveWords mark a header, field or query parameter as sensitive when its name contains one of them
+var sensitiveWords = []string{"secret", "password", "token", "signature", "authorization", "cookie", "email", "card", "cvv", "iban", "account", "holder"}
+
+// sensitiveNames mark a field as sensitive when its name is one of them
+var sensitiveNames = map[string]bool{"name": true}
+
+// isSensitive tells whether the value of a header, field or query parameter must not be stored
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	if sensitiveNames[name] {
+		return true
+	}
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders returns the headers with the values of the sensitive ones replaced
+func RedactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if isSensitive(name) {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// RedactEndpoint returns the URL without its host, with the values of the sensitive query parameters replaced
+func RedactEndpoint(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	return u.Path + "?" + redactValues(u.Query())
+}
+
+// RedactBody returns the body with the values of the sensitive fields replaced. JSON, XML and form bodies are
+// redacted field by field; bodies in any other format are not stored, since they cannot be redacted.
+func RedactBody(contentType string, body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	var text string
+	switch {
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		var v interface{}
+		if err := json.Unmarshal(trimmed, &v); err != nil {
+			return unredactable(contentType, body)
+		}
+		redacted, _ := json.Marshal(redactJSON(v))
+		text = string(redacted)
+	case trimmed[0] == '<':
+		redacted, err := redactXML(trimmed)
+		if err != nil {
+			return unredactable(contentType, body)
+		}
+		text = redacted
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(trimmed))
+		if err != nil {
+			return unredactable(contentType, body)
+		}
+		text = redactValues(values)
+	default:
+		return unredactable(contentType, body)
+	}
+
+	if len(text) > maxBodySize {
+		text = text[:maxBodySize] + "...[truncated]"
+	}
+	return strings.ToValidUTF8(text, "")
+}
+
+// unredactable describes a body that is not stored
+func unredactable(contentType string, body []byte) string {
+	return fmt.Sprintf("[%d bytes of %q not stored]", len(body), contentType)
+}
+
+// redactJSON replaces the values of the sensitive fields of a decoded JSON value
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+	return v
+}
+
+// redactXML replaces the text of the sensitive elements of an XML document and the values of their sensitive attributes
+func redactXML(body []byte) (string, error) {
+	var out bytes.Buffer
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	encoder := xml.NewEncoder(&out)
+
+	// sensitive counts the open elements that are sensitive, their text is replaced
+	sensitive := 0
+	var stack []bool
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			isSensitiveElement := isSensitive(t.Name.Local)
+			stack = append(stack, isSensitiveElement)
+			if isSensitiveElement {
+				sensitive++
+			}
+			for i := range t.Attr {
+				if isSensitive(t.Attr[i].Name.Local) {
+					t.Attr[i].Value = redacted
+				}
+			}
+			token = t
+		case xml.EndElement:
+			if len(stack) > 0 {
+				if stack[len(stack)-1] {
+					sensitive--
+				}
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if sensitive > 0 && len(bytes.TrimSpace(t)) > 0 {
+				token = xml.CharData(redacted)
+			}
+		}
+
+		if err := encoder.EncodeToken(token); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// redactValues encodes query or form values with the sensitive ones replaced, sorted by name
+func redactValues(values url.Values) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		for _, value := range values[name] {
+			if isSensitive(name) {
+				value = redacted
+			}
+			parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,103 @@
+package interaction
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("user_id", "1")
+	header.Set("user_secret", "s3cr3t")
+	header.Set("X-AUTH-TOKEN", "pk_live_123")
+	header.Add("Accept", "application/json")
+	header.Add("Accept", "text/xml")
+
+	assert.Equal(t, map[string]string{
+		"User_id":      "1",
+		"User_secret":  redacted,
+		"X-Auth-Token": redacted,
+		"Accept":       "application/json, text/xml",
+	}, RedactHeaders(header))
+}
+
+func TestRedactEndpoint(t *testing.T) {
+	u, _ := url.Parse("http://localhost:8080/payment/callbacks/success?id=ext-1&signature=abc")
+
+	assert.Equal(t, "/payment/callbacks/success?id=ext-1&signature=%5BREDACTED%5D", RedactEndpoint(u))
+
+	u, _ = url.Parse("http://hsbc/hsbc/payment")
+	assert.Equal(t, "/hsbc/payment", RedactEndpoint(u))
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name: "json",
+			body: `{"amount":100,"customer":{"email":"jane@example.com","locale":"en-GB","name":"Jane Doe"},"reference":"order-42"}`,
+			want: `{"amount":100,"customer":{"email":"[REDACTED]","locale":"en-GB","name":"[REDACTED]"},"reference":"order-42"}`,
+		},
+		{
+			name: "json array",
+			body: `[{"token":"tok_1"}]`,
+			want: `[{"token":"[REDACTED]"}]`,
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<PaymentRequest><Amount>100</Amount><CardNumber>4111111111111111</CardNumber></PaymentRequest>",
+			want:        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<PaymentRequest><Amount>100</Amount><CardNumber>[REDACTED]</CardNumber></PaymentRequest>",
+		},
+		{
+			name: "json payout",
+			body: `{"amount":40,"beneficiary":{"bank_code":"021000021","holder_name":"Jane Doe","iban":"GB82WEST12345698765432"}}`,
+			want: `{"amount":40,"beneficiary":{"bank_code":"021000021","holder_name":"[REDACTED]","iban":"[REDACTED]"}}`,
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "status=success&password=hunter2",
+			want:        "password=%5BREDACTED%5D&status=success",
+		},
+		{
+			name:        "unknown format",
+			contentType: "text/plain",
+			body:        "secret=1",
+			want:        `[8 bytes of "text/plain" not stored]`,
+		},
+		{
+			name: "invalid json",
+			body: `{"secret":`,
+			want: `[10 bytes of "" not stored]`,
+		},
+		{
+			name: "empty",
+			body: "  ",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedactBody(tt.contentType, []byte(tt.body)))
+		})
+	}
+}
+
+func TestRedactBody_Truncated(t *testing.T) {
+	body := `{"description":"` + strings.Repeat("a", maxBodySize) + `"}`
+
+	redactedBody := RedactBody("application/json", []byte(body))
+
+	assert.Len(t, redactedBody, maxBodySize+len("...[truncated]"))
+	assert.True(t, strings.HasSuffix(redactedBody, "...[truncated]"))
+}
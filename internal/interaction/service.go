@@ -0,0 +1,72 @@
+package interaction
+
+import (
+	"context"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Recorder stores interactions with providers
+type Recorder interface {
+	Record(ctx context.Context, interaction *Interaction) error
+}
+
+// InteractionServiceInterface defines the methods that the InteractionService must implement.
+type InteractionServiceInterface interface {
+	Recorder
+	ListForPayment(ctx context.Context, paymentID string) ([]Interaction, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+// InteractionService stores the interactions with providers.
+type InteractionService struct {
+	db *gorm.DB
+}
+
+// NewInteractionService initializes a new InteractionService with the provided database connection.
+func NewInteractionService(db *gorm.DB) *InteractionService {
+	return &InteractionService{db: db}
+}
+
+// Record stores an interaction. It is not part of the transaction of the payment, so the interactions of a
+// payment that failed to be created are kept too.
+func (s *InteractionService) Record(ctx context.Context, interaction *Interaction) error {
+	if interaction.RequestHeaders == nil {
+		interaction.RequestHeaders = map[string]string{}
+	}
+	if err := s.db.WithContext(ctx).Create(interaction).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("InteractionService: Failed to record %s interaction with %s: %v", interaction.Direction, interaction.Endpoint, err))
+		return err
+	}
+	return nil
+}
+
+// ListForPayment lists the interactions of a payment in the order they happened: the requests made for it
+// and the callbacks carrying its external ID.
+func (s *InteractionService) ListForPayment(ctx context.Context, paymentID string) ([]Interaction, error) {
+	var interactions []Interaction
+	err := s.db.WithContext(ctx).
+		Where("payment_id = ? OR (external_id <> '' AND external_id IN (SELECT external_id FROM payments WHERE id = ?))", paymentID, paymentID).
+		Order("created_at, id").
+		Find(&interactions).Error
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("InteractionService: Failed to list interactions of payment %s: %v", paymentID, err))
+		return nil, err
+	}
+	return interactions, nil
+}
+
+// DeleteOlderThan deletes the interactions recorded before a point in time and returns how many were deleted
+func (s *InteractionService) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("created_at < ?", before).Delete(&Interaction{})
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("InteractionService: Failed to delete interactions older than %s: %v", before.Format(time.RFC3339), result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+var _ InteractionServiceInterface = (*InteractionService)(nil)
@@ -0,0 +1,82 @@
+package interaction
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+const paymentID = "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"
+
+func TestListForPayment(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the request sent for the payment and the callback carrying its external ID
+	sqlRows := sqlmock.NewRows([]string{"id", "payment_id", "external_id", "direction", "method", "endpoint", "request_headers", "status_code"}).
+		AddRow(1, paymentID, "", "OUTBOUND", "POST", "/hsbc/payment", `{"User_secret":"[REDACTED]"}`, 200).
+		AddRow(2, nil, "ext-1", "INBOUND", "GET", "/payment/callbacks/success/ext-1", `{}`, 302)
+	mock.ExpectQuery(`^SELECT \* FROM "provider_interactions" WHERE payment_id = \$1 OR \(external_id <> '' AND external_id IN \(SELECT external_id FROM payments WHERE id = \$2\)\) ORDER BY created_at, id$`).
+		WithArgs(paymentID, paymentID).
+		WillReturnRows(sqlRows)
+
+	service := NewInteractionService(gormDB)
+
+	// Call the method under test
+	interactions, err := service.ListForPayment(context.TODO(), paymentID)
+
+	assert.NoError(t, err)
+	assert.Len(t, interactions, 2)
+	assert.Equal(t, paymentID, *interactions[0].PaymentID)
+	assert.Equal(t, map[string]string{"User_secret": "[REDACTED]"}, interactions[0].RequestHeaders)
+	assert.Nil(t, interactions[1].PaymentID)
+	assert.Equal(t, DirectionInbound, interactions[1].Direction)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteOlderThan(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Setup mock expectations for the deletion
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "provider_interactions" WHERE created_at < \$1$`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	service := NewInteractionService(gormDB)
+
+	// Call the method under test
+	deleted, err := service.DeleteOlderThan(context.TODO(), before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
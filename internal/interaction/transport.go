@@ -0,0 +1,68 @@
+package interaction
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Transport records every request made to a provider and its response
+type Transport struct {
+	base     http.RoundTripper
+	provider string
+	recorder Recorder
+}
+
+// NewTransport initializes a new Transport recording the requests to the named provider. The requests
+// are sent with the default transport.
+func NewTransport(providerName string, recorder Recorder) *Transport {
+	return &Transport{base: http.DefaultTransport, provider: providerName, recorder: recorder}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	paymentID, externalID := paymentFromContext(req.Context())
+	interaction := &Interaction{
+		PaymentID:      paymentID,
+		ExternalID:     externalID,
+		Provider:       t.provider,
+		Direction:      DirectionOutbound,
+		Method:         req.Method,
+		Endpoint:       RedactEndpoint(req.URL),
+		RequestHeaders: RedactHeaders(req.Header),
+	}
+
+	// The body is read from a copy, a RoundTripper must not consume the request
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			requestBody, _ := io.ReadAll(body)
+			_ = body.Close()
+			interaction.RequestBody = RedactBody(req.Header.Get("Content-Type"), requestBody)
+		}
+	}
+
+	startTime := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		var responseBody []byte
+		responseBody, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+		interaction.StatusCode = resp.StatusCode
+		interaction.ResponseBody = RedactBody(resp.Header.Get("Content-Type"), responseBody)
+	}
+	interaction.LatencyMs = time.Since(startTime).Milliseconds()
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+
+	// Recording must not fail the payment, and must outlive a request that timed out. Failures are logged by the recorder.
+	_ = t.recorder.Record(context.WithoutCancel(req.Context()), interaction)
+
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+package interaction
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecorder keeps the recorded interactions in memory
+type memoryRecorder struct {
+	interactions []*Interaction
+}
+
+func (r *memoryRecorder) Record(_ context.Context, interaction *Interaction) error {
+	r.interactions = append(r.interactions, interaction)
+	return nil
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"amount":100,"customer":{"email":"jane@example.com"}}`, string(body), "the provider gets the body unredacted")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"url":"http://hsbc/pay","external_id":"ext-1"}`))
+	}))
+	defer server.Close()
+
+	recorder := &memoryRecorder{}
+	client := &http.Client{Transport: NewTransport("HSBC", recorder)}
+
+	req, err := http.NewRequestWithContext(WithPaymentID(context.Background(), "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"), http.MethodPost, server.URL+"/hsbc/payment",
+		bytes.NewBufferString(`{"amount":100,"customer":{"email":"jane@example.com"}}`))
+	require.NoError(t, err)
+	req.Header.Set("user_secret", "s3cr3t")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"url":"http://hsbc/pay","external_id":"ext-1"}`, string(body), "the adapter still reads the response")
+
+	require.Len(t, recorder.interactions, 1)
+	recorded := recorder.interactions[0]
+	assert.Equal(t, "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21", *recorded.PaymentID)
+	assert.Equal(t, "HSBC", recorded.Provider)
+	assert.Equal(t, DirectionOutbound, recorded.Direction)
+	assert.Equal(t, http.MethodPost, recorded.Method)
+	assert.Equal(t, "/hsbc/payment", recorded.Endpoint)
+	assert.Equal(t, redacted, recorded.RequestHeaders["User_secret"])
+	assert.Equal(t, `{"amount":100,"customer":{"email":"[REDACTED]"}}`, recorded.RequestBody)
+	assert.Equal(t, `{"external_id":"ext-1","url":"http://hsbc/pay"}`, recorded.ResponseBody)
+	assert.Equal(t, http.StatusOK, recorded.StatusCode)
+	assert.Empty(t, recorded.Error)
+}
+
+func TestTransport_RoundTrip_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	recorder := &memoryRecorder{}
+	client := &http.Client{Transport: NewTransport("ADCB", recorder)}
+
+	_, err := client.Post(server.URL+"/adcb/payment", "application/xml", bytes.NewBufferString("<PaymentRequest/>"))
+
+	assert.Error(t, err)
+	require.Len(t, recorder.interactions, 1)
+	assert.Nil(t, recorder.interactions[0].PaymentID)
+	assert.Equal(t, 0, recorder.interactions[0].StatusCode)
+	assert.NotEmpty(t, recorder.interactions[0].Error)
+}
@@ -0,0 +1,14 @@
+package ledger
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrInvalidMerchantID is returned when the ledger of a merchant is requested with an ID that is not a number
+	ErrInvalidMerchantID = utils.NewAPIError(http.StatusBadRequest, "invalid_merchant_id", "Merchant ID must be a number")
+
+	// ErrDuplicateEntry is returned when recording an entry of a type the dispute already has an entry of
+	ErrDuplicateEntry = utils.NewAPIError(http.StatusConflict, "duplicate_ledger_entry", "The dispute already has a ledger entry of this type")
+)
@@ -0,0 +1,47 @@
+package ledger
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// LedgerHandler handles the ledger admin requests
+type LedgerHandler struct {
+	service LedgerServiceInterface
+}
+
+// NewLedgerHandler initializes a new LedgerHandler
+func NewLedgerHandler(db *gorm.DB) *LedgerHandler {
+	return &LedgerHandler{service: NewLedgerService(db)}
+}
+
+// List lists the ledger entries of a merchant
+// @Summary List the ledger entries of a merchant
+// @Description Lists the movements of the balance of a merchant, newest first, such as the chargebacks of the disputes it lost. Debits are negative.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path int true "Merchant ID"
+// @Success 200 {object} utils.APIResponse{data=[]Entry} "Ledger entries"
+// @Failure 400 {object} utils.APIResponse "Invalid merchant ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /admin/merchants/{id}/ledger [get]
+func (h *LedgerHandler) List(c *gin.Context) {
+	merchantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrInvalidMerchantID)
+		return
+	}
+
+	entries, err := h.service.List(c, uint(merchantID))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Ledger entries", entries)
+}
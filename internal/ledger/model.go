@@ -0,0 +1,29 @@
+package ledger
+
+import "time"
+
+// EntryType is what moved the balance of a merchant
+type EntryType string
+
+const (
+	// EntryTypeChargeback debits the amount of a dispute the merchant lost
+	EntryTypeChargeback EntryType = "CHARGEBACK"
+)
+
+// Entry is a movement of the balance of a merchant in a currency, negative for debits. Entries are
+// never updated nor deleted: a movement is undone by another entry.
+type Entry struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	MerchantID   uint      `gorm:"not null" json:"merchant_id"`
+	Type         EntryType `gorm:"type:varchar(20);not null" json:"type"`
+	Amount       float64   `gorm:"type:numeric(12,2);not null" json:"amount"`
+	CurrencyCode string    `gorm:"type:varchar(3);not null" json:"currency_code"`
+	PaymentID    *string   `gorm:"type:uuid" json:"payment_id,omitempty"`
+	DisputeID    *string   `gorm:"type:uuid" json:"dispute_id,omitempty"`
+	Description  string    `gorm:"type:text;not null;default:''" json:"description,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Entry) TableName() string {
+	return "ledger_entries"
+}
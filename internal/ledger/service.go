@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	// uniqueViolation is the Postgres error code of a violated unique index
+	uniqueViolation = "23505"
+
+	// disputeEntryIndex is the unique index of the ledger on the dispute and the type of the entry
+	disputeEntryIndex = "idx_ledger_entries_dispute_id"
+)
+
+// LedgerServiceInterface defines the methods that the LedgerService must implement.
+type LedgerServiceInterface interface {
+	List(ctx context.Context, merchantID uint) ([]Entry, error)
+}
+
+// LedgerService reads the balance movements of merchants. Entries are recorded with Record, in the
+// transaction of the change that caused them.
+type LedgerService struct {
+	db *gorm.DB
+}
+
+// NewLedgerService initializes a new LedgerService
+func NewLedgerService(db *gorm.DB) *LedgerService {
+	return &LedgerService{db: db}
+}
+
+var _ LedgerServiceInterface = (*LedgerService)(nil)
+
+// Record adds an entry to the ledger within tx, so that it is only kept if the change that caused it is.
+// A dispute has at most one entry of each type, a second one is refused with ErrDuplicateEntry.
+func Record(ctx context.Context, tx *gorm.DB, entry *Entry) error {
+	if err := tx.Create(entry).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("Ledger: Failed to record %s of merchant %d: %v", entry.Type, entry.MerchantID, err))
+
+		var pgErr *pgconn.PgError
+		if entry.DisputeID != nil && (errors.Is(err, gorm.ErrDuplicatedKey) ||
+			(errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == disputeEntryIndex)) {
+			return fmt.Errorf("%w: %s of dispute %s", ErrDuplicateEntry, entry.Type, *entry.DisputeID)
+		}
+		return err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("Ledger: Recorded %s of %.2f %s for merchant %d", entry.Type, entry.Amount, entry.CurrencyCode, entry.MerchantID))
+	return nil
+}
+
+// List lists the ledger entries of a merchant, newest first
+func (s *LedgerService) List(ctx context.Context, merchantID uint) ([]Entry, error) {
+	var entries []Entry
+	if err := s.db.WithContext(ctx).Where("merchant_id = ?", merchantID).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("LedgerService: Failed to list entries of merchant %d: %v", merchantID, err))
+		return nil, err
+	}
+	return entries, nil
+}
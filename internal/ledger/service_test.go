@@ -0,0 +1,117 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+const (
+	paymentID = "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"
+	disputeID = "3f6b9c2d-7e1a-4b8c-9d5e-0a2f4c6e8b1d"
+)
+
+const insertEntry = `^INSERT INTO "ledger_entries" \("merchant_id","type","amount","currency_code","payment_id","dispute_id","description","created_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8\) RETURNING "id"$`
+
+// chargeback returns the entry of the lost dispute of the tests
+func chargeback(createdAt time.Time) *Entry {
+	payment, dispute := paymentID, disputeID
+	return &Entry{
+		MerchantID:   1,
+		Type:         EntryTypeChargeback,
+		Amount:       -30,
+		CurrencyCode: "USD",
+		PaymentID:    &payment,
+		DisputeID:    &dispute,
+		Description:  "Chargeback of lost dispute " + disputeID,
+		CreatedAt:    createdAt,
+	}
+}
+
+func TestRecord(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The entry is inserted in the transaction of the caller
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertEntry).
+		WithArgs(1, "CHARGEBACK", float64(-30), "USD", paymentID, disputeID, "Chargeback of lost dispute "+disputeID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectCommit()
+
+	entry := chargeback(now)
+	err := gormDB.Transaction(func(tx *gorm.DB) error {
+		return Record(context.TODO(), tx, entry)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), entry.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecord_DuplicateDisputeEntry(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The unique index on the dispute and the type refuses a second chargeback, and the transaction rolls back
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertEntry).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_ledger_entries_dispute_id"})
+	mock.ExpectRollback()
+
+	err := gormDB.Transaction(func(tx *gorm.DB) error {
+		return Record(context.TODO(), tx, chargeback(time.Now()))
+	})
+
+	assert.ErrorIs(t, err, ErrDuplicateEntry)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestList(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Entries of the merchant only, newest first
+	mock.ExpectQuery(`^SELECT \* FROM "ledger_entries" WHERE merchant_id = \$1 ORDER BY created_at DESC, id DESC$`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "type", "amount", "currency_code", "dispute_id"}).
+			AddRow(8, 1, "CHARGEBACK", -20, "EUR", disputeID).
+			AddRow(7, 1, "CHARGEBACK", -30, "USD", disputeID))
+
+	service := NewLedgerService(gormDB)
+
+	entries, err := service.List(context.TODO(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint(8), entries[0].ID)
+	assert.Equal(t, float64(-30), entries[1].Amount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,20 @@
+package merchant
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, malformed, expired or revoked API keys
+	ErrInvalidAPIKey = utils.NewAPIError(http.StatusUnauthorized, utils.ErrCodeUnauthorized, "Unauthorized")
+
+	// ErrMerchantNotFound is returned when no merchant matches the lookup
+	ErrMerchantNotFound = utils.NewAPIError(http.StatusNotFound, "merchant_not_found", "Merchant not found")
+
+	// ErrUnknownScope is returned when an API key is requested with a scope that does not exist
+	ErrUnknownScope = utils.NewAPIError(http.StatusBadRequest, "unknown_scope", "Unknown API key scope")
+
+	// ErrAPIKeyNotFound is returned when no API key matches the lookup
+	ErrAPIKeyNotFound = utils.NewAPIError(http.StatusNotFound, "api_key_not_found", "API key not found")
+)
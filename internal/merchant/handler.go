@@ -0,0 +1,145 @@
+package merchant
+
+import (
+	"fmt"
+	"net/http"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MerchantHandler handles the merchant API key admin requests
+type MerchantHandler struct {
+	service MerchantServiceInterface
+}
+
+// NewMerchantHandler initializes a new MerchantHandler
+func NewMerchantHandler(db *gorm.DB) *MerchantHandler {
+	return &MerchantHandler{service: NewMerchantService(db)}
+}
+
+// CreateAPIKey issues a new API key for a merchant
+// @Summary Create a merchant API key
+// @Description Issues a new API key with the given scopes. The raw key, and the signing secret of hmac keys, are only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path int true "Merchant ID"
+// @Param validatedBody body CreateAPIKeyRequest true "API key details"
+// @Success 201 {object} utils.APIResponse "Created API key"
+// @Failure 400 {object} utils.APIResponse "Invalid request or unknown scope"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Merchant not found"
+// @Router /admin/merchants/{id}/api-keys [post]
+func (h *MerchantHandler) CreateAPIKey(c *gin.Context) {
+	merchantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrMerchantNotFound)
+		return
+	}
+
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CreateAPIKeyRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	scopes, err := ParseScopes(request.Scopes)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	rawKey, apiKey, err := h.service.CreateAPIKey(c, uint(merchantID), request.Name, scopes, AuthMode(request.AuthMode), request.ExpiresAt)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Created API key %d for merchant %d", apiKey.ID, merchantID))
+
+	response := gin.H{"key": rawKey, "api_key": apiKey}
+	if apiKey.AuthMode == AuthModeHMAC {
+		response["signing_secret"] = apiKey.SigningSecret
+	}
+
+	// Not sent with utils.SuccessResponse, which would write the secrets to the logs
+	c.JSON(http.StatusCreated, utils.APIResponse{
+		Status:  "success",
+		Message: "API key created",
+		Data:    response,
+	})
+}
+
+// RevokeAPIKey revokes a merchant API key
+// @Summary Revoke a merchant API key
+// @Description Revokes an API key. Requests made with a revoked key are rejected.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path int true "Merchant ID"
+// @Param key_id path int true "API key ID"
+// @Success 200 {object} utils.APIResponse "API key revoked"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "API key not found"
+// @Router /admin/merchants/{id}/api-keys/{key_id} [delete]
+func (h *MerchantHandler) RevokeAPIKey(c *gin.Context) {
+	merchantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrAPIKeyNotFound)
+		return
+	}
+	keyID, err := strconv.ParseUint(c.Param("key_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrAPIKeyNotFound)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(c, uint(merchantID), uint(keyID)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked", nil)
+}
+
+// UpdateRedirectDomains replaces the redirect domain allowlist of a merchant
+// @Summary Update the redirect domains of a merchant
+// @Description Replaces the domains, including their subdomains, that the success, failure and cancel URLs of the merchant's payments may point to.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path int true "Merchant ID"
+// @Param validatedBody body UpdateRedirectDomainsRequest true "Allowed domains"
+// @Success 200 {object} utils.APIResponse{data=Merchant} "Updated merchant"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Merchant not found"
+// @Router /admin/merchants/{id}/redirect-domains [put]
+func (h *MerchantHandler) UpdateRedirectDomains(c *gin.Context) {
+	merchantID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrMerchantNotFound)
+		return
+	}
+
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*UpdateRedirectDomainsRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	merchant, err := h.service.UpdateRedirectDomains(c, uint(merchantID), request.Domains)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Redirect domains updated", merchant)
+}
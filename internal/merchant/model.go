@@ -0,0 +1,52 @@
+package merchant
+
+import (
+	"time"
+)
+
+// Merchant represents a client of the gateway that creates payments through the API.
+type Merchant struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"unique;not null" json:"name"`
+	// AllowedRedirectDomains is the space separated list of domains payments may redirect to
+	AllowedRedirectDomains string    `gorm:"type:text;not null;default:''" json:"allowed_redirect_domains"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+func (Merchant) TableName() string {
+	return "merchants"
+}
+
+// APIKey represents an API key issued to a merchant. Only the hash of the key is stored.
+type APIKey struct {
+	ID         uint     `gorm:"primaryKey" json:"id"`
+	MerchantID uint     `gorm:"not null" json:"merchant_id"`
+	Name       string   `gorm:"type:varchar(255);not null" json:"name"`
+	KeyPrefix  string   `gorm:"type:varchar(32);unique;not null" json:"key_prefix"`
+	KeyHash    string   `gorm:"type:varchar(64);not null" json:"-"`
+	Scopes     string   `gorm:"type:varchar(255);not null" json:"scopes"`
+	AuthMode   AuthMode `gorm:"type:varchar(16);not null;default:token" json:"auth_mode"`
+	// SigningSecret is only set for keys in hmac mode. Unlike the key itself it must be kept
+	// in clear text because the gateway needs it to recompute request signatures.
+	SigningSecret string     `gorm:"type:varchar(128)" json:"-"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	Merchant Merchant `gorm:"foreignKey:MerchantID" json:"-"`
+}
+
+func (APIKey) TableName() string {
+	return "merchant_api_keys"
+}
+
+// IsActive reports whether the key is neither revoked nor expired at the given time.
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
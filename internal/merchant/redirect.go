@@ -0,0 +1,57 @@
+package merchant
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// RedirectDomainList returns the domains the merchant may redirect its customers to
+func (m *Merchant) RedirectDomainList() []string {
+	return strings.Fields(m.AllowedRedirectDomains)
+}
+
+// AllowsRedirect reports whether a URL points to one of the merchant's allowed domains or one
+// of their subdomains. Only https URLs are allowed, except for localhost during development.
+func (m *Merchant) AllowsRedirect(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(host)) {
+		return false
+	}
+
+	for _, domain := range m.RedirectDomainList() {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomains lower-cases and deduplicates redirect domains for storage as a space separated list
+func normalizeDomains(domains []string) string {
+	seen := make(map[string]bool, len(domains))
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		normalized = append(normalized, domain)
+	}
+	return strings.Join(normalized, " ")
+}
+
+// isLoopback reports whether a host name refers to the local machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
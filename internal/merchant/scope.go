@@ -0,0 +1,65 @@
+package merchant
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope is a permission granted to an API key
+type Scope string
+
+const (
+	ScopePaymentsDeposit    Scope = "payments:deposit"
+	ScopePaymentsWithdrawal Scope = "payments:withdrawal"
+	ScopePaymentsRead       Scope = "payments:read"
+)
+
+// knownScopes lists every scope that can be granted to an API key. The admin routes are not granted by
+// a scope, they require an operator key.
+var knownScopes = map[Scope]bool{
+	ScopePaymentsDeposit:    true,
+	ScopePaymentsWithdrawal: true,
+	ScopePaymentsRead:       true,
+}
+
+// ParseScopes parses and validates a list of scope names
+func ParseScopes(names []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		scope := Scope(strings.TrimSpace(name))
+		if !knownScopes[scope] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, name)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// joinScopes encodes scopes for storage as a space separated list
+func joinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, " ")
+}
+
+// ScopeList returns the scopes granted to the key
+func (k *APIKey) ScopeList() []Scope {
+	fields := strings.Fields(k.Scopes)
+	scopes := make([]Scope, len(fields))
+	for i, field := range fields {
+		scopes[i] = Scope(field)
+	}
+	return scopes
+}
+
+// HasScope reports whether a set of granted scopes allows the required scope
+func HasScope(granted []Scope, required Scope) bool {
+	for _, scope := range granted {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MerchantServiceInterface defines the methods that the MerchantService must implement.
+type MerchantServiceInterface interface {
+	Authenticate(ctx context.Context, rawKey string) (*APIKey, error)
+	CreateAPIKey(ctx context.Context, merchantID uint, name string, scopes []Scope, authMode AuthMode, expiresAt *time.Time) (string, *APIKey, error)
+	RevokeAPIKey(ctx context.Context, merchantID, keyID uint) error
+	FindOrCreateMerchant(ctx context.Context, name string) (*Merchant, error)
+	FindMerchantByID(ctx context.Context, id uint) (*Merchant, error)
+	UpdateRedirectDomains(ctx context.Context, merchantID uint, domains []string) (*Merchant, error)
+}
+
+// MerchantService handles operations related to merchants and their API keys.
+type MerchantService struct {
+	db *gorm.DB
+}
+
+// NewMerchantService initializes a new MerchantService with the provided database connection.
+func NewMerchantService(db *gorm.DB) *MerchantService {
+	return &MerchantService{db: db}
+}
+
+// Authenticate resolves a raw API key to an active APIKey. Unknown, expired and revoked keys
+// all return ErrInvalidAPIKey so callers cannot tell them apart.
+func (s *MerchantService) Authenticate(ctx context.Context, rawKey string) (*APIKey, error) {
+	keyPrefix, ok := parseKeyPrefix(rawKey)
+	if !ok {
+		utils.LogWithRequestID(ctx, "MerchantService: Malformed API key")
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey APIKey
+	if err := s.db.Where("key_prefix = ?", keyPrefix).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: No API key found with prefix %s", keyPrefix))
+			return nil, ErrInvalidAPIKey
+		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Error finding API key: %v", err))
+		return nil, err
+	}
+
+	if !verifyKey(rawKey, apiKey.KeyHash) {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: API key hash mismatch for prefix %s", keyPrefix))
+		return nil, ErrInvalidAPIKey
+	}
+
+	if !apiKey.IsActive(time.Now()) {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: API key %d is expired or revoked", apiKey.ID))
+		return nil, ErrInvalidAPIKey
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Authenticated merchant %d with API key %d", apiKey.MerchantID, apiKey.ID))
+	return &apiKey, nil
+}
+
+// CreateAPIKey issues a new API key for a merchant. The raw key is returned only once and is never stored.
+// Keys in hmac mode also get a signing secret, available on the returned APIKey.
+func (s *MerchantService) CreateAPIKey(ctx context.Context, merchantID uint, name string, scopes []Scope, authMode AuthMode, expiresAt *time.Time) (string, *APIKey, error) {
+	var merchant Merchant
+	if err := s.db.First(&merchant, merchantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrMerchantNotFound
+		}
+		return "", nil, err
+	}
+
+	rawKey, keyPrefix, err := generateKey()
+	if err != nil {
+		utils.LogWithRequestID(ctx, "MerchantService: Failed to generate API key")
+		return "", nil, err
+	}
+
+	apiKey := &APIKey{
+		MerchantID: merchant.ID,
+		Name:       name,
+		KeyPrefix:  keyPrefix,
+		KeyHash:    hashKey(rawKey),
+		Scopes:     joinScopes(scopes),
+		AuthMode:   AuthModeToken,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if authMode == AuthModeHMAC {
+		signingSecret, err := generateSigningSecret()
+		if err != nil {
+			utils.LogWithRequestID(ctx, "MerchantService: Failed to generate signing secret")
+			return "", nil, err
+		}
+		apiKey.AuthMode = AuthModeHMAC
+		apiKey.SigningSecret = signingSecret
+	}
+	if err := s.db.Create(apiKey).Error; err != nil {
+		utils.LogWithRequestID(ctx, "MerchantService: Failed to save API key")
+		return "", nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Created API key %d for merchant %d", apiKey.ID, merchant.ID))
+	return rawKey, apiKey, nil
+}
+
+// RevokeAPIKey revokes one of the merchant's API keys. Revoking an already revoked key is a no-op.
+func (s *MerchantService) RevokeAPIKey(ctx context.Context, merchantID, keyID uint) error {
+	result := s.db.Model(&APIKey{}).
+		Where("id = ? AND merchant_id = ? AND revoked_at IS NULL", keyID, merchantID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Failed to revoke API key %d: %v", keyID, result.Error))
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := s.db.Model(&APIKey{}).Where("id = ? AND merchant_id = ?", keyID, merchantID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrAPIKeyNotFound
+		}
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Revoked API key %d for merchant %d", keyID, merchantID))
+	return nil
+}
+
+// FindOrCreateMerchant returns the merchant with the given name, creating it if it does not exist.
+func (s *MerchantService) FindOrCreateMerchant(ctx context.Context, name string) (*Merchant, error) {
+	merchant := Merchant{Name: name}
+	if err := s.db.Where(Merchant{Name: name}).FirstOrCreate(&merchant).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Failed to find or create merchant %s: %v", name, err))
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// FindMerchantByID returns a merchant by its ID
+func (s *MerchantService) FindMerchantByID(ctx context.Context, id uint) (*Merchant, error) {
+	var merchant Merchant
+	if err := s.db.First(&merchant, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Failed to find merchant %d: %v", id, err))
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// UpdateRedirectDomains replaces the domains the merchant's payments may redirect customers to.
+func (s *MerchantService) UpdateRedirectDomains(ctx context.Context, merchantID uint, domains []string) (*Merchant, error) {
+	merchant, err := s.FindMerchantByID(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant.AllowedRedirectDomains = normalizeDomains(domains)
+	if err := s.db.Model(merchant).Update("allowed_redirect_domains", merchant.AllowedRedirectDomains).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Failed to update redirect domains of merchant %d: %v", merchantID, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("MerchantService: Updated redirect domains of merchant %d", merchantID))
+	return merchant, nil
+}
+
+// Ensure MerchantService implements MerchantServiceInterface.
+var _ MerchantServiceInterface = (*MerchantService)(nil)
@@ -0,0 +1,175 @@
+package merchant
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+const apiKeyQuery = `^SELECT \* FROM "merchant_api_keys" WHERE key_prefix = \$1 ORDER BY "merchant_api_keys"."id" LIMIT \$2$`
+
+// apiKeyRows builds a merchant_api_keys row for the given raw key
+func apiKeyRows(rawKey string, expiresAt, revokedAt *time.Time) *sqlmock.Rows {
+	keyPrefix, _ := parseKeyPrefix(rawKey)
+	return sqlmock.NewRows([]string{"id", "merchant_id", "key_prefix", "key_hash", "expires_at", "revoked_at"}).
+		AddRow(7, 3, keyPrefix, hashKey(rawKey), expiresAt, revokedAt)
+}
+
+func TestAuthenticate_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	rawKey, keyPrefix, err := generateKey()
+	assert.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(apiKeyQuery).
+		WithArgs(keyPrefix, 1).
+		WillReturnRows(apiKeyRows(rawKey, &expiresAt, nil))
+
+	// Call the service method
+	apiKey, err := NewMerchantService(gormDB).Authenticate(context.TODO(), rawKey)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), apiKey.MerchantID)
+	assert.Equal(t, uint(7), apiKey.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticate_WrongSecret(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	rawKey, keyPrefix, err := generateKey()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(apiKeyQuery).
+		WithArgs(keyPrefix, 1).
+		WillReturnRows(apiKeyRows(rawKey, nil, nil))
+
+	// Same prefix, different secret
+	apiKey, err := NewMerchantService(gormDB).Authenticate(context.TODO(), fmt.Sprintf("%s_%s_%s", keyScheme, keyPrefix, "forged"))
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.Nil(t, apiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticate_Revoked(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	rawKey, keyPrefix, err := generateKey()
+	assert.NoError(t, err)
+
+	revokedAt := time.Now().Add(-time.Minute)
+	mock.ExpectQuery(apiKeyQuery).
+		WithArgs(keyPrefix, 1).
+		WillReturnRows(apiKeyRows(rawKey, nil, &revokedAt))
+
+	// Call the service method
+	apiKey, err := NewMerchantService(gormDB).Authenticate(context.TODO(), rawKey)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.Nil(t, apiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticate_Expired(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	rawKey, keyPrefix, err := generateKey()
+	assert.NoError(t, err)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	mock.ExpectQuery(apiKeyQuery).
+		WithArgs(keyPrefix, 1).
+		WillReturnRows(apiKeyRows(rawKey, &expiresAt, nil))
+
+	// Call the service method
+	apiKey, err := NewMerchantService(gormDB).Authenticate(context.TODO(), rawKey)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.Nil(t, apiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticate_Malformed(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// A malformed key never reaches the database
+	apiKey, err := NewMerchantService(gormDB).Authenticate(context.TODO(), "not-a-key")
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.Nil(t, apiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMerchant_AllowsRedirect(t *testing.T) {
+	merchant := &Merchant{AllowedRedirectDomains: normalizeDomains([]string{"Shop.example.com", "localhost", "shop.example.com"})}
+	assert.Equal(t, "shop.example.com localhost", merchant.AllowedRedirectDomains)
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://shop.example.com/done", true},
+		{"https://eu.shop.example.com/done?order=1", true},
+		{"http://localhost:3000/done", true},
+		{"http://shop.example.com/done", false},
+		{"https://evilshop.example.com/done", false},
+		{"https://shop.example.com.evil.com/done", false},
+		{"https://user@shop.example.com/done", false},
+		{"javascript:alert(1)", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, merchant.AllowsRedirect(test.url), test.url)
+	}
+}
+
+func TestUpdateRedirectDomains_MerchantNotFound(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectQuery(`^SELECT \* FROM "merchants" WHERE "merchants"."id" = \$1 ORDER BY "merchants"."id" LIMIT \$2$`).
+		WithArgs(9, 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	merchant, err := NewMerchantService(gormDB).UpdateRedirectDomains(context.TODO(), 9, []string{"shop.example.com"})
+
+	assert.ErrorIs(t, err, ErrMerchantNotFound)
+	assert.Nil(t, merchant)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
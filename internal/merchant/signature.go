@@ -0,0 +1,59 @@
+package merchant
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// AuthMode is how requests made with an API key are authenticated
+type AuthMode string
+
+const (
+	// AuthModeToken keys authenticate with the X-AUTH-TOKEN header alone
+	AuthModeToken AuthMode = "token"
+
+	// AuthModeHMAC keys must additionally sign payment creation requests with their signing secret
+	AuthModeHMAC AuthMode = "hmac"
+)
+
+const signingSecretBytes = 32
+
+// generateSigningSecret creates a new random secret for HMAC request signing
+func generateSigningSecret() (string, error) {
+	secret := make([]byte, signingSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// StringToSign builds the canonical string a client signs: the method, the path including the
+// query string, the unix timestamp, the nonce and the hex encoded SHA-256 hash of the body,
+// separated by newlines.
+func StringToSign(method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{strings.ToUpper(method), path, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the string to sign
+func Sign(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a hex encoded signature in constant time
+func VerifySignature(secret, stringToSign, signature string) bool {
+	expected, err := hex.DecodeString(Sign(secret, stringToSign))
+	if err != nil {
+		return false
+	}
+	provided, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, provided)
+}
@@ -0,0 +1,16 @@
+package merchant
+
+import "time"
+
+// CreateAPIKeyRequest represents the request payload for issuing a merchant API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	AuthMode  string     `json:"auth_mode" binding:"omitempty,oneof=token hmac"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// UpdateRedirectDomainsRequest represents the request payload for replacing a merchant's redirect domain allowlist
+type UpdateRedirectDomainsRequest struct {
+	Domains []string `json:"domains" binding:"required,dive,hostname"`
+}
@@ -2,28 +2,65 @@ package middleware
 
 import (
 	"net/http"
-	config "payment-gateway-service/config"
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/operator"
 	"payment-gateway-service/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware is the middleware function for authentication
-func AuthMiddleware() gin.HandlerFunc {
-	cfg := config.LoadConfig() // Load configuration here
+// contextKeyAPIKey stores the authenticated *merchant.APIKey for the other middlewares
+const contextKeyAPIKey = "APIKey"
 
+// AuthMiddleware is the middleware function for authentication. It resolves the X-AUTH-TOKEN
+// header to a merchant API key and stores the merchant in the context.
+func AuthMiddleware(merchantSvc merchant.MerchantServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check for the X-AUTH-TOKEN header
 		authTokenHeader := c.GetHeader("X-AUTH-TOKEN")
+		if authTokenHeader == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, utils.ErrCodeUnauthorized, "Unauthorized", nil)
+			return
+		}
 
-		// Validate the header value against the configured token
-		if authTokenHeader != cfg.AuthToken {
-			// If invalid, respond with 401 Unauthorized
-			utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", nil)
+		// Validate the header value against the merchant API keys
+		apiKey, err := merchantSvc.Authenticate(c, authTokenHeader)
+		if err != nil {
+			// Invalid keys map to 401 Unauthorized in the error middleware
+			_ = c.Error(err)
+			c.Abort()
 			return
 		}
 
+		// Attach the authenticated merchant to the context
+		c.Set(utils.ContextKeyMerchantID, apiKey.MerchantID)
+		c.Set(utils.ContextKeyAPIKeyID, apiKey.ID)
+		c.Set(utils.ContextKeyScopes, apiKey.ScopeList())
+		c.Set(contextKeyAPIKey, apiKey)
+
 		// If valid, proceed with the request
 		c.Next()
 	}
 }
+
+// OperatorAuthMiddleware authenticates the admin routes. It resolves the X-OPERATOR-TOKEN header to an
+// operator key, which belongs to no merchant, so merchant API keys are never accepted by those routes.
+func OperatorAuthMiddleware(operatorSvc operator.OperatorServiceInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operatorTokenHeader := c.GetHeader("X-OPERATOR-TOKEN")
+		if operatorTokenHeader == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, utils.ErrCodeUnauthorized, "Unauthorized", nil)
+			return
+		}
+
+		operatorKey, err := operatorSvc.Authenticate(c, operatorTokenHeader)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Set(utils.ContextKeyOperatorKeyID, operatorKey.ID)
+		c.Next()
+	}
+}
@@ -1,7 +1,15 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"payment-gateway-service/config"
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,3 +20,68 @@ func CallbacMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// NotificationSecrets looks up the secret the provider of a payment signs its notifications with
+type NotificationSecrets interface {
+	// NotificationSecret returns the secret of the provider of the payment with the external ID, empty when none is configured
+	NotificationSecret(ctx context.Context, externalID string) (string, error)
+}
+
+// NotificationSignatureMiddleware verifies the HMAC signature of the notifications providers send about the
+// payment with the external ID in the path, made with the secret of the provider of the payment. Providers sign
+// the request the way API keys in hmac mode do, with an empty nonce: notifications can be repeated safely, so
+// only the timestamp limits replays. Notifications of a provider without a secret, or of an unknown payment, are
+// rejected as wrongly signed.
+func NotificationSignatureMiddleware(secrets NotificationSecrets, maxSkew time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signature := c.GetHeader(HeaderSignature)
+		timestamp := c.GetHeader(HeaderSignatureTimestamp)
+		if signature == "" || timestamp == "" {
+			utils.LogWithRequestID(c, "Notification signature headers missing")
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+
+		unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+		if skew := time.Since(time.Unix(unixTime, 0)); skew > maxSkew || skew < -maxSkew {
+			utils.LogWithRequestID(c, fmt.Sprintf("Notification signature timestamp skew %v exceeds %v", skew, maxSkew))
+			abortWithError(c, ErrSignatureExpired)
+			return
+		}
+
+		// Unknown payments are answered like wrong signatures, so that callers cannot probe the external IDs
+		externalID := c.Param("external_id")
+		secret, err := secrets.NotificationSecret(c, externalID)
+		if err != nil {
+			utils.LogWithRequestID(c, fmt.Sprintf("Failed to look up the notification secret of payment %s: %v", externalID, err))
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+		if secret == "" {
+			utils.LogWithRequestID(c, fmt.Sprintf("No notification secret is configured for the provider of payment %s", externalID))
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+
+		// Read the body and put it back for the validation middleware
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stringToSign := merchant.StringToSign(c.Request.Method, c.Request.URL.RequestURI(), timestamp, "", body)
+		if !merchant.VerifySignature(secret, stringToSign, signature) {
+			utils.LogWithRequestID(c, fmt.Sprintf("Notification signature mismatch for payment %s", externalID))
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// errUnknownPayment stands for the error of a payment that is not found
+var errUnknownPayment = utils.NewAPIError(http.StatusNotFound, "payment_not_found", "Payment not found")
+
+// staticSecrets returns the secret of the provider of each payment, keyed by external ID
+type staticSecrets map[string]string
+
+func (s staticSecrets) NotificationSecret(ctx context.Context, externalID string) (string, error) {
+	secret, ok := s[externalID]
+	if !ok {
+		return "", errUnknownPayment
+	}
+	return secret, nil
+}
+
+// newNotificationRouter returns a router that verifies the chargeback notifications with the given secrets
+func newNotificationRouter(secrets NotificationSecrets) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.POST("/payment/callbacks/chargeback/:external_id", NotificationSignatureMiddleware(secrets, DefaultSignatureMaxSkew), func(c *gin.Context) {
+		// The body must still be readable after verification
+		body, _ := io.ReadAll(c.Request.Body)
+		utils.SuccessResponse(c, http.StatusOK, "ok", string(body))
+	})
+	return router
+}
+
+// signedNotification builds a chargeback notification of the payment signed with secret at the given time
+func signedNotification(externalID, body, secret string, signedAt time.Time) *http.Request {
+	path := "/payment/callbacks/chargeback/" + externalID
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	stringToSign := merchant.StringToSign(http.MethodPost, path, timestamp, "", []byte(body))
+
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(HeaderSignature, merchant.Sign(secret, stringToSign))
+	req.Header.Set(HeaderSignatureTimestamp, timestamp)
+	return req
+}
+
+func TestNotificationSignatureMiddleware(t *testing.T) {
+	secrets := staticSecrets{"hsbc-payment": "hsbc-secret", "adcb-payment": ""}
+	body := `{"reference":"cb-1"}`
+
+	unsigned, _ := http.NewRequest(http.MethodPost, "/payment/callbacks/chargeback/hsbc-payment", strings.NewReader(body))
+	tampered := signedNotification("hsbc-payment", body, "hsbc-secret", time.Now())
+	tampered.Body = io.NopCloser(strings.NewReader(`{"reference":"cb-1","amount":10000}`))
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid", req: signedNotification("hsbc-payment", body, "hsbc-secret", time.Now()), wantStatus: http.StatusOK},
+		{name: "unsigned", req: unsigned, wantStatus: http.StatusUnauthorized, wantCode: ErrInvalidSignature.Code},
+		{name: "tampered body", req: tampered, wantStatus: http.StatusUnauthorized, wantCode: ErrInvalidSignature.Code},
+		{name: "secret of another provider", req: signedNotification("hsbc-payment", body, "adcb-secret", time.Now()), wantStatus: http.StatusUnauthorized, wantCode: ErrInvalidSignature.Code},
+		{name: "provider without a secret", req: signedNotification("adcb-payment", body, "", time.Now()), wantStatus: http.StatusUnauthorized, wantCode: ErrInvalidSignature.Code},
+		{name: "expired", req: signedNotification("hsbc-payment", body, "hsbc-secret", time.Now().Add(-2*DefaultSignatureMaxSkew)), wantStatus: http.StatusUnauthorized, wantCode: ErrSignatureExpired.Code},
+		{name: "unknown payment", req: signedNotification("unknown", body, "hsbc-secret", time.Now()), wantStatus: http.StatusUnauthorized, wantCode: ErrInvalidSignature.Code},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newNotificationRouter(secrets)
+
+			w, response := serve(router, tt.req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, response.Code)
+			} else {
+				assert.Equal(t, body, response.Data)
+			}
+		})
+	}
+}
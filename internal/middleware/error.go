@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandlerMiddleware turns errors attached with c.Error into API error responses.
+// Errors wrapping a *utils.APIError are mapped to its status and code, anything else becomes a 500.
+func ErrorHandlerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// Nothing to do if there are no errors or a response was already written
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		utils.LogWithRequestID(c, fmt.Sprintf("Request failed: %v", err))
+
+		apiErr := asAPIError(err)
+		utils.ErrorResponse(c, apiErr.Status, apiErr.Code, apiErr.Message, nil)
+	}
+}
+
+// asAPIError returns the *utils.APIError an error wraps, or utils.ErrInternal if it wraps none
+func asAPIError(err error) *utils.APIError {
+	var apiErr *utils.APIError
+	if !errors.As(err, &apiErr) {
+		return utils.ErrInternal
+	}
+	return apiErr
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// performRequest runs a single GET request through a router whose handler attaches the given error
+func performRequest(t *testing.T, handlerErr error) (*httptest.ResponseRecorder, utils.APIResponse) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		_ = c.Error(handlerErr)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	router.ServeHTTP(w, req)
+
+	var response utils.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return w, response
+}
+
+func TestErrorHandlerMiddleware_APIError(t *testing.T) {
+	apiErr := utils.NewAPIError(http.StatusNotFound, "thing_not_found", "Thing not found")
+
+	w, response := performRequest(t, apiErr)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "error", response.Status)
+	assert.Equal(t, "thing_not_found", response.Code)
+	assert.Equal(t, "Thing not found", response.Message)
+}
+
+func TestErrorHandlerMiddleware_WrappedAPIError(t *testing.T) {
+	apiErr := utils.NewAPIError(http.StatusBadGateway, "upstream_down", "Upstream is down")
+
+	w, response := performRequest(t, fmt.Errorf("%w: connection refused", apiErr))
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, "upstream_down", response.Code)
+	assert.Equal(t, "Upstream is down", response.Message)
+}
+
+func TestErrorHandlerMiddleware_UnknownError(t *testing.T) {
+	w, response := performRequest(t, errors.New("database exploded"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, utils.ErrCodeInternal, response.Code)
+	assert.NotContains(t, response.Message, "database exploded")
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"payment-gateway-service/internal/interaction"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InteractionMiddleware records the provider callbacks it handles as inbound interactions. The handler sets
+// the external ID of the callback and, once it found the payment, the payment ID on the context.
+func InteractionMiddleware(recorder interaction.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		startTime := time.Now()
+		c.Next()
+
+		record := &interaction.Interaction{
+			ExternalID:     c.GetString(interaction.ContextKeyExternalID),
+			Direction:      interaction.DirectionInbound,
+			Method:         c.Request.Method,
+			Endpoint:       interaction.RedactEndpoint(c.Request.URL),
+			RequestHeaders: interaction.RedactHeaders(c.Request.Header),
+			RequestBody:    interaction.RedactBody(c.ContentType(), body),
+			StatusCode:     c.Writer.Status(),
+			LatencyMs:      time.Since(startTime).Milliseconds(),
+		}
+		if paymentID := c.GetString(interaction.ContextKeyPaymentID); paymentID != "" {
+			record.PaymentID = &paymentID
+		}
+
+		// Errors are only written by the error middleware once this one returned
+		if len(c.Errors) > 0 {
+			err := c.Errors.Last().Err
+			record.Error = err.Error()
+			if !c.Writer.Written() {
+				record.StatusCode = asAPIError(err).Status
+			}
+		}
+
+		// Failures are logged by the recorder, the callback is handled either way
+		_ = recorder.Record(context.WithoutCancel(c), record)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecorder keeps the recorded interactions in memory
+type memoryRecorder struct {
+	interactions []*interaction.Interaction
+}
+
+func (r *memoryRecorder) Record(_ context.Context, i *interaction.Interaction) error {
+	r.interactions = append(r.interactions, i)
+	return nil
+}
+
+// performCallback runs a callback through the error and interaction middlewares to the handler
+func performCallback(target string, handler gin.HandlerFunc) (*httptest.ResponseRecorder, *memoryRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := &memoryRecorder{}
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.GET("/payment/callbacks/success/:external_id", InteractionMiddleware(recorder), handler)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("X-Signature", "abc")
+	router.ServeHTTP(w, req)
+	return w, recorder
+}
+
+func TestInteractionMiddleware_Handled(t *testing.T) {
+	w, recorder := performCallback("/payment/callbacks/success/ext-1?token=tok_1", func(c *gin.Context) {
+		c.Set(interaction.ContextKeyExternalID, "ext-1")
+		c.Set(interaction.ContextKeyPaymentID, "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21")
+		c.Redirect(http.StatusFound, "https://shop.example.com/done")
+	})
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	require.Len(t, recorder.interactions, 1)
+	recorded := recorder.interactions[0]
+	assert.Equal(t, "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21", *recorded.PaymentID)
+	assert.Equal(t, "ext-1", recorded.ExternalID)
+	assert.Equal(t, interaction.DirectionInbound, recorded.Direction)
+	assert.Equal(t, "/payment/callbacks/success/ext-1?token=%5BREDACTED%5D", recorded.Endpoint)
+	assert.Equal(t, "[REDACTED]", recorded.RequestHeaders["X-Signature"])
+	assert.Equal(t, http.StatusFound, recorded.StatusCode)
+	assert.Empty(t, recorded.Error)
+}
+
+func TestInteractionMiddleware_Failed(t *testing.T) {
+	notFound := utils.NewAPIError(http.StatusNotFound, "payment_not_found", "Payment not found")
+
+	w, recorder := performCallback("/payment/callbacks/success/ext-2", func(c *gin.Context) {
+		c.Set(interaction.ContextKeyExternalID, "ext-2")
+		_ = c.Error(notFound)
+	})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	require.Len(t, recorder.interactions, 1)
+	recorded := recorder.interactions[0]
+	assert.Nil(t, recorded.PaymentID)
+	assert.Equal(t, "ext-2", recorded.ExternalID)
+	assert.Equal(t, http.StatusNotFound, recorded.StatusCode, "the status the error middleware responds with")
+	assert.Equal(t, "Payment not found", recorded.Error)
+}
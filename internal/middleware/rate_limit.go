@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"payment-gateway-service/internal/ratelimit"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userScopedRequest is implemented by validated bodies that identify the end user of a merchant
+type userScopedRequest interface {
+	GetUserID() int
+}
+
+// rateLimitDimensions is the order in which the dimensions of a policy are checked
+var rateLimitDimensions = []ratelimit.Dimension{ratelimit.DimensionAPIKey, ratelimit.DimensionUser, ratelimit.DimensionIP}
+
+// RateLimitMiddleware limits the requests to a route per API key, per user and per client IP
+// according to the policy. It must be registered after AuthMiddleware and ValidationMiddleware
+// so the key and the user are known, and routes without either are only limited per IP. A request
+// only uses up tokens when every limit allows it. The policy is read for every request so it can
+// be reloaded. If the store fails the request is let through.
+func RateLimitMiddleware(store ratelimit.Store, route string, policy func() ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := policy()
+
+		var dimensions []ratelimit.Dimension
+		var buckets []ratelimit.Bucket
+		for _, dimension := range rateLimitDimensions {
+			limit, ok := policy[dimension]
+			if !ok || limit.IsZero() {
+				continue
+			}
+
+			value, ok := rateLimitValue(c, dimension)
+			if !ok {
+				continue
+			}
+
+			dimensions = append(dimensions, dimension)
+			buckets = append(buckets, ratelimit.Bucket{Key: fmt.Sprintf("%s:%s:%s", route, dimension, value), Limit: limit})
+		}
+		if len(buckets) == 0 {
+			c.Next()
+			return
+		}
+
+		results, err := store.Take(c, buckets)
+		if err != nil {
+			utils.LogWithRequestID(c, fmt.Sprintf("Rate limit store failed, letting request through: %v", err))
+			c.Next()
+			return
+		}
+
+		var tightest *ratelimit.Result
+		for i, result := range results {
+			if !result.Allowed {
+				setRateLimitHeaders(c, result)
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				utils.ErrorResponse(c, http.StatusTooManyRequests, utils.ErrCodeRateLimited,
+					fmt.Sprintf("Rate limit of %s per %s exceeded", buckets[i].Limit, dimensions[i]), nil)
+				return
+			}
+
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = &results[i]
+			}
+		}
+
+		// Report the limit closest to being exhausted
+		setRateLimitHeaders(c, *tightest)
+
+		c.Next()
+	}
+}
+
+// rateLimitValue returns the value requests are grouped by for a dimension
+func rateLimitValue(c *gin.Context, dimension ratelimit.Dimension) (string, bool) {
+	switch dimension {
+	case ratelimit.DimensionAPIKey:
+		apiKeyID, ok := c.Get(utils.ContextKeyAPIKeyID)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprint(apiKeyID), true
+	case ratelimit.DimensionUser:
+		body, _ := c.Get("validatedBody")
+		request, ok := body.(userScopedRequest)
+		if !ok {
+			return "", false
+		}
+		// User IDs are only unique per merchant
+		merchantID, _ := utils.MerchantIDFromContext(c)
+		return fmt.Sprintf("%d:%d", merchantID, request.GetUserID()), true
+	case ratelimit.DimensionIP:
+		return c.ClientIP(), true
+	}
+	return "", false
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers from the IETF draft
+func setRateLimitHeaders(c *gin.Context, result ratelimit.Result) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
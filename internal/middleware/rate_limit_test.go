@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payment-gateway-service/internal/ratelimit"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	policy := ratelimit.Policy{
+		ratelimit.DimensionAPIKey: {Requests: 5, Per: time.Minute},
+		ratelimit.DimensionIP:     {Requests: 2, Per: time.Minute},
+	}
+	router.GET("/", func(c *gin.Context) {
+		c.Set(utils.ContextKeyAPIKeyID, uint(1))
+		c.Next()
+	}, RateLimitMiddleware(ratelimit.NewMemoryStore(), "test", func() ratelimit.Policy { return policy }), func(c *gin.Context) {
+		utils.SuccessResponse(c, http.StatusOK, "ok", nil)
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The IP limit is the tightest and is the one reported
+	w := request()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+
+	w = request()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = request()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), utils.ErrCodeRateLimited)
+}
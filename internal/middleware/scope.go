@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope is the middleware function that checks the authenticated API key was granted a scope.
+// It must be registered after AuthMiddleware.
+func RequireScope(scope merchant.Scope) gin.HandlerFunc {
+	return RequireAnyScope(scope)
+}
+
+// RequireAnyScope is the middleware function that checks the authenticated API key was granted at least
+// one of the scopes, for routes serving both deposits and withdrawals. It must be registered after AuthMiddleware.
+func RequireAnyScope(required ...merchant.Scope) gin.HandlerFunc {
+	names := make([]string, len(required))
+	for i, scope := range required {
+		names[i] = string(scope)
+	}
+	message := fmt.Sprintf("API key is missing the required scope: %s", strings.Join(names, ", "))
+	if len(required) > 1 {
+		message = fmt.Sprintf("API key is missing one of the required scopes: %s", strings.Join(names, ", "))
+	}
+
+	return func(c *gin.Context) {
+		granted, _ := c.Get(utils.ContextKeyScopes)
+		scopes, _ := granted.([]merchant.Scope)
+
+		for _, scope := range required {
+			if merchant.HasScope(scopes, scope) {
+				c.Next()
+				return
+			}
+		}
+
+		// Name the missing scopes so the client knows which key to use
+		utils.ErrorResponse(c, http.StatusForbidden, utils.ErrCodeInsufficientScope, message,
+			map[string][]string{"scope": names})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// performScopedRequest runs a request for a key with the granted scopes against a route requiring any of the scopes
+func performScopedRequest(granted []merchant.Scope, required ...merchant.Scope) (*httptest.ResponseRecorder, utils.APIResponse) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Set(utils.ContextKeyScopes, granted)
+		c.Next()
+	}, RequireAnyScope(required...), func(c *gin.Context) {
+		utils.SuccessResponse(c, http.StatusOK, "ok", nil)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	router.ServeHTTP(w, req)
+
+	var response utils.APIResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestRequireScope_Granted(t *testing.T) {
+	w, response := performScopedRequest([]merchant.Scope{merchant.ScopePaymentsDeposit}, merchant.ScopePaymentsDeposit)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "success", response.Status)
+}
+
+func TestRequireScope_Missing(t *testing.T) {
+	w, response := performScopedRequest([]merchant.Scope{merchant.ScopePaymentsDeposit}, merchant.ScopePaymentsWithdrawal)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, utils.ErrCodeInsufficientScope, response.Code)
+	assert.Contains(t, response.Message, string(merchant.ScopePaymentsWithdrawal))
+	assert.Equal(t, []string{string(merchant.ScopePaymentsWithdrawal)}, response.Errors["scope"])
+}
+
+func TestRequireScope_RetiredAdminScope(t *testing.T) {
+	// The admin scope of keys issued before operator keys existed grants nothing
+	w, response := performScopedRequest([]merchant.Scope{"admin"}, merchant.ScopePaymentsWithdrawal)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, utils.ErrCodeInsufficientScope, response.Code)
+}
+
+func TestRequireScope_Unauthenticated(t *testing.T) {
+	w, response := performScopedRequest(nil, merchant.ScopePaymentsRead)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, utils.ErrCodeInsufficientScope, response.Code)
+}
+
+func TestRequireAnyScope(t *testing.T) {
+	w, _ := performScopedRequest([]merchant.Scope{merchant.ScopePaymentsWithdrawal}, merchant.ScopePaymentsDeposit, merchant.ScopePaymentsWithdrawal)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, response := performScopedRequest([]merchant.Scope{merchant.ScopePaymentsRead}, merchant.ScopePaymentsDeposit, merchant.ScopePaymentsWithdrawal)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, utils.ErrCodeInsufficientScope, response.Code)
+	assert.Equal(t, []string{string(merchant.ScopePaymentsDeposit), string(merchant.ScopePaymentsWithdrawal)}, response.Errors["scope"])
+}
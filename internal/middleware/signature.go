@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers carrying the request signature of API keys in hmac mode
+const (
+	HeaderSignature          = "X-Signature"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	HeaderSignatureNonce     = "X-Signature-Nonce"
+)
+
+// DefaultSignatureMaxSkew is how far the signature timestamp may be from the server clock
+const DefaultSignatureMaxSkew = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned when the signature headers are missing or the signature does not match
+	ErrInvalidSignature = utils.NewAPIError(http.StatusUnauthorized, "invalid_signature", "Request signature is missing or invalid")
+
+	// ErrSignatureExpired is returned when the signature timestamp is outside of the allowed clock skew
+	ErrSignatureExpired = utils.NewAPIError(http.StatusUnauthorized, "signature_expired", "Request signature timestamp is outside of the allowed window")
+
+	// ErrReplayedRequest is returned when a nonce is used twice
+	ErrReplayedRequest = utils.NewAPIError(http.StatusUnauthorized, "replayed_request", "Request nonce has already been used")
+)
+
+// SignatureMiddleware verifies the HMAC signature of requests made with API keys in hmac mode.
+// Requests made with token mode keys pass through. It must be registered after AuthMiddleware.
+func SignatureMiddleware(nonces NonceCache, maxSkew time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(contextKeyAPIKey)
+		apiKey, ok := value.(*merchant.APIKey)
+		if !ok || apiKey.AuthMode != merchant.AuthModeHMAC {
+			c.Next()
+			return
+		}
+
+		signature := c.GetHeader(HeaderSignature)
+		timestamp := c.GetHeader(HeaderSignatureTimestamp)
+		nonce := c.GetHeader(HeaderSignatureNonce)
+		if signature == "" || timestamp == "" || nonce == "" {
+			utils.LogWithRequestID(c, "Signature headers missing")
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+
+		// Reject signatures made too far from now, the nonce cache only has to cover that window
+		unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+		signedAt := time.Unix(unixTime, 0)
+		if skew := time.Since(signedAt); skew > maxSkew || skew < -maxSkew {
+			utils.LogWithRequestID(c, fmt.Sprintf("Signature timestamp skew %v exceeds %v", skew, maxSkew))
+			abortWithError(c, ErrSignatureExpired)
+			return
+		}
+
+		// Read the body and put it back for the validation middleware
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stringToSign := merchant.StringToSign(c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+		if !merchant.VerifySignature(apiKey.SigningSecret, stringToSign, signature) {
+			utils.LogWithRequestID(c, fmt.Sprintf("Signature mismatch for API key %d", apiKey.ID))
+			abortWithError(c, ErrInvalidSignature)
+			return
+		}
+
+		// Only remember nonces of valid signatures so nobody can burn a nonce for someone else
+		if nonces.Remember(fmt.Sprintf("%d:%s", apiKey.ID, nonce), signedAt.Add(maxSkew)) {
+			utils.LogWithRequestID(c, fmt.Sprintf("Replayed nonce for API key %d", apiKey.ID))
+			abortWithError(c, ErrReplayedRequest)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// abortWithError hands the error to the error middleware and stops the chain
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// NonceCache remembers the nonces of signed requests until their signatures expire
+type NonceCache interface {
+	// Remember stores the nonce until expiresAt and reports whether it was already stored
+	Remember(nonce string, expiresAt time.Time) bool
+}
+
+// MemoryNonceCache is a NonceCache kept in process memory. It protects a single instance only.
+type MemoryNonceCache struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryNonceCache initializes a new MemoryNonceCache
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{nonces: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// Remember implements NonceCache
+func (m *MemoryNonceCache) Remember(nonce string, expiresAt time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop expired nonces at most once a minute
+	if now.Sub(m.lastSweep) > time.Minute {
+		for key, expiry := range m.nonces {
+			if now.After(expiry) {
+				delete(m.nonces, key)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	if expiry, exists := m.nonces[nonce]; exists && now.Before(expiry) {
+		return true
+	}
+
+	m.nonces[nonce] = expiresAt
+	return false
+}
+
+// Ensure MemoryNonceCache implements NonceCache.
+var _ NonceCache = (*MemoryNonceCache)(nil)
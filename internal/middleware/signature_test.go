@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-gateway-service/internal/merchant"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSigningSecret = "test-signing-secret"
+
+// newSignatureRouter returns a router that authenticates every request with the given key
+func newSignatureRouter(apiKey *merchant.APIKey, nonces NonceCache) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandlerMiddleware())
+	router.POST("/payment/deposit", func(c *gin.Context) {
+		c.Set(contextKeyAPIKey, apiKey)
+		c.Next()
+	}, SignatureMiddleware(nonces, DefaultSignatureMaxSkew), func(c *gin.Context) {
+		// The body must still be readable after verification
+		body, _ := io.ReadAll(c.Request.Body)
+		utils.SuccessResponse(c, http.StatusOK, "ok", string(body))
+	})
+	return router
+}
+
+// signedRequest builds a deposit request signed at the given time
+func signedRequest(body, nonce string, signedAt time.Time) *http.Request {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	stringToSign := merchant.StringToSign(http.MethodPost, "/payment/deposit", timestamp, nonce, []byte(body))
+
+	req, _ := http.NewRequest(http.MethodPost, "/payment/deposit", strings.NewReader(body))
+	req.Header.Set(HeaderSignature, merchant.Sign(testSigningSecret, stringToSign))
+	req.Header.Set(HeaderSignatureTimestamp, timestamp)
+	req.Header.Set(HeaderSignatureNonce, nonce)
+	return req
+}
+
+func serve(router *gin.Engine, req *http.Request) (*httptest.ResponseRecorder, utils.APIResponse) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response utils.APIResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func hmacKey() *merchant.APIKey {
+	return &merchant.APIKey{ID: 1, AuthMode: merchant.AuthModeHMAC, SigningSecret: testSigningSecret}
+}
+
+func TestSignatureMiddleware_Valid(t *testing.T) {
+	router := newSignatureRouter(hmacKey(), NewMemoryNonceCache())
+
+	w, response := serve(router, signedRequest(`{"amount":10}`, "nonce-1", time.Now()))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"amount":10}`, response.Data)
+}
+
+func TestSignatureMiddleware_TamperedBody(t *testing.T) {
+	router := newSignatureRouter(hmacKey(), NewMemoryNonceCache())
+
+	req := signedRequest(`{"amount":10}`, "nonce-1", time.Now())
+	req.Body = io.NopCloser(strings.NewReader(`{"amount":10000}`))
+	w, response := serve(router, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, ErrInvalidSignature.Code, response.Code)
+}
+
+func TestSignatureMiddleware_Expired(t *testing.T) {
+	router := newSignatureRouter(hmacKey(), NewMemoryNonceCache())
+
+	w, response := serve(router, signedRequest(`{"amount":10}`, "nonce-1", time.Now().Add(-2*DefaultSignatureMaxSkew)))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, ErrSignatureExpired.Code, response.Code)
+}
+
+func TestSignatureMiddleware_Replay(t *testing.T) {
+	router := newSignatureRouter(hmacKey(), NewMemoryNonceCache())
+
+	w, _ := serve(router, signedRequest(`{"amount":10}`, "nonce-1", time.Now()))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, response := serve(router, signedRequest(`{"amount":10}`, "nonce-1", time.Now()))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, ErrReplayedRequest.Code, response.Code)
+}
+
+func TestSignatureMiddleware_TokenModeSkipsVerification(t *testing.T) {
+	router := newSignatureRouter(&merchant.APIKey{ID: 1, AuthMode: merchant.AuthModeToken}, NewMemoryNonceCache())
+
+	req, _ := http.NewRequest(http.MethodPost, "/payment/deposit", strings.NewReader(`{"amount":10}`))
+	w, _ := serve(router, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -3,6 +3,8 @@ package middleware
 import (
 	"net/http"
 	"payment-gateway-service/internal/utils"
+	"reflect"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -10,14 +12,16 @@ import (
 
 // ValidationMiddleware validates the incoming request body against the provided struct
 func ValidationMiddleware(obj interface{}) gin.HandlerFunc {
+	objType := reflect.TypeOf(obj).Elem()
+
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
 
-		// Create a new instance of the provided struct type
-		objInstance := obj
+		// Create a new instance of the provided struct type so requests never share state
+		objInstance := reflect.New(objType).Interface()
 
 		// Bind the incoming JSON to the struct
-		if err := c.ShouldBindJSON(&objInstance); err != nil {
+		if err := c.ShouldBindJSON(objInstance); err != nil {
 			utils.LogWithRequestID(ctx, "Validation error occurred")
 
 			// Handle validation errors
@@ -25,26 +29,10 @@ func ValidationMiddleware(obj interface{}) gin.HandlerFunc {
 				errors := make(map[string][]string)
 				for _, validationErr := range validationErrs {
 					field := validationErr.Field()
-					tag := validationErr.Tag()
-
-					var errorMessage string
-					switch tag {
-					case "required":
-						errorMessage = "is required"
-					case "gt":
-						errorMessage = "must be greater than " + validationErr.Param()
-					case "len":
-						errorMessage = "must be exactly " + validationErr.Param() + " characters"
-					case "oneof":
-						errorMessage = "must be one of " + validationErr.Param()
-					default:
-						errorMessage = "is invalid"
-					}
-
-					errors[field] = append(errors[field], errorMessage)
+					errors[field] = append(errors[field], ValidationMessage(validationErr))
 				}
 
-				utils.ErrorResponse(c, http.StatusBadRequest, "Validation failed", errors)
+				utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", errors)
 				return
 			}
 
@@ -52,7 +40,7 @@ func ValidationMiddleware(obj interface{}) gin.HandlerFunc {
 			errors := make(map[string][]string)
 			errors["validation"] = append(errors["validation"], err.Error())
 
-			utils.ErrorResponse(c, http.StatusBadRequest, "Validation failed", errors)
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", errors)
 			return
 		}
 
@@ -64,3 +52,29 @@ func ValidationMiddleware(obj interface{}) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// ValidationMessage describes why a field failed validation, such as "must be exactly 3 characters"
+func ValidationMessage(validationErr validator.FieldError) string {
+	switch validationErr.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + validationErr.Param()
+	case "gte":
+		return "must be greater than or equal to " + validationErr.Param()
+	case "min":
+		return "must contain at least " + validationErr.Param() + " item(s)"
+	case "url":
+		return "must be a valid URL"
+	case "len":
+		return "must be exactly " + validationErr.Param() + " characters"
+	case "oneof":
+		return "must be one of " + validationErr.Param()
+	case "required_without":
+		return "is required unless " + validationErr.Param() + " is set"
+	case "excluded_if", "excluded_with":
+		return "must not be set together with " + strings.Fields(validationErr.Param())[0]
+	default:
+		return "is invalid"
+	}
+}
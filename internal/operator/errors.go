@@ -0,0 +1,14 @@
+package operator
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrInvalidOperatorKey is returned for unknown, malformed or revoked operator keys, including merchant keys
+	ErrInvalidOperatorKey = utils.NewAPIError(http.StatusUnauthorized, utils.ErrCodeUnauthorized, "Unauthorized")
+
+	// ErrOperatorKeyNotFound is returned when no operator key matches the lookup
+	ErrOperatorKeyNotFound = utils.NewAPIError(http.StatusNotFound, "operator_key_not_found", "Operator key not found")
+)
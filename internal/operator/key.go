@@ -0,0 +1,53 @@
+package operator
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// Operator keys have the form "pgo_<prefix>_<secret>", so they can never be mistaken for the "pgw_" keys
+// of merchants. As for merchant keys, the prefix is stored in clear text and the key only as a hash.
+const (
+	keyScheme      = "pgo"
+	keyPrefixBytes = 6
+	keySecretBytes = 32
+)
+
+// generateKey creates a new random operator key and returns it together with its prefix
+func generateKey() (string, string, error) {
+	prefix := make([]byte, keyPrefixBytes)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, keySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	keyPrefix := hex.EncodeToString(prefix)
+	return keyScheme + "_" + keyPrefix + "_" + hex.EncodeToString(secret), keyPrefix, nil
+}
+
+// parseKeyPrefix extracts the lookup prefix from a raw operator key
+func parseKeyPrefix(rawKey string) (string, bool) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != keyScheme || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hashKey returns the hex encoded SHA-256 hash of a raw operator key
+func hashKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyKey compares a raw operator key with a stored hash in constant time
+func verifyKey(rawKey, keyHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashKey(rawKey)), []byte(keyHash)) == 1
+}
@@ -0,0 +1,19 @@
+package operator
+
+import "time"
+
+// APIKey is the credential of an operator of the gateway. It belongs to no merchant and is the only
+// credential accepted by the admin routes. Only the hash of the key is stored.
+type APIKey struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Name      string     `gorm:"type:varchar(255);not null" json:"name"`
+	KeyPrefix string     `gorm:"type:varchar(32);unique;not null" json:"key_prefix"`
+	KeyHash   string     `gorm:"type:varchar(64);not null" json:"-"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (APIKey) TableName() string {
+	return "operator_api_keys"
+}
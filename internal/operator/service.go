@@ -0,0 +1,123 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OperatorServiceInterface defines the methods that the OperatorService must implement.
+type OperatorServiceInterface interface {
+	Authenticate(ctx context.Context, rawKey string) (*APIKey, error)
+	CreateKey(ctx context.Context, name string) (string, *APIKey, error)
+	RevokeKey(ctx context.Context, keyID uint) error
+	ListKeys(ctx context.Context) ([]APIKey, error)
+}
+
+// OperatorService handles the API keys of the operators of the gateway.
+type OperatorService struct {
+	db *gorm.DB
+}
+
+// NewOperatorService initializes a new OperatorService with the provided database connection.
+func NewOperatorService(db *gorm.DB) *OperatorService {
+	return &OperatorService{db: db}
+}
+
+// Authenticate resolves a raw operator key to an active APIKey. Unknown, revoked and merchant keys all
+// return ErrInvalidOperatorKey so callers cannot tell them apart.
+func (s *OperatorService) Authenticate(ctx context.Context, rawKey string) (*APIKey, error) {
+	keyPrefix, ok := parseKeyPrefix(rawKey)
+	if !ok {
+		utils.LogWithRequestID(ctx, "OperatorService: Malformed operator key")
+		return nil, ErrInvalidOperatorKey
+	}
+
+	var apiKey APIKey
+	if err := s.db.WithContext(ctx).Where("key_prefix = ?", keyPrefix).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: No operator key found with prefix %s", keyPrefix))
+			return nil, ErrInvalidOperatorKey
+		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Error finding operator key: %v", err))
+		return nil, err
+	}
+
+	if !verifyKey(rawKey, apiKey.KeyHash) {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Operator key hash mismatch for prefix %s", keyPrefix))
+		return nil, ErrInvalidOperatorKey
+	}
+
+	if apiKey.RevokedAt != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Operator key %d is revoked", apiKey.ID))
+		return nil, ErrInvalidOperatorKey
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Authenticated operator key %d", apiKey.ID))
+	return &apiKey, nil
+}
+
+// CreateKey issues a new operator key. The raw key is returned only once and is never stored.
+func (s *OperatorService) CreateKey(ctx context.Context, name string) (string, *APIKey, error) {
+	rawKey, keyPrefix, err := generateKey()
+	if err != nil {
+		utils.LogWithRequestID(ctx, "OperatorService: Failed to generate operator key")
+		return "", nil, err
+	}
+
+	apiKey := &APIKey{
+		Name:      name,
+		KeyPrefix: keyPrefix,
+		KeyHash:   hashKey(rawKey),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(apiKey).Error; err != nil {
+		utils.LogWithRequestID(ctx, "OperatorService: Failed to save operator key")
+		return "", nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Created operator key %d", apiKey.ID))
+	return rawKey, apiKey, nil
+}
+
+// RevokeKey revokes an operator key. Revoking an already revoked key is a no-op.
+func (s *OperatorService) RevokeKey(ctx context.Context, keyID uint) error {
+	result := s.db.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", keyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Failed to revoke operator key %d: %v", keyID, result.Error))
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&APIKey{}).Where("id = ?", keyID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrOperatorKeyNotFound
+		}
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Revoked operator key %d", keyID))
+	return nil
+}
+
+// ListKeys returns every operator key, revoked ones included
+func (s *OperatorService) ListKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	if err := s.db.WithContext(ctx).Order("id").Find(&keys).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("OperatorService: Failed to list operator keys: %v", err))
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Ensure OperatorService implements OperatorServiceInterface.
+var _ OperatorServiceInterface = (*OperatorService)(nil)
@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+const operatorKeyQuery = `^SELECT \* FROM "operator_api_keys" WHERE key_prefix = \$1 ORDER BY "operator_api_keys"."id" LIMIT \$2$`
+
+// operatorKeyRows builds an operator_api_keys row for the given raw key
+func operatorKeyRows(rawKey string, revokedAt *time.Time) *sqlmock.Rows {
+	keyPrefix, _ := parseKeyPrefix(rawKey)
+	return sqlmock.NewRows([]string{"id", "name", "key_prefix", "key_hash", "revoked_at"}).
+		AddRow(4, "ops", keyPrefix, hashKey(rawKey), revokedAt)
+}
+
+func TestAuthenticate_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	rawKey, keyPrefix, err := generateKey()
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(operatorKeyQuery).
+		WithArgs(keyPrefix, 1).
+		WillReturnRows(operatorKeyRows(rawKey, nil))
+
+	// Call the service method
+	apiKey, err := NewOperatorService(gormDB).Authenticate(context.TODO(), rawKey)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), apiKey.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticate_Revoked(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	rawKey, keyPrefix, err := generateKey()
+	assert.NoError(t, err)
+
+	revokedAt := time.Now().Add(-time.Minute)
+	mock.ExpectQuery(operatorKeyQuery).
+		WithArgs(keyPrefix, 1).
+		WillReturnRows(operatorKeyRows(rawKey, &revokedAt))
+
+	// Call the service method
+	apiKey, err := NewOperatorService(gormDB).Authenticate(context.TODO(), rawKey)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidOperatorKey)
+	assert.Nil(t, apiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthenticate_MerchantKey(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// A merchant key never reaches the operator keys, whatever its scopes
+	apiKey, err := NewOperatorService(gormDB).Authenticate(context.TODO(), "pgw_0a1b2c3d4e5f_secret")
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrInvalidOperatorKey)
+	assert.Nil(t, apiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeKey_NotFound(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "operator_api_keys" SET "revoked_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND revoked_at IS NULL$`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^SELECT count\(\*\) FROM "operator_api_keys" WHERE id = \$1$`).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// Call the service method
+	err := NewOperatorService(gormDB).RevokeKey(context.TODO(), 9)
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrOperatorKeyNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
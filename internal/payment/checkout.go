@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// pageTemplates are the hosted checkout and result pages
+var pageTemplates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// page is the data the hosted checkout templates are rendered with
+type page struct {
+	Locale  *locale
+	Title   string
+	Payment *Payment
+	Options []provider.ProviderConfiguration
+	Message string
+}
+
+// Checkout renders the hosted checkout page of a payment
+// @Summary Hosted checkout page
+// @Description Renders the payment summary and the providers the customer can pay with. Payments already sent to a provider redirect to the result page. The language is taken from the lang query parameter or the Accept-Language header.
+// @Tags checkout
+// @Produce html
+// @Param id path string true "Payment ID"
+// @Param lang query string false "Language (en or ar)"
+// @Success 200 {string} string "Checkout page"
+// @Success 303 {string} string "Redirects to the result page"
+// @Failure 404 {string} string "Payment not found page"
+// @Router /checkout/{id} [get]
+func (h *PaymentHandler) Checkout(c *gin.Context) {
+	payment, err := h.service.FindCheckoutPayment(c, c.Param("id"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	if payment.Status != utils.PaymentStatusInitialized {
+		c.Redirect(http.StatusSeeOther, resultPath(payment, localeFromRequest(c)))
+		return
+	}
+
+	options, err := h.service.CheckoutOptions(c, payment)
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	renderPage(c, http.StatusOK, "checkout.html", &page{
+		Locale:  localeFromRequest(c),
+		Title:   "checkout_title",
+		Payment: payment,
+		Options: options,
+	})
+}
+
+// StartCheckout sends the payment to the provider picked on the checkout page
+// @Summary Start a hosted checkout
+// @Description Sends the payment to the chosen provider configuration and redirects the customer to the provider.
+// @Tags checkout
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param id path string true "Payment ID"
+// @Param provider_config_id formData int true "Provider configuration ID"
+// @Success 303 {string} string "Redirects to the provider"
+// @Failure 404 {string} string "Payment not found page"
+// @Failure 409 {string} string "Checkout already started page"
+// @Failure 422 {string} string "Provider not eligible page"
+// @Router /checkout/{id} [post]
+func (h *PaymentHandler) StartCheckout(c *gin.Context) {
+	id := c.Param("id")
+
+	providerConfigID, err := strconv.ParseUint(c.PostForm("provider_config_id"), 10, 32)
+	if err != nil {
+		h.renderError(c, ErrProviderNotEligible)
+		return
+	}
+
+	providerURL, err := h.service.StartCheckout(c, id, uint(providerConfigID))
+	if err != nil {
+		utils.LogWithRequestID(c, fmt.Sprintf("Failed to start checkout of payment %s: %v", id, err))
+		h.renderError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, providerURL)
+}
+
+// PaymentStatus renders the result page the customer lands on after a payment
+// @Summary Payment result page
+// @Description Renders the current status of a payment. Customers are redirected here after the provider callback unless the payment has its own redirect URLs.
+// @Tags checkout
+// @Produce html
+// @Param id query string true "Payment ID"
+// @Param lang query string false "Language (en or ar)"
+// @Success 200 {string} string "Result page"
+// @Failure 404 {string} string "Payment not found page"
+// @Router /payment [get]
+func (h *PaymentHandler) PaymentStatus(c *gin.Context) {
+	// The status in the query string is informational, the page shows the stored one
+	payment, err := h.service.FindCheckoutPayment(c, c.Query("id"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	renderPage(c, http.StatusOK, "result.html", &page{
+		Locale:  localeFromRequest(c),
+		Title:   "result_title",
+		Payment: payment,
+	})
+}
+
+// renderError renders the error page for an error of the checkout
+func (h *PaymentHandler) renderError(c *gin.Context, err error) {
+	status, message := http.StatusInternalServerError, "error_unavailable"
+	switch {
+	case errors.Is(err, ErrPaymentNotFound):
+		status, message = http.StatusNotFound, "error_not_found"
+	case errors.Is(err, ErrInvalidTransition):
+		status, message = http.StatusConflict, "error_already_started"
+	case errors.Is(err, ErrProviderNotEligible):
+		status, message = http.StatusUnprocessableEntity, "error_not_eligible"
+	default:
+		var apiErr *utils.APIError
+		if errors.As(err, &apiErr) {
+			status = apiErr.Status
+		}
+	}
+
+	renderPage(c, status, "error.html", &page{
+		Locale:  localeFromRequest(c),
+		Title:   "error_title",
+		Message: message,
+	})
+}
+
+// renderPage renders one of the hosted checkout templates
+func renderPage(c *gin.Context, status int, name string, data *page) {
+	c.Render(status, render.HTML{Template: pageTemplates, Name: name, Data: data})
+}
+
+// resultPath returns the path of the result page of a payment
+func resultPath(payment *Payment, l *locale) string {
+	query := url.Values{}
+	query.Set("status", string(payment.Status))
+	query.Set("id", payment.ID)
+	query.Set("lang", l.Code)
+	return "/payment?" + query.Encode()
+}
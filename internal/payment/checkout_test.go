@@ -0,0 +1,55 @@
+package payment
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payment-gateway-service/internal/provider"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// renderTestPage renders a checkout template for a request with the given Accept-Language header
+func renderTestPage(name, acceptLanguage string, data func(l *locale) *page) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/checkout/1", nil)
+	c.Request.Header.Set("Accept-Language", acceptLanguage)
+
+	renderPage(c, http.StatusOK, name, data(localeFromRequest(c)))
+	return w
+}
+
+func TestRenderCheckoutPage(t *testing.T) {
+	w := renderTestPage("checkout.html", "", func(l *locale) *page {
+		return &page{
+			Locale:  l,
+			Title:   "checkout_title",
+			Payment: &Payment{ID: "abc", Amount: 40, CurrencyCode: "USD", PaymentType: "DEPOSIT"},
+			Options: []provider.ProviderConfiguration{{ID: 10, ProviderName: "HSBC"}, {ID: 11, ProviderName: "<ADCB>"}},
+		}
+	})
+
+	body := w.Body.String()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, body, `lang="en" dir="ltr"`)
+	assert.Contains(t, body, "40.00 USD")
+	assert.Contains(t, body, `action="/checkout/abc?lang=en"`)
+	assert.Contains(t, body, `value="11"`)
+	assert.Contains(t, body, "&lt;ADCB&gt;", "provider names are escaped")
+}
+
+func TestRenderResultPage_Localized(t *testing.T) {
+	w := renderTestPage("result.html", "ar-AE,ar;q=0.9,en;q=0.8", func(l *locale) *page {
+		return &page{Locale: l, Title: "result_title", Payment: &Payment{ID: "abc", Status: "SUCCESS", PaymentType: "WITHDRAWAL"}}
+	})
+
+	body := w.Body.String()
+	assert.Contains(t, body, `lang="ar" dir="rtl"`)
+	assert.Contains(t, body, locales["ar"].T("status_SUCCESS"))
+	assert.Contains(t, body, locales["ar"].T("type_WITHDRAWAL"))
+}
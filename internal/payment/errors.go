@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrPaymentNotFound is returned when no payment matches the lookup
+	ErrPaymentNotFound = utils.NewAPIError(http.StatusNotFound, "payment_not_found", "Payment not found")
+
+	// ErrInvalidTransition is returned when a payment cannot move from its current status to the requested one
+	ErrInvalidTransition = utils.NewAPIError(http.StatusConflict, "invalid_transition", "Payment status transition is not allowed")
+
+	// ErrExternalIDRequired is returned when a callback does not carry the provider external ID
+	ErrExternalIDRequired = utils.NewAPIError(http.StatusBadRequest, "external_id_required", "External ID is required")
+
+	// ErrProviderNotEligible is returned when a checkout picks a provider that is not configured for the payment
+	ErrProviderNotEligible = utils.NewAPIError(http.StatusUnprocessableEntity, "provider_not_eligible", "Provider is not available for the currency and country of the payment")
+
+	// ErrProviderNotAvailable is returned when the preferred provider of a payment is excluded or not configured for its currency and country
+	ErrProviderNotAvailable = utils.NewAPIError(http.StatusUnprocessableEntity, "provider_not_available", "Requested provider is not available for the given currency and country")
+
+	// ErrAllProvidersExcluded is returned when every provider configured for the currency and country of a payment is excluded
+	ErrAllProvidersExcluded = utils.NewAPIError(http.StatusUnprocessableEntity, "all_providers_excluded", "Every payment provider available for the given currency and country is excluded")
+
+	// ErrRedirectURLNotAllowed is returned when a redirect URL is not on one of the merchant's allowed domains
+	ErrRedirectURLNotAllowed = utils.NewAPIError(http.StatusUnprocessableEntity, "redirect_url_not_allowed", "Redirect URL is not on an allowed domain of the merchant")
+
+	// ErrDuplicateMerchantReference is returned when the merchant already has a payment with the merchant reference
+	ErrDuplicateMerchantReference = utils.NewAPIError(http.StatusConflict, "duplicate_merchant_reference", "A payment with this merchant reference already exists")
+
+	// ErrPayoutNotSettled is returned when a callback reports the result of a payout the provider has not settled
+	ErrPayoutNotSettled = utils.NewAPIError(http.StatusConflict, "payout_not_settled", "The provider has not settled the payout")
+
+	// ErrQuoteCurrencyMismatch is returned when the FX quote of a payment does not convert from the currency of the payment
+	ErrQuoteCurrencyMismatch = utils.NewAPIError(http.StatusUnprocessableEntity, "fx_quote_currency_mismatch", "FX quote does not convert from the currency of the payment")
+
+	// ErrSavedMethodDepositOnly is returned when a withdrawal is given a saved payment method
+	ErrSavedMethodDepositOnly = utils.NewAPIError(http.StatusUnprocessableEntity, "saved_method_deposit_only", "Saved payment methods can only be charged with deposits")
+
+	// ErrManualCaptureDepositOnly is returned when a withdrawal or a payout is made with manual capture
+	ErrManualCaptureDepositOnly = utils.NewAPIError(http.StatusUnprocessableEntity, "manual_capture_deposit_only", "Only deposits can be made with manual capture")
+
+	// ErrCaptureAmountExceeded is returned when a capture is for more than the authorized amount
+	ErrCaptureAmountExceeded = utils.NewAPIError(http.StatusUnprocessableEntity, "capture_amount_exceeded", "Capture amount exceeds the authorized amount")
+)
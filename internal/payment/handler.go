@@ -4,8 +4,15 @@ import (
 	"fmt"
 	"net/http"
 	"payment-gateway-service/config"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/fx"
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/merchant"
 	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/routing"
+	"payment-gateway-service/internal/savedmethod"
 	"payment-gateway-service/internal/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,27 +21,47 @@ import (
 // PaymentHandler handles payment-related requests
 type PaymentHandler struct {
 	service PaymentServiceInterface
+	config  *config.Store
 }
 
 // NewPaymentHandler initializes a new PaymentHandler
-func NewPaymentHandler(db *gorm.DB) *PaymentHandler {
+func NewPaymentHandler(db *gorm.DB, configStore *config.Store) *PaymentHandler {
 	providerSvc := provider.NewProviderService(db)
-	adapterFactory := provider.NewAdapterFactory(providerSvc)
-	service := NewPaymentService(db, providerSvc, adapterFactory)
-	return &PaymentHandler{service: service}
+	providerTimeout := func() time.Duration { return configStore.Current().ProviderTimeoutDuration() }
+	// The last 100 calls of the last 15 minutes to each provider steer the weighted routing
+	stats := provider.NewStats(100, 15*time.Minute)
+	// Every request sent to a provider is kept in the provider interactions
+	adapterFactory := provider.NewAdapterFactory(providerSvc, configStore.Current().ProviderCredentials(), providerTimeout, stats, interaction.NewInteractionService(db)).
+		WithPayoutLimiter(provider.NewPayoutLimiter(configStore.Current().PayoutConcurrencyLimit()))
+	router := routing.NewEngine(routing.NewRoutingService(db), stats)
+	fxSvc := fx.NewFXService(db, func() time.Duration { return configStore.Current().FXRateMaxAgeDuration() })
+	// Nil without BENEFICIARY_ENCRYPTION_KEY, whose length is validated with the configuration
+	cipher, _ := beneficiary.NewCipher(configStore.Current().BeneficiaryEncryptionKeyBytes())
+	service := NewPaymentService(db, providerSvc, adapterFactory, router, fxSvc, beneficiary.NewBeneficiaryService(db, cipher), savedmethod.NewSavedMethodService(db))
+	return &PaymentHandler{service: service, config: configStore}
+}
+
+// Service returns the PaymentService of the handler, shared with the handlers that create payments
+func (h *PaymentHandler) Service() PaymentServiceInterface {
+	return h.service
 }
 
 // Deposit handles deposit requests
 // @Summary Handles deposit requests
-// @Description Processes a deposit request and returns a URL for payment.
+// @Description Processes a deposit request and returns a URL for payment. With save_method the provider saves the payment method once the deposit succeeded, and it is listed with GET /saved-methods. With saved_method_id a method the user saved is charged right away with its provider, with no redirect, and the payment is returned instead of a URL: SUCCESS or FAILED, or PENDING until the provider calls back. With capture_mode manual the provider only authorizes the deposit, which becomes AUTHORIZED instead of SUCCESS, and the merchant captures it with POST /payment/{id}/capture or voids it with POST /payment/{id}/void.
 // @Tags payment
 // @Accept json
 // @Produce json
-// @Param X-AUTH-TOKEN header string true "Authorization token"
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
 // @Param validatedBody body PaymentRequest true "Validated Payment Request"
-// @Success 200 {object} map[string]interface{} "url"
-// @Failure 400 {object} map[string]interface{} "Invalid request"
-// @Failure 500 {object} map[string]interface{} "Failed to process request"
+// @Success 200 {object} map[string]interface{} "url, or the payment charging a saved method"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Saved method not found"
+// @Failure 422 {object} utils.APIResponse "No route for currency/country, saved method disabled or payment rejected by provider"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse "Failed to process request"
+// @Failure 502 {object} utils.APIResponse "Payment provider unavailable"
 // @Router /payment/deposit [post]
 // @Param exampleRequest body PaymentRequest true "Example request" Example({"amount": 40, "country_code": "US", "currency_code": "USD" "user_id": 1})
 func (h *PaymentHandler) Deposit(c *gin.Context) {
@@ -49,11 +76,15 @@ func (h *PaymentHandler) Deposit(c *gin.Context) {
 // @Tags payment
 // @Accept json
 // @Produce json
-// @Param X-AUTH-TOKEN header string true "Authorization token"
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
 // @Param validatedBody body PaymentRequest true "Validated Payment Request"
 // @Success 200 {object} map[string]interface{} "url"
-// @Failure 400 {object} map[string]interface{} "Invalid request"
-// @Failure 500 {object} map[string]interface{} "Failed to process request"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 422 {object} utils.APIResponse "No route for currency/country or payment rejected by provider"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse "Failed to process request"
+// @Failure 502 {object} utils.APIResponse "Payment provider unavailable"
 // @Router /payment/withdrawal [post]
 // @Param exampleRequest body PaymentRequest true "Example request" Example({"amount": 40, "country_code": "US", "currency_code": "USD", "user_id": 1})
 func (h *PaymentHandler) Withdrawal(c *gin.Context) {
@@ -62,6 +93,56 @@ func (h *PaymentHandler) Withdrawal(c *gin.Context) {
 	h.processPayment(c, utils.PaymentTypeWithdrawal)
 }
 
+// Payout handles payout requests
+// @Summary Pay out to a bank account
+// @Description Sends a withdrawal to the beneficiary's bank account, server to server, with no redirect. The account is given as beneficiary, or is the verified saved beneficiary of the user referenced by beneficiary_id. It is validated for the country: an IBAN in IBAN countries, otherwise an account number and the bank code of the country. The payout is PENDING until the provider settles it and then SUCCESS or FAILED; its status is read with GET /payment/{id}. The beneficiary is returned and stored masked.
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param validatedBody body PayoutRequest true "Validated Payout Request"
+// @Success 202 {object} utils.APIResponse{data=Payment} "Pending payout"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:withdrawal"
+// @Failure 409 {object} utils.APIResponse "Duplicate merchant reference"
+// @Failure 422 {object} utils.APIResponse "Invalid or unverified beneficiary, no route for currency/country or payout rejected by provider"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse "Failed to process request"
+// @Failure 502 {object} utils.APIResponse "Payment provider unavailable"
+// @Router /payment/payout [post]
+// @Param exampleRequest body PayoutRequest true "Example request" Example({"amount": 40, "country_code": "GB", "currency_code": "GBP", "user_id": 1, "beneficiary": {"holder_name": "Jane Doe", "iban": "GB82WEST12345698765432"}})
+func (h *PaymentHandler) Payout(c *gin.Context) {
+	utils.LogWithRequestID(c, "Processing payout request")
+
+	req, exists := c.Get("validatedBody")
+	if !exists {
+		utils.LogWithRequestID(c, "Invalid request: no validated body found")
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	payoutRequest, ok := req.(*PayoutRequest)
+	if !ok {
+		utils.LogWithRequestID(c, "Failed to process request: type assertion failed")
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.ErrCodeInternal, "Failed to process request", nil)
+		return
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Received payout request: UserID=%d, Amount=%.2f, CurrencyCode=%s, CountryCode=%s",
+		payoutRequest.UserID, payoutRequest.Amount, payoutRequest.CurrencyCode, payoutRequest.CountryCode))
+
+	payment, err := h.service.CreatePayout(c, payoutRequest)
+	if err != nil {
+		utils.LogWithRequestID(c, fmt.Sprintf("Failed to create payout: %v", err))
+		_ = c.Error(err)
+		return
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Payout %s sent with ExternalID: %s", payment.ID, payment.ExternalID))
+	utils.SuccessResponse(c, http.StatusAccepted, "Payout accepted", payment)
+}
+
 // processPayment handles the common logic for deposit and withdrawal
 func (h *PaymentHandler) processPayment(c *gin.Context, paymentType utils.PaymentType) {
 	utils.LogWithRequestID(c, "Processing payment request")
@@ -69,7 +150,7 @@ func (h *PaymentHandler) processPayment(c *gin.Context, paymentType utils.Paymen
 	req, exists := c.Get("validatedBody")
 	if !exists {
 		utils.LogWithRequestID(c, "Invalid request: no validated body found")
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", nil)
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
 		return
 	}
 
@@ -77,7 +158,7 @@ func (h *PaymentHandler) processPayment(c *gin.Context, paymentType utils.Paymen
 	paymentRequest, ok := req.(*PaymentRequest)
 	if !ok {
 		utils.LogWithRequestID(c, "Failed to process request: type assertion failed")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process request", nil)
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.ErrCodeInternal, "Failed to process request", nil)
 		return
 	}
 
@@ -85,11 +166,40 @@ func (h *PaymentHandler) processPayment(c *gin.Context, paymentType utils.Paymen
 	utils.LogWithRequestID(c, fmt.Sprintf("Received payment request: UserID=%d, Amount=%.2f, CurrencyCode=%s, CountryCode=%s, PaymentType=%s",
 		paymentRequest.UserID, paymentRequest.Amount, paymentRequest.CurrencyCode, paymentRequest.CountryCode, paymentType))
 
+	// With the hosted checkout the customer is sent to the gateway to pick a provider
+	if paymentRequest.HostedCheckout {
+		payment, err := h.service.CreateCheckout(c, paymentRequest, paymentType)
+		if err != nil {
+			utils.LogWithRequestID(c, fmt.Sprintf("Failed to create checkout: %v", err))
+			_ = c.Error(err)
+			return
+		}
+
+		url := fmt.Sprintf("%s/checkout/%s", h.config.Current().AppHost, payment.ID)
+		utils.LogWithRequestID(c, fmt.Sprintf("%s checkout created with URL: %s", paymentType, url))
+		utils.SuccessResponse(c, http.StatusOK, fmt.Sprintf("%s successful", paymentType), gin.H{"url": url})
+		return
+	}
+
+	// A saved method is charged right away, there is no page to send the customer to
+	if paymentRequest.SavedMethodID != "" && paymentType == utils.PaymentTypeDeposit {
+		payment, err := h.service.ChargeSavedMethod(c, paymentRequest)
+		if err != nil {
+			utils.LogWithRequestID(c, fmt.Sprintf("Failed to charge saved method: %v", err))
+			_ = c.Error(err)
+			return
+		}
+
+		utils.LogWithRequestID(c, fmt.Sprintf("%s %s charged saved method %s", paymentType, payment.ID, paymentRequest.SavedMethodID))
+		utils.SuccessResponse(c, http.StatusOK, fmt.Sprintf("%s %s", paymentType, payment.Status), payment)
+		return
+	}
+
 	// Create the payment using the service and get the URL
 	url, err := h.service.CreatePayment(c, paymentRequest, paymentType)
 	if err != nil {
 		utils.LogWithRequestID(c, fmt.Sprintf("Failed to create payment: %v", err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create payment", nil)
+		_ = c.Error(err)
 		return
 	}
 
@@ -104,8 +214,10 @@ func (h *PaymentHandler) processPayment(c *gin.Context, paymentType utils.Paymen
 // @Produce json
 // @Param external_id path string true "External ID"
 // @Success 302 {string} string "Redirects to status URL"
-// @Failure 400 {object} map[string]interface{} "Error extracting external ID"
-// @Failure 500 {object} map[string]interface{} "Failed to handle callback"
+// @Failure 400 {object} utils.APIResponse "Error extracting external ID"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is not pending, or payout not settled by the provider"
+// @Failure 500 {object} utils.APIResponse "Failed to handle callback"
 // @Router /payment/callback/success [get]
 func (h *PaymentHandler) HandleSuccessCallback(c *gin.Context) {
 	h.handleCallback(c, utils.PaymentStatusSuccess, "successful")
@@ -118,8 +230,10 @@ func (h *PaymentHandler) HandleSuccessCallback(c *gin.Context) {
 // @Produce json
 // @Param external_id path string true "External ID"
 // @Success 302 {string} string "Redirects to status URL"
-// @Failure 400 {object} map[string]interface{} "Error extracting external ID"
-// @Failure 500 {object} map[string]interface{} "Failed to handle callback"
+// @Failure 400 {object} utils.APIResponse "Error extracting external ID"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is not pending, or payout not settled by the provider"
+// @Failure 500 {object} utils.APIResponse "Failed to handle callback"
 // @Router /payment/callback/failure [get]
 func (h *PaymentHandler) HandleFailedCallback(c *gin.Context) {
 	h.handleCallback(c, utils.PaymentStatusFailed, "failed")
@@ -132,33 +246,233 @@ func (h *PaymentHandler) handleCallback(c *gin.Context, status utils.PaymentStat
 	externalID, err := ExtractExternalID(c)
 	if err != nil {
 		utils.LogWithRequestID(c, fmt.Sprintf("Error extracting external ID: %v", err))
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
+		_ = c.Error(err)
 		return
 	}
+	c.Set(interaction.ContextKeyExternalID, externalID)
 
 	// Handle the callback using the service
 	payment, err := h.service.HandleCallback(c, externalID, status)
 	if err != nil {
 		utils.LogWithRequestID(c, fmt.Sprintf("Failed to handle %s callback: %v", result, err))
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": fmt.Sprintf("Failed to handle %s callback", result)})
+		_ = c.Error(err)
 		return
 	}
 
+	c.Set(interaction.ContextKeyPaymentID, payment.ID)
+
 	utils.LogWithRequestID(c, fmt.Sprintf("%s callback handled for payment: %+v", result, payment))
-	cfg := config.LoadConfig()
-	// Redirect user to the desired URL
-	redirectURL := fmt.Sprintf("%s/payment?status=%s&id=%v", cfg.AppHost, result, payment.ID)
+	// Redirect the customer to the merchant's page for the result, or to the gateway status page
+	redirectURL := RedirectURL(payment, result, h.config.Current().AppHost)
 	c.Redirect(http.StatusFound, redirectURL)
 }
 
-func (h *PaymentHandler) PaymentStatus(c *gin.Context) {
-	// Extract the status and id query parameters from the URL
-	status := c.Query("status")
-	id := c.Query("id")
+// HandleCancelCallback handles provider callbacks for payments the customer cancelled (with and without external_id)
+// @Summary Handles cancelled payment provider callbacks
+// @Description Marks the payment as failed and redirects to its cancel URL, falling back to its failure URL and then to the status page.
+// @Tags payment
+// @Produce json
+// @Param external_id path string true "External ID"
+// @Success 302 {string} string "Redirects to cancel URL"
+// @Failure 400 {object} utils.APIResponse "Error extracting external ID"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is not pending, or payout not settled by the provider"
+// @Failure 500 {object} utils.APIResponse "Failed to handle callback"
+// @Router /payment/callbacks/cancel [get]
+func (h *PaymentHandler) HandleCancelCallback(c *gin.Context) {
+	h.handleCallback(c, utils.PaymentStatusFailed, "cancelled")
+}
+
+// PaymentMethods lists the providers available for a currency and country
+// @Summary List payment methods
+// @Description Lists the providers a payment in the currency and country can be sent to, by priority. Their names can be used as the provider preference or exclusions of a payment.
+// @Tags payment
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param currency query string true "Currency code"
+// @Param country query string true "Country code"
+// @Success 200 {object} utils.APIResponse{data=[]PaymentMethod} "Payment methods"
+// @Failure 400 {object} utils.APIResponse "Invalid currency or country"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /payment/methods [get]
+func (h *PaymentHandler) PaymentMethods(c *gin.Context) {
+	var query PaymentMethodsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", map[string][]string{"validation": {err.Error()}})
+		return
+	}
+
+	methods, err := h.service.PaymentMethods(c, query.CurrencyCode, query.CountryCode)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment methods", methods)
+}
+
+// GetPayment returns a payment of the authenticated merchant
+// @Summary Get a payment
+// @Description Returns a single payment owned by the authenticated merchant.
+// @Tags payment
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Payment ID"
+// @Success 200 {object} utils.APIResponse{data=Payment} "Payment"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /payment/{id} [get]
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	id := c.Param("id")
+	utils.LogWithRequestID(c, fmt.Sprintf("Fetching payment %s", id))
+
+	payment, err := h.service.FindPaymentByID(c, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment found", payment)
+}
+
+// GetPaymentByReference returns a payment of the authenticated merchant by its merchant reference
+// @Summary Get a payment by merchant reference
+// @Description Returns the payment the authenticated merchant created with the merchant reference.
+// @Tags payment
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param reference path string true "Merchant reference"
+// @Success 200 {object} utils.APIResponse{data=Payment} "Payment"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /payment/reference/{reference} [get]
+func (h *PaymentHandler) GetPaymentByReference(c *gin.Context) {
+	reference := c.Param("reference")
+	utils.LogWithRequestID(c, fmt.Sprintf("Fetching payment with merchant reference %s", reference))
+
+	payment, err := h.service.FindPaymentByReference(c, reference)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment found", payment)
+}
+
+// CancelPayment cancels a payment of the authenticated merchant
+// @Summary Cancel a payment
+// @Description Cancels an INITIALIZED or PENDING payment, for example when the customer abandoned the checkout. The provider of a PENDING payment is notified. A provider reporting the payment as successful afterwards flags it for manual review. Cancelling a deposit requires the payments:deposit scope and a withdrawal the payments:withdrawal scope.
+// @Tags payment
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Payment ID"
+// @Success 200 {object} utils.APIResponse{data=Payment} "Cancelled payment"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope for the payment type"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is already completed"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /payment/{id}/cancel [post]
+func (h *PaymentHandler) CancelPayment(c *gin.Context) {
+	id := c.Param("id")
+	utils.LogWithRequestID(c, fmt.Sprintf("Cancelling payment %s", id))
+
+	payment, err := h.service.FindPaymentByID(c, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Cancelling a payment takes the scope needed to create it
+	scope := merchant.ScopePaymentsDeposit
+	if payment.PaymentType == utils.PaymentTypeWithdrawal {
+		scope = merchant.ScopePaymentsWithdrawal
+	}
+	granted, _ := c.Get(utils.ContextKeyScopes)
+	scopes, _ := granted.([]merchant.Scope)
+	if !merchant.HasScope(scopes, scope) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.ErrCodeInsufficientScope,
+			fmt.Sprintf("API key is missing the required scope: %s", scope),
+			map[string][]string{"scope": {string(scope)}})
+		return
+	}
+
+	payment, err = h.service.CancelPayment(c, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment cancelled", payment)
+}
+
+// CapturePayment captures an authorized deposit of the authenticated merchant
+// @Summary Capture an authorized payment
+// @Description Captures an AUTHORIZED deposit made with capture_mode manual, in full or in part. Without an amount the full amount is captured, and the rest of a partial capture is released by the provider. Authorizations not captured before CAPTURE_DEADLINE are voided.
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Payment ID"
+// @Param validatedBody body CaptureRequest true "Amount to capture, the full amount if not set"
+// @Success 200 {object} utils.APIResponse{data=Payment} "Captured payment"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing payments:deposit scope"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is not authorized, or is being captured or voided"
+// @Failure 422 {object} utils.APIResponse "Amount exceeds the authorized amount or capture rejected by provider"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Failure 502 {object} utils.APIResponse "Payment provider unavailable"
+// @Router /payment/{id}/capture [post]
+func (h *PaymentHandler) CapturePayment(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CaptureRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	id := c.Param("id")
+	utils.LogWithRequestID(c, fmt.Sprintf("Capturing payment %s", id))
+
+	payment, err := h.service.CapturePayment(c, id, request.Amount)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment captured", payment)
+}
+
+// VoidPayment voids an authorized deposit of the authenticated merchant
+// @Summary Void an authorized payment
+// @Description Voids an AUTHORIZED deposit made with capture_mode manual, and the provider releases the authorized amount.
+// @Tags payment
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Payment ID"
+// @Success 200 {object} utils.APIResponse{data=Payment} "Voided payment"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing payments:deposit scope"
+// @Failure 404 {object} utils.APIResponse "Payment not found"
+// @Failure 409 {object} utils.APIResponse "Payment is not authorized, or is being captured or voided"
+// @Failure 422 {object} utils.APIResponse "Void rejected by provider"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Failure 502 {object} utils.APIResponse "Payment provider unavailable"
+// @Router /payment/{id}/void [post]
+func (h *PaymentHandler) VoidPayment(c *gin.Context) {
+	id := c.Param("id")
+	utils.LogWithRequestID(c, fmt.Sprintf("Voiding payment %s", id))
+
+	payment, err := h.service.VoidPayment(c, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
 
-	// For simplicity
-	c.JSON(http.StatusOK, gin.H{
-		"status": status,
-		"id":     id,
-	})
+	utils.SuccessResponse(c, http.StatusOK, "Payment voided", payment)
 }
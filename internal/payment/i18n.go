@@ -0,0 +1,103 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// locale holds the messages of the hosted checkout pages in one language
+type locale struct {
+	Code     string
+	Dir      string
+	messages map[string]string
+}
+
+// defaultLocale is used when the customer asks for no supported language
+const defaultLocale = "en"
+
+// locales are the languages the hosted checkout pages are available in
+var locales = map[string]*locale{
+	"en": {
+		Code: "en",
+		Dir:  "ltr",
+		messages: map[string]string{
+			"checkout_title":        "Checkout",
+			"result_title":          "Payment status",
+			"error_title":           "Payment error",
+			"amount":                "Amount",
+			"reference":             "Reference",
+			"type_DEPOSIT":          "Deposit",
+			"type_WITHDRAWAL":       "Withdrawal",
+			"choose_provider":       "Choose how to pay",
+			"continue":              "Continue to payment",
+			"status_INITIALIZED":    "Waiting for a payment method to be chosen",
+			"status_PENDING":        "Your payment is being processed",
+			"status_SUCCESS":        "Your payment was successful",
+			"status_FAILED":         "Your payment failed",
+			"status_EXPIRED":        "Your payment expired",
+			"status_CANCELLED":      "Your payment was cancelled",
+			"error_not_found":       "This payment does not exist.",
+			"error_already_started": "This payment has already been sent to a provider.",
+			"error_not_eligible":    "The chosen payment method is not available for this payment.",
+			"error_unavailable":     "The payment could not be started. Please try again later.",
+		},
+	},
+	"ar": {
+		Code: "ar",
+		Dir:  "rtl",
+		messages: map[string]string{
+			"checkout_title":        "الدفع",
+			"result_title":          "حالة الدفع",
+			"error_title":           "خطأ في الدفع",
+			"amount":                "المبلغ",
+			"reference":             "المرجع",
+			"type_DEPOSIT":          "إيداع",
+			"type_WITHDRAWAL":       "سحب",
+			"choose_provider":       "اختر طريقة الدفع",
+			"continue":              "متابعة الدفع",
+			"status_INITIALIZED":    "في انتظار اختيار طريقة الدفع",
+			"status_PENDING":        "جارٍ معالجة الدفع",
+			"status_SUCCESS":        "تمت عملية الدفع بنجاح",
+			"status_FAILED":         "فشلت عملية الدفع",
+			"status_EXPIRED":        "انتهت صلاحية عملية الدفع",
+			"status_CANCELLED":      "تم إلغاء عملية الدفع",
+			"error_not_found":       "عملية الدفع هذه غير موجودة.",
+			"error_already_started": "تم إرسال عملية الدفع هذه إلى مزود الدفع بالفعل.",
+			"error_not_eligible":    "طريقة الدفع المختارة غير متاحة لعملية الدفع هذه.",
+			"error_unavailable":     "تعذر بدء عملية الدفع. يرجى المحاولة لاحقاً.",
+		},
+	},
+}
+
+// T returns the message for a key, formatted with the arguments. Unknown keys are returned as is.
+func (l *locale) T(key string, args ...interface{}) string {
+	message, ok := l.messages[key]
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// localeFromRequest picks the language of the page from the lang query parameter, then from the
+// Accept-Language header, falling back to English.
+func localeFromRequest(c *gin.Context) *locale {
+	if l, ok := locales[strings.ToLower(c.Query("lang"))]; ok {
+		return l
+	}
+
+	for _, tag := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		// "ar-AE;q=0.9" -> "ar"
+		tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		language := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if l, ok := locales[language]; ok {
+			return l
+		}
+	}
+
+	return locales[defaultLocale]
+}
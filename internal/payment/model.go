@@ -1,7 +1,11 @@
 package payment
 
 import (
+	"math"
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/fx"
 	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/routing"
 	"payment-gateway-service/internal/utils"
 	"time"
 )
@@ -12,10 +16,195 @@ type Payment struct {
 	PaymentType  utils.PaymentType   `gorm:"type:payment_type;not null" json:"payment_type"`
 	Status       utils.PaymentStatus `gorm:"type:payment_status;default:INITIALIZED" json:"status"`
 	CurrencyCode string              `gorm:"type:varchar(3);not null" json:"currency_code"`
+	CountryCode  string              `gorm:"type:varchar(2);not null;default:''" json:"country_code"`
 	UserID       int                 `gorm:"not null" json:"user_id"`
+	MerchantID   uint                `gorm:"not null" json:"merchant_id"`
 	ProviderID   uint                `gorm:"not null;foreignKey:ProviderID;constraint:OnDelete:SET NULL" json:"provider_id"`
 	Provider     provider.Provider   `gorm:"foreignKey:ProviderID" json:"provider"`
 	ExternalID   string              `gorm:"type:varchar(255)" json:"external_id"`
+	SuccessURL   string              `gorm:"type:text;not null;default:''" json:"success_url,omitempty"`
+	FailureURL   string              `gorm:"type:text;not null;default:''" json:"failure_url,omitempty"`
+	CancelURL    string              `gorm:"type:text;not null;default:''" json:"cancel_url,omitempty"`
 	CreatedAt    time.Time           `json:"created_at"`
 	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// RoutingDecision records how the provider of the payment was chosen, for later analysis
+	RoutingDecision routing.Decision `gorm:"type:jsonb;serializer:json;not null" json:"routing_decision"`
+
+	// ProviderConfigurationID and ProviderPriority are the configuration the payment was sent to the provider with
+	// and its priority at the time. They are nil until the payment is routed.
+	ProviderConfigurationID *uint  `json:"provider_configuration_id,omitempty"`
+	ProviderPriority        *int   `json:"provider_priority,omitempty"`
+	CheckoutURL             string `gorm:"type:text;not null;default:''" json:"checkout_url,omitempty"`
+
+	// A payment settled in another currency keeps its original Amount and CurrencyCode; the provider is
+	// sent the converted amount, converted at FXRate as of FXRateAt
+	ConvertedAmount   *float64   `gorm:"type:numeric(12,2)" json:"converted_amount,omitempty"`
+	ConvertedCurrency string     `gorm:"type:varchar(3);not null;default:''" json:"converted_currency,omitempty"`
+	FXRate            *float64   `gorm:"type:numeric(18,8)" json:"fx_rate,omitempty"`
+	FXRateAt          *time.Time `json:"fx_rate_at,omitempty"`
+
+	// NeedsReview flags a payment an operator has to look at, such as one the provider completed after it was cancelled
+	NeedsReview  bool   `gorm:"not null;default:false" json:"needs_review"`
+	ReviewReason string `gorm:"type:text;not null;default:''" json:"review_reason,omitempty"`
+
+	// MerchantReference is the merchant's own identifier of the payment, unique per merchant
+	MerchantReference string                 `gorm:"type:varchar(255);not null;default:''" json:"merchant_reference,omitempty"`
+	Description       string                 `gorm:"type:text;not null;default:''" json:"description,omitempty"`
+	Metadata          map[string]interface{} `gorm:"type:jsonb;serializer:json;not null" json:"metadata"`
+	Customer          Customer               `gorm:"embedded;embeddedPrefix:customer_" json:"customer"`
+
+	// Beneficiary is the bank account a payout is sent to, with its identifiers masked, and BeneficiaryID the
+	// saved beneficiary it was taken from, if any. Both are nil for other payments.
+	BeneficiaryID *string                  `gorm:"type:uuid" json:"beneficiary_id,omitempty"`
+	Beneficiary   *bankaccount.BankAccount `gorm:"type:jsonb;serializer:json" json:"beneficiary,omitempty"`
+
+	// SaveMethod asks the provider of a deposit for a reusable token of the payment method once it succeeded,
+	// and SavedMethodID is the saved method the token is stored as, or the saved method the deposit charged
+	SaveMethod    bool    `gorm:"not null;default:false" json:"save_method"`
+	SavedMethodID *string `gorm:"type:uuid" json:"saved_method_id,omitempty"`
+
+	// CaptureMode is manual for a deposit the provider only authorizes: it is AUTHORIZED as of AuthorizedAt,
+	// then CAPTURED for CapturedAmount, up to its Amount, or VOIDED
+	CaptureMode    string     `gorm:"type:varchar(10);not null;default:automatic" json:"capture_mode"`
+	AuthorizedAt   *time.Time `json:"authorized_at,omitempty"`
+	CapturedAmount *float64   `gorm:"type:numeric(12,2)" json:"captured_amount,omitempty"`
+	CapturedAt     *time.Time `json:"captured_at,omitempty"`
+}
+
+// Capture modes of payments
+const (
+	CaptureModeAutomatic = "automatic"
+	CaptureModeManual    = "manual"
+)
+
+// CaptureRequest is the amount of an authorized deposit to capture, the whole amount if omitted
+type CaptureRequest struct {
+	Amount float64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// Customer is the person paying, as known to the merchant
+type Customer struct {
+	Email  string `gorm:"type:varchar(255);not null;default:''" json:"email,omitempty" binding:"omitempty,email,max=255"`
+	Name   string `gorm:"type:varchar(255);not null;default:''" json:"name,omitempty" binding:"omitempty,max=255"`
+	Locale string `gorm:"type:varchar(35);not null;default:''" json:"locale,omitempty" binding:"omitempty,bcp47_language_tag,max=35"`
+}
+
+// providerDetails returns what the provider is told about the payment besides its amount, type, currency and country
+func (p *Payment) providerDetails() provider.PaymentDetails {
+	return provider.PaymentDetails{
+		MerchantReference: p.MerchantReference,
+		Description:       p.Description,
+		CustomerEmail:     p.Customer.Email,
+		CustomerName:      p.Customer.Name,
+		CustomerLocale:    p.Customer.Locale,
+		SaveMethod:        p.SaveMethod,
+		ManualCapture:     p.manualCapture(),
+	}
+}
+
+// manualCapture reports whether the provider only authorizes the payment
+func (p *Payment) manualCapture() bool {
+	return p.CaptureMode == CaptureModeManual
+}
+
+// authorize records that the provider authorized a payment made with manual capture
+func (p *Payment) authorize(now time.Time) {
+	p.Status = utils.PaymentStatusAuthorized
+	p.AuthorizedAt = &now
+}
+
+// settlementCaptureAmount returns the amount of a capture sent to the provider, converted like the payment
+func (p *Payment) settlementCaptureAmount(amount float64) float64 {
+	if amount == p.Amount {
+		return p.SettlementAmount()
+	}
+	if p.FXRate != nil {
+		return math.Round(amount**p.FXRate*100) / 100
+	}
+	return amount
+}
+
+// applyRoute records the provider configuration the payment is sent to and how it was chosen
+func (p *Payment) applyRoute(providerConfig *provider.ProviderConfiguration, decision routing.Decision) {
+	p.ProviderID = providerConfig.ProviderID
+	p.ProviderConfigurationID = &providerConfig.ID
+	p.ProviderPriority = &providerConfig.Priority
+	p.RoutingDecision = decision
+}
+
+// applyConversion records the conversion of the payment to its settlement currency
+func (p *Payment) applyConversion(conversion *fx.Conversion) {
+	if conversion == nil {
+		return
+	}
+	p.ConvertedAmount = &conversion.Amount
+	p.ConvertedCurrency = conversion.Currency
+	p.FXRate = &conversion.Rate
+	p.FXRateAt = &conversion.RateAt
+}
+
+// SettlementAmount returns the amount the provider is sent, the converted amount if the payment was converted
+func (p *Payment) SettlementAmount() float64 {
+	if p.ConvertedAmount != nil {
+		return *p.ConvertedAmount
+	}
+	return p.Amount
+}
+
+// SettledAmount returns the amount the provider settled in the settlement currency, the converted capture if the
+// payment was captured
+func (p *Payment) SettledAmount() float64 {
+	if p.CapturedAmount != nil {
+		return p.settlementCaptureAmount(*p.CapturedAmount)
+	}
+	return p.SettlementAmount()
+}
+
+// SettlementCurrency returns the currency the provider is sent, the converted currency if the payment was converted
+func (p *Payment) SettlementCurrency() string {
+	if p.ConvertedCurrency != "" {
+		return p.ConvertedCurrency
+	}
+	return p.CurrencyCode
+}
+
+// PaymentMethod is a provider a payment can be sent to
+type PaymentMethod struct {
+	Provider    string `json:"provider"`
+	DisplayName string `json:"display_name"`
+	Priority    int    `json:"priority"`
+}
+
+// PaymentMethodsQuery is the currency and country to list the payment methods for
+type PaymentMethodsQuery struct {
+	CurrencyCode string `form:"currency" binding:"required,len=3"`
+	CountryCode  string `form:"country" binding:"required,len=2"`
+}
+
+// PaymentFilter narrows down the payments listed for operators
+type PaymentFilter struct {
+	ID                string
+	MerchantID        uint
+	Status            utils.PaymentStatus
+	MerchantReference string
+	NeedsReview       bool
+	CreatedBefore     time.Time
+	Limit             int
+}
+
+// StatusCount is the number of payments of a provider in a status
+type StatusCount struct {
+	ProviderID   uint                `json:"provider_id"`
+	ProviderName string              `json:"provider_name"`
+	Status       utils.PaymentStatus `json:"status"`
+	Count        int64               `json:"count"`
+}
+
+// ReconciliationReport summarizes the payments created since a point in time and lists
+// the ones still waiting for a provider callback for too long
+type ReconciliationReport struct {
+	Since         time.Time     `json:"since"`
+	StatusCounts  []StatusCount `json:"status_counts"`
+	StalePayments []Payment     `json:"stale_payments"`
 }
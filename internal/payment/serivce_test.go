@@ -6,16 +6,42 @@ import (
 	"testing"
 	"time"
 
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/fx"
+	"payment-gateway-service/internal/interaction"
 	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/routing"
+	"payment-gateway-service/internal/savedmethod"
 	"payment-gateway-service/internal/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// merchantCtx carries the authenticated merchant the way the auth middleware sets it
+var merchantCtx = context.WithValue(context.TODO(), utils.ContextKeyMerchantID, uint(1))
+
+// paymentCtx matches the context handed down to a provider adapter for the payment with the ID
+func paymentCtx(paymentID string) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(interaction.ContextKeyPaymentID) == paymentID
+	})
+}
+
+// anyProviderConfig matches the provider configuration a payment is routed to
+var anyProviderConfig = mock.AnythingOfType("*provider.ProviderConfiguration")
+
+// anyPayoutDetails matches the payout sent to a provider adapter
+var anyPayoutDetails = mock.AnythingOfType("provider.PayoutDetails")
+
+// anyTokenChargeDetails matches the charge of a saved method sent to a provider adapter
+var anyTokenChargeDetails = mock.AnythingOfType("provider.TokenChargeDetails")
+
 // Helper function to create a mock GORM DB and service
 func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
 	db, mock, err := sqlmock.New()
@@ -42,51 +68,70 @@ func TestCreatePayment_Success(t *testing.T) {
 	// Setup mock dependencies
 	providerSvc := new(MockProviderService)
 	adapterFactory := new(MockAdapterFactory)
-	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
 
 	// Setup expectations for SQL queries
 	sqlRows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 	mock.ExpectBegin()
-	mock.ExpectQuery(`^INSERT INTO "payments" \("amount","payment_type","status","currency_code","user_id","provider_id","external_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\) RETURNING "id"$`).
+	mock.ExpectQuery(`^INSERT INTO "payments" \("amount","payment_type","status","currency_code","country_code","user_id","merchant_id","provider_id","external_id","success_url","failure_url","cancel_url","created_at","updated_at","routing_decision","provider_configuration_id","provider_priority","checkout_url","converted_amount","converted_currency","fx_rate","fx_rate_at","needs_review","review_reason","merchant_reference","description","metadata","customer_email","customer_name","customer_locale","beneficiary_id","beneficiary","save_method","saved_method_id","capture_mode","authorized_at","captured_amount","captured_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19,\$20,\$21,\$22,\$23,\$24,\$25,\$26,\$27,\$28,\$29,\$30,\$31,\$32,\$33,\$34,\$35,\$36,\$37,\$38\) RETURNING "id"$`).
 		WithArgs(
 			float64(100),     // Amount
 			"DEPOSIT",        // PaymentType
 			"INITIALIZED",    // Status
 			"USD",            // CurrencyCode
+			"US",             // CountryCode
 			1,                // UserID
+			1,                // MerchantID
 			1,                // ProviderID
 			"",               // ExternalID
+			"",               // SuccessURL
+			"",               // FailureURL
+			"",               // CancelURL
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // RoutingDecision
+			sqlmock.AnyArg(), // ProviderConfigurationID
+			sqlmock.AnyArg(), // ProviderPriority
+			sqlmock.AnyArg(), // CheckoutURL
+			nil,              // ConvertedAmount
+			"",               // ConvertedCurrency
+			nil,              // FXRate
+			nil,              // FXRateAt
+			false,            // NeedsReview
+			"",               // ReviewReason
+			"",               // MerchantReference
+			"",               // Description
+			sqlmock.AnyArg(), // Metadata
+			"",               // Customer.Email
+			"",               // Customer.Name
+			"",               // Customer.Locale
+			nil,              // BeneficiaryID
+			nil,              // Beneficiary
+			false,            // SaveMethod
+			nil,              // SavedMethodID
+			"automatic",      // CaptureMode
+			nil,              // AuthorizedAt
+			nil,              // CapturedAmount
+			nil,              // CapturedAt
 		).
 		WillReturnRows(sqlRows)
-	mock.ExpectExec(`^UPDATE "payments" SET "amount"=\$1,"payment_type"=\$2,"status"=\$3,"currency_code"=\$4,"user_id"=\$5,"provider_id"=\$6,"external_id"=\$7,"created_at"=\$8,"updated_at"=\$9 WHERE "id" = \$10$`).
-		WithArgs(
-			float64(100),     // Amount
-			"DEPOSIT",        // PaymentType
-			"PENDING",        // Status
-			"USD",            // CurrencyCode
-			1,                // UserID
-			1,                // ProviderID
-			"external-id",    // ExternalID
-			sqlmock.AnyArg(), // CreatedAt
-			sqlmock.AnyArg(), // UpdatedAt
-			"1",              // ID
-		).
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "checkout_url"=\$1,"external_id"=\$2,"status"=\$3,"updated_at"=\$4 WHERE "id" = \$5$`).
+		WithArgs("http://payment.url", "external-id", "PENDING", sqlmock.AnyArg(), "1").
 		WillReturnResult(sqlmock.NewResult(1, 1))
-
 	mock.ExpectCommit()
 
 	// Mock expectations for adapter and provider service
 	mockAdapter := new(MockProviderAdapter)
-	mockAdapter.On("GetDetails", context.TODO(), float64(100), "DEPOSIT", "USD", "US").Return("http://payment.url", "external-id", nil)
-	adapterFactory.On("GetAdapter", context.TODO(), "USD", "US").Return(mockAdapter, nil)
-	providerSvc.On("FindProviderConfig", context.TODO(), "USD", "US").Return(&provider.ProviderConfiguration{
-		ProviderID: 1,
-	}, nil)
+	mockAdapter.On("GetDetails", paymentCtx("1"), float64(100), "DEPOSIT", "USD", "US", provider.PaymentDetails{}).Return("http://payment.url", "external-id", nil)
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
 
 	// Call the method under test
-	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
 		UserID:       1,
 		Amount:       float64(100),
 		CurrencyCode: "USD",
@@ -105,38 +150,66 @@ func TestCreatePayment_Failure_Insert(t *testing.T) {
 	// Setup mock dependencies
 	providerSvc := new(MockProviderService)
 	adapterFactory := new(MockAdapterFactory)
-	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
 
 	// Setup expectations for SQL queries
 	mock.ExpectBegin()
-	mock.ExpectQuery(`^INSERT INTO "payments" \("amount","payment_type","status","currency_code","user_id","provider_id","external_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\) RETURNING "id"$`).
+	mock.ExpectQuery(`^INSERT INTO "payments" \("amount","payment_type","status","currency_code","country_code","user_id","merchant_id","provider_id","external_id","success_url","failure_url","cancel_url","created_at","updated_at","routing_decision","provider_configuration_id","provider_priority","checkout_url","converted_amount","converted_currency","fx_rate","fx_rate_at","needs_review","review_reason","merchant_reference","description","metadata","customer_email","customer_name","customer_locale","beneficiary_id","beneficiary","save_method","saved_method_id","capture_mode","authorized_at","captured_amount","captured_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19,\$20,\$21,\$22,\$23,\$24,\$25,\$26,\$27,\$28,\$29,\$30,\$31,\$32,\$33,\$34,\$35,\$36,\$37,\$38\) RETURNING "id"$`).
 		WithArgs(
 			float64(100),     // Amount
 			"DEPOSIT",        // PaymentType
 			"INITIALIZED",    // Status
 			"USD",            // CurrencyCode
+			"US",             // CountryCode
 			1,                // UserID
+			1,                // MerchantID
 			1,                // ProviderID
 			"",               // ExternalID
+			"",               // SuccessURL
+			"",               // FailureURL
+			"",               // CancelURL
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // RoutingDecision
+			sqlmock.AnyArg(), // ProviderConfigurationID
+			sqlmock.AnyArg(), // ProviderPriority
+			sqlmock.AnyArg(), // CheckoutURL
+			nil,              // ConvertedAmount
+			"",               // ConvertedCurrency
+			nil,              // FXRate
+			nil,              // FXRateAt
+			false,            // NeedsReview
+			"",               // ReviewReason
+			"",               // MerchantReference
+			"",               // Description
+			sqlmock.AnyArg(), // Metadata
+			"",               // Customer.Email
+			"",               // Customer.Name
+			"",               // Customer.Locale
+			nil,              // BeneficiaryID
+			nil,              // Beneficiary
+			false,            // SaveMethod
+			nil,              // SavedMethodID
+			"automatic",      // CaptureMode
+			nil,              // AuthorizedAt
+			nil,              // CapturedAmount
+			nil,              // CapturedAt
 		).
 		WillReturnError(fmt.Errorf("insert error"))
 
 	mock.ExpectRollback()
 
 	// Setup mock expectations for provider service
-	providerSvc.On("FindProviderConfig", context.TODO(), "USD", "US").Return(&provider.ProviderConfiguration{
-		ProviderID: 1,
-	}, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
 
 	// Setup mock expectations for adapter
 	mockAdapter := new(MockProviderAdapter)
-	adapterFactory.On("GetAdapter", context.TODO(), "USD", "US").Return(mockAdapter, nil)
-	mockAdapter.On("GetDetails", context.TODO(), float64(100), "DEPOSIT", "USD", "US").Return("", "", nil)
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
 
 	// Call the method under test
-	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
 		UserID:       1,
 		Amount:       float64(100),
 		CurrencyCode: "USD",
@@ -155,13 +228,13 @@ func TestCreatePayment_Failure_FindProviderConfig(t *testing.T) {
 	// Setup mock dependencies
 	providerSvc := new(MockProviderService)
 	adapterFactory := new(MockAdapterFactory)
-	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
 
-	// Setup mock to return an error for FindProviderConfig
-	providerSvc.On("FindProviderConfig", context.TODO(), "USD", "US").Return(nil, fmt.Errorf("find provider config error"))
+	// Setup mock to return an error for FindProviderConfigs
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return([]provider.ProviderConfiguration(nil), fmt.Errorf("find provider config error"))
 
 	// Call the method under test
-	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
 		UserID:       1,
 		Amount:       float64(100),
 		CurrencyCode: "USD",
@@ -179,16 +252,16 @@ func TestCreatePayment_Failure_GetAdapter(t *testing.T) {
 	// Setup mock dependencies
 	providerSvc := new(MockProviderService)
 	adapterFactory := new(MockAdapterFactory)
-	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
 
 	// Setup mock expectations
-	providerSvc.On("FindProviderConfig", context.TODO(), "USD", "US").Return(&provider.ProviderConfiguration{
-		ProviderID: 1,
-	}, nil)
-	adapterFactory.On("GetAdapter", context.TODO(), "USD", "US").Return(nil, fmt.Errorf("get adapter error"))
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(nil, fmt.Errorf("get adapter error"))
 
 	// Call the method under test
-	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
 		UserID:       1,
 		Amount:       float64(100),
 		CurrencyCode: "USD",
@@ -200,24 +273,34 @@ func TestCreatePayment_Failure_GetAdapter(t *testing.T) {
 }
 
 func TestCreatePayment_Failure_GetDetails(t *testing.T) {
-	gormDB, _, teardown := setupTest(t)
+	gormDB, mock, teardown := setupTest(t)
 	defer teardown()
 
 	// Setup mock dependencies
 	providerSvc := new(MockProviderService)
 	adapterFactory := new(MockAdapterFactory)
-	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
 
 	// Setup mock expectations
-	providerSvc.On("FindProviderConfig", context.TODO(), "USD", "US").Return(&provider.ProviderConfiguration{
-		ProviderID: 1,
-	}, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
 	mockAdapter := new(MockProviderAdapter)
-	adapterFactory.On("GetAdapter", context.TODO(), "USD", "US").Return(mockAdapter, nil)
-	mockAdapter.On("GetDetails", context.TODO(), float64(100), "DEPOSIT", "USD", "US").Return("", "", fmt.Errorf("get details error"))
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
+	mockAdapter.On("GetDetails", paymentCtx("1"), float64(100), "DEPOSIT", "USD", "US", provider.PaymentDetails{}).Return("", "", fmt.Errorf("get details error"))
+
+	// The payment is saved before the provider is called, and fails without a checkout URL
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments" `).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "status"=\$1,"updated_at"=\$2 WHERE status = \$3 AND "id" = \$4$`).
+		WithArgs("FAILED", sqlmock.AnyArg(), "INITIALIZED", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	// Call the method under test
-	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
 		UserID:       1,
 		Amount:       float64(100),
 		CurrencyCode: "USD",
@@ -226,6 +309,7 @@ func TestCreatePayment_Failure_GetDetails(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestCreatePayment_Failure_Update(t *testing.T) {
@@ -235,50 +319,70 @@ func TestCreatePayment_Failure_Update(t *testing.T) {
 	// Setup mock dependencies
 	providerSvc := new(MockProviderService)
 	adapterFactory := new(MockAdapterFactory)
-	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
 
 	// Setup mock expectations
 	sqlRows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 	mock.ExpectBegin()
-	mock.ExpectQuery(`^INSERT INTO "payments" \("amount","payment_type","status","currency_code","user_id","provider_id","external_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\) RETURNING "id"$`).
+	mock.ExpectQuery(`^INSERT INTO "payments" \("amount","payment_type","status","currency_code","country_code","user_id","merchant_id","provider_id","external_id","success_url","failure_url","cancel_url","created_at","updated_at","routing_decision","provider_configuration_id","provider_priority","checkout_url","converted_amount","converted_currency","fx_rate","fx_rate_at","needs_review","review_reason","merchant_reference","description","metadata","customer_email","customer_name","customer_locale","beneficiary_id","beneficiary","save_method","saved_method_id","capture_mode","authorized_at","captured_amount","captured_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19,\$20,\$21,\$22,\$23,\$24,\$25,\$26,\$27,\$28,\$29,\$30,\$31,\$32,\$33,\$34,\$35,\$36,\$37,\$38\) RETURNING "id"$`).
 		WithArgs(
 			float64(100),     // Amount
 			"DEPOSIT",        // PaymentType
 			"INITIALIZED",    // Status
 			"USD",            // CurrencyCode
+			"US",             // CountryCode
 			1,                // UserID
+			1,                // MerchantID
 			1,                // ProviderID
 			"",               // ExternalID
+			"",               // SuccessURL
+			"",               // FailureURL
+			"",               // CancelURL
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // RoutingDecision
+			sqlmock.AnyArg(), // ProviderConfigurationID
+			sqlmock.AnyArg(), // ProviderPriority
+			sqlmock.AnyArg(), // CheckoutURL
+			nil,              // ConvertedAmount
+			"",               // ConvertedCurrency
+			nil,              // FXRate
+			nil,              // FXRateAt
+			false,            // NeedsReview
+			"",               // ReviewReason
+			"",               // MerchantReference
+			"",               // Description
+			sqlmock.AnyArg(), // Metadata
+			"",               // Customer.Email
+			"",               // Customer.Name
+			"",               // Customer.Locale
+			nil,              // BeneficiaryID
+			nil,              // Beneficiary
+			false,            // SaveMethod
+			nil,              // SavedMethodID
+			"automatic",      // CaptureMode
+			nil,              // AuthorizedAt
+			nil,              // CapturedAmount
+			nil,              // CapturedAt
 		).
 		WillReturnRows(sqlRows)
-	mock.ExpectExec(`^UPDATE "payments" SET "amount"=\$1,"payment_type"=\$2,"status"=\$3,"currency_code"=\$4,"user_id"=\$5,"provider_id"=\$6,"external_id"=\$7,"created_at"=\$8,"updated_at"=\$9 WHERE "id" = \$10$`).
-		WithArgs(
-			float64(100),     // Amount
-			"DEPOSIT",        // PaymentType
-			"PENDING",        // Status
-			"USD",            // CurrencyCode
-			1,                // UserID
-			1,                // ProviderID
-			"external-id",    // ExternalID
-			sqlmock.AnyArg(), // CreatedAt
-			sqlmock.AnyArg(), // UpdatedAt
-			"1",              // ID
-		).
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "checkout_url"=\$1,"external_id"=\$2,"status"=\$3,"updated_at"=\$4 WHERE "id" = \$5$`).
+		WithArgs("http://payment.url", "external-id", "PENDING", sqlmock.AnyArg(), "1").
 		WillReturnError(fmt.Errorf("update error"))
 	mock.ExpectRollback()
 
 	// Setup mock expectations for provider service and adapter
 	mockAdapter := new(MockProviderAdapter)
-	mockAdapter.On("GetDetails", context.TODO(), float64(100), "DEPOSIT", "USD", "US").Return("http://payment.url", "external-id", nil)
-	adapterFactory.On("GetAdapter", context.TODO(), "USD", "US").Return(mockAdapter, nil)
-	providerSvc.On("FindProviderConfig", context.TODO(), "USD", "US").Return(&provider.ProviderConfiguration{
-		ProviderID: 1,
-	}, nil)
+	mockAdapter.On("GetDetails", paymentCtx("1"), float64(100), "DEPOSIT", "USD", "US", provider.PaymentDetails{}).Return("http://payment.url", "external-id", nil)
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
 
 	// Call the method under test
-	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
 		UserID:       1,
 		Amount:       float64(100),
 		CurrencyCode: "USD",
@@ -297,17 +401,46 @@ func TestUpdatePayment_Success(t *testing.T) {
 	// Setup mock expectations
 	sqlmock.NewRows([]string{"id"}).AddRow(1)
 	mock.ExpectBegin()
-	mock.ExpectExec(`^UPDATE "payments" SET "amount"=\$1,"payment_type"=\$2,"status"=\$3,"currency_code"=\$4,"user_id"=\$5,"provider_id"=\$6,"external_id"=\$7,"created_at"=\$8,"updated_at"=\$9 WHERE "id" = \$10$`).
+	mock.ExpectExec(`^UPDATE "payments" SET "amount"=\$1,"payment_type"=\$2,"status"=\$3,"currency_code"=\$4,"country_code"=\$5,"user_id"=\$6,"merchant_id"=\$7,"provider_id"=\$8,"external_id"=\$9,"success_url"=\$10,"failure_url"=\$11,"cancel_url"=\$12,"created_at"=\$13,"updated_at"=\$14,"routing_decision"=\$15,"provider_configuration_id"=\$16,"provider_priority"=\$17,"checkout_url"=\$18,"converted_amount"=\$19,"converted_currency"=\$20,"fx_rate"=\$21,"fx_rate_at"=\$22,"needs_review"=\$23,"review_reason"=\$24,"merchant_reference"=\$25,"description"=\$26,"metadata"=\$27,"customer_email"=\$28,"customer_name"=\$29,"customer_locale"=\$30,"beneficiary_id"=\$31,"beneficiary"=\$32,"save_method"=\$33,"saved_method_id"=\$34,"capture_mode"=\$35,"authorized_at"=\$36,"captured_amount"=\$37,"captured_at"=\$38 WHERE "id" = \$39$`).
 		WithArgs(
 			100.0,            // Amount
 			"DEPOSIT",        // PaymentType
 			"PENDING",        // Status
 			"USD",            // CurrencyCode
+			"US",             // CountryCode
 			1,                // UserID
+			1,                // MerchantID
 			1,                // ProviderID
 			"external-id",    // ExternalID
+			"",               // SuccessURL
+			"",               // FailureURL
+			"",               // CancelURL
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // RoutingDecision
+			sqlmock.AnyArg(), // ProviderConfigurationID
+			sqlmock.AnyArg(), // ProviderPriority
+			sqlmock.AnyArg(), // CheckoutURL
+			nil,              // ConvertedAmount
+			"",               // ConvertedCurrency
+			nil,              // FXRate
+			nil,              // FXRateAt
+			false,            // NeedsReview
+			"",               // ReviewReason
+			"",               // MerchantReference
+			"",               // Description
+			sqlmock.AnyArg(), // Metadata
+			"",               // Customer.Email
+			"",               // Customer.Name
+			"",               // Customer.Locale
+			nil,              // BeneficiaryID
+			nil,              // Beneficiary
+			false,            // SaveMethod
+			nil,              // SavedMethodID
+			"",               // CaptureMode
+			nil,              // AuthorizedAt
+			nil,              // CapturedAmount
+			nil,              // CapturedAt
 			"1",              // ID
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
@@ -320,7 +453,9 @@ func TestUpdatePayment_Success(t *testing.T) {
 		PaymentType:  "DEPOSIT",
 		Status:       "PENDING",
 		CurrencyCode: "USD",
+		CountryCode:  "US",
 		UserID:       1,
+		MerchantID:   1,
 		ProviderID:   1,
 		ExternalID:   "external-id",
 		CreatedAt:    time.Now(),
@@ -328,7 +463,7 @@ func TestUpdatePayment_Success(t *testing.T) {
 	}
 
 	// Setup the payment service
-	paymentService := NewPaymentService(gormDB, nil, nil)
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
 
 	// Call the method under test
 	err := paymentService.UpdatePayment(payment)
@@ -346,17 +481,46 @@ func TestUpdatePayment_Failure(t *testing.T) {
 
 	// Setup mock expectations for a failure scenario
 	mock.ExpectBegin()
-	mock.ExpectExec(`^UPDATE "payments" SET "amount"=\$1,"payment_type"=\$2,"status"=\$3,"currency_code"=\$4,"user_id"=\$5,"provider_id"=\$6,"external_id"=\$7,"created_at"=\$8,"updated_at"=\$9 WHERE "id" = \$10$`).
+	mock.ExpectExec(`^UPDATE "payments" SET "amount"=\$1,"payment_type"=\$2,"status"=\$3,"currency_code"=\$4,"country_code"=\$5,"user_id"=\$6,"merchant_id"=\$7,"provider_id"=\$8,"external_id"=\$9,"success_url"=\$10,"failure_url"=\$11,"cancel_url"=\$12,"created_at"=\$13,"updated_at"=\$14,"routing_decision"=\$15,"provider_configuration_id"=\$16,"provider_priority"=\$17,"checkout_url"=\$18,"converted_amount"=\$19,"converted_currency"=\$20,"fx_rate"=\$21,"fx_rate_at"=\$22,"needs_review"=\$23,"review_reason"=\$24,"merchant_reference"=\$25,"description"=\$26,"metadata"=\$27,"customer_email"=\$28,"customer_name"=\$29,"customer_locale"=\$30,"beneficiary_id"=\$31,"beneficiary"=\$32,"save_method"=\$33,"saved_method_id"=\$34,"capture_mode"=\$35,"authorized_at"=\$36,"captured_amount"=\$37,"captured_at"=\$38 WHERE "id" = \$39$`).
 		WithArgs(
 			100.0,            // Amount
 			"DEPOSIT",        // PaymentType
 			"PENDING",        // Status
 			"USD",            // CurrencyCode
+			"US",             // CountryCode
 			1,                // UserID
+			1,                // MerchantID
 			1,                // ProviderID
 			"external-id",    // ExternalID
+			"",               // SuccessURL
+			"",               // FailureURL
+			"",               // CancelURL
 			sqlmock.AnyArg(), // CreatedAt
 			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // RoutingDecision
+			sqlmock.AnyArg(), // ProviderConfigurationID
+			sqlmock.AnyArg(), // ProviderPriority
+			sqlmock.AnyArg(), // CheckoutURL
+			nil,              // ConvertedAmount
+			"",               // ConvertedCurrency
+			nil,              // FXRate
+			nil,              // FXRateAt
+			false,            // NeedsReview
+			"",               // ReviewReason
+			"",               // MerchantReference
+			"",               // Description
+			sqlmock.AnyArg(), // Metadata
+			"",               // Customer.Email
+			"",               // Customer.Name
+			"",               // Customer.Locale
+			nil,              // BeneficiaryID
+			nil,              // Beneficiary
+			false,            // SaveMethod
+			nil,              // SavedMethodID
+			"",               // CaptureMode
+			nil,              // AuthorizedAt
+			nil,              // CapturedAmount
+			nil,              // CapturedAt
 			"1",              // ID
 		).
 		WillReturnError(fmt.Errorf("update error"))
@@ -369,7 +533,9 @@ func TestUpdatePayment_Failure(t *testing.T) {
 		PaymentType:  "DEPOSIT",
 		Status:       "PENDING",
 		CurrencyCode: "USD",
+		CountryCode:  "US",
 		UserID:       1,
+		MerchantID:   1,
 		ProviderID:   1,
 		ExternalID:   "external-id",
 		CreatedAt:    time.Now(),
@@ -377,7 +543,7 @@ func TestUpdatePayment_Failure(t *testing.T) {
 	}
 
 	// Setup the payment service
-	paymentService := NewPaymentService(gormDB, nil, nil)
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
 
 	// Call the method under test
 	err := paymentService.UpdatePayment(payment)
@@ -389,30 +555,1803 @@ func TestUpdatePayment_Failure(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-// Mock implementations
-type MockProviderService struct {
-	mock.Mock
+const callbackPaymentQuery = `^SELECT \* FROM "payments" WHERE external_id = \$1 ORDER BY "payments"."id" LIMIT \$2$`
+
+// callbackPaymentRows is the payment HandleCallback reads before completing it
+func callbackPaymentRows(status string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "status", "external_id"}).AddRow("1", status, "external-id")
 }
 
-func (m *MockProviderService) FindProviderConfig(ctx context.Context, currencyCode, countryCode string) (*provider.ProviderConfiguration, error) {
-	args := m.Called(ctx, currencyCode, countryCode)
-	return args.Get(0).(*provider.ProviderConfiguration), args.Error(1)
+func TestHandleCallback_PaymentNotFound(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations for a missing payment
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnError(gorm.ErrRecordNotFound)
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.ErrorIs(t, err, ErrPaymentNotFound)
+	assert.Nil(t, payment)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-type MockAdapterFactory struct {
-	mock.Mock
+func TestHandleCallback_NotPending(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations for a payment that was already completed
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "external_id"}).AddRow("1", "SUCCESS", "external-id")
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(callbackPaymentRows("SUCCESS"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(sqlRows)
+	mock.ExpectRollback()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusFailed)
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Nil(t, payment)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func (m *MockAdapterFactory) GetAdapter(ctx context.Context, currencyCode, countryCode string) (provider.ProviderAdapter, error) {
-	args := m.Called(ctx, currencyCode, countryCode)
-	return args.Get(0).(provider.ProviderAdapter), args.Error(1)
+func TestHandleCallback_SuccessAfterCancellation(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the cancelled payment stays cancelled and is flagged for review
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "external_id"}).AddRow("1", "CANCELLED", "external-id")
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(callbackPaymentRows("CANCELLED"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET .*"status"=\$3,.*"needs_review"=\$23,"review_reason"=\$24,.* WHERE "id" = \$39$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCancelled, payment.Status)
+	assert.True(t, payment.NeedsReview)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-type MockProviderAdapter struct {
-	mock.Mock
+func TestHandleCallback_RepeatedSuccessAfterCancellation(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the payment was flagged by the first callback, the repeat changes nothing
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "external_id", "needs_review", "review_reason"}).
+		AddRow("1", "CANCELLED", "external-id", true, "provider reported success after the payment was cancelled")
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(callbackPaymentRows("CANCELLED"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(sqlRows)
+	mock.ExpectCommit()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCancelled, payment.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func (m *MockProviderAdapter) GetDetails(ctx context.Context, amount float64, paymentType string, currencyCode, countryCode string) (string, string, error) {
-	args := m.Called(ctx, amount, paymentType, currencyCode, countryCode)
-	return args.String(0), args.String(1), args.Error(2)
+const cancelPaymentQuery = `^SELECT \* FROM "payments" WHERE id = \$1 AND merchant_id = \$2 ORDER BY "payments"."id" LIMIT \$3 FOR UPDATE$`
+
+func TestCancelPayment_Pending(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the payment is cancelled and its provider notified
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "currency_code", "country_code", "provider_id", "external_id"}).
+		AddRow(checkoutPaymentID, "PENDING", "USD", "US", 2, "external-id")
+	mock.ExpectBegin()
+	mock.ExpectQuery(cancelPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET .*"status"=\$3,.* WHERE "id" = \$39$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[1]).Return(mockAdapter, nil)
+	mockAdapter.On("Cancel", paymentCtx(checkoutPaymentID), "external-id").Return(nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.CancelPayment(merchantCtx, checkoutPaymentID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCancelled, payment.Status)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelPayment_ProviderUnavailable(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "currency_code", "country_code", "provider_id", "external_id"}).
+		AddRow(checkoutPaymentID, "PENDING", "USD", "US", 1, "external-id")
+	mock.ExpectBegin()
+	mock.ExpectQuery(cancelPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("Cancel", paymentCtx(checkoutPaymentID), "external-id").Return(provider.ErrProviderUnavailable)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// The payment is cancelled even though the provider could not be told
+	payment, err := paymentService.CancelPayment(merchantCtx, checkoutPaymentID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCancelled, payment.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// cancelUnsupportedAdapter is the adapter of a provider that cannot be told about cancellations
+type cancelUnsupportedAdapter struct {
+	provider.ProviderAdapter
+}
+
+func TestCancelPayment_CancelNotSupported(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "currency_code", "country_code", "provider_id", "external_id"}).
+		AddRow(checkoutPaymentID, "PENDING", "USD", "US", 1, "external-id")
+	mock.ExpectBegin()
+	mock.ExpectQuery(cancelPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[0]).Return(&cancelUnsupportedAdapter{ProviderAdapter: new(MockProviderAdapter)}, nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// The payment is cancelled by the gateway alone
+	payment, err := paymentService.CancelPayment(merchantCtx, checkoutPaymentID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCancelled, payment.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelPayment_Initialized(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// A payment that was never sent to a provider is only cancelled locally
+	sqlRows := sqlmock.NewRows([]string{"id", "status"}).AddRow(checkoutPaymentID, "INITIALIZED")
+	mock.ExpectBegin()
+	mock.ExpectQuery(cancelPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	payment, err := paymentService.CancelPayment(merchantCtx, checkoutPaymentID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCancelled, payment.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelPayment_Completed(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	sqlRows := sqlmock.NewRows([]string{"id", "status"}).AddRow(checkoutPaymentID, "SUCCESS")
+	mock.ExpectBegin()
+	mock.ExpectQuery(cancelPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(sqlRows)
+	mock.ExpectRollback()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	payment, err := paymentService.CancelPayment(merchantCtx, checkoutPaymentID)
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Nil(t, payment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expirePaymentsQuery selects the payments to expire, leaving out payouts and payments flagged for review
+const expirePaymentsQuery = `^SELECT \* FROM "payments" WHERE \(status IN \(\$1,\$2\) AND created_at < \$3\) AND \(beneficiary IS NULL AND needs_review = \$4\) FOR UPDATE$`
+
+func TestExpirePayments_DryRun(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the payments are locked and read but not updated
+	before := time.Now().Add(-24 * time.Hour)
+	sqlRows := sqlmock.NewRows([]string{"id", "status"}).AddRow("1", "PENDING")
+	mock.ExpectBegin()
+	mock.ExpectQuery(expirePaymentsQuery).
+		WithArgs(utils.PaymentStatusInitialized, utils.PaymentStatusPending, before, false).
+		WillReturnRows(sqlRows)
+	mock.ExpectCommit()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payments, err := paymentService.ExpirePayments(context.TODO(), before, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, payments, 1)
+	assert.Equal(t, utils.PaymentStatusPending, payments[0].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpirePayments_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the locked payments, payouts and flagged payments left out, are updated to EXPIRED
+	before := time.Now().Add(-24 * time.Hour)
+	sqlRows := sqlmock.NewRows([]string{"id", "status"}).AddRow("1", "PENDING").AddRow("2", "INITIALIZED")
+	mock.ExpectBegin()
+	mock.ExpectQuery(expirePaymentsQuery).
+		WithArgs(utils.PaymentStatusInitialized, utils.PaymentStatusPending, before, false).
+		WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET "status"=\$1,"updated_at"=\$2 WHERE id IN \(\$3,\$4\)$`).
+		WithArgs(utils.PaymentStatusExpired, sqlmock.AnyArg(), "1", "2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payments, err := paymentService.ExpirePayments(context.TODO(), before, false)
+
+	assert.NoError(t, err)
+	assert.Len(t, payments, 2)
+	for _, payment := range payments {
+		assert.Equal(t, utils.PaymentStatusExpired, payment.Status)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListPayments_InvalidID(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test, no query is expected for an ID that is not a UUID
+	payments, err := paymentService.ListPayments(context.TODO(), PaymentFilter{ID: "not-a-uuid"})
+
+	assert.ErrorIs(t, err, ErrPaymentNotFound)
+	assert.Nil(t, payments)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayment_RedirectURLNotAllowed(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations for the merchant and its allowed redirect domains
+	sqlRows := sqlmock.NewRows([]string{"id", "allowed_redirect_domains"}).AddRow(1, "shop.example.com")
+	mock.ExpectQuery(`^SELECT \* FROM "merchants" WHERE "merchants"."id" = \$1 ORDER BY "merchants"."id" LIMIT \$2$`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlRows)
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
+		UserID:       1,
+		Amount:       float64(100),
+		CurrencyCode: "USD",
+		CountryCode:  "US",
+		SuccessURL:   "https://shop.example.com/done",
+		FailureURL:   "https://attacker.example.net/phish",
+	}, utils.PaymentTypeDeposit)
+
+	assert.ErrorIs(t, err, ErrRedirectURLNotAllowed)
+	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayment_NoMerchant(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test, no query is expected without an authenticated merchant
+	url, err := paymentService.CreatePayment(context.TODO(), &PaymentRequest{
+		UserID:       1,
+		Amount:       float64(100),
+		CurrencyCode: "USD",
+		CountryCode:  "US",
+	}, utils.PaymentTypeDeposit)
+
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayment_DuplicateMerchantReference(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations for an existing payment with the reference
+	mock.ExpectQuery(`^SELECT count\(\*\) FROM "payments" WHERE merchant_id = \$1 AND merchant_reference = \$2$`).
+		WithArgs(1, "order-42").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
+		UserID:            1,
+		Amount:            float64(100),
+		CurrencyCode:      "USD",
+		CountryCode:       "US",
+		MerchantReference: "order-42",
+	}, utils.PaymentTypeDeposit)
+
+	assert.ErrorIs(t, err, ErrDuplicateMerchantReference)
+	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertPayment_ConcurrentMerchantReference(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: a concurrent request inserted a payment with the reference first
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments"`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_payments_merchant_reference"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments"`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "payments_pkey"})
+	mock.ExpectRollback()
+
+	err := insertPayment(gormDB, &Payment{MerchantID: 1, MerchantReference: "order-42"})
+	assert.ErrorIs(t, err, ErrDuplicateMerchantReference)
+
+	// Other unique indexes are not about the reference
+	err = insertPayment(gormDB, &Payment{MerchantID: 1, MerchantReference: "order-43"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDuplicateMerchantReference)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindPaymentByReference(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations for the payment of the merchant with the reference
+	sqlRows := sqlmock.NewRows([]string{"id", "merchant_id", "merchant_reference", "metadata"}).
+		AddRow(checkoutPaymentID, 1, "order-42", `{"cart":"42"}`)
+	mock.ExpectQuery(`^SELECT \* FROM "payments" WHERE merchant_id = \$1 AND merchant_reference = \$2 ORDER BY "payments"."id" LIMIT \$3$`).
+		WithArgs(1, "order-42", 1).
+		WillReturnRows(sqlRows)
+	mock.ExpectQuery(`^SELECT \* FROM "payments" WHERE merchant_id = \$1 AND merchant_reference = \$2 ORDER BY "payments"."id" LIMIT \$3$`).
+		WithArgs(1, "unknown", 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.FindPaymentByReference(merchantCtx, "order-42")
+	assert.NoError(t, err)
+	assert.Equal(t, checkoutPaymentID, payment.ID)
+	assert.Equal(t, map[string]interface{}{"cart": "42"}, payment.Metadata)
+
+	_, err = paymentService.FindPaymentByReference(merchantCtx, "unknown")
+	assert.ErrorIs(t, err, ErrPaymentNotFound)
+
+	_, err = paymentService.FindPaymentByReference(context.TODO(), "order-42")
+	assert.ErrorIs(t, err, ErrPaymentNotFound, "lookups without a merchant find nothing")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewPayment(t *testing.T) {
+	req := &PaymentRequest{
+		UserID:            1,
+		Amount:            float64(100),
+		CurrencyCode:      "USD",
+		CountryCode:       "US",
+		MerchantReference: "order-42",
+		Description:       "Order 42",
+		Customer:          Customer{Email: "jane@example.com", Name: "Jane Doe", Locale: "en-GB"},
+	}
+
+	payment := req.newPayment(1, utils.PaymentTypeDeposit, 2)
+
+	assert.Equal(t, "order-42", payment.MerchantReference)
+	assert.NotNil(t, payment.Metadata, "metadata defaults to an empty object")
+	assert.Empty(t, payment.Metadata)
+	assert.Equal(t, provider.PaymentDetails{
+		MerchantReference: "order-42",
+		Description:       "Order 42",
+		CustomerEmail:     "jane@example.com",
+		CustomerName:      "Jane Doe",
+		CustomerLocale:    "en-GB",
+	}, payment.providerDetails())
+}
+
+func TestRedirectURL(t *testing.T) {
+	payment := &Payment{
+		ID:         "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21",
+		SuccessURL: "https://shop.example.com/done?order=42",
+		FailureURL: "https://shop.example.com/failed",
+	}
+
+	assert.Equal(t, "https://shop.example.com/done?id=8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21&order=42&status=successful", RedirectURL(payment, "successful", "http://gateway"))
+	assert.Equal(t, "https://shop.example.com/failed?id=8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21&status=failed", RedirectURL(payment, "failed", "http://gateway"))
+	assert.Equal(t, "https://shop.example.com/failed?id=8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21&status=cancelled", RedirectURL(payment, "cancelled", "http://gateway"), "cancel falls back to the failure URL")
+
+	payment.SuccessURL = ""
+	assert.Equal(t, "http://gateway/payment?status=successful&id=8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21", RedirectURL(payment, "successful", "http://gateway"))
+}
+
+const checkoutPaymentID = "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"
+
+// checkoutPaymentQuery finds the payment of a checkout
+const checkoutPaymentQuery = `^SELECT \* FROM "payments" WHERE id = \$1 ORDER BY "payments"."id" LIMIT \$2$`
+
+// lockedPaymentQuery locks the payment to capture or void
+const lockedPaymentQuery = `^SELECT \* FROM "payments" WHERE id = \$1 ORDER BY "payments"."id" LIMIT \$2 FOR UPDATE$`
+
+// checkoutClaimQuery claims the payment of a checkout if it is still INITIALIZED
+const checkoutClaimQuery = `^UPDATE "payments" SET "status"=\$1,"provider_id"=\$2,"updated_at"=\$3,"routing_decision"=\$4,"provider_configuration_id"=\$5,"provider_priority"=\$6 WHERE status = \$7 AND "id" = \$8$`
+
+func TestStartCheckout_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Setup mock expectations: the payment is claimed, sent to the second provider and updated
+	sqlRows := sqlmock.NewRows([]string{"id", "amount", "payment_type", "status", "currency_code", "country_code", "user_id", "merchant_id", "provider_id"}).
+		AddRow(checkoutPaymentID, 100, "DEPOSIT", "INITIALIZED", "USD", "US", 1, 1, 1)
+	mock.ExpectQuery(checkoutPaymentQuery).WithArgs(checkoutPaymentID, 1).WillReturnRows(sqlRows)
+	mock.ExpectBegin()
+	mock.ExpectExec(checkoutClaimQuery).
+		WithArgs("PENDING", 2, sqlmock.AnyArg(), sqlmock.AnyArg(), 11, 1, "INITIALIZED", checkoutPaymentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "checkout_url"=\$1,"external_id"=\$2,"updated_at"=\$3 WHERE "id" = \$4$`).
+		WithArgs("http://adcb.url", "external-id", sqlmock.AnyArg(), checkoutPaymentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	options := []provider.ProviderConfiguration{{ID: 10, ProviderID: 1, ProviderName: "HSBC"}, {ID: 11, ProviderID: 2, ProviderName: "ADCB", Priority: 1}}
+	providerSvc.On("FindProviderConfigs", context.TODO(), "USD", "US").Return(options, nil)
+	mockAdapter := new(MockProviderAdapter)
+	mockAdapter.On("GetDetails", paymentCtx(checkoutPaymentID), float64(100), "DEPOSIT", "USD", "US", provider.PaymentDetails{}).Return("http://adcb.url", "external-id", nil)
+	adapterFactory.On("AdapterFor", context.TODO(), &options[1]).Return(mockAdapter, nil)
+
+	// Call the method under test
+	url, err := paymentService.StartCheckout(context.TODO(), checkoutPaymentID, 11)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://adcb.url", url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartCheckout_ProviderFailureReleasesPayment(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Setup mock expectations: the payment is claimed, and released once the provider fails
+	sqlRows := sqlmock.NewRows([]string{"id", "amount", "payment_type", "status", "currency_code", "country_code", "user_id", "merchant_id", "provider_id"}).
+		AddRow(checkoutPaymentID, 100, "DEPOSIT", "INITIALIZED", "USD", "US", 1, 1, 1)
+	mock.ExpectQuery(checkoutPaymentQuery).WithArgs(checkoutPaymentID, 1).WillReturnRows(sqlRows)
+	mock.ExpectBegin()
+	mock.ExpectExec(checkoutClaimQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3 AND status = \$4 AND external_id = ''$`).
+		WithArgs("INITIALIZED", sqlmock.AnyArg(), checkoutPaymentID, "PENDING").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	options := []provider.ProviderConfiguration{{ID: 10, ProviderID: 1, ProviderName: "HSBC"}}
+	providerSvc.On("FindProviderConfigs", context.TODO(), "USD", "US").Return(options, nil)
+	mockAdapter := new(MockProviderAdapter)
+	mockAdapter.On("GetDetails", paymentCtx(checkoutPaymentID), float64(100), "DEPOSIT", "USD", "US", provider.PaymentDetails{}).Return("", "", fmt.Errorf("provider unavailable"))
+	adapterFactory.On("AdapterFor", context.TODO(), &options[0]).Return(mockAdapter, nil)
+
+	// Call the method under test
+	url, err := paymentService.StartCheckout(context.TODO(), checkoutPaymentID, 10)
+
+	assert.EqualError(t, err, "provider unavailable")
+	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartCheckout_ClaimedConcurrently(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Setup mock expectations: another checkout claimed the payment first, the provider is not called
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "currency_code", "country_code"}).AddRow(checkoutPaymentID, "INITIALIZED", "USD", "US")
+	mock.ExpectQuery(checkoutPaymentQuery).WithArgs(checkoutPaymentID, 1).WillReturnRows(sqlRows)
+	mock.ExpectBegin()
+	mock.ExpectExec(checkoutClaimQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	options := []provider.ProviderConfiguration{{ID: 10, ProviderID: 1, ProviderName: "HSBC"}}
+	providerSvc.On("FindProviderConfigs", context.TODO(), "USD", "US").Return(options, nil)
+	mockAdapter := new(MockProviderAdapter)
+	adapterFactory.On("AdapterFor", context.TODO(), &options[0]).Return(mockAdapter, nil)
+
+	// Call the method under test
+	url, err := paymentService.StartCheckout(context.TODO(), checkoutPaymentID, 10)
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Empty(t, url)
+	mockAdapter.AssertNotCalled(t, "GetDetails")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartCheckout_NotEligible(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	paymentService := NewPaymentService(gormDB, providerSvc, nil, nil, nil, nil, nil)
+
+	// Setup mock expectations: the picked configuration does not route the payment
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "currency_code", "country_code"}).AddRow(checkoutPaymentID, "INITIALIZED", "USD", "US")
+	mock.ExpectQuery(checkoutPaymentQuery).WithArgs(checkoutPaymentID, 1).WillReturnRows(sqlRows)
+
+	providerSvc.On("FindProviderConfigs", context.TODO(), "USD", "US").Return([]provider.ProviderConfiguration{{ID: 10, ProviderName: "HSBC"}}, nil)
+
+	// Call the method under test
+	url, err := paymentService.StartCheckout(context.TODO(), checkoutPaymentID, 99)
+
+	assert.ErrorIs(t, err, ErrProviderNotEligible)
+	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartCheckout_AlreadyStarted(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations for a payment that was already sent to a provider
+	sqlRows := sqlmock.NewRows([]string{"id", "status"}).AddRow(checkoutPaymentID, "PENDING")
+	mock.ExpectQuery(checkoutPaymentQuery).WithArgs(checkoutPaymentID, 1).WillReturnRows(sqlRows)
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	url, err := paymentService.StartCheckout(context.TODO(), checkoutPaymentID, 10)
+
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Empty(t, url)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// routeConfigs are the provider configurations of a route, by priority
+var routeConfigs = []provider.ProviderConfiguration{
+	{ID: 1, ProviderID: 1, ProviderName: "HSBC", ProviderDisplayName: "HSBC", Priority: 1},
+	{ID: 2, ProviderID: 2, ProviderName: "ADCB", ProviderDisplayName: "Abu Dhabi Commercial Bank", Priority: 2},
+}
+
+// anyRouteRequest matches the request of a payment passed to the router
+var anyRouteRequest = mock.AnythingOfType("routing.Request")
+
+func TestRoutePayment(t *testing.T) {
+	tests := []struct {
+		name         string
+		preferred    string
+		excluded     []string
+		routed       []provider.ProviderConfiguration
+		wantID       uint
+		wantStrategy routing.Strategy
+		wantErr      error
+	}{
+		{name: "routed", routed: routeConfigs, wantID: 1, wantStrategy: routing.StrategyWeighted},
+		{name: "excluded", excluded: []string{"HSBC"}, routed: routeConfigs[1:], wantID: 2, wantStrategy: routing.StrategyWeighted},
+		{name: "preferred", preferred: "adcb", wantID: 2, wantStrategy: routing.StrategyPreferred},
+		{name: "preferred not configured", preferred: "CITI", wantErr: ErrProviderNotAvailable},
+		{name: "preferred excluded", preferred: "ADCB", excluded: []string{"adcb"}, wantErr: ErrProviderNotAvailable},
+		{name: "all excluded", excluded: []string{"HSBC", "ADCB"}, wantErr: ErrAllProvidersExcluded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerSvc := new(MockProviderService)
+			router := new(MockRouter)
+			paymentService := NewPaymentService(nil, providerSvc, nil, router, nil, nil, nil)
+
+			providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+			if tt.routed != nil {
+				router.On("Route", merchantCtx, anyRouteRequest, tt.routed).
+					Return(&tt.routed[0], routing.Decision{Strategy: routing.StrategyWeighted}, nil)
+			}
+
+			providerConfig, decision, err := paymentService.routePayment(merchantCtx, &PaymentRequest{
+				Amount:           float64(100),
+				CurrencyCode:     "USD",
+				CountryCode:      "US",
+				Provider:         tt.preferred,
+				ExcludeProviders: tt.excluded,
+			}, utils.PaymentTypeDeposit, nil)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				router.AssertNotCalled(t, "Route", merchantCtx, anyRouteRequest, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, providerConfig.ID)
+			assert.Equal(t, tt.wantStrategy, decision.Strategy)
+			router.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoutePayment_Converted(t *testing.T) {
+	providerSvc := new(MockProviderService)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(nil, providerSvc, nil, router, nil, nil, nil)
+
+	// A payment converted to INR is routed to the INR providers by its converted amount
+	providerSvc.On("FindProviderConfigs", merchantCtx, "INR", "US").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, routing.Request{CurrencyCode: "INR", CountryCode: "US", PaymentType: utils.PaymentTypeDeposit, Amount: 8312}, routeConfigs).
+		Return(&routeConfigs[0], routing.Decision{Strategy: routing.StrategyWeighted}, nil)
+
+	providerConfig, _, err := paymentService.routePayment(merchantCtx, &PaymentRequest{
+		Amount:             float64(100),
+		CurrencyCode:       "USD",
+		CountryCode:        "US",
+		SettlementCurrency: "INR",
+	}, utils.PaymentTypeDeposit, &fx.Conversion{Amount: 8312, Currency: "INR", Rate: 83.12})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), providerConfig.ID)
+	router.AssertExpectations(t)
+}
+
+func TestConvertPayment(t *testing.T) {
+	quoteID := "9a0f3c1e-3b0c-4f4e-8b1f-0c2b7c1d2e3f"
+	rateAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		request     PaymentRequest
+		setup       func(fxSvc *MockFXService)
+		want        *fx.Conversion
+		wantErrorIs error
+	}{
+		{
+			name:    "same currency",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "USD"},
+		},
+		{
+			name:    "settlement currency equals the currency",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "USD", SettlementCurrency: "usd"},
+		},
+		{
+			name:    "latest rate",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "usd", SettlementCurrency: "inr"},
+			setup: func(fxSvc *MockFXService) {
+				fxSvc.On("Convert", merchantCtx, float64(100), "USD", "INR").Return(&fx.Conversion{Amount: 8312, Currency: "INR", Rate: 83.12, RateAt: rateAt}, nil)
+			},
+			want: &fx.Conversion{Amount: 8312, Currency: "INR", Rate: 83.12, RateAt: rateAt},
+		},
+		{
+			name:    "no rate",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "USD", SettlementCurrency: "JPY"},
+			setup: func(fxSvc *MockFXService) {
+				fxSvc.On("Convert", merchantCtx, float64(100), "USD", "JPY").Return((*fx.Conversion)(nil), fx.ErrRateUnavailable)
+			},
+			wantErrorIs: fx.ErrRateUnavailable,
+		},
+		{
+			name:    "quoted rate",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "USD", FXQuoteID: quoteID},
+			setup: func(fxSvc *MockFXService) {
+				fxSvc.On("FindQuote", merchantCtx, uint(1), quoteID).Return(&fx.Quote{ID: quoteID, FromCurrency: "USD", ToCurrency: "AED", Rate: 3.6725, RateAt: rateAt}, nil)
+			},
+			want: &fx.Conversion{Amount: 367.25, Currency: "AED", Rate: 3.6725, RateAt: rateAt, QuoteID: quoteID},
+		},
+		{
+			name:    "quote of another currency",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "EUR", FXQuoteID: quoteID},
+			setup: func(fxSvc *MockFXService) {
+				fxSvc.On("FindQuote", merchantCtx, uint(1), quoteID).Return(&fx.Quote{ID: quoteID, FromCurrency: "USD", ToCurrency: "AED", Rate: 3.6725, RateAt: rateAt}, nil)
+			},
+			wantErrorIs: ErrQuoteCurrencyMismatch,
+		},
+		{
+			name:    "expired quote",
+			request: PaymentRequest{Amount: 100, CurrencyCode: "USD", FXQuoteID: quoteID},
+			setup: func(fxSvc *MockFXService) {
+				fxSvc.On("FindQuote", merchantCtx, uint(1), quoteID).Return((*fx.Quote)(nil), fx.ErrQuoteExpired)
+			},
+			wantErrorIs: fx.ErrQuoteExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fxSvc := new(MockFXService)
+			if tt.setup != nil {
+				tt.setup(fxSvc)
+			}
+			paymentService := NewPaymentService(nil, nil, nil, nil, fxSvc, nil, nil)
+
+			conversion, err := paymentService.convertPayment(merchantCtx, 1, &tt.request)
+
+			if tt.wantErrorIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrorIs)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, conversion)
+			fxSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSavePayment_Quote(t *testing.T) {
+	quoteID := "9a0f3c1e-3b0c-4f4e-8b1f-0c2b7c1d2e3f"
+	useQuery := `^UPDATE "fx_quotes" SET "used_at"=\$1 WHERE id = \$2 AND merchant_id = \$3 AND used_at IS NULL AND expires_at > \$4$`
+	insertErr := fmt.Errorf("insert error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		wantErrorIs error
+	}{
+		{
+			name: "quote used with the insert",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(useQuery).WithArgs(sqlmock.AnyArg(), quoteID, 1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`^INSERT INTO "payments" `).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "quote used concurrently",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(useQuery).WithArgs(sqlmock.AnyArg(), quoteID, 1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErrorIs: fx.ErrQuoteUsed,
+		},
+		{
+			name: "insert failed",
+			setup: func(mock sqlmock.Sqlmock) {
+				// The rollback leaves the quote unused
+				mock.ExpectBegin()
+				mock.ExpectExec(useQuery).WithArgs(sqlmock.AnyArg(), quoteID, 1, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`^INSERT INTO "payments" `).WillReturnError(insertErr)
+				mock.ExpectRollback()
+			},
+			wantErrorIs: insertErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+			tt.setup(mock)
+
+			paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+			payment := &Payment{MerchantID: 1, Amount: 100, CurrencyCode: "USD"}
+			conversion := &fx.Conversion{Amount: 367.25, Currency: "AED", Rate: 3.6725, RateAt: time.Now(), QuoteID: quoteID}
+			payment.applyConversion(conversion)
+
+			err := paymentService.savePayment(merchantCtx, payment, conversion)
+
+			if tt.wantErrorIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrorIs)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPaymentSettlement(t *testing.T) {
+	payment := &Payment{Amount: 100, CurrencyCode: "USD"}
+	assert.Equal(t, 100.0, payment.SettlementAmount())
+	assert.Equal(t, "USD", payment.SettlementCurrency())
+
+	payment.applyConversion(&fx.Conversion{Amount: 8312, Currency: "INR", Rate: 83.12, RateAt: time.Now()})
+	assert.Equal(t, 8312.0, payment.SettlementAmount())
+	assert.Equal(t, "INR", payment.SettlementCurrency())
+	assert.Equal(t, 100.0, payment.Amount, "the original amount is kept")
+	assert.Equal(t, 83.12, *payment.FXRate)
+	assert.Equal(t, 8312.0, payment.SettledAmount())
+
+	captured := 50.0
+	payment.CapturedAmount = &captured
+	assert.Equal(t, 4156.0, payment.SettledAmount(), "a partial capture is converted at the rate of the payment")
+}
+
+func TestCreatePayment_ProviderNotAvailable(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+
+	// Call the method under test
+	url, err := paymentService.CreatePayment(merchantCtx, &PaymentRequest{
+		UserID:       1,
+		Amount:       float64(100),
+		CurrencyCode: "USD",
+		CountryCode:  "US",
+		Provider:     "CITI",
+	}, utils.PaymentTypeDeposit)
+
+	assert.ErrorIs(t, err, ErrProviderNotAvailable)
+	assert.Empty(t, url)
+	adapterFactory.AssertNotCalled(t, "AdapterFor", merchantCtx, anyProviderConfig)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentMethods(t *testing.T) {
+	providerSvc := new(MockProviderService)
+	paymentService := NewPaymentService(nil, providerSvc, nil, nil, nil, nil, nil)
+
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "EUR", "US").Return([]provider.ProviderConfiguration(nil), provider.ErrNoRouteForCurrencyCountry)
+
+	methods, err := paymentService.PaymentMethods(merchantCtx, "USD", "US")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []PaymentMethod{
+		{Provider: "HSBC", DisplayName: "HSBC", Priority: 1},
+		{Provider: "ADCB", DisplayName: "Abu Dhabi Commercial Bank", Priority: 2},
+	}, methods)
+
+	methods, err = paymentService.PaymentMethods(merchantCtx, "EUR", "US")
+
+	assert.NoError(t, err)
+	assert.Empty(t, methods, "a currency and country without providers has no payment methods")
+}
+
+// Mock implementations
+// payoutRequest pays out 100 GBP to a GB bank account
+var payoutRequest = PayoutRequest{
+	PaymentRequest: PaymentRequest{UserID: 1, Amount: float64(100), CurrencyCode: "GBP", CountryCode: "GB"},
+	Beneficiary:    &bankaccount.BankAccount{HolderName: "Jane Doe", IBAN: "gb82 west 1234 5698 7654 32"},
+}
+
+const payoutPendingUpdate = `^UPDATE "payments" SET "external_id"=\$1,"status"=\$2,"updated_at"=\$3 WHERE "id" = \$4$`
+
+func TestCreatePayout_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	router := new(MockRouter)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
+
+	// Setup mock expectations: the payout is saved with the masked beneficiary, sent and set pending
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments" .* RETURNING "id"$`).
+		WithArgs(
+			float64(100),     // Amount
+			"WITHDRAWAL",     // PaymentType
+			"INITIALIZED",    // Status
+			"GBP",            // CurrencyCode
+			"GB",             // CountryCode
+			1,                // UserID
+			1,                // MerchantID
+			1,                // ProviderID
+			"",               // ExternalID
+			"",               // SuccessURL
+			"",               // FailureURL
+			"",               // CancelURL
+			sqlmock.AnyArg(), // CreatedAt
+			sqlmock.AnyArg(), // UpdatedAt
+			sqlmock.AnyArg(), // RoutingDecision
+			sqlmock.AnyArg(), // ProviderConfigurationID
+			sqlmock.AnyArg(), // ProviderPriority
+			"",               // CheckoutURL
+			nil,              // ConvertedAmount
+			"",               // ConvertedCurrency
+			nil,              // FXRate
+			nil,              // FXRateAt
+			false,            // NeedsReview
+			"",               // ReviewReason
+			"",               // MerchantReference
+			"",               // Description
+			sqlmock.AnyArg(), // Metadata
+			"",               // Customer.Email
+			"",               // Customer.Name
+			"",               // Customer.Locale
+			nil,              // BeneficiaryID
+			`{"holder_name":"Jane Doe","iban":"GB****************5432"}`, // Beneficiary
+			false,       // SaveMethod
+			nil,         // SavedMethodID
+			"automatic", // CaptureMode
+			nil,         // AuthorizedAt
+			nil,         // CapturedAmount
+			nil,         // CapturedAt
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(payoutPendingUpdate).
+		WithArgs("external-id", "PENDING", sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	mockAdapter := new(MockProviderAdapter)
+	mockAdapter.On("Payout", paymentCtx("1"), provider.PayoutDetails{
+		Amount:         float64(100),
+		CurrencyCode:   "GBP",
+		CountryCode:    "GB",
+		IdempotencyKey: "1",
+		Beneficiary:    bankaccount.BankAccount{HolderName: "Jane Doe", IBAN: "GB82WEST12345698765432"},
+	}).Return("external-id", nil)
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "GBP", "GB").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
+
+	// Call the method under test
+	request := payoutRequest
+	payment, err := paymentService.CreatePayout(merchantCtx, &request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusPending, payment.Status)
+	assert.Equal(t, "external-id", payment.ExternalID)
+	assert.Equal(t, "GB****************5432", payment.Beneficiary.IBAN)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayout_SendFailed(t *testing.T) {
+	tests := []struct {
+		name        string
+		sendErr     error
+		update      string
+		wantStatus  utils.PaymentStatus
+		wantsReview bool
+	}{
+		{"rejected", provider.ErrProviderRejected, `^UPDATE "payments" SET "status"=\$1,"updated_at"=\$2 WHERE "id" = \$3$`, utils.PaymentStatusFailed, false},
+		{"outcome unknown", provider.ErrProviderUnavailable, `^UPDATE "payments" SET "needs_review"=\$1,"review_reason"=\$2,"updated_at"=\$3 WHERE "id" = \$4$`, utils.PaymentStatusInitialized, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			providerSvc := new(MockProviderService)
+			adapterFactory := new(MockAdapterFactory)
+			router := new(MockRouter)
+			paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, nil, nil)
+
+			// Setup mock expectations: the payout is saved before it is sent, then the failure is recorded
+			mock.ExpectBegin()
+			mock.ExpectQuery(`^INSERT INTO "payments" .* RETURNING "id"$`).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(tt.update).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			mockAdapter := new(MockProviderAdapter)
+			mockAdapter.On("Payout", paymentCtx("1"), anyPayoutDetails).Return("", tt.sendErr)
+			adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
+			providerSvc.On("FindProviderConfigs", merchantCtx, "GBP", "GB").Return(routeConfigs, nil)
+			router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
+
+			// Call the method under test
+			request := payoutRequest
+			payment, err := paymentService.CreatePayout(merchantCtx, &request)
+
+			assert.Nil(t, payment)
+			assert.ErrorIs(t, err, tt.sendErr)
+			sent := mockAdapter.Calls[0].Arguments.Get(1).(provider.PayoutDetails)
+			assert.Equal(t, "1", sent.IdempotencyKey)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreatePayout_InvalidBeneficiary(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// A US account is identified by an account number and a routing number, not an IBAN
+	request := payoutRequest
+	request.CountryCode = "US"
+	request.CurrencyCode = "USD"
+	payment, err := paymentService.CreatePayout(merchantCtx, &request)
+
+	assert.Nil(t, payment)
+	assert.ErrorIs(t, err, bankaccount.ErrInvalidAccountNumber)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayout_SavedBeneficiary(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	router := new(MockRouter)
+	beneficiaries := new(MockBeneficiaryService)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, router, nil, beneficiaries, nil)
+
+	// Setup mock expectations: the payout is sent to the decrypted account of the saved beneficiary
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments" .* RETURNING "id"$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(payoutPendingUpdate).
+		WithArgs("external-id", "PENDING", sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	account := &bankaccount.BankAccount{HolderName: "Jane Doe", IBAN: "GB82WEST12345698765432"}
+	beneficiaries.On("AccountForPayout", merchantCtx, checkoutPaymentID, 1, "GB").Return(account, nil)
+	mockAdapter := new(MockProviderAdapter)
+	mockAdapter.On("Payout", paymentCtx("1"), anyPayoutDetails).Return("external-id", nil)
+	adapterFactory.On("AdapterFor", merchantCtx, anyProviderConfig).Return(mockAdapter, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "GBP", "GB").Return(routeConfigs, nil)
+	router.On("Route", merchantCtx, anyRouteRequest, routeConfigs).Return(&routeConfigs[0], routing.Decision{}, nil)
+
+	// Call the method under test
+	request := payoutRequest
+	request.Beneficiary = nil
+	request.BeneficiaryID = checkoutPaymentID
+	payment, err := paymentService.CreatePayout(merchantCtx, &request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, checkoutPaymentID, *payment.BeneficiaryID)
+	assert.Equal(t, "GB****************5432", payment.Beneficiary.IBAN)
+	assert.Equal(t, *account, mockAdapter.Calls[0].Arguments.Get(1).(provider.PayoutDetails).Beneficiary)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayout_UnverifiedBeneficiary(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	beneficiaries := new(MockBeneficiaryService)
+	beneficiaries.On("AccountForPayout", merchantCtx, checkoutPaymentID, 1, "GB").Return((*bankaccount.BankAccount)(nil), beneficiary.ErrBeneficiaryNotVerified)
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, beneficiaries, nil)
+
+	// Call the method under test
+	request := payoutRequest
+	request.Beneficiary = nil
+	request.BeneficiaryID = checkoutPaymentID
+	payment, err := paymentService.CreatePayout(merchantCtx, &request)
+
+	assert.Nil(t, payment)
+	assert.ErrorIs(t, err, beneficiary.ErrBeneficiaryNotVerified)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckPayout(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	beneficiaries := new(MockBeneficiaryService)
+	paymentService := NewPaymentService(gormDB, providerSvc, nil, nil, nil, beneficiaries, nil)
+
+	providerSvc.On("FindProviderConfigs", merchantCtx, "GBP", "GB").Return(routeConfigs, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "JPY", "GB").Return([]provider.ProviderConfiguration(nil), provider.ErrNoRouteForCurrencyCountry)
+	beneficiaries.On("AccountForPayout", merchantCtx, checkoutPaymentID, 1, "GB").Return((*bankaccount.BankAccount)(nil), beneficiary.ErrBeneficiaryNotVerified)
+
+	request := payoutRequest
+	assert.NoError(t, paymentService.CheckPayout(merchantCtx, &request))
+
+	// A payout converted to a currency no provider pays out in the country cannot be sent
+	request.SettlementCurrency = "jpy"
+	assert.ErrorIs(t, paymentService.CheckPayout(merchantCtx, &request), provider.ErrNoRouteForCurrencyCountry)
+
+	request = payoutRequest
+	request.Beneficiary = nil
+	request.BeneficiaryID = checkoutPaymentID
+	assert.ErrorIs(t, paymentService.CheckPayout(merchantCtx, &request), beneficiary.ErrBeneficiaryNotVerified)
+
+	// Nothing is sent or saved
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollPayouts(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: of the two pending payouts, the provider settled the first one
+	pendingSince := time.Now().Add(-5 * time.Minute)
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "payment_type", "currency_code", "country_code", "provider_id", "external_id"}).
+		AddRow("1", "PENDING", "WITHDRAWAL", "GBP", "GB", 1, "payout-1").
+		AddRow("2", "PENDING", "WITHDRAWAL", "GBP", "GB", 1, "payout-2")
+	mock.ExpectQuery(`^SELECT \* FROM "payments" WHERE status = \$1 AND beneficiary IS NOT NULL AND external_id <> '' AND updated_at < \$2 ORDER BY updated_at$`).
+		WithArgs("PENDING", pendingSince).
+		WillReturnRows(sqlRows)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "payments" WHERE external_id = \$1 ORDER BY "payments"."id" LIMIT \$2$`).
+		WithArgs("payout-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "external_id"}).AddRow("1", "PENDING", "payout-1"))
+	mock.ExpectExec(`^UPDATE "payments" SET .*"status"=\$3,.* WHERE "id" = \$39$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", context.TODO(), "GBP", "GB").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", context.TODO(), &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("PayoutStatus", paymentCtx("1"), "payout-1").Return(utils.PaymentStatusSuccess, nil)
+	mockAdapter.On("PayoutStatus", paymentCtx("2"), "payout-2").Return(utils.PaymentStatusPending, nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payouts, err := paymentService.PollPayouts(context.TODO(), pendingSince)
+
+	assert.NoError(t, err)
+	assert.Len(t, payouts, 1)
+	assert.Equal(t, utils.PaymentStatusSuccess, payouts[0].Status)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleCallback_PayoutConfirmed(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the provider confirms the payout the callback reports as successful
+	payoutRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "status", "payment_type", "currency_code", "country_code", "provider_id", "external_id", "beneficiary"}).
+			AddRow("1", "PENDING", "WITHDRAWAL", "GBP", "GB", 1, "external-id", `{"holder_name":"Jane Doe","iban":"GB****************5432"}`)
+	}
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(payoutRows())
+	mock.ExpectBegin()
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(payoutRows())
+	mock.ExpectExec(`^UPDATE "payments" SET .*"status"=\$3,.* WHERE "id" = \$39$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", context.TODO(), "GBP", "GB").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", context.TODO(), &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("PayoutStatus", paymentCtx("1"), "external-id").Return(utils.PaymentStatusSuccess, nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusSuccess, payment.Status)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleCallback_PayoutNotSettled(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the callback reports a payout the provider still has pending, nothing changes
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "payment_type", "currency_code", "country_code", "provider_id", "external_id", "beneficiary"}).
+		AddRow("1", "PENDING", "WITHDRAWAL", "GBP", "GB", 1, "external-id", `{"holder_name":"Jane Doe","iban":"GB****************5432"}`)
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(sqlRows)
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", context.TODO(), "GBP", "GB").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", context.TODO(), &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("PayoutStatus", paymentCtx("1"), "external-id").Return(utils.PaymentStatusPending, nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.ErrorIs(t, err, ErrPayoutNotSettled)
+	assert.Nil(t, payment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+const savedMethodID = "5b7e2c1a-9d3f-4e8b-a6c2-1f0d9e8c7b6a"
+
+const chargeCompletedUpdate = `^UPDATE "payments" SET "authorized_at"=\$1,"external_id"=\$2,"status"=\$3,"updated_at"=\$4 WHERE "id" = \$5$`
+
+func TestChargeSavedMethod_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	savedMethods := new(MockSavedMethodService)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, savedMethods)
+
+	// Setup mock expectations: the deposit is saved, charged with the token and completed right away
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments" .* RETURNING "id"$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(chargeCompletedUpdate).
+		WithArgs(nil, "external-id", "SUCCESS", sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Only ADCB, the provider the method was saved with, can charge it
+	method := &savedmethod.SavedMethod{ID: savedMethodID, MerchantID: 1, UserID: 1, ProviderID: 2, Token: "tok_1"}
+	savedMethods.On("MethodForCharge", merchantCtx, savedMethodID, 1).Return(method, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	mockAdapter := new(MockProviderAdapter)
+	mockAdapter.On("ChargeToken", paymentCtx("1"), provider.TokenChargeDetails{
+		Amount:       float64(100),
+		CurrencyCode: "USD",
+		CountryCode:  "US",
+		Token:        "tok_1",
+	}).Return("external-id", utils.PaymentStatusSuccess, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[1]).Return(mockAdapter, nil)
+
+	// Call the method under test
+	request := PaymentRequest{UserID: 1, Amount: float64(100), CurrencyCode: "USD", CountryCode: "US", SavedMethodID: savedMethodID}
+	payment, err := paymentService.ChargeSavedMethod(merchantCtx, &request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusSuccess, payment.Status)
+	assert.Equal(t, routing.StrategySavedMethod, payment.RoutingDecision.Strategy)
+	assert.Equal(t, uint(2), payment.ProviderID)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChargeSavedMethod_ProviderUnavailable(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	savedMethods := new(MockSavedMethodService)
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, savedMethods)
+
+	// Setup mock expectations: the deposit is saved before the charge, whose outcome is unknown, so it is flagged
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "payments" .* RETURNING "id"$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "needs_review"=\$1,"review_reason"=\$2,"updated_at"=\$3 WHERE "id" = \$4$`).
+		WithArgs(true, sqlmock.AnyArg(), sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	method := &savedmethod.SavedMethod{ID: savedMethodID, MerchantID: 1, UserID: 1, ProviderID: 2, Token: "tok_1"}
+	savedMethods.On("MethodForCharge", merchantCtx, savedMethodID, 1).Return(method, nil)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	mockAdapter := new(MockProviderAdapter)
+	mockAdapter.On("ChargeToken", paymentCtx("1"), anyTokenChargeDetails).Return("", utils.PaymentStatus(""), provider.ErrProviderUnavailable)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[1]).Return(mockAdapter, nil)
+
+	// Call the method under test
+	request := PaymentRequest{UserID: 1, Amount: float64(100), CurrencyCode: "USD", CountryCode: "US", SavedMethodID: savedMethodID}
+	payment, err := paymentService.ChargeSavedMethod(merchantCtx, &request)
+
+	assert.Nil(t, payment)
+	assert.ErrorIs(t, err, provider.ErrProviderUnavailable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChargeSavedMethod_Disabled(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	savedMethods := new(MockSavedMethodService)
+	savedMethods.On("MethodForCharge", merchantCtx, savedMethodID, 1).Return((*savedmethod.SavedMethod)(nil), savedmethod.ErrSavedMethodDisabled)
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, savedMethods)
+
+	// Call the method under test
+	request := PaymentRequest{UserID: 1, Amount: float64(100), CurrencyCode: "USD", CountryCode: "US", SavedMethodID: savedMethodID}
+	url, err := paymentService.CreatePayment(merchantCtx, &request, utils.PaymentTypeDeposit)
+
+	assert.Empty(t, url)
+	assert.ErrorIs(t, err, savedmethod.ErrSavedMethodDisabled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayment_SavedMethodWithdrawal(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	request := PaymentRequest{UserID: 1, Amount: float64(100), CurrencyCode: "USD", CountryCode: "US", SavedMethodID: savedMethodID}
+	_, err := paymentService.CreatePayment(merchantCtx, &request, utils.PaymentTypeWithdrawal)
+
+	assert.ErrorIs(t, err, ErrSavedMethodDepositOnly)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleCallback_SavesMethod(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the deposit succeeds, then the saved method is recorded on it
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "payment_type", "currency_code", "country_code", "user_id", "merchant_id", "provider_id", "external_id", "save_method"}).
+		AddRow("1", "PENDING", "DEPOSIT", "USD", "US", 7, 1, 1, "external-id", true)
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(callbackPaymentRows("PENDING"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET .*"status"=\$3,.* WHERE "id" = \$39$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "saved_method_id"=\$1,"updated_at"=\$2 WHERE "id" = \$3$`).
+		WithArgs(savedMethodID, sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	savedMethods := new(MockSavedMethodService)
+	providerSvc.On("FindProviderConfigs", context.TODO(), "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", context.TODO(), &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("PaymentToken", paymentCtx("1"), "external-id").Return("tok_1", nil)
+	savedMethods.On("Save", context.TODO(), &savedmethod.SavedMethod{MerchantID: 1, UserID: 7, ProviderID: 1, Token: "tok_1", SourcePaymentID: "1"}).
+		Return(&savedmethod.SavedMethod{ID: savedMethodID}, nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, savedMethods)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusSuccess, payment.Status)
+	assert.Equal(t, savedMethodID, *payment.SavedMethodID)
+	savedMethods.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePayment_ManualCaptureWithdrawal(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	request := PaymentRequest{UserID: 1, Amount: float64(100), CurrencyCode: "USD", CountryCode: "US", CaptureMode: CaptureModeManual}
+	_, err := paymentService.CreatePayment(merchantCtx, &request, utils.PaymentTypeWithdrawal)
+
+	assert.ErrorIs(t, err, ErrManualCaptureDepositOnly)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleCallback_ManualCaptureAuthorizes(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the successful payment is only authorized
+	sqlRows := sqlmock.NewRows([]string{"id", "status", "external_id", "capture_mode"}).AddRow("1", "PENDING", "external-id", "manual")
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(callbackPaymentRows("PENDING"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(callbackPaymentQuery).WithArgs("external-id", 1).WillReturnRows(sqlRows)
+	mock.ExpectExec(`^UPDATE "payments" SET .*"status"=\$3,.* WHERE "id" = \$39$`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "AUTHORIZED", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), "manual", sqlmock.AnyArg(), nil, nil, "1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.HandleCallback(context.TODO(), "external-id", utils.PaymentStatusSuccess)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusAuthorized, payment.Status)
+	assert.NotNil(t, payment.AuthorizedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// authorizedPaymentQuery loads the payment of the merchant to capture or void
+const authorizedPaymentQuery = `^SELECT \* FROM "payments" WHERE id = \$1 AND merchant_id = \$2 ORDER BY "payments"."id" LIMIT \$3$`
+
+// claimAuthorizationUpdate flags an authorized payment for review before its provider is called
+const claimAuthorizationUpdate = `^UPDATE "payments" SET "needs_review"=\$1,"review_reason"=\$2,"updated_at"=\$3 WHERE \(status = \$4 AND needs_review = \$5\) AND "id" = \$6$`
+
+// authorizedPaymentRows returns an authorized payment of 100 USD sent to the given provider
+func authorizedPaymentRows(providerID int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "amount", "status", "currency_code", "country_code", "provider_id", "external_id", "capture_mode"}).
+		AddRow(checkoutPaymentID, 100, "AUTHORIZED", "USD", "US", providerID, "external-id", "manual")
+}
+
+func TestCapturePayment_Partial(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the payment is claimed, then 40 of the 100 authorized are captured with the provider
+	mock.ExpectQuery(authorizedPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(authorizedPaymentRows(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(claimAuthorizationUpdate).
+		WithArgs(true, sqlmock.AnyArg(), sqlmock.AnyArg(), "AUTHORIZED", false, checkoutPaymentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "captured_amount"=\$1,"captured_at"=\$2,"needs_review"=\$3,"review_reason"=\$4,"status"=\$5,"updated_at"=\$6 WHERE "id" = \$7$`).
+		WithArgs(float64(40), sqlmock.AnyArg(), false, "", "CAPTURED", sqlmock.AnyArg(), checkoutPaymentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("Capture", paymentCtx(checkoutPaymentID), "external-id", float64(40), "USD").Return(nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.CapturePayment(merchantCtx, checkoutPaymentID, 40)
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusCaptured, payment.Status)
+	assert.Equal(t, float64(40), *payment.CapturedAmount)
+	assert.False(t, payment.NeedsReview)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCapturePayment_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		needsReview bool
+		amount      float64
+		wantErr     error
+	}{
+		{name: "more than authorized", status: "AUTHORIZED", amount: 150, wantErr: ErrCaptureAmountExceeded},
+		{name: "already captured", status: "CAPTURED", wantErr: ErrInvalidTransition},
+		{name: "not authorized", status: "PENDING", wantErr: ErrInvalidTransition},
+		{name: "flagged for review", status: "AUTHORIZED", needsReview: true, wantErr: ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			// The provider is never asked to capture
+			sqlRows := sqlmock.NewRows([]string{"id", "amount", "status", "capture_mode", "needs_review"}).AddRow(checkoutPaymentID, 100, tt.status, "manual", tt.needsReview)
+			mock.ExpectQuery(authorizedPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(sqlRows)
+
+			paymentService := NewPaymentService(gormDB, nil, nil, nil, nil, nil, nil)
+
+			payment, err := paymentService.CapturePayment(merchantCtx, checkoutPaymentID, tt.amount)
+
+			assert.Nil(t, payment)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCapturePayment_ClaimedByAnotherRequest(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: another request claimed the payment between the read and the claim
+	mock.ExpectQuery(authorizedPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(authorizedPaymentRows(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(claimAuthorizationUpdate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[0]).Return(mockAdapter, nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.CapturePayment(merchantCtx, checkoutPaymentID, 0)
+
+	assert.Nil(t, payment)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCapturePayment_SaveFailed(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the provider captured the payment, which then cannot be saved and stays flagged
+	mock.ExpectQuery(authorizedPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(authorizedPaymentRows(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(claimAuthorizationUpdate).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "captured_amount"=\$1,.*"status"=\$5,"updated_at"=\$6 WHERE "id" = \$7$`).
+		WillReturnError(fmt.Errorf("connection reset"))
+	mock.ExpectRollback()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("Capture", paymentCtx(checkoutPaymentID), "external-id", float64(100), "USD").Return(nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payment, err := paymentService.CapturePayment(merchantCtx, checkoutPaymentID, 0)
+
+	assert.Nil(t, payment)
+	assert.Error(t, err)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoidPayment_ProviderFailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		voidErr  error
+		released bool
+	}{
+		// A refused void leaves the payment authorized for the merchant to capture or void again
+		{name: "rejected", voidErr: provider.ErrProviderRejected, released: true},
+		// The provider may have voided the authorization, so the payment stays flagged for review
+		{name: "unavailable", voidErr: provider.ErrProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			mock.ExpectQuery(authorizedPaymentQuery).WithArgs(checkoutPaymentID, 1, 1).WillReturnRows(authorizedPaymentRows(2))
+			mock.ExpectBegin()
+			mock.ExpectExec(claimAuthorizationUpdate).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			if tt.released {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "payments" SET "needs_review"=\$1,"review_reason"=\$2,"updated_at"=\$3 WHERE "id" = \$4$`).
+					WithArgs(false, "", sqlmock.AnyArg(), checkoutPaymentID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			providerSvc := new(MockProviderService)
+			adapterFactory := new(MockAdapterFactory)
+			mockAdapter := new(MockProviderAdapter)
+			providerSvc.On("FindProviderConfigs", merchantCtx, "USD", "US").Return(routeConfigs, nil)
+			adapterFactory.On("AdapterFor", merchantCtx, &routeConfigs[1]).Return(mockAdapter, nil)
+			mockAdapter.On("Void", paymentCtx(checkoutPaymentID), "external-id").Return(tt.voidErr)
+
+			paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+			// Call the method under test
+			payment, err := paymentService.VoidPayment(merchantCtx, checkoutPaymentID)
+
+			assert.Nil(t, payment)
+			assert.ErrorIs(t, err, tt.voidErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestVoidExpiredAuthorizations(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// Setup mock expectations: the expired authorization not flagged for review is voided with its provider
+	authorizedBefore := time.Now().Add(-168 * time.Hour)
+	mock.ExpectQuery(`^SELECT "id" FROM "payments" WHERE status = \$1 AND needs_review = \$2 AND authorized_at < \$3 ORDER BY authorized_at$`).
+		WithArgs("AUTHORIZED", false, authorizedBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(checkoutPaymentID))
+	mock.ExpectQuery(checkoutPaymentQuery).WithArgs(checkoutPaymentID, 1).WillReturnRows(authorizedPaymentRows(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(claimAuthorizationUpdate).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "payments" SET "needs_review"=\$1,"review_reason"=\$2,"status"=\$3,"updated_at"=\$4 WHERE "id" = \$5$`).
+		WithArgs(false, "", "VOIDED", sqlmock.AnyArg(), checkoutPaymentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	providerSvc := new(MockProviderService)
+	adapterFactory := new(MockAdapterFactory)
+	mockAdapter := new(MockProviderAdapter)
+	providerSvc.On("FindProviderConfigs", context.TODO(), "USD", "US").Return(routeConfigs, nil)
+	adapterFactory.On("AdapterFor", context.TODO(), &routeConfigs[0]).Return(mockAdapter, nil)
+	mockAdapter.On("Void", paymentCtx(checkoutPaymentID), "external-id").Return(nil)
+
+	paymentService := NewPaymentService(gormDB, providerSvc, adapterFactory, nil, nil, nil, nil)
+
+	// Call the method under test
+	payments, err := paymentService.VoidExpiredAuthorizations(context.TODO(), authorizedBefore)
+
+	assert.NoError(t, err)
+	assert.Len(t, payments, 1)
+	assert.Equal(t, utils.PaymentStatusVoided, payments[0].Status)
+	mockAdapter.AssertExpectations(t)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSettlementCaptureAmount(t *testing.T) {
+	convertedAmount, rate := 367.25, 3.6725
+
+	// A converted payment captures the converted amount, a partial capture is converted at the rate of the payment
+	converted := &Payment{Amount: 100, ConvertedAmount: &convertedAmount, FXRate: &rate}
+	assert.Equal(t, 367.25, converted.settlementCaptureAmount(100))
+	assert.Equal(t, 146.9, converted.settlementCaptureAmount(40))
+
+	payment := &Payment{Amount: 100}
+	assert.Equal(t, float64(40), payment.settlementCaptureAmount(40))
+}
+
+type MockProviderService struct {
+	mock.Mock
+}
+
+func (m *MockProviderService) FindProviderConfig(ctx context.Context, currencyCode, countryCode string) (*provider.ProviderConfiguration, error) {
+	args := m.Called(ctx, currencyCode, countryCode)
+	return args.Get(0).(*provider.ProviderConfiguration), args.Error(1)
+}
+
+func (m *MockProviderService) FindProviderConfigs(ctx context.Context, currencyCode, countryCode string) ([]provider.ProviderConfiguration, error) {
+	args := m.Called(ctx, currencyCode, countryCode)
+	return args.Get(0).([]provider.ProviderConfiguration), args.Error(1)
+}
+
+type MockRouter struct {
+	mock.Mock
+}
+
+func (m *MockRouter) Route(ctx context.Context, request routing.Request, providerConfigs []provider.ProviderConfiguration) (*provider.ProviderConfiguration, routing.Decision, error) {
+	args := m.Called(ctx, request, providerConfigs)
+	return args.Get(0).(*provider.ProviderConfiguration), args.Get(1).(routing.Decision), args.Error(2)
+}
+
+type MockFXService struct {
+	mock.Mock
+}
+
+func (m *MockFXService) Convert(ctx context.Context, amount float64, fromCurrency, toCurrency string) (*fx.Conversion, error) {
+	args := m.Called(ctx, amount, fromCurrency, toCurrency)
+	return args.Get(0).(*fx.Conversion), args.Error(1)
+}
+
+func (m *MockFXService) FindQuote(ctx context.Context, merchantID uint, id string) (*fx.Quote, error) {
+	args := m.Called(ctx, merchantID, id)
+	return args.Get(0).(*fx.Quote), args.Error(1)
+}
+
+type MockBeneficiaryService struct {
+	mock.Mock
+}
+
+func (m *MockBeneficiaryService) AccountForPayout(ctx context.Context, id string, userID int, countryCode string) (*bankaccount.BankAccount, error) {
+	args := m.Called(ctx, id, userID, countryCode)
+	return args.Get(0).(*bankaccount.BankAccount), args.Error(1)
+}
+
+type MockSavedMethodService struct {
+	mock.Mock
+}
+
+func (m *MockSavedMethodService) Save(ctx context.Context, method *savedmethod.SavedMethod) (*savedmethod.SavedMethod, error) {
+	args := m.Called(ctx, method)
+	return args.Get(0).(*savedmethod.SavedMethod), args.Error(1)
+}
+
+func (m *MockSavedMethodService) MethodForCharge(ctx context.Context, id string, userID int) (*savedmethod.SavedMethod, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).(*savedmethod.SavedMethod), args.Error(1)
+}
+
+type MockAdapterFactory struct {
+	mock.Mock
+}
+
+func (m *MockAdapterFactory) AdapterFor(ctx context.Context, providerConfig *provider.ProviderConfiguration) (provider.ProviderAdapter, error) {
+	args := m.Called(ctx, providerConfig)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(provider.ProviderAdapter), args.Error(1)
+}
+
+type MockProviderAdapter struct {
+	mock.Mock
+}
+
+func (m *MockProviderAdapter) GetDetails(ctx context.Context, amount float64, paymentType string, currencyCode, countryCode string, details provider.PaymentDetails) (string, string, error) {
+	args := m.Called(ctx, amount, paymentType, currencyCode, countryCode, details)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockProviderAdapter) Cancel(ctx context.Context, externalID string) error {
+	args := m.Called(ctx, externalID)
+	return args.Error(0)
+}
+
+func (m *MockProviderAdapter) Payout(ctx context.Context, payout provider.PayoutDetails) (string, error) {
+	args := m.Called(ctx, payout)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockProviderAdapter) PayoutStatus(ctx context.Context, externalID string) (utils.PaymentStatus, error) {
+	args := m.Called(ctx, externalID)
+	return args.Get(0).(utils.PaymentStatus), args.Error(1)
+}
+
+func (m *MockProviderAdapter) PaymentToken(ctx context.Context, externalID string) (string, error) {
+	args := m.Called(ctx, externalID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockProviderAdapter) ChargeToken(ctx context.Context, charge provider.TokenChargeDetails) (string, utils.PaymentStatus, error) {
+	args := m.Called(ctx, charge)
+	return args.String(0), args.Get(1).(utils.PaymentStatus), args.Error(2)
+}
+
+func (m *MockProviderAdapter) Capture(ctx context.Context, externalID string, amount float64, currencyCode string) error {
+	args := m.Called(ctx, externalID, amount, currencyCode)
+	return args.Error(0)
+}
+
+func (m *MockProviderAdapter) Void(ctx context.Context, externalID string) error {
+	args := m.Called(ctx, externalID)
+	return args.Error(0)
 }
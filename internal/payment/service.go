@@ -4,11 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/fx"
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/merchant"
 	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/routing"
+	"payment-gateway-service/internal/savedmethod"
 	"payment-gateway-service/internal/utils"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PaymentServiceInterface defines the methods that the PaymentService must implement.
@@ -17,16 +28,52 @@ type PaymentServiceInterface interface {
 	HandleCallback(ctx context.Context, externalID string, status utils.PaymentStatus) (*Payment, error)
 	UpdatePayment(payment *Payment) error
 	FindPaymentByExternalID(externalID string) (*Payment, error)
+	FindPaymentByID(ctx context.Context, id string) (*Payment, error)
+	FindPaymentByReference(ctx context.Context, reference string) (*Payment, error)
+	CreateCheckout(ctx context.Context, paymentRequest *PaymentRequest, paymentType utils.PaymentType) (*Payment, error)
+	FindCheckoutPayment(ctx context.Context, id string) (*Payment, error)
+	CheckoutOptions(ctx context.Context, payment *Payment) ([]provider.ProviderConfiguration, error)
+	StartCheckout(ctx context.Context, id string, providerConfigID uint) (string, error)
+	PaymentMethods(ctx context.Context, currencyCode, countryCode string) ([]PaymentMethod, error)
+	CancelPayment(ctx context.Context, id string) (*Payment, error)
+	CreatePayout(ctx context.Context, payoutRequest *PayoutRequest) (*Payment, error)
+	CheckPayout(ctx context.Context, payoutRequest *PayoutRequest) error
+	ChargeSavedMethod(ctx context.Context, paymentRequest *PaymentRequest) (*Payment, error)
+	CapturePayment(ctx context.Context, id string, amount float64) (*Payment, error)
+	VoidPayment(ctx context.Context, id string) (*Payment, error)
 }
 
 // ProviderServiceInterface defines the methods that the ProviderService must implement.
 type ProviderServiceInterface interface {
 	FindProviderConfig(ctx context.Context, currencyCode, countryCode string) (*provider.ProviderConfiguration, error)
+	FindProviderConfigs(ctx context.Context, currencyCode, countryCode string) ([]provider.ProviderConfiguration, error)
 }
 
 // AdapterFactoryInterface defines the method that the AdapterFactory must implement.
 type AdapterFactoryInterface interface {
-	GetAdapter(ctx context.Context, currencyCode, countryCode string) (provider.ProviderAdapter, error)
+	AdapterFor(ctx context.Context, providerConfig *provider.ProviderConfiguration) (provider.ProviderAdapter, error)
+}
+
+// RouterInterface defines the method that the routing Engine must implement.
+type RouterInterface interface {
+	Route(ctx context.Context, request routing.Request, providerConfigs []provider.ProviderConfiguration) (*provider.ProviderConfiguration, routing.Decision, error)
+}
+
+// FXServiceInterface defines the methods of the FXService used to convert payments.
+type FXServiceInterface interface {
+	Convert(ctx context.Context, amount float64, fromCurrency, toCurrency string) (*fx.Conversion, error)
+	FindQuote(ctx context.Context, merchantID uint, id string) (*fx.Quote, error)
+}
+
+// BeneficiaryServiceInterface defines the method of the BeneficiaryService used to pay out to saved beneficiaries.
+type BeneficiaryServiceInterface interface {
+	AccountForPayout(ctx context.Context, id string, userID int, countryCode string) (*bankaccount.BankAccount, error)
+}
+
+// SavedMethodServiceInterface defines the methods of the SavedMethodService used to save and charge payment methods.
+type SavedMethodServiceInterface interface {
+	Save(ctx context.Context, method *savedmethod.SavedMethod) (*savedmethod.SavedMethod, error)
+	MethodForCharge(ctx context.Context, id string, userID int) (*savedmethod.SavedMethod, error)
 }
 
 // PaymentService handles operations related to payments.
@@ -34,108 +81,835 @@ type PaymentService struct {
 	db             *gorm.DB
 	providerSvc    ProviderServiceInterface
 	adapterFactory AdapterFactoryInterface
+	router         RouterInterface
+	fxSvc          FXServiceInterface
+	beneficiaries  BeneficiaryServiceInterface
+	savedMethods   SavedMethodServiceInterface
 }
 
 // NewPaymentService initializes a new PaymentService.
-func NewPaymentService(db *gorm.DB, providerSvc ProviderServiceInterface, adapterFactory AdapterFactoryInterface) *PaymentService {
+func NewPaymentService(db *gorm.DB, providerSvc ProviderServiceInterface, adapterFactory AdapterFactoryInterface, router RouterInterface, fxSvc FXServiceInterface, beneficiaries BeneficiaryServiceInterface, savedMethods SavedMethodServiceInterface) *PaymentService {
 	return &PaymentService{
 		db:             db,
 		providerSvc:    providerSvc,
 		adapterFactory: adapterFactory,
+		router:         router,
+		fxSvc:          fxSvc,
+		beneficiaries:  beneficiaries,
+		savedMethods:   savedMethods,
 	}
 }
 
 // CreatePayment creates a new payment in the database and returns the URL for further processing.
+// A deposit charging a saved method has no URL: it is charged right away with ChargeSavedMethod.
 func (s *PaymentService) CreatePayment(ctx context.Context, paymentRequest *PaymentRequest, paymentType utils.PaymentType) (string, error) {
 	utils.LogWithRequestID(ctx, "PaymentService: Starting payment creation")
 
-	var url string
+	if err := paymentRequest.checkCaptureMode(paymentType); err != nil {
+		return "", err
+	}
+	if paymentRequest.SavedMethodID != "" {
+		if paymentType != utils.PaymentTypeDeposit {
+			return "", ErrSavedMethodDepositOnly
+		}
+		_, err := s.ChargeSavedMethod(ctx, paymentRequest)
+		return "", err
+	}
 
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		// Find the appropriate provider configuration.
-		providerConfig, err := s.providerSvc.FindProviderConfig(ctx, paymentRequest.CurrencyCode, paymentRequest.CountryCode)
+	// Payments always belong to the authenticated merchant
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.checkRedirectURLs(ctx, merchantID, paymentRequest); err != nil {
+		return "", err
+	}
+	if err := s.checkMerchantReference(ctx, merchantID, paymentRequest.MerchantReference); err != nil {
+		return "", err
+	}
+
+	conversion, err := s.convertPayment(ctx, merchantID, paymentRequest)
+	if err != nil {
+		return "", err
+	}
+
+	// Find the appropriate provider configuration.
+	providerConfig, decision, err := s.routePayment(ctx, paymentRequest, paymentType, conversion)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to find provider configuration")
+		return "", err
+	}
+
+	// Get the adapter of the selected provider using the factory.
+	adapter, err := s.adapterFactory.AdapterFor(ctx, providerConfig)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to get adapter for provider")
+		return "", err
+	}
+
+	// Save the payment with the initial status before it is sent, so that no transaction is held open while the
+	// provider answers.
+	payment := paymentRequest.newPayment(merchantID, paymentType, providerConfig.ProviderID)
+	payment.applyRoute(providerConfig, decision)
+	payment.applyConversion(conversion)
+	if err := s.savePayment(ctx, payment, conversion); err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to save payment to the database")
+		return "", err
+	}
+
+	// Generate payment details using the adapter.
+	url, externalID, err := adapter.GetDetails(interaction.WithPaymentID(ctx, payment.ID), payment.SettlementAmount(), string(paymentType), payment.SettlementCurrency(), paymentRequest.CountryCode, payment.providerDetails())
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to generate payment details using adapter")
+
+		// The customer never gets a checkout URL, so the payment cannot be paid
+		if failErr := s.db.WithContext(ctx).Model(payment).
+			Where("status = ?", utils.PaymentStatusInitialized).
+			Updates(map[string]interface{}{"status": utils.PaymentStatusFailed, "updated_at": time.Now()}).Error; failErr != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to mark payment %s as failed: %v", payment.ID, failErr))
+		}
+		return "", err
+	}
+
+	// Update the payment record with the external ID, the checkout URL and status to "Pending".
+	if err := s.db.WithContext(ctx).Model(payment).Updates(map[string]interface{}{
+		"external_id":  externalID,
+		"checkout_url": url,
+		"status":       utils.PaymentStatusPending,
+		"updated_at":   time.Now(),
+	}).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to update payment %s with external ID %s and pending status: %v", payment.ID, externalID, err))
+		return "", err
+	}
+
+	utils.LogWithRequestID(ctx, "PaymentService: Payment created successfully")
+	return url, nil
+}
+
+// CreatePayout creates a withdrawal paid out to the beneficiary's bank account and sends it to the provider.
+// The payout is saved before it is sent, with its ID as the idempotency key of the provider, so that no
+// transaction is held open while the provider answers. It is PENDING until the provider calls back with its
+// result, or PollPayouts finds it settled.
+func (s *PaymentService) CreatePayout(ctx context.Context, payoutRequest *PayoutRequest) (*Payment, error) {
+	utils.LogWithRequestID(ctx, "PaymentService: Starting payout creation")
+
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paymentRequest := &payoutRequest.PaymentRequest
+	if err := paymentRequest.checkCaptureMode(utils.PaymentTypeWithdrawal); err != nil {
+		return nil, err
+	}
+
+	beneficiary, err := s.payoutBeneficiary(ctx, payoutRequest)
+	if err != nil {
+		return nil, err
+	}
+	if err := beneficiary.Validate(paymentRequest.CountryCode); err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Invalid beneficiary: %v", err))
+		return nil, err
+	}
+	if err := s.checkMerchantReference(ctx, merchantID, paymentRequest.MerchantReference); err != nil {
+		return nil, err
+	}
+
+	conversion, err := s.convertPayment(ctx, merchantID, paymentRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var beneficiaryID *string
+	if payoutRequest.BeneficiaryID != "" {
+		beneficiaryID = &payoutRequest.BeneficiaryID
+	}
+
+	providerConfig, decision, err := s.routePayment(ctx, paymentRequest, utils.PaymentTypeWithdrawal, conversion)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to find provider configuration")
+		return nil, err
+	}
+
+	adapter, err := s.adapterFactory.AdapterFor(ctx, providerConfig)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to get adapter for provider")
+		return nil, err
+	}
+
+	// Payouts have no customer pages to redirect to
+	payment := paymentRequest.newPayment(merchantID, utils.PaymentTypeWithdrawal, providerConfig.ProviderID)
+	payment.SuccessURL, payment.FailureURL, payment.CancelURL = "", "", ""
+	payment.applyRoute(providerConfig, decision)
+	payment.applyConversion(conversion)
+	masked := beneficiary.Masked()
+	payment.Beneficiary = &masked
+	payment.BeneficiaryID = beneficiaryID
+
+	if err := s.savePayment(ctx, payment, conversion); err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to save payout to the database")
+		return nil, err
+	}
+
+	externalID, err := adapter.Payout(interaction.WithPaymentID(ctx, payment.ID), provider.PayoutDetails{
+		Amount:         payment.SettlementAmount(),
+		CurrencyCode:   payment.SettlementCurrency(),
+		CountryCode:    payment.CountryCode,
+		Reference:      payment.MerchantReference,
+		IdempotencyKey: payment.ID,
+		Beneficiary:    beneficiary,
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to send payout %s to provider: %v", payment.ID, err))
+		s.failSend(ctx, payment, err)
+		return nil, err
+	}
+
+	payment.ExternalID = externalID
+	payment.Status = utils.PaymentStatusPending
+	payment.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Model(payment).Updates(map[string]interface{}{
+		"external_id": payment.ExternalID,
+		"status":      payment.Status,
+		"updated_at":  payment.UpdatedAt,
+	}).Error; err != nil {
+		// The provider has the payout, which an operator completes from its external ID
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to update payout %s with external ID %s and pending status: %v", payment.ID, externalID, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, "PaymentService: Payout created successfully")
+	return payment, nil
+}
+
+// failSend records that the provider did not accept a payment sent server to server, a payout or the charge of
+// a saved method. A payment the provider rejected is FAILED. Any other error leaves the outcome unknown, as the
+// provider may have received the payment before the error, so the payment stays INITIALIZED and is flagged for
+// an operator to check with the provider.
+func (s *PaymentService) failSend(ctx context.Context, payment *Payment, sendErr error) {
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	if errors.Is(sendErr, provider.ErrProviderRejected) {
+		payment.Status = utils.PaymentStatusFailed
+		updates["status"] = payment.Status
+	} else {
+		payment.NeedsReview = true
+		payment.ReviewReason = fmt.Sprintf("sending the payment failed at %s, the provider may have received it: %v", time.Now().UTC().Format(time.RFC3339), sendErr)
+		updates["needs_review"] = payment.NeedsReview
+		updates["review_reason"] = payment.ReviewReason
+	}
+
+	if err := s.db.WithContext(ctx).Model(payment).Updates(updates).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to record the failure of payment %s: %v", payment.ID, err))
+	}
+}
+
+// CheckPayout checks that a payout can be sent, without sending it: that its saved beneficiary, if any, is a
+// VERIFIED beneficiary of the user for the country, and that a provider pays out its currency in its country.
+func (s *PaymentService) CheckPayout(ctx context.Context, payoutRequest *PayoutRequest) error {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := s.payoutBeneficiary(ctx, payoutRequest); err != nil {
+		return err
+	}
+
+	// A converted payout is sent in the currency it is converted to
+	currencyCode := payoutRequest.CurrencyCode
+	if payoutRequest.FXQuoteID != "" {
+		quote, err := s.fxSvc.FindQuote(ctx, merchantID, payoutRequest.FXQuoteID)
+		if err != nil {
+			return err
+		}
+		currencyCode = quote.ToCurrency
+	} else if payoutRequest.SettlementCurrency != "" {
+		currencyCode = payoutRequest.SettlementCurrency
+	}
+
+	providerConfigs, err := s.providerSvc.FindProviderConfigs(ctx, strings.ToUpper(currencyCode), payoutRequest.CountryCode)
+	if err != nil {
+		return err
+	}
+	eligible := eligibleProviderConfigs(providerConfigs, payoutRequest.ExcludeProviders)
+	if payoutRequest.Provider != "" {
+		_, err := preferredProviderConfig(eligible, payoutRequest.Provider)
+		return err
+	}
+	if len(eligible) == 0 {
+		return ErrAllProvidersExcluded
+	}
+	return nil
+}
+
+// ChargeSavedMethod creates a deposit charging a saved payment method of the user with the provider it was
+// saved with, server to server with no redirect. The deposit is SUCCESS or FAILED as soon as the provider
+// answers, or PENDING until the provider calls back. A declined charge is not an error.
+func (s *PaymentService) ChargeSavedMethod(ctx context.Context, paymentRequest *PaymentRequest) (*Payment, error) {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Starting charge of saved method %s", paymentRequest.SavedMethodID))
+
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := s.savedMethods.MethodForCharge(ctx, paymentRequest.SavedMethodID, paymentRequest.UserID)
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Cannot charge saved method %s: %v", paymentRequest.SavedMethodID, err))
+		return nil, err
+	}
+	if err := s.checkRedirectURLs(ctx, merchantID, paymentRequest); err != nil {
+		return nil, err
+	}
+	if err := s.checkMerchantReference(ctx, merchantID, paymentRequest.MerchantReference); err != nil {
+		return nil, err
+	}
+
+	conversion, err := s.convertPayment(ctx, merchantID, paymentRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := paymentRequest.newPayment(merchantID, utils.PaymentTypeDeposit, method.ProviderID)
+	payment.applyConversion(conversion)
+	payment.SavedMethodID = &method.ID
+
+	// Only the provider the method was saved with can charge its token
+	providerConfigs, err := s.providerSvc.FindProviderConfigs(ctx, payment.SettlementCurrency(), payment.CountryCode)
+	if err != nil {
+		return nil, err
+	}
+	var providerConfig *provider.ProviderConfiguration
+	for i := range providerConfigs {
+		if providerConfigs[i].ProviderID == method.ProviderID {
+			providerConfig = &providerConfigs[i]
+			break
+		}
+	}
+	if providerConfig == nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Provider %d of saved method %s is not configured for the payment", method.ProviderID, method.ID))
+		return nil, ErrProviderNotAvailable
+	}
+
+	adapter, err := s.adapterFactory.AdapterFor(ctx, providerConfig)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to get adapter for provider")
+		return nil, err
+	}
+
+	payment.applyRoute(providerConfig, routing.NewDecision(routing.StrategySavedMethod, providerConfig))
+	if err := s.savePayment(ctx, payment, conversion); err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to save payment to the database")
+		return nil, err
+	}
+
+	externalID, status, err := adapter.ChargeToken(interaction.WithPaymentID(ctx, payment.ID), provider.TokenChargeDetails{
+		Amount:        payment.SettlementAmount(),
+		CurrencyCode:  payment.SettlementCurrency(),
+		CountryCode:   payment.CountryCode,
+		Reference:     payment.MerchantReference,
+		Description:   payment.Description,
+		Token:         method.Token,
+		ManualCapture: payment.manualCapture(),
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to charge saved method %s with provider: %v", method.ID, err))
+		s.failSend(ctx, payment, err)
+		return nil, err
+	}
+
+	payment.ExternalID = externalID
+	payment.Status = status
+	if status == utils.PaymentStatusAuthorized {
+		payment.authorize(time.Now())
+	}
+	payment.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Model(payment).Updates(map[string]interface{}{
+		"external_id":   payment.ExternalID,
+		"status":        payment.Status,
+		"authorized_at": payment.AuthorizedAt,
+		"updated_at":    payment.UpdatedAt,
+	}).Error; err != nil {
+		// The provider charged the token, which an operator completes from its external ID
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to update payment %s with external ID %s and charge status %s: %v", payment.ID, externalID, status, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Saved method %s charged, payment %s is %s", method.ID, payment.ID, payment.Status))
+	return payment, nil
+}
+
+// payoutBeneficiary returns the normalized account of the beneficiary of a payout: the saved beneficiary
+// it references, which must be verified, or the account it carries
+func (s *PaymentService) payoutBeneficiary(ctx context.Context, payoutRequest *PayoutRequest) (bankaccount.BankAccount, error) {
+	if payoutRequest.BeneficiaryID == "" {
+		return payoutRequest.Beneficiary.Normalized(), nil
+	}
+
+	account, err := s.beneficiaries.AccountForPayout(ctx, payoutRequest.BeneficiaryID, payoutRequest.UserID, payoutRequest.CountryCode)
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Cannot pay out to beneficiary %s: %v", payoutRequest.BeneficiaryID, err))
+		return bankaccount.BankAccount{}, err
+	}
+	return account.Normalized(), nil
+}
+
+// PollPayouts asks the providers for the status of the payouts PENDING since before pendingSince and
+// completes the ones they settled, for providers whose callbacks were lost. It returns the payouts completed.
+func (s *PaymentService) PollPayouts(ctx context.Context, pendingSince time.Time) ([]Payment, error) {
+	var payouts []Payment
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND beneficiary IS NOT NULL AND external_id <> '' AND updated_at < ?", utils.PaymentStatusPending, pendingSince).
+		Order("updated_at").
+		Find(&payouts).Error; err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to list pending payouts")
+		return nil, err
+	}
+
+	completed := []Payment{}
+	for i := range payouts {
+		payout := &payouts[i]
+		adapter, err := s.adapterForPayment(ctx, payout)
 		if err != nil {
-			utils.LogWithRequestID(ctx, "PaymentService: Failed to find provider configuration")
-			return errors.New("failed to find provider configuration")
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: No adapter to poll payout %s: %v", payout.ID, err))
+			continue
+		}
+
+		status, err := adapter.PayoutStatus(interaction.WithPaymentID(ctx, payout.ID), payout.ExternalID)
+		if err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to poll payout %s: %v", payout.ID, err))
+			continue
+		}
+		if status == utils.PaymentStatusPending {
+			continue
+		}
+
+		updated, err := s.completePayment(ctx, payout.ExternalID, status)
+		if err != nil {
+			// The callback of the provider may have completed the payout in the meantime
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to complete payout %s: %v", payout.ID, err))
+			continue
+		}
+		completed = append(completed, *updated)
+	}
+
+	return completed, nil
+}
+
+// convertPayment converts a payment to its settlement currency, at the rate of its FX quote if any, which
+// is then used up, and otherwise at the latest rate. It returns nil if the payment is settled in its own currency.
+func (s *PaymentService) convertPayment(ctx context.Context, merchantID uint, paymentRequest *PaymentRequest) (*fx.Conversion, error) {
+	if paymentRequest.FXQuoteID != "" {
+		quote, err := s.fxSvc.FindQuote(ctx, merchantID, paymentRequest.FXQuoteID)
+		if err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: FX quote %s cannot be used: %v", paymentRequest.FXQuoteID, err))
+			return nil, err
+		}
+		if !strings.EqualFold(quote.FromCurrency, paymentRequest.CurrencyCode) {
+			return nil, fmt.Errorf("%w: quote converts from %s", ErrQuoteCurrencyMismatch, quote.FromCurrency)
+		}
+		// The quote is used up by savePayment, once the payment is routed
+		return quote.Convert(paymentRequest.Amount), nil
+	}
+
+	if paymentRequest.SettlementCurrency == "" || strings.EqualFold(paymentRequest.SettlementCurrency, paymentRequest.CurrencyCode) {
+		return nil, nil
+	}
+
+	conversion, err := s.fxSvc.Convert(ctx, paymentRequest.Amount, strings.ToUpper(paymentRequest.CurrencyCode), strings.ToUpper(paymentRequest.SettlementCurrency))
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to convert payment to %s: %v", paymentRequest.SettlementCurrency, err))
+		return nil, err
+	}
+	return conversion, nil
+}
+
+// routePayment finds the provider configuration a payment is sent to and records how it was chosen: the
+// preferred provider if any, otherwise the configuration picked by the router among the ones not excluded.
+// A converted payment is routed by its settlement currency and converted amount.
+func (s *PaymentService) routePayment(ctx context.Context, paymentRequest *PaymentRequest, paymentType utils.PaymentType, conversion *fx.Conversion) (*provider.ProviderConfiguration, routing.Decision, error) {
+	currencyCode, amount := paymentRequest.CurrencyCode, paymentRequest.Amount
+	if conversion != nil {
+		currencyCode, amount = conversion.Currency, conversion.Amount
+	}
+
+	providerConfigs, err := s.providerSvc.FindProviderConfigs(ctx, currencyCode, paymentRequest.CountryCode)
+	if err != nil {
+		return nil, routing.Decision{}, err
+	}
+	eligible := eligibleProviderConfigs(providerConfigs, paymentRequest.ExcludeProviders)
+
+	if paymentRequest.Provider != "" {
+		providerConfig, err := preferredProviderConfig(eligible, paymentRequest.Provider)
+		if err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Preferred provider %q is not available", paymentRequest.Provider))
+			return nil, routing.Decision{}, err
+		}
+		return providerConfig, routing.NewDecision(routing.StrategyPreferred, providerConfig), nil
+	}
+
+	if len(eligible) == 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Every provider is excluded by %v", paymentRequest.ExcludeProviders))
+		return nil, routing.Decision{}, ErrAllProvidersExcluded
+	}
+
+	return s.router.Route(ctx, routing.Request{
+		CurrencyCode: currencyCode,
+		CountryCode:  paymentRequest.CountryCode,
+		PaymentType:  paymentType,
+		Amount:       amount,
+		UserSegment:  paymentRequest.UserSegment,
+	}, eligible)
+}
+
+// PaymentMethods lists the providers a payment in the currency and country can be sent to, by priority.
+func (s *PaymentService) PaymentMethods(ctx context.Context, currencyCode, countryCode string) ([]PaymentMethod, error) {
+	providerConfigs, err := s.providerSvc.FindProviderConfigs(ctx, currencyCode, countryCode)
+	if err != nil {
+		if errors.Is(err, provider.ErrNoRouteForCurrencyCountry) {
+			return []PaymentMethod{}, nil
+		}
+		return nil, err
+	}
+
+	methods := make([]PaymentMethod, 0, len(providerConfigs))
+	for _, providerConfig := range providerConfigs {
+		// A provider configured twice for the same route is listed once
+		if slices.ContainsFunc(methods, func(method PaymentMethod) bool { return method.Provider == providerConfig.ProviderName }) {
+			continue
+		}
+		methods = append(methods, PaymentMethod{
+			Provider:    providerConfig.ProviderName,
+			DisplayName: providerConfig.DisplayName(),
+			Priority:    providerConfig.Priority,
+		})
+	}
+	return methods, nil
+}
+
+// CreateCheckout creates a payment for the hosted checkout. No provider is contacted until the customer
+// picks one with StartCheckout; the payment is assigned the highest priority provider in the meantime.
+func (s *PaymentService) CreateCheckout(ctx context.Context, paymentRequest *PaymentRequest, paymentType utils.PaymentType) (*Payment, error) {
+	utils.LogWithRequestID(ctx, "PaymentService: Starting checkout creation")
+
+	if err := paymentRequest.checkCaptureMode(paymentType); err != nil {
+		return nil, err
+	}
+
+	// Payments always belong to the authenticated merchant
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkRedirectURLs(ctx, merchantID, paymentRequest); err != nil {
+		return nil, err
+	}
+	if err := s.checkMerchantReference(ctx, merchantID, paymentRequest.MerchantReference); err != nil {
+		return nil, err
+	}
+
+	// The rate is locked when the checkout is created, not when the customer picks a provider
+	conversion, err := s.convertPayment(ctx, merchantID, paymentRequest)
+	if err != nil {
+		return nil, err
+	}
+	currencyCode := paymentRequest.CurrencyCode
+	if conversion != nil {
+		currencyCode = conversion.Currency
+	}
+
+	// Make sure the payment can be routed at all before the customer is sent to the checkout
+	providerConfig, err := s.providerSvc.FindProviderConfig(ctx, currencyCode, paymentRequest.CountryCode)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to find provider configuration")
+		return nil, err
+	}
+
+	payment := paymentRequest.newPayment(merchantID, paymentType, providerConfig.ProviderID)
+	payment.applyConversion(conversion)
+	if err := s.savePayment(ctx, payment, conversion); err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to save payment to the database")
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Checkout created for payment %s", payment.ID))
+	return payment, nil
+}
+
+// FindCheckoutPayment retrieves a payment for the hosted checkout and result pages. Unlike FindPaymentByID it
+// is not scoped to a merchant: the customer has no API key, the unguessable payment ID is the credential.
+func (s *PaymentService) FindCheckoutPayment(ctx context.Context, id string) (*Payment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	var payment Payment
+	if err := s.db.WithContext(ctx).Preload("Provider").Where("id = ?", id).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
 		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to find payment %s: %v", id, err))
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CheckoutOptions returns the provider configurations the customer can pick from for a payment, by priority.
+func (s *PaymentService) CheckoutOptions(ctx context.Context, payment *Payment) ([]provider.ProviderConfiguration, error) {
+	return s.providerSvc.FindProviderConfigs(ctx, payment.SettlementCurrency(), payment.CountryCode)
+}
+
+// StartCheckout sends an INITIALIZED payment to the provider configuration picked by the customer and
+// returns the provider URL to redirect the customer to. The payment is claimed before the provider is
+// called, so it is never sent to two providers, and is released if the provider fails.
+func (s *PaymentService) StartCheckout(ctx context.Context, id string, providerConfigID uint) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", ErrPaymentNotFound
+	}
+
+	var payment Payment
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrPaymentNotFound
+		}
+		return "", err
+	}
+	if payment.Status != utils.PaymentStatusInitialized {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Checkout of payment %s already started (status: %s)", id, payment.Status))
+		return "", fmt.Errorf("%w: payment status is %s", ErrInvalidTransition, payment.Status)
+	}
+
+	// Only the configurations routing the payment's settlement currency and country can be picked
+	providerConfigs, err := s.providerSvc.FindProviderConfigs(ctx, payment.SettlementCurrency(), payment.CountryCode)
+	if err != nil {
+		return "", err
+	}
+	var providerConfig *provider.ProviderConfiguration
+	for i := range providerConfigs {
+		if providerConfigs[i].ID == providerConfigID {
+			providerConfig = &providerConfigs[i]
+		}
+	}
+	if providerConfig == nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Provider configuration %d is not eligible for payment %s", providerConfigID, id))
+		return "", ErrProviderNotEligible
+	}
+
+	adapter, err := s.adapterFactory.AdapterFor(ctx, providerConfig)
+	if err != nil {
+		return "", err
+	}
+
+	// Claim the payment by moving it to PENDING only if it is still INITIALIZED, so that concurrent
+	// checkouts cannot both send it. No lock is held while the provider is called.
+	payment.applyRoute(providerConfig, routing.NewDecision(routing.StrategyCheckout, providerConfig))
+	payment.Status = utils.PaymentStatusPending
+	payment.UpdatedAt = time.Now()
+	claim := s.db.WithContext(ctx).Model(&payment).
+		Where("status = ?", utils.PaymentStatusInitialized).
+		Select("provider_id", "provider_configuration_id", "provider_priority", "routing_decision", "status", "updated_at").
+		Updates(&payment)
+	if claim.Error != nil {
+		return "", claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Checkout of payment %s was started concurrently", id))
+		return "", fmt.Errorf("%w: checkout already started", ErrInvalidTransition)
+	}
+
+	url, externalID, err := adapter.GetDetails(interaction.WithPaymentID(ctx, payment.ID), payment.SettlementAmount(), string(payment.PaymentType), payment.SettlementCurrency(), payment.CountryCode, payment.providerDetails())
+	if err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to generate payment details using adapter")
+
+		// Release the payment so the customer can pick a provider again
+		if releaseErr := s.db.WithContext(ctx).Model(&Payment{}).
+			Where("id = ? AND status = ? AND external_id = ''", id, utils.PaymentStatusPending).
+			Updates(map[string]interface{}{"status": utils.PaymentStatusInitialized, "updated_at": time.Now()}).Error; releaseErr != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to release payment %s after the provider failed: %v", id, releaseErr))
+		}
+		return "", err
+	}
+
+	if err := s.db.WithContext(ctx).Model(&payment).Updates(map[string]interface{}{
+		"external_id":  externalID,
+		"checkout_url": url,
+		"updated_at":   time.Now(),
+	}).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to save external ID %s of payment %s: %v", externalID, id, err))
+		return "", err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Checkout of payment %s sent to %s", id, providerConfig.ProviderName))
+	return url, nil
+}
+
+// checkRedirectURLs verifies that the redirect URLs of a payment request are on the merchant's allowed domains
+func (s *PaymentService) checkRedirectURLs(ctx context.Context, merchantID uint, paymentRequest *PaymentRequest) error {
+	urls := paymentRequest.redirectURLs()
+	if len(urls) == 0 {
+		return nil
+	}
+
+	var m merchant.Merchant
+	if err := s.db.WithContext(ctx).First(&m, merchantID).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to find merchant %d: %v", merchantID, err))
+		return err
+	}
 
-		// Get the right adapter using the factory.
-		adapter, err := s.adapterFactory.GetAdapter(ctx, paymentRequest.CurrencyCode, paymentRequest.CountryCode)
-		if err != nil {
-			utils.LogWithRequestID(ctx, "PaymentService: Failed to get adapter for provider")
-			return err
+	for _, u := range urls {
+		if !m.AllowsRedirect(u) {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Redirect URL %s is not allowed for merchant %d", u, merchantID))
+			return fmt.Errorf("%w: %s", ErrRedirectURLNotAllowed, u)
 		}
+	}
+	return nil
+}
 
-		// Create a new payment record with the initial status.
-		payment := &Payment{
-			UserID:       paymentRequest.UserID,
-			Amount:       paymentRequest.Amount,
-			PaymentType:  paymentType,
-			Status:       utils.PaymentStatusInitialized,
-			CurrencyCode: paymentRequest.CurrencyCode,
-			ProviderID:   providerConfig.ProviderID,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
+const (
+	// uniqueViolation is the Postgres error code of a violated unique index
+	uniqueViolation = "23505"
 
-		// Save the payment in the database.
-		if err := tx.Create(payment).Error; err != nil {
-			utils.LogWithRequestID(ctx, "PaymentService: Failed to save payment to the database")
-			return err
-		}
+	// merchantReferenceIndex is the unique index of the payments table on the merchant and its reference
+	merchantReferenceIndex = "idx_payments_merchant_reference"
+)
 
-		// Generate payment details using the adapter.
-		var externalID string
-		url, externalID, err = adapter.GetDetails(ctx, payment.Amount, string(paymentType), payment.CurrencyCode, paymentRequest.CountryCode)
-		if err != nil {
-			utils.LogWithRequestID(ctx, "PaymentService: Failed to generate payment details using adapter")
+// savePayment inserts a new payment converted with conversion, if any. The FX quote the payment was converted
+// with is used up in the same transaction, so that it converts this payment only and stays usable if the
+// payment cannot be saved.
+func (s *PaymentService) savePayment(ctx context.Context, payment *Payment, conversion *fx.Conversion) error {
+	if conversion == nil || conversion.QuoteID == "" {
+		return insertPayment(s.db.WithContext(ctx), payment)
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fx.UseQuote(ctx, tx, payment.MerchantID, conversion.QuoteID); err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: FX quote %s cannot be used: %v", conversion.QuoteID, err))
 			return err
 		}
+		return insertPayment(tx, payment)
+	})
+}
 
-		// Update the payment record with the external ID and status to "Pending".
-		payment.ExternalID = externalID
-		payment.Status = utils.PaymentStatusPending // Set status to "Pending"
-		if err := tx.Save(payment).Error; err != nil {
-			utils.LogWithRequestID(ctx, "PaymentService: Failed to update payment with external ID and pending status")
-			return err
-		}
+// insertPayment inserts a new payment. A concurrent payment of the merchant with the same reference, which
+// checkMerchantReference did not see yet, is reported as ErrDuplicateMerchantReference.
+func insertPayment(db *gorm.DB, payment *Payment) error {
+	err := db.Create(payment).Error
 
-		utils.LogWithRequestID(ctx, "PaymentService: Payment created successfully")
-		return nil
-	})
+	var pgErr *pgconn.PgError
+	duplicate := errors.Is(err, gorm.ErrDuplicatedKey) ||
+		(errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == merchantReferenceIndex)
+	if duplicate && payment.MerchantReference != "" {
+		return fmt.Errorf("%w: %s", ErrDuplicateMerchantReference, payment.MerchantReference)
+	}
+	return err
+}
 
-	if err != nil {
-		return "", err
+// checkMerchantReference verifies that the merchant has no other payment with the reference. The unique index
+// on the payments table settles concurrent requests with the same reference.
+func (s *PaymentService) checkMerchantReference(ctx context.Context, merchantID uint, reference string) error {
+	if reference == "" {
+		return nil
 	}
 
-	return url, nil
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&Payment{}).Where("merchant_id = ? AND merchant_reference = ?", merchantID, reference).Count(&count).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to check merchant reference %s: %v", reference, err))
+		return err
+	}
+	if count > 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Merchant %d already has a payment with reference %s", merchantID, reference))
+		return fmt.Errorf("%w: %s", ErrDuplicateMerchantReference, reference)
+	}
+	return nil
 }
 
 // HandleCallback processes callbacks from payment providers and updates the payment status and user balance.
+// Callbacks are not authenticated, so the result of a payout is confirmed with the provider before it is applied.
 func (s *PaymentService) HandleCallback(ctx context.Context, externalID string, status utils.PaymentStatus) (*Payment, error) {
 	utils.LogWithRequestID(ctx, "PaymentService: Handling callback for ExternalID")
 
+	status, err := s.confirmPayoutStatus(ctx, externalID, status)
+	if err != nil {
+		return nil, err
+	}
+	return s.completePayment(ctx, externalID, status)
+}
+
+// confirmPayoutStatus returns the status the provider of a PENDING payout reports for it, in place of the status
+// of its callback. The statuses of the callbacks of other payments are returned as is.
+func (s *PaymentService) confirmPayoutStatus(ctx context.Context, externalID string, status utils.PaymentStatus) (utils.PaymentStatus, error) {
+	var payment Payment
+	if err := s.db.WithContext(ctx).Where("external_id = ?", externalID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogWithRequestID(ctx, "PaymentService: Payment not found with ExternalID")
+			return "", ErrPaymentNotFound
+		}
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to find payment with ExternalID")
+		return "", err
+	}
+	if payment.Beneficiary == nil || payment.Status != utils.PaymentStatusPending {
+		return status, nil
+	}
+
+	adapter, err := s.adapterForPayment(ctx, &payment)
+	if err != nil {
+		return "", err
+	}
+	confirmed, err := adapter.PayoutStatus(interaction.WithPaymentID(ctx, payment.ID), externalID)
+	if err != nil {
+		// The payout is completed by PollPayouts once the provider can be reached
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to confirm the status of payout %s: %v", payment.ID, err))
+		return "", err
+	}
+	if confirmed == utils.PaymentStatusPending {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Callback reported payout %s as %s, but the provider has not settled it", payment.ID, status))
+		return "", ErrPayoutNotSettled
+	}
+	if confirmed != status {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Callback reported payout %s as %s, the provider reports %s", payment.ID, status, confirmed))
+	}
+	return confirmed, nil
+}
+
+// completePayment moves a PENDING payment to the status its provider reported
+func (s *PaymentService) completePayment(ctx context.Context, externalID string, status utils.PaymentStatus) (*Payment, error) {
 	var payment *Payment
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// Find the payment by the external ID within the transaction.
 		if err := tx.Where("external_id = ?", externalID).First(&payment).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				utils.LogWithRequestID(ctx, "PaymentService: Payment not found with ExternalID")
-				return errors.New("payment not found")
+				return ErrPaymentNotFound
 			}
 			utils.LogWithRequestID(ctx, "PaymentService: Failed to find payment with ExternalID")
 			return err
 		}
 
+		// A provider completing a payment the merchant cancelled needs an operator, the customer may have been charged.
+		// The payment stays cancelled and the customer is redirected as usual; repeated callbacks flag it only once.
+		if payment.Status == utils.PaymentStatusCancelled && status == utils.PaymentStatusSuccess {
+			if payment.NeedsReview {
+				utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Success callback for cancelled payment %s, already flagged for review", payment.ID))
+				return nil
+			}
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Success callback for cancelled payment %s, flagging it for review", payment.ID))
+			payment.NeedsReview = true
+			payment.ReviewReason = fmt.Sprintf("provider reported success at %s after the payment was cancelled", time.Now().UTC().Format(time.RFC3339))
+			payment.UpdatedAt = time.Now()
+			return tx.Save(payment).Error
+		}
+
 		// Check if the current status is "Pending". If not, do not update.
 		if payment.Status != utils.PaymentStatusPending {
 			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Payment status is not pending (current status: %s), no update performed", payment.Status))
-			return errors.New("payment status is not pending, update skipped")
+			return fmt.Errorf("%w: payment status is %s, update skipped", ErrInvalidTransition, payment.Status)
 		}
 
-		// Handle the callback based on the status.
+		// Handle the callback based on the status. A successful payment made with manual capture is only authorized.
 		payment.Status = status
 		payment.UpdatedAt = time.Now()
+		if status == utils.PaymentStatusSuccess && payment.manualCapture() {
+			payment.authorize(payment.UpdatedAt)
+		}
 		if err := tx.Save(payment).Error; err != nil {
 			utils.LogWithRequestID(ctx, "PaymentService: Failed to update payment status")
 			return err
@@ -149,9 +923,326 @@ func (s *PaymentService) HandleCallback(ctx context.Context, externalID string,
 		return nil, err
 	}
 
+	if (payment.Status == utils.PaymentStatusSuccess || payment.Status == utils.PaymentStatusAuthorized) && payment.SaveMethod && payment.SavedMethodID == nil {
+		if err := s.saveMethod(ctx, payment); err != nil {
+			// The payment succeeded all the same, the user saves the method with a later deposit
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to save the payment method of payment %s: %v", payment.ID, err))
+		}
+	}
+
 	return payment, nil
 }
 
+// saveMethod asks the provider of a successful deposit for the token of its payment method and saves it for the user
+func (s *PaymentService) saveMethod(ctx context.Context, payment *Payment) error {
+	adapter, err := s.adapterForPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+
+	token, err := adapter.PaymentToken(interaction.WithPaymentID(ctx, payment.ID), payment.ExternalID)
+	if err != nil {
+		return err
+	}
+
+	method, err := s.savedMethods.Save(ctx, &savedmethod.SavedMethod{
+		MerchantID:      payment.MerchantID,
+		UserID:          payment.UserID,
+		ProviderID:      payment.ProviderID,
+		Token:           token,
+		SourcePaymentID: payment.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	payment.SavedMethodID = &method.ID
+	return s.db.Model(payment).Update("saved_method_id", method.ID).Error
+}
+
+// CancelPayment cancels an INITIALIZED or PENDING payment of the authenticated merchant. The provider of a
+// PENDING payment is told about it, but the payment is cancelled even if the provider cannot be reached.
+func (s *PaymentService) CancelPayment(ctx context.Context, id string) (*Payment, error) {
+	merchantID, ok := utils.MerchantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	var payment Payment
+	var wasPending bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND merchant_id = ?", id, merchantID).First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+
+		if payment.Status != utils.PaymentStatusInitialized && payment.Status != utils.PaymentStatusPending {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Payment %s cannot be cancelled (status: %s)", id, payment.Status))
+			return fmt.Errorf("%w: payment status is %s", ErrInvalidTransition, payment.Status)
+		}
+
+		wasPending = payment.Status == utils.PaymentStatusPending
+		payment.Status = utils.PaymentStatusCancelled
+		payment.UpdatedAt = time.Now()
+		return tx.Save(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Payment %s cancelled", id))
+
+	if wasPending && payment.ExternalID != "" {
+		err := s.notifyCancellation(ctx, &payment)
+		if errors.Is(err, provider.ErrCancelNotSupported) {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: The provider of payment %s is not told about cancellations", id))
+		} else if err != nil {
+			// A later success callback flags the payment for review, so the cancellation stands
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to notify the provider of the cancellation of payment %s: %v", id, err))
+		}
+	}
+
+	return &payment, nil
+}
+
+// CapturePayment captures an AUTHORIZED deposit of the authenticated merchant made with manual capture, for
+// the given amount up to the authorized amount, or the whole amount if it is 0. The rest of the authorization
+// is released by the provider. The payment stays AUTHORIZED if the provider refuses to capture it, and is
+// flagged for review if the outcome of the capture is unknown.
+func (s *PaymentService) CapturePayment(ctx context.Context, id string, amount float64) (*Payment, error) {
+	merchantID, ok := utils.MerchantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	var payment Payment
+	if err := s.findAuthorizedPayment(ctx, &payment, "id = ? AND merchant_id = ?", id, merchantID); err != nil {
+		return nil, err
+	}
+
+	if amount == 0 {
+		amount = payment.Amount
+	}
+	if amount > payment.Amount {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Capture of %.2f exceeds the %.2f authorized for payment %s", amount, payment.Amount, id))
+		return nil, fmt.Errorf("%w: %.2f authorized", ErrCaptureAmountExceeded, payment.Amount)
+	}
+
+	adapter, err := s.adapterForPayment(ctx, &payment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.claimAuthorization(ctx, &payment, fmt.Sprintf("capture of %.2f %s", amount, payment.CurrencyCode)); err != nil {
+		return nil, err
+	}
+
+	// The provider is called without holding a database connection, the claim keeps other requests away
+	if err := adapter.Capture(interaction.WithPaymentID(ctx, payment.ID), payment.ExternalID, payment.settlementCaptureAmount(amount), payment.SettlementCurrency()); err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Provider failed to capture payment %s: %v", id, err))
+		s.releaseAuthorization(ctx, &payment, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	payment.Status = utils.PaymentStatusCaptured
+	payment.CapturedAmount = &amount
+	payment.CapturedAt = &now
+	if err := s.settleAuthorization(ctx, &payment, map[string]interface{}{"captured_amount": amount, "captured_at": now}); err != nil {
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Payment %s captured for %.2f %s", id, amount, payment.CurrencyCode))
+	return &payment, nil
+}
+
+// VoidPayment releases the authorization of an AUTHORIZED deposit of the authenticated merchant made with
+// manual capture. The payment stays AUTHORIZED if the provider refuses to void it, and is flagged for review
+// if the outcome of the void is unknown.
+func (s *PaymentService) VoidPayment(ctx context.Context, id string) (*Payment, error) {
+	merchantID, ok := utils.MerchantIDFromContext(ctx)
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	return s.voidAuthorization(ctx, "id = ? AND merchant_id = ?", id, merchantID)
+}
+
+// VoidExpiredAuthorizations voids the AUTHORIZED payments authorized before the given time, whose capture
+// deadline passed, and returns them. Payments the provider refuses to void are logged and left AUTHORIZED for
+// the next run. Payments flagged for review are skipped, as a capture of theirs may have reached the provider.
+func (s *PaymentService) VoidExpiredAuthorizations(ctx context.Context, authorizedBefore time.Time) ([]Payment, error) {
+	var ids []string
+	if err := s.db.WithContext(ctx).Model(&Payment{}).
+		Where("status = ? AND needs_review = ? AND authorized_at < ?", utils.PaymentStatusAuthorized, false, authorizedBefore).
+		Order("authorized_at").
+		Pluck("id", &ids).Error; err != nil {
+		utils.LogWithRequestID(ctx, "PaymentService: Failed to list expired authorizations")
+		return nil, err
+	}
+
+	voided := []Payment{}
+	for _, id := range ids {
+		payment, err := s.voidAuthorization(ctx, "id = ?", id)
+		if err != nil {
+			// The merchant may have captured the payment in the meantime
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to void expired authorization of payment %s: %v", id, err))
+			continue
+		}
+		voided = append(voided, *payment)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Voided %d authorization(s) of %d authorized before %s", len(voided), len(ids), authorizedBefore.Format(time.RFC3339)))
+	return voided, nil
+}
+
+// voidAuthorization voids the AUTHORIZED payment matching the query with its provider
+func (s *PaymentService) voidAuthorization(ctx context.Context, query string, args ...interface{}) (*Payment, error) {
+	var payment Payment
+	if err := s.findAuthorizedPayment(ctx, &payment, query, args...); err != nil {
+		return nil, err
+	}
+
+	adapter, err := s.adapterForPayment(ctx, &payment)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.claimAuthorization(ctx, &payment, "void"); err != nil {
+		return nil, err
+	}
+
+	if err := adapter.Void(interaction.WithPaymentID(ctx, payment.ID), payment.ExternalID); err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Provider failed to void payment %s: %v", payment.ID, err))
+		s.releaseAuthorization(ctx, &payment, err)
+		return nil, err
+	}
+
+	payment.Status = utils.PaymentStatusVoided
+	if err := s.settleAuthorization(ctx, &payment, map[string]interface{}{}); err != nil {
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Authorization of payment %s voided", payment.ID))
+	return &payment, nil
+}
+
+// findAuthorizedPayment loads the payment matching the query and checks that it is AUTHORIZED and not flagged
+// for review, which a capture or void with an unknown outcome leaves it
+func (s *PaymentService) findAuthorizedPayment(ctx context.Context, payment *Payment, query string, args ...interface{}) error {
+	if err := s.db.WithContext(ctx).Where(query, args...).First(payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		return err
+	}
+
+	if payment.Status != utils.PaymentStatusAuthorized {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Payment %s is not authorized (status: %s)", payment.ID, payment.Status))
+		return fmt.Errorf("%w: payment status is %s", ErrInvalidTransition, payment.Status)
+	}
+	if payment.NeedsReview {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Authorized payment %s is flagged for review: %s", payment.ID, payment.ReviewReason))
+		return fmt.Errorf("%w: payment is flagged for review", ErrInvalidTransition)
+	}
+	return nil
+}
+
+// claimAuthorization flags an AUTHORIZED payment for review before its provider is asked to capture or void it,
+// so that the payment is captured or voided only once, and that a payment whose outcome is never recorded is
+// left to an operator rather than voided at its capture deadline
+func (s *PaymentService) claimAuthorization(ctx context.Context, payment *Payment, action string) error {
+	now := time.Now()
+	reason := fmt.Sprintf("%s sent to the provider at %s, the outcome was not recorded", action, now.UTC().Format(time.RFC3339))
+	result := s.db.WithContext(ctx).Model(payment).
+		Where("status = ? AND needs_review = ?", utils.PaymentStatusAuthorized, false).
+		Updates(map[string]interface{}{"needs_review": true, "review_reason": reason, "updated_at": now})
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to claim authorized payment %s: %v", payment.ID, result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Authorized payment %s was captured or voided by another request", payment.ID))
+		return fmt.Errorf("%w: payment is being captured or voided", ErrInvalidTransition)
+	}
+
+	payment.NeedsReview = true
+	payment.ReviewReason = reason
+	payment.UpdatedAt = now
+	return nil
+}
+
+// releaseAuthorization clears the claim of a payment the provider refused to capture or void. Any other error
+// leaves the outcome unknown, so the payment stays flagged for an operator to check with the provider.
+func (s *PaymentService) releaseAuthorization(ctx context.Context, payment *Payment, sendErr error) {
+	if !errors.Is(sendErr, provider.ErrProviderRejected) {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Authorized payment %s stays flagged for review", payment.ID))
+		return
+	}
+
+	payment.NeedsReview = false
+	payment.ReviewReason = ""
+	payment.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Model(payment).
+		Updates(map[string]interface{}{"needs_review": false, "review_reason": "", "updated_at": payment.UpdatedAt}).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to release authorized payment %s: %v", payment.ID, err))
+	}
+}
+
+// settleAuthorization saves the new status of a payment the provider captured or voided with the given updates,
+// and clears its claim. A payment that cannot be saved stays AUTHORIZED and flagged for review.
+func (s *PaymentService) settleAuthorization(ctx context.Context, payment *Payment, updates map[string]interface{}) error {
+	payment.NeedsReview = false
+	payment.ReviewReason = ""
+	payment.UpdatedAt = time.Now()
+	updates["needs_review"] = false
+	updates["review_reason"] = ""
+	updates["status"] = payment.Status
+	updates["updated_at"] = payment.UpdatedAt
+	if err := s.db.WithContext(ctx).Model(payment).Updates(updates).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to save payment %s as %s, it stays flagged for review: %v", payment.ID, payment.Status, err))
+		return err
+	}
+	return nil
+}
+
+// notifyCancellation tells the provider of a payment that it was cancelled, if the provider supports it
+func (s *PaymentService) notifyCancellation(ctx context.Context, payment *Payment) error {
+	adapter, err := s.adapterForPayment(ctx, payment)
+	if err != nil {
+		return err
+	}
+	canceller, ok := adapter.(provider.Canceller)
+	if !ok {
+		return provider.ErrCancelNotSupported
+	}
+	return canceller.Cancel(interaction.WithPaymentID(ctx, payment.ID), payment.ExternalID)
+}
+
+// adapterForPayment returns the adapter of the provider a payment was sent to, as currently configured
+func (s *PaymentService) adapterForPayment(ctx context.Context, payment *Payment) (provider.ProviderAdapter, error) {
+	providerConfigs, err := s.providerSvc.FindProviderConfigs(ctx, payment.SettlementCurrency(), payment.CountryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range providerConfigs {
+		if providerConfigs[i].ProviderID == payment.ProviderID {
+			return s.adapterFactory.AdapterFor(ctx, &providerConfigs[i])
+		}
+	}
+	return nil, fmt.Errorf("%w: provider %d is no longer configured for the payment", provider.ErrProviderConfigNotFound, payment.ProviderID)
+}
+
 // UpdatePayment updates an existing payment in the database.
 func (s *PaymentService) UpdatePayment(payment *Payment) error {
 	payment.UpdatedAt = time.Now()
@@ -173,5 +1264,148 @@ func (s *PaymentService) FindPaymentByExternalID(externalID string) (*Payment, e
 	return &payment, nil
 }
 
+// FindPaymentByID finds a payment by its ID, scoped to the authenticated merchant.
+func (s *PaymentService) FindPaymentByID(ctx context.Context, id string) (*Payment, error) {
+	merchantID, ok := utils.MerchantIDFromContext(ctx)
+	if !ok {
+		utils.LogWithRequestID(ctx, "PaymentService: No merchant in context, refusing payment lookup")
+		return nil, ErrPaymentNotFound
+	}
+
+	// IDs that are not UUIDs can never match and would only make the query fail
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPaymentNotFound
+	}
+
+	var payment Payment
+	if err := s.db.Where("id = ? AND merchant_id = ?", id, merchantID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Payment %s not found for merchant %d", id, merchantID))
+			return nil, ErrPaymentNotFound
+		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to find payment %s: %v", id, err))
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindPaymentByReference finds a payment by its merchant reference, scoped to the authenticated merchant.
+func (s *PaymentService) FindPaymentByReference(ctx context.Context, reference string) (*Payment, error) {
+	merchantID, ok := utils.MerchantIDFromContext(ctx)
+	if !ok || reference == "" {
+		return nil, ErrPaymentNotFound
+	}
+
+	var payment Payment
+	if err := s.db.WithContext(ctx).Where("merchant_id = ? AND merchant_reference = ?", merchantID, reference).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: No payment with reference %s for merchant %d", reference, merchantID))
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ListPayments lists payments across all merchants, newest first. It is meant for operators
+// and, unlike FindPaymentByID, is not scoped to the merchant in the context.
+func (s *PaymentService) ListPayments(ctx context.Context, filter PaymentFilter) ([]Payment, error) {
+	query := s.db.WithContext(ctx).Preload("Provider").Order("created_at DESC")
+	if filter.ID != "" {
+		if _, err := uuid.Parse(filter.ID); err != nil {
+			return nil, ErrPaymentNotFound
+		}
+		query = query.Where("id = ?", filter.ID)
+	}
+	if filter.MerchantID != 0 {
+		query = query.Where("merchant_id = ?", filter.MerchantID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.MerchantReference != "" {
+		query = query.Where("merchant_reference = ?", filter.MerchantReference)
+	}
+	if filter.NeedsReview {
+		query = query.Where("needs_review = ?", true)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var payments []Payment
+	if err := query.Find(&payments).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to list payments: %v", err))
+		return nil, err
+	}
+	return payments, nil
+}
+
+// ExpirePayments marks the INITIALIZED and PENDING payments created before the given time as EXPIRED
+// and returns them. With dryRun the payments are only returned. Payouts are left out, as the provider may
+// still pay them out, and so are payments flagged for an operator to check with the provider.
+func (s *PaymentService) ExpirePayments(ctx context.Context, before time.Time, dryRun bool) ([]Payment, error) {
+	var payments []Payment
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status IN ? AND created_at < ?", []utils.PaymentStatus{utils.PaymentStatusInitialized, utils.PaymentStatusPending}, before).
+			Where("beneficiary IS NULL AND needs_review = ?", false).
+			Find(&payments).Error; err != nil {
+			return err
+		}
+		if dryRun || len(payments) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(payments))
+		for i := range payments {
+			ids[i] = payments[i].ID
+			payments[i].Status = utils.PaymentStatusExpired
+		}
+		return tx.Model(&Payment{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"status": utils.PaymentStatusExpired, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to expire payments: %v", err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Expired %d payment(s) created before %s (dry run: %t)", len(payments), before.Format(time.RFC3339), dryRun))
+	return payments, nil
+}
+
+// Reconcile counts the payments created since the given time per provider and status, and lists
+// the payments that have been waiting for a provider callback for longer than staleAfter.
+func (s *PaymentService) Reconcile(ctx context.Context, since time.Time, staleAfter time.Duration) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{Since: since}
+
+	err := s.db.WithContext(ctx).
+		Table("payments").
+		Joins("LEFT JOIN payment_providers ON payment_providers.id = payments.provider_id").
+		Select("payments.provider_id, payment_providers.name AS provider_name, payments.status, COUNT(*) AS count").
+		Where("payments.created_at >= ?", since).
+		Group("payments.provider_id, payment_providers.name, payments.status").
+		Order("payment_providers.name, payments.status").
+		Scan(&report.StatusCounts).Error
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("PaymentService: Failed to count payments: %v", err))
+		return nil, err
+	}
+
+	report.StalePayments, err = s.ListPayments(ctx, PaymentFilter{
+		Status:        utils.PaymentStatusPending,
+		CreatedBefore: time.Now().Add(-staleAfter),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
 // Ensure PaymentService implements PaymentServiceInterface.
 var _ PaymentServiceInterface = (*PaymentService)(nil)
@@ -2,7 +2,13 @@ package payment
 
 import (
 	"fmt"
+	"net/url"
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/provider"
 	"payment-gateway-service/internal/utils"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,6 +19,140 @@ type PaymentRequest struct {
 	Amount       float64 `json:"amount" binding:"required,gt=1"`
 	CurrencyCode string  `json:"currency_code" binding:"required,len=3"`
 	CountryCode  string  `json:"country_code" binding:"required,len=2"`
+
+	// Optional pages the customer is sent to after the payment, on one of the merchant's allowed redirect domains
+	SuccessURL string `json:"success_url" binding:"omitempty,url,max=2048"`
+	FailureURL string `json:"failure_url" binding:"omitempty,url,max=2048"`
+	CancelURL  string `json:"cancel_url" binding:"omitempty,url,max=2048"`
+
+	// HostedCheckout returns a gateway checkout page where the customer picks the provider,
+	// instead of the URL of the highest priority provider
+	HostedCheckout bool `json:"hosted_checkout"`
+
+	// UserSegment is the merchant's label for the customer, such as "vip", that routing rules can match
+	UserSegment string `json:"user_segment" binding:"omitempty,max=50"`
+
+	// Provider routes the payment to the named provider instead of the highest priority one, and
+	// ExcludeProviders never routes it to the named providers. The customer picks the provider on
+	// the hosted checkout, so neither can be combined with it.
+	Provider         string   `json:"provider" binding:"excluded_if=HostedCheckout true,max=255"`
+	ExcludeProviders []string `json:"exclude_providers" binding:"excluded_if=HostedCheckout true,max=10,dive,required,max=255"`
+
+	// SettlementCurrency converts the payment at the latest rate and routes it to the providers of that
+	// currency, and FXQuoteID does the same at the rate locked by a quote of CurrencyCode to the
+	// settlement currency
+	SettlementCurrency string `json:"settlement_currency" binding:"omitempty,len=3,excluded_with=FXQuoteID"`
+	FXQuoteID          string `json:"fx_quote_id" binding:"omitempty,uuid"`
+
+	// MerchantReference is the merchant's own identifier of the payment, unique per merchant, and the
+	// payment can be looked up by it. Metadata is stored as is and returned with the payment.
+	MerchantReference string                 `json:"merchant_reference" binding:"omitempty,max=255"`
+	Description       string                 `json:"description" binding:"omitempty,max=1000"`
+	Metadata          map[string]interface{} `json:"metadata" binding:"omitempty,max=50,dive,keys,max=64,endkeys"`
+	Customer          Customer               `json:"customer"`
+
+	// SaveMethod saves the payment method of a deposit with its provider once it succeeded, and
+	// SavedMethodID charges a method the user saved before, server to server with no redirect, with
+	// the provider the method was saved with
+	SaveMethod    bool   `json:"save_method" binding:"excluded_with=SavedMethodID"`
+	SavedMethodID string `json:"saved_method_id" binding:"omitempty,uuid,excluded_if=HostedCheckout true"`
+
+	// CaptureMode manual only has the provider authorize a deposit, for the merchant to capture or void it later
+	CaptureMode string `json:"capture_mode" binding:"omitempty,oneof=automatic manual"`
+}
+
+// PayoutRequest is a withdrawal paid out to a bank account, server to server. The redirect URLs and
+// the hosted checkout of payment requests do not apply to payouts and are ignored. The account is
+// either a verified saved beneficiary of the user, or given with the request.
+type PayoutRequest struct {
+	PaymentRequest
+	BeneficiaryID string                   `json:"beneficiary_id" binding:"required_without=Beneficiary,excluded_with=Beneficiary,omitempty,uuid"`
+	Beneficiary   *bankaccount.BankAccount `json:"beneficiary" binding:"required_without=BeneficiaryID"`
+}
+
+// newPayment returns the INITIALIZED payment of a request, assigned to a provider
+func (r *PaymentRequest) newPayment(merchantID uint, paymentType utils.PaymentType, providerID uint) *Payment {
+	metadata := r.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	return &Payment{
+		UserID:       r.UserID,
+		MerchantID:   merchantID,
+		Amount:       r.Amount,
+		PaymentType:  paymentType,
+		Status:       utils.PaymentStatusInitialized,
+		CurrencyCode: r.CurrencyCode,
+		CountryCode:  r.CountryCode,
+		ProviderID:   providerID,
+		SuccessURL:   r.SuccessURL,
+		FailureURL:   r.FailureURL,
+		CancelURL:    r.CancelURL,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+
+		MerchantReference: r.MerchantReference,
+		Description:       r.Description,
+		Metadata:          metadata,
+		Customer:          r.Customer,
+		SaveMethod:        r.SaveMethod && paymentType == utils.PaymentTypeDeposit,
+		CaptureMode:       r.captureMode(),
+	}
+}
+
+// captureMode returns the capture mode of the request, automatic if not set
+func (r *PaymentRequest) captureMode() string {
+	if r.CaptureMode == "" {
+		return CaptureModeAutomatic
+	}
+	return r.CaptureMode
+}
+
+// checkCaptureMode rejects manual capture for anything but deposits
+func (r *PaymentRequest) checkCaptureMode(paymentType utils.PaymentType) error {
+	if r.captureMode() == CaptureModeManual && paymentType != utils.PaymentTypeDeposit {
+		return ErrManualCaptureDepositOnly
+	}
+	return nil
+}
+
+// preferredProviderConfig returns the highest priority configuration of the preferred provider among
+// the eligible configurations. Provider names are matched case-insensitively.
+func preferredProviderConfig(eligible []provider.ProviderConfiguration, preferred string) (*provider.ProviderConfiguration, error) {
+	for i := range eligible {
+		if strings.EqualFold(eligible[i].ProviderName, preferred) {
+			return &eligible[i], nil
+		}
+	}
+	return nil, ErrProviderNotAvailable
+}
+
+// eligibleProviderConfigs returns the provider configurations whose provider is not excluded
+func eligibleProviderConfigs(providerConfigs []provider.ProviderConfiguration, excluded []string) []provider.ProviderConfiguration {
+	var eligible []provider.ProviderConfiguration
+	for _, providerConfig := range providerConfigs {
+		if !slices.ContainsFunc(excluded, func(name string) bool { return strings.EqualFold(name, providerConfig.ProviderName) }) {
+			eligible = append(eligible, providerConfig)
+		}
+	}
+	return eligible
+}
+
+// redirectURLs returns the redirect URLs set on the request
+func (r *PaymentRequest) redirectURLs() []string {
+	var urls []string
+	for _, u := range []string{r.SuccessURL, r.FailureURL, r.CancelURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// GetUserID returns the merchant's user the payment is for, used to rate limit per user
+func (r *PaymentRequest) GetUserID() int {
+	return r.UserID
 }
 
 // ExtractExternalID extracts the external ID from path or query parameters
@@ -34,7 +174,34 @@ func ExtractExternalID(c *gin.Context) (string, error) {
 		return externalID, nil
 	}
 
-	err := fmt.Errorf("external ID is required")
-	utils.LogWithRequestID(c, fmt.Sprintf("Error: %v", err))
-	return "", err
+	utils.LogWithRequestID(c, fmt.Sprintf("Error: %v", ErrExternalIDRequired))
+	return "", ErrExternalIDRequired
+}
+
+// RedirectURL returns where the customer is sent after a callback with the given result
+// ("successful", "failed" or "cancelled"). The payment's own URL is used if set, falling back
+// from cancel to failure, otherwise the gateway status page on appHost. The result and the
+// payment ID are added to the query string either way.
+func RedirectURL(payment *Payment, result, appHost string) string {
+	target := payment.FailureURL
+	switch result {
+	case "successful":
+		target = payment.SuccessURL
+	case "cancelled":
+		if payment.CancelURL != "" {
+			target = payment.CancelURL
+		}
+	}
+
+	if target != "" {
+		if u, err := url.Parse(target); err == nil {
+			query := u.Query()
+			query.Set("status", result)
+			query.Set("id", payment.ID)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+
+	return fmt.Sprintf("%s/payment?status=%s&id=%v", appHost, result, payment.ID)
 }
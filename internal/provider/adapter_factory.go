@@ -2,8 +2,10 @@ package provider
 
 import (
 	"context"
-	"errors"
+	"net/http"
+	"payment-gateway-service/internal/interaction"
 	"payment-gateway-service/internal/utils"
+	"time"
 )
 
 // AdapterFactoryInterface defines the method that the AdapterFactory must implement.
@@ -14,11 +16,25 @@ type AdapterFactoryInterface interface {
 // AdapterFactory is responsible for creating provider adapters based on the provider configuration.
 type AdapterFactory struct {
 	providerService ProviderServiceInterface
+	credentials     map[string]Credentials
+	timeout         func() time.Duration
+	stats           *Stats
+	interactions    interaction.Recorder
+	payoutLimiter   *PayoutLimiter
 }
 
-// NewAdapterFactory initializes a new AdapterFactory with a ProviderServiceInterface.
-func NewAdapterFactory(providerService ProviderServiceInterface) *AdapterFactory {
-	return &AdapterFactory{providerService: providerService}
+// NewAdapterFactory initializes a new AdapterFactory with a ProviderServiceInterface, the
+// credentials of each provider, keyed by provider name, and a function returning the current
+// timeout of provider requests. A nil timeout function means no timeout. The calls made through
+// the adapters are recorded in stats, and the requests they send in interactions, unless they are nil.
+func NewAdapterFactory(providerService ProviderServiceInterface, credentials map[string]Credentials, timeout func() time.Duration, stats *Stats, interactions interaction.Recorder) *AdapterFactory {
+	return &AdapterFactory{providerService: providerService, credentials: credentials, timeout: timeout, stats: stats, interactions: interactions}
+}
+
+// WithPayoutLimiter bounds the payouts sent through the adapters of the factory with limiter
+func (f *AdapterFactory) WithPayoutLimiter(limiter *PayoutLimiter) *AdapterFactory {
+	f.payoutLimiter = limiter
+	return f
 }
 
 // GetAdapter returns the appropriate adapter based on the currency code, country code, and priority.
@@ -32,19 +48,47 @@ func (f *AdapterFactory) GetAdapter(ctx context.Context, currencyCode, countryCo
 		return nil, err
 	}
 
+	utils.LogWithRequestID(ctx, "AdapterFactory: Found provider: "+providerConfig.ProviderName)
+	return f.AdapterFor(ctx, providerConfig)
+}
+
+// AdapterFor returns the adapter of a given provider configuration.
+func (f *AdapterFactory) AdapterFor(ctx context.Context, providerConfig *ProviderConfiguration) (ProviderAdapter, error) {
 	providerName := providerConfig.ProviderName
-	utils.LogWithRequestID(ctx, "AdapterFactory: Found provider: "+providerName)
+
+	var timeout time.Duration
+	if f.timeout != nil {
+		timeout = f.timeout()
+	}
+
+	var transport http.RoundTripper
+	if f.interactions != nil {
+		transport = interaction.NewTransport(providerName, f.interactions)
+	}
 
 	// Pass the baseURL from the database to the appropriate adapter.
+	var adapter ProviderAdapter
 	switch providerName {
 	case "HSBC":
 		utils.LogWithRequestID(ctx, "AdapterFactory: Creating HSBCAdapter")
-		return NewHSBCAdapter(providerConfig.BaseURL), nil
+		hsbcAdapter := NewHSBCAdapter(providerConfig.BaseURL, f.credentials[providerName], timeout)
+		hsbcAdapter.transport = transport
+		adapter = hsbcAdapter
 	case "ADCB":
 		utils.LogWithRequestID(ctx, "AdapterFactory: Creating ADCBAdapter")
-		return NewADCBAdapter(providerConfig.BaseURL), nil
+		adcbAdapter := NewADCBAdapter(providerConfig.BaseURL, f.credentials[providerName], timeout)
+		adcbAdapter.transport = transport
+		adapter = adcbAdapter
 	default:
 		utils.LogWithRequestID(ctx, "AdapterFactory: Unsupported provider: "+providerName)
-		return nil, errors.New("provider not supported")
+		return nil, ErrProviderNotSupported
+	}
+
+	if f.payoutLimiter != nil {
+		adapter = &limitedAdapter{ProviderAdapter: adapter, providerName: providerName, limiter: f.payoutLimiter}
+	}
+	if f.stats != nil {
+		adapter = &recordingAdapter{ProviderAdapter: adapter, providerName: providerName, stats: f.stats}
 	}
+	return adapter, nil
 }
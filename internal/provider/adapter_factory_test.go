@@ -42,7 +42,7 @@ func setupTest(_ *testing.T) (*provider.AdapterFactory, *MockProviderService) {
 	mockProviderService := new(MockProviderService)
 
 	// Inject the mock service into the AdapterFactory
-	factory := provider.NewAdapterFactory(mockProviderService)
+	factory := provider.NewAdapterFactory(mockProviderService, nil, nil, nil, nil)
 
 	return factory, mockProviderService
 }
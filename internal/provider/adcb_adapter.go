@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"payment-gateway-service/internal/utils"
 	"time"
 )
@@ -16,16 +15,18 @@ type ADCBAdapter struct {
 	baseURL    string
 	userID     string
 	userSecret string
-}
+	timeout    time.Duration
 
-func NewADCBAdapter(baseURL string) *ADCBAdapter {
-	userID := os.Getenv("ADCB_USER_ID")
-	userSecret := os.Getenv("ADCB_USER_SECRET")
+	// transport sends the requests, the default transport if nil
+	transport http.RoundTripper
+}
 
+func NewADCBAdapter(baseURL string, credentials Credentials, timeout time.Duration) *ADCBAdapter {
 	return &ADCBAdapter{
 		baseURL:    baseURL,
-		userID:     userID,
-		userSecret: userSecret,
+		userID:     credentials.UserID,
+		userSecret: credentials.UserSecret,
+		timeout:    timeout,
 	}
 }
 
@@ -36,6 +37,16 @@ type ADCBPaymentRequest struct {
 	PaymentType string   `xml:"PaymentType"`
 	Currency    string   `xml:"Currency"`
 	Country     string   `xml:"Country"`
+
+	// ADCB accepts the merchant reference and a description, but nothing about the customer
+	Reference   string `xml:"Reference,omitempty"`
+	Description string `xml:"Description,omitempty"`
+
+	// SaveMethod asks ADCB to issue a token for the payment method
+	SaveMethod bool `xml:"SaveMethod,omitempty"`
+
+	// CaptureMode is MANUAL to only authorize the payment
+	CaptureMode string `xml:"CaptureMode,omitempty"`
 }
 
 // Define the PaymentResponse structure with correct XML tags
@@ -45,7 +56,13 @@ type ADCBPaymentResponse struct {
 	ExternalID string   `xml:"ExternalID"`
 }
 
-func (a *ADCBAdapter) GetDetails(ctx context.Context, amount float64, paymentType, currencyCode, countryCode string) (string, string, error) {
+// ADCBCancelRequest asks ADCB to cancel a payment
+type ADCBCancelRequest struct {
+	XMLName    xml.Name `xml:"CancelRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+func (a *ADCBAdapter) GetDetails(ctx context.Context, amount float64, paymentType, currencyCode, countryCode string, details PaymentDetails) (string, string, error) {
 	startTime := time.Now() // Capture the start time
 	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Starting to generate payment details for Amount: %.2f, Payment Type: %s, currencyCode: %s, countryCode: %s", amount, paymentType, currencyCode, countryCode))
 
@@ -62,6 +79,10 @@ func (a *ADCBAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 		PaymentType: paymentType,
 		Currency:    currencyCode,
 		Country:     countryCode,
+		Reference:   details.MerchantReference,
+		Description: details.Description,
+		SaveMethod:  details.SaveMethod,
+		CaptureMode: adcbCaptureMode(details.ManualCapture),
 	}
 
 	// Marshal the request to XML with XML declaration
@@ -87,17 +108,15 @@ func (a *ADCBAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 	request.Header.Set("user_id", a.userID)
 	request.Header.Set("user_secret", a.userSecret)
 
-	// Log the request details
+	// Log the request URL, the headers and body are kept redacted in the provider interactions
 	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Request URL: %s", requestURL))
-	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Request Headers: %v", request.Header))
-	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Request Body: %s", string(soapRequestBody)))
 
 	// Perform the HTTP request
-	client := &http.Client{}
+	client := &http.Client{Timeout: a.timeout, Transport: a.transport}
 	resp, err := client.Do(request)
 	if err != nil {
 		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to perform HTTP request")
-		return "", "", err
+		return "", "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -106,7 +125,7 @@ func (a *ADCBAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 		// Read and log the response body
 		responseBody, _ := ioutil.ReadAll(resp.Body)
 		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: HTTP request failed with status code %d, Response Body: %s", resp.StatusCode, string(responseBody)))
-		return "", "", fmt.Errorf("HTTP request failed with status code %d", resp.StatusCode)
+		return "", "", statusError(resp.StatusCode)
 	}
 
 	// Read and log the response body
@@ -121,16 +140,324 @@ func (a *ADCBAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 	var paymentResponse ADCBPaymentResponse
 	if err := xml.Unmarshal(responseBody, &paymentResponse); err != nil {
 		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to unmarshal response")
-		return "", "", err
+		return "", "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
 	}
 
 	// Extract URL and ExternalID
 	if paymentResponse.URL == "" || paymentResponse.ExternalID == "" {
 		utils.LogWithRequestID(ctx, "ADCB Adapter: Missing URL or ExternalID in response")
-		return "", "", fmt.Errorf("failed to get payment details")
+		return "", "", fmt.Errorf("%w: missing URL or external ID in response", ErrProviderUnavailable)
 	}
 
 	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Successfully received response with URL: %s and ExternalID: %s", paymentResponse.URL, paymentResponse.ExternalID))
 
 	return paymentResponse.URL, paymentResponse.ExternalID, nil
 }
+
+// Cancel implements Canceller
+func (a *ADCBAdapter) Cancel(ctx context.Context, externalID string) error {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Cancelling payment with ExternalID: %s", externalID))
+
+	cancelRequestBody, err := xml.Marshal(ADCBCancelRequest{ExternalID: externalID})
+	if err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to marshal request")
+		return err
+	}
+	cancelRequestBody = []byte(fmt.Sprintf("%s\n%s", `<?xml version="1.0" encoding="UTF-8"?>`, string(cancelRequestBody)))
+
+	request, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/adcb/payment/cancel", a.baseURL), bytes.NewBuffer(cancelRequestBody))
+	if err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to create HTTP request")
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/xml")
+	request.Header.Set("user_id", a.userID)
+	request.Header.Set("user_secret", a.userSecret)
+
+	client := &http.Client{Timeout: a.timeout, Transport: a.transport}
+	resp, err := client.Do(request)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to perform HTTP request")
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := ioutil.ReadAll(resp.Body)
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Cancel request failed with status code %d, Response Body: %s", resp.StatusCode, string(responseBody)))
+		return statusError(resp.StatusCode)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Cancelled payment with ExternalID: %s", externalID))
+	return nil
+}
+
+// ADCBPayoutRequest asks ADCB to pay out to a bank account
+type ADCBPayoutRequest struct {
+	XMLName       xml.Name `xml:"PayoutRequest"`
+	Amount        float64  `xml:"Amount"`
+	Currency      string   `xml:"Currency"`
+	Country       string   `xml:"Country"`
+	Reference     string   `xml:"Reference,omitempty"`
+	RequestID     string   `xml:"RequestID"`
+	HolderName    string   `xml:"Beneficiary>HolderName"`
+	IBAN          string   `xml:"Beneficiary>IBAN,omitempty"`
+	AccountNumber string   `xml:"Beneficiary>AccountNumber,omitempty"`
+	BankCode      string   `xml:"Beneficiary>BankCode,omitempty"`
+}
+
+// ADCBPayoutStatusRequest asks ADCB for the status of a payout
+type ADCBPayoutStatusRequest struct {
+	XMLName    xml.Name `xml:"PayoutStatusRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// ADCBPayoutResponse is the answer of ADCB to a payout and to a payout status request
+type ADCBPayoutResponse struct {
+	XMLName    xml.Name `xml:"PayoutResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// adcbPayoutStatuses maps the statuses of ADCB payouts to payment statuses
+var adcbPayoutStatuses = map[string]utils.PaymentStatus{
+	"PENDING":  utils.PaymentStatusPending,
+	"PAID":     utils.PaymentStatusSuccess,
+	"REJECTED": utils.PaymentStatusFailed,
+}
+
+// Payout implements ProviderAdapter
+func (a *ADCBAdapter) Payout(ctx context.Context, payout PayoutDetails) (string, error) {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Sending payout of %.2f %s to %s", payout.Amount, payout.CurrencyCode, payout.CountryCode))
+
+	var payoutResponse ADCBPayoutResponse
+	err := a.doXML(ctx, fmt.Sprintf("%s/adcb/payout", a.baseURL), ADCBPayoutRequest{
+		Amount:        payout.Amount,
+		Currency:      payout.CurrencyCode,
+		Country:       payout.CountryCode,
+		Reference:     payout.Reference,
+		RequestID:     payout.IdempotencyKey,
+		HolderName:    payout.Beneficiary.HolderName,
+		IBAN:          payout.Beneficiary.IBAN,
+		AccountNumber: payout.Beneficiary.AccountNumber,
+		BankCode:      payout.Beneficiary.BankCode,
+	}, &payoutResponse)
+	if err != nil {
+		return "", err
+	}
+	if payoutResponse.ExternalID == "" {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Missing ExternalID in payout response")
+		return "", fmt.Errorf("%w: missing external ID in response", ErrProviderUnavailable)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Payout accepted with ExternalID: %s", payoutResponse.ExternalID))
+	return payoutResponse.ExternalID, nil
+}
+
+// PayoutStatus implements ProviderAdapter
+func (a *ADCBAdapter) PayoutStatus(ctx context.Context, externalID string) (utils.PaymentStatus, error) {
+	var payoutResponse ADCBPayoutResponse
+	if err := a.doXML(ctx, fmt.Sprintf("%s/adcb/payout/status", a.baseURL), ADCBPayoutStatusRequest{ExternalID: externalID}, &payoutResponse); err != nil {
+		return "", err
+	}
+
+	status, ok := adcbPayoutStatuses[payoutResponse.Status]
+	if !ok {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Unknown payout status %q for ExternalID: %s", payoutResponse.Status, externalID))
+		return "", fmt.Errorf("%w: unknown payout status %q", ErrProviderUnavailable, payoutResponse.Status)
+	}
+	return status, nil
+}
+
+// ADCBTokenRequest asks ADCB for the token issued for the payment method of a payment
+type ADCBTokenRequest struct {
+	XMLName    xml.Name `xml:"TokenRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// ADCBTokenResponse is the answer of ADCB to a token request
+type ADCBTokenResponse struct {
+	XMLName xml.Name `xml:"TokenResponse"`
+	Token   string   `xml:"Token"`
+}
+
+// ADCBChargeRequest asks ADCB to charge a token
+type ADCBChargeRequest struct {
+	XMLName     xml.Name `xml:"ChargeRequest"`
+	Amount      float64  `xml:"Amount"`
+	Currency    string   `xml:"Currency"`
+	Country     string   `xml:"Country"`
+	Reference   string   `xml:"Reference,omitempty"`
+	Description string   `xml:"Description,omitempty"`
+	Token       string   `xml:"Token"`
+	CaptureMode string   `xml:"CaptureMode,omitempty"`
+}
+
+// ADCBChargeResponse is the answer of ADCB to the charge of a token
+type ADCBChargeResponse struct {
+	XMLName    xml.Name `xml:"ChargeResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// adcbChargeStatuses maps the statuses of ADCB token charges to payment statuses
+var adcbChargeStatuses = map[string]utils.PaymentStatus{
+	"PENDING":    utils.PaymentStatusPending,
+	"AUTHORIZED": utils.PaymentStatusAuthorized,
+	"APPROVED":   utils.PaymentStatusSuccess,
+	"DECLINED":   utils.PaymentStatusFailed,
+}
+
+// PaymentToken implements ProviderAdapter
+func (a *ADCBAdapter) PaymentToken(ctx context.Context, externalID string) (string, error) {
+	var tokenResponse ADCBTokenResponse
+	if err := a.doXML(ctx, fmt.Sprintf("%s/adcb/payment/token", a.baseURL), ADCBTokenRequest{ExternalID: externalID}, &tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.Token == "" {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Missing token for ExternalID: %s", externalID))
+		return "", fmt.Errorf("%w: missing token in response", ErrProviderUnavailable)
+	}
+	return tokenResponse.Token, nil
+}
+
+// ChargeToken implements ProviderAdapter
+func (a *ADCBAdapter) ChargeToken(ctx context.Context, charge TokenChargeDetails) (string, utils.PaymentStatus, error) {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Charging saved method for %.2f %s in %s", charge.Amount, charge.CurrencyCode, charge.CountryCode))
+
+	var chargeResponse ADCBChargeResponse
+	err := a.doXML(ctx, fmt.Sprintf("%s/adcb/charge", a.baseURL), ADCBChargeRequest{
+		Amount:      charge.Amount,
+		Currency:    charge.CurrencyCode,
+		Country:     charge.CountryCode,
+		Reference:   charge.Reference,
+		Description: charge.Description,
+		Token:       charge.Token,
+		CaptureMode: adcbCaptureMode(charge.ManualCapture),
+	}, &chargeResponse)
+	if err != nil {
+		return "", "", err
+	}
+	if chargeResponse.ExternalID == "" {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Missing ExternalID in charge response")
+		return "", "", fmt.Errorf("%w: missing external ID in response", ErrProviderUnavailable)
+	}
+
+	status, ok := adcbChargeStatuses[chargeResponse.Status]
+	if !ok {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Unknown charge status %q for ExternalID: %s", chargeResponse.Status, chargeResponse.ExternalID))
+		return "", "", fmt.Errorf("%w: unknown charge status %q", ErrProviderUnavailable, chargeResponse.Status)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Charge %s with ExternalID: %s", status, chargeResponse.ExternalID))
+	return chargeResponse.ExternalID, status, nil
+}
+
+// ADCBCaptureRequest asks ADCB to capture an authorized payment
+type ADCBCaptureRequest struct {
+	XMLName    xml.Name `xml:"CaptureRequest"`
+	ExternalID string   `xml:"ExternalID"`
+	Amount     float64  `xml:"Amount"`
+	Currency   string   `xml:"Currency"`
+}
+
+// ADCBVoidRequest asks ADCB to release the authorization of a payment
+type ADCBVoidRequest struct {
+	XMLName    xml.Name `xml:"VoidRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// ADCBAuthorizationResponse is the answer of ADCB to the capture and the void of an authorization
+type ADCBAuthorizationResponse struct {
+	XMLName    xml.Name `xml:"AuthorizationResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// adcbCaptureMode returns the capture mode of ADCB payments and charges, empty for automatic capture
+func adcbCaptureMode(manualCapture bool) string {
+	if manualCapture {
+		return "MANUAL"
+	}
+	return ""
+}
+
+// Capture implements ProviderAdapter
+func (a *ADCBAdapter) Capture(ctx context.Context, externalID string, amount float64, currencyCode string) error {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Capturing %.2f %s of payment with ExternalID: %s", amount, currencyCode, externalID))
+
+	var authorizationResponse ADCBAuthorizationResponse
+	err := a.doXML(ctx, fmt.Sprintf("%s/adcb/payment/capture", a.baseURL), ADCBCaptureRequest{
+		ExternalID: externalID,
+		Amount:     amount,
+		Currency:   currencyCode,
+	}, &authorizationResponse)
+	if err != nil {
+		return err
+	}
+	if authorizationResponse.Status != "CAPTURED" {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Unexpected capture status %q for ExternalID: %s", authorizationResponse.Status, externalID))
+		return fmt.Errorf("%w: unexpected capture status %q", ErrProviderUnavailable, authorizationResponse.Status)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Captured payment with ExternalID: %s", externalID))
+	return nil
+}
+
+// Void implements ProviderAdapter
+func (a *ADCBAdapter) Void(ctx context.Context, externalID string) error {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Voiding authorization of payment with ExternalID: %s", externalID))
+
+	var authorizationResponse ADCBAuthorizationResponse
+	if err := a.doXML(ctx, fmt.Sprintf("%s/adcb/payment/void", a.baseURL), ADCBVoidRequest{ExternalID: externalID}, &authorizationResponse); err != nil {
+		return err
+	}
+	if authorizationResponse.Status != "VOIDED" {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Unexpected void status %q for ExternalID: %s", authorizationResponse.Status, externalID))
+		return fmt.Errorf("%w: unexpected void status %q", ErrProviderUnavailable, authorizationResponse.Status)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: Voided payment with ExternalID: %s", externalID))
+	return nil
+}
+
+// doXML posts an authenticated XML request to ADCB and decodes its XML response into v
+func (a *ADCBAdapter) doXML(ctx context.Context, requestURL string, body interface{}, v interface{}) error {
+	requestBody, err := xml.Marshal(body)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to marshal request")
+		return err
+	}
+	requestBody = []byte(fmt.Sprintf("%s\n%s", `<?xml version="1.0" encoding="UTF-8"?>`, string(requestBody)))
+
+	request, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to create HTTP request")
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/xml")
+	request.Header.Set("user_id", a.userID)
+	request.Header.Set("user_secret", a.userSecret)
+
+	client := &http.Client{Timeout: a.timeout, Transport: a.transport}
+	resp, err := client.Do(request)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to perform HTTP request")
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ADCB Adapter: HTTP request failed with status code %d, Response Body: %s", resp.StatusCode, string(responseBody)))
+		return statusError(resp.StatusCode)
+	}
+
+	if err := xml.Unmarshal(responseBody, v); err != nil {
+		utils.LogWithRequestID(ctx, "ADCB Adapter: Failed to unmarshal response")
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrNoRouteForCurrencyCountry is returned when no provider is configured for the currency and country
+	ErrNoRouteForCurrencyCountry = utils.NewAPIError(http.StatusUnprocessableEntity, "no_route_for_currency_country", "No payment provider available for the given currency and country")
+
+	// ErrProviderUnavailable is returned when the provider could not be reached or answered with a server error
+	ErrProviderUnavailable = utils.NewAPIError(http.StatusBadGateway, "provider_unavailable", "Payment provider is unavailable")
+
+	// ErrProviderRejected is returned when the provider refused the payment request
+	ErrProviderRejected = utils.NewAPIError(http.StatusUnprocessableEntity, "provider_rejected", "Payment was rejected by the provider")
+
+	// ErrProviderConfigNotFound is returned when no provider configuration matches the lookup
+	ErrProviderConfigNotFound = utils.NewAPIError(http.StatusNotFound, "provider_configuration_not_found", "Provider configuration not found")
+
+	// ErrProviderNotSupported is returned when a configuration points to a provider without an adapter
+	ErrProviderNotSupported = errors.New("provider not supported")
+
+	// ErrCancelNotSupported is returned when cancelling a payment with a provider that cannot be told about cancellations
+	ErrCancelNotSupported = errors.New("provider does not support cancellations")
+)
+
+// statusError maps a non-successful provider HTTP status code to the matching provider error
+func statusError(statusCode int) error {
+	if statusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: HTTP status %d", ErrProviderUnavailable, statusCode)
+	}
+	return fmt.Errorf("%w: HTTP status %d", ErrProviderRejected, statusCode)
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ProviderHandler handles the routing configuration admin requests
+type ProviderHandler struct {
+	service ProviderAdminServiceInterface
+}
+
+// NewProviderHandler initializes a new ProviderHandler
+func NewProviderHandler(db *gorm.DB) *ProviderHandler {
+	return &ProviderHandler{service: NewProviderService(db)}
+}
+
+// ListConfigurations lists the provider routing configurations
+// @Summary List provider configurations
+// @Description Lists every provider configuration used to route payments by currency, country and priority.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Success 200 {object} utils.APIResponse{data=[]ProviderConfiguration} "Provider configurations"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /admin/provider-configurations [get]
+func (h *ProviderHandler) ListConfigurations(c *gin.Context) {
+	providerConfigs, err := h.service.ListProviderConfigs(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Provider configurations", providerConfigs)
+}
+
+// UpdateConfiguration updates the priority and base URL of a provider configuration
+// @Summary Update a provider configuration
+// @Description Changes the routing priority and optionally the base URL and weight of a provider configuration.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path int true "Provider configuration ID"
+// @Param validatedBody body UpdateProviderConfigRequest true "Provider configuration update"
+// @Success 200 {object} utils.APIResponse{data=ProviderConfiguration} "Updated provider configuration"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Provider configuration not found"
+// @Router /admin/provider-configurations/{id} [put]
+func (h *ProviderHandler) UpdateConfiguration(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrProviderConfigNotFound)
+		return
+	}
+
+	req, _ := c.Get("validatedBody")
+	update, ok := req.(*UpdateProviderConfigRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Updating provider configuration %d: priority=%d", id, update.Priority))
+
+	providerConfig, err := h.service.UpdateProviderConfig(c, uint(id), update)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Provider configuration updated", providerConfig)
+}
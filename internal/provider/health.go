@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CheckReachability sends a GET request to a provider base URL and reports the HTTP status
+// and latency. Any HTTP response counts as reachable, only transport errors are returned.
+func CheckReachability(ctx context.Context, baseURL string, timeout time.Duration) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	startTime := time.Now()
+	resp, err := http.DefaultClient.Do(request)
+	latency := time.Since(startTime)
+	if err != nil {
+		return 0, latency, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, latency, nil
+}
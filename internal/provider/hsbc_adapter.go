@@ -5,9 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
+	"net/url"
 	"payment-gateway-service/internal/utils"
 	"time"
 )
@@ -16,16 +17,18 @@ type HSBCAdapter struct {
 	baseURL    string
 	userID     string
 	userSecret string
-}
+	timeout    time.Duration
 
-func NewHSBCAdapter(baseURL string) *HSBCAdapter {
-	userID := os.Getenv("HSBC_USER_ID")
-	userSecret := os.Getenv("HSBC_USER_SECRET")
+	// transport sends the requests, the default transport if nil
+	transport http.RoundTripper
+}
 
+func NewHSBCAdapter(baseURL string, credentials Credentials, timeout time.Duration) *HSBCAdapter {
 	return &HSBCAdapter{
 		baseURL:    baseURL,
-		userID:     userID,
-		userSecret: userSecret,
+		userID:     credentials.UserID,
+		userSecret: credentials.UserSecret,
+		timeout:    timeout,
 	}
 }
 
@@ -34,7 +37,7 @@ type HSBCResponse struct {
 	ExternalID string `json:"external_id"`
 }
 
-func (a *HSBCAdapter) GetDetails(ctx context.Context, amount float64, paymentType, currencyCode, countryCode string) (string, string, error) {
+func (a *HSBCAdapter) GetDetails(ctx context.Context, amount float64, paymentType, currencyCode, countryCode string, details PaymentDetails) (string, string, error) {
 	startTime := time.Now() // Capture the start time
 	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Starting to generate payment details for Amount: %.2f, Transaction Type: %s, Currency Code: %s, Country Code: %s", amount, paymentType, currencyCode, countryCode))
 
@@ -60,6 +63,27 @@ func (a *HSBCAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 		"country":      countryCode,
 	}
 
+	// HSBC accepts the merchant reference, a description and the customer
+	if details.MerchantReference != "" {
+		reqBody["reference"] = details.MerchantReference
+	}
+	if details.Description != "" {
+		reqBody["description"] = details.Description
+	}
+	if details.CustomerEmail != "" || details.CustomerName != "" || details.CustomerLocale != "" {
+		reqBody["customer"] = map[string]string{
+			"email":  details.CustomerEmail,
+			"name":   details.CustomerName,
+			"locale": details.CustomerLocale,
+		}
+	}
+	if details.SaveMethod {
+		reqBody["save_method"] = true
+	}
+	if details.ManualCapture {
+		reqBody["capture_mode"] = "manual"
+	}
+
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to marshal request body to JSON")
@@ -75,11 +99,11 @@ func (a *HSBCAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 	req.Header.Set("user_id", a.userID)
 	req.Header.Set("user_secret", a.userSecret)
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: a.timeout, Transport: a.transport}
 	resp, err := client.Do(req)
 	if err != nil {
 		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to perform HTTP request")
-		return "", "", err
+		return "", "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
 	}
 	defer resp.Body.Close()
 
@@ -91,13 +115,284 @@ func (a *HSBCAdapter) GetDetails(ctx context.Context, amount float64, paymentTyp
 	}
 	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Raw response body: %s", string(body)))
 
+	// Check the HTTP status code
+	if resp.StatusCode != http.StatusOK {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: HTTP request failed with status code %d", resp.StatusCode))
+		return "", "", statusError(resp.StatusCode)
+	}
+
 	var hsbcResponse HSBCResponse
 	if err := json.Unmarshal(body, &hsbcResponse); err != nil {
 		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to decode response from HSBC service")
-		return "", "", err
+		return "", "", fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+
+	if hsbcResponse.URL == "" || hsbcResponse.ExternalID == "" {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Missing URL or ExternalID in response")
+		return "", "", fmt.Errorf("%w: missing URL or external ID in response", ErrProviderUnavailable)
 	}
 
 	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Successfully received response with URL: %s and ExternalID: %s", hsbcResponse.URL, hsbcResponse.ExternalID))
 
 	return hsbcResponse.URL, hsbcResponse.ExternalID, nil
 }
+
+// Cancel implements Canceller
+func (a *HSBCAdapter) Cancel(ctx context.Context, externalID string) error {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Cancelling payment with ExternalID: %s", externalID))
+
+	jsonData, err := json.Marshal(map[string]string{"external_id": externalID})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/hsbc/payment/cancel", a.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to create new HTTP request")
+		return err
+	}
+
+	req.Header.Set("user_id", a.userID)
+	req.Header.Set("user_secret", a.userSecret)
+
+	client := &http.Client{Timeout: a.timeout, Transport: a.transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to perform HTTP request")
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Cancel request failed with status code %d", resp.StatusCode))
+		return statusError(resp.StatusCode)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Cancelled payment with ExternalID: %s", externalID))
+	return nil
+}
+
+// HSBCPayoutResponse is the answer of HSBC to a payout and to a payout status request
+type HSBCPayoutResponse struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+// hsbcPayoutStatuses maps the statuses of HSBC payouts to payment statuses
+var hsbcPayoutStatuses = map[string]utils.PaymentStatus{
+	"PENDING":   utils.PaymentStatusPending,
+	"COMPLETED": utils.PaymentStatusSuccess,
+	"FAILED":    utils.PaymentStatusFailed,
+}
+
+// Payout implements ProviderAdapter
+func (a *HSBCAdapter) Payout(ctx context.Context, payout PayoutDetails) (string, error) {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Sending payout of %.2f %s to %s", payout.Amount, payout.CurrencyCode, payout.CountryCode))
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"amount":     payout.Amount,
+		"currency":   payout.CurrencyCode,
+		"country":    payout.CountryCode,
+		"reference":  payout.Reference,
+		"request_id": payout.IdempotencyKey,
+		"beneficiary": map[string]string{
+			"holder_name":    payout.Beneficiary.HolderName,
+			"iban":           payout.Beneficiary.IBAN,
+			"account_number": payout.Beneficiary.AccountNumber,
+			"bank_code":      payout.Beneficiary.BankCode,
+		},
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to marshal request body to JSON")
+		return "", err
+	}
+
+	var hsbcResponse HSBCPayoutResponse
+	if err := a.doJSON(ctx, "POST", fmt.Sprintf("%s/hsbc/payout", a.baseURL), bytes.NewBuffer(jsonData), &hsbcResponse); err != nil {
+		return "", err
+	}
+	if hsbcResponse.ExternalID == "" {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Missing ExternalID in payout response")
+		return "", fmt.Errorf("%w: missing external ID in response", ErrProviderUnavailable)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Payout accepted with ExternalID: %s", hsbcResponse.ExternalID))
+	return hsbcResponse.ExternalID, nil
+}
+
+// PayoutStatus implements ProviderAdapter
+func (a *HSBCAdapter) PayoutStatus(ctx context.Context, externalID string) (utils.PaymentStatus, error) {
+	var hsbcResponse HSBCPayoutResponse
+	requestURL := fmt.Sprintf("%s/hsbc/payout/status?external_id=%s", a.baseURL, url.QueryEscape(externalID))
+	if err := a.doJSON(ctx, "GET", requestURL, nil, &hsbcResponse); err != nil {
+		return "", err
+	}
+
+	status, ok := hsbcPayoutStatuses[hsbcResponse.Status]
+	if !ok {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Unknown payout status %q for ExternalID: %s", hsbcResponse.Status, externalID))
+		return "", fmt.Errorf("%w: unknown payout status %q", ErrProviderUnavailable, hsbcResponse.Status)
+	}
+	return status, nil
+}
+
+// HSBCTokenResponse is the answer of HSBC to a payment token request
+type HSBCTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// HSBCChargeResponse is the answer of HSBC to the charge of a token
+type HSBCChargeResponse struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+// hsbcChargeStatuses maps the statuses of HSBC token charges to payment statuses
+var hsbcChargeStatuses = map[string]utils.PaymentStatus{
+	"PENDING":    utils.PaymentStatusPending,
+	"AUTHORIZED": utils.PaymentStatusAuthorized,
+	"COMPLETED":  utils.PaymentStatusSuccess,
+	"DECLINED":   utils.PaymentStatusFailed,
+}
+
+// PaymentToken implements ProviderAdapter
+func (a *HSBCAdapter) PaymentToken(ctx context.Context, externalID string) (string, error) {
+	var hsbcResponse HSBCTokenResponse
+	requestURL := fmt.Sprintf("%s/hsbc/payment/token?external_id=%s", a.baseURL, url.QueryEscape(externalID))
+	if err := a.doJSON(ctx, "GET", requestURL, nil, &hsbcResponse); err != nil {
+		return "", err
+	}
+	if hsbcResponse.Token == "" {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Missing token for ExternalID: %s", externalID))
+		return "", fmt.Errorf("%w: missing token in response", ErrProviderUnavailable)
+	}
+	return hsbcResponse.Token, nil
+}
+
+// ChargeToken implements ProviderAdapter
+func (a *HSBCAdapter) ChargeToken(ctx context.Context, charge TokenChargeDetails) (string, utils.PaymentStatus, error) {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Charging saved method for %.2f %s in %s", charge.Amount, charge.CurrencyCode, charge.CountryCode))
+
+	reqBody := map[string]interface{}{
+		"amount":      charge.Amount,
+		"currency":    charge.CurrencyCode,
+		"country":     charge.CountryCode,
+		"reference":   charge.Reference,
+		"description": charge.Description,
+		"token":       charge.Token,
+	}
+	if charge.ManualCapture {
+		reqBody["capture_mode"] = "manual"
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to marshal request body to JSON")
+		return "", "", err
+	}
+
+	var hsbcResponse HSBCChargeResponse
+	if err := a.doJSON(ctx, "POST", fmt.Sprintf("%s/hsbc/charge", a.baseURL), bytes.NewBuffer(jsonData), &hsbcResponse); err != nil {
+		return "", "", err
+	}
+	if hsbcResponse.ExternalID == "" {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Missing ExternalID in charge response")
+		return "", "", fmt.Errorf("%w: missing external ID in response", ErrProviderUnavailable)
+	}
+
+	status, ok := hsbcChargeStatuses[hsbcResponse.Status]
+	if !ok {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Unknown charge status %q for ExternalID: %s", hsbcResponse.Status, hsbcResponse.ExternalID))
+		return "", "", fmt.Errorf("%w: unknown charge status %q", ErrProviderUnavailable, hsbcResponse.Status)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Charge %s with ExternalID: %s", status, hsbcResponse.ExternalID))
+	return hsbcResponse.ExternalID, status, nil
+}
+
+// HSBCAuthorizationResponse is the answer of HSBC to the capture and the void of an authorization
+type HSBCAuthorizationResponse struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+// Capture implements ProviderAdapter
+func (a *HSBCAdapter) Capture(ctx context.Context, externalID string, amount float64, currencyCode string) error {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Capturing %.2f %s of payment with ExternalID: %s", amount, currencyCode, externalID))
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"external_id": externalID,
+		"amount":      amount,
+		"currency":    currencyCode,
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to marshal request body to JSON")
+		return err
+	}
+
+	var hsbcResponse HSBCAuthorizationResponse
+	if err := a.doJSON(ctx, "POST", fmt.Sprintf("%s/hsbc/payment/capture", a.baseURL), bytes.NewBuffer(jsonData), &hsbcResponse); err != nil {
+		return err
+	}
+	if hsbcResponse.Status != "CAPTURED" {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Unexpected capture status %q for ExternalID: %s", hsbcResponse.Status, externalID))
+		return fmt.Errorf("%w: unexpected capture status %q", ErrProviderUnavailable, hsbcResponse.Status)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Captured payment with ExternalID: %s", externalID))
+	return nil
+}
+
+// Void implements ProviderAdapter
+func (a *HSBCAdapter) Void(ctx context.Context, externalID string) error {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Voiding authorization of payment with ExternalID: %s", externalID))
+
+	jsonData, err := json.Marshal(map[string]string{"external_id": externalID})
+	if err != nil {
+		return err
+	}
+
+	var hsbcResponse HSBCAuthorizationResponse
+	if err := a.doJSON(ctx, "POST", fmt.Sprintf("%s/hsbc/payment/void", a.baseURL), bytes.NewBuffer(jsonData), &hsbcResponse); err != nil {
+		return err
+	}
+	if hsbcResponse.Status != "VOIDED" {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Unexpected void status %q for ExternalID: %s", hsbcResponse.Status, externalID))
+		return fmt.Errorf("%w: unexpected void status %q", ErrProviderUnavailable, hsbcResponse.Status)
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: Voided payment with ExternalID: %s", externalID))
+	return nil
+}
+
+// doJSON sends an authenticated request to HSBC and decodes its JSON response into v
+func (a *HSBCAdapter) doJSON(ctx context.Context, method, requestURL string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to create new HTTP request")
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("user_id", a.userID)
+	req.Header.Set("user_secret", a.userSecret)
+
+	client := &http.Client{Timeout: a.timeout, Transport: a.transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to perform HTTP request")
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("HSBC Adapter: HTTP request failed with status code %d", resp.StatusCode))
+		return statusError(resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		utils.LogWithRequestID(ctx, "HSBC Adapter: Failed to decode response from HSBC service")
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	return nil
+}
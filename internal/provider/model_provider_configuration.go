@@ -14,14 +14,26 @@ type ProviderConfiguration struct {
 	ProviderID   uint      `gorm:"not null" json:"provider_id"`
 	BaseURL      string    `gorm:"not null" json:"base_url"`
 	Priority     int       `gorm:"not null;check:priority >= 1" json:"priority"`
+	Weight       int       `gorm:"not null;default:1;check:weight >= 0" json:"weight"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	ProviderName string    `gorm:"column:provider_name" json:"provider_name"`
 
+	// ProviderDisplayName is the name of the provider shown to merchants and customers
+	ProviderDisplayName string `gorm:"column:provider_display_name" json:"provider_display_name"`
+
 	// Relationships
-	Country  country.Country   `gorm:"foreignKey:CountryID"`
-	Currency currency.Currency `gorm:"foreignKey:CurrencyID"`
-	Provider Provider          `gorm:"foreignKey:ProviderID"`
+	Country  country.Country   `gorm:"foreignKey:CountryID" json:"-"`
+	Currency currency.Currency `gorm:"foreignKey:CurrencyID" json:"-"`
+	Provider Provider          `gorm:"foreignKey:ProviderID" json:"-"`
+}
+
+// DisplayName returns the display name of the provider, or its name when it has none
+func (pc ProviderConfiguration) DisplayName() string {
+	if pc.ProviderDisplayName != "" {
+		return pc.ProviderDisplayName
+	}
+	return pc.ProviderName
 }
 
 func (ProviderConfiguration) TableName() string {
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"sync"
+)
+
+// PayoutLimiter bounds the number of payouts sent to each provider at the same time, so that a
+// batch of payouts does not flood a provider
+type PayoutLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// NewPayoutLimiter initializes a PayoutLimiter allowing limit concurrent payouts per provider
+func NewPayoutLimiter(limit int) *PayoutLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &PayoutLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire waits for a free payout slot of the provider, or for the context to be done.
+// The returned function releases the slot.
+func (l *PayoutLimiter) acquire(ctx context.Context, providerName string) (func(), error) {
+	l.mu.Lock()
+	slots, ok := l.slots[providerName]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[providerName] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// limitedAdapter sends the payouts of the adapter it wraps within the limit of its provider
+type limitedAdapter struct {
+	ProviderAdapter
+	providerName string
+	limiter      *PayoutLimiter
+}
+
+// Payout implements ProviderAdapter
+func (a *limitedAdapter) Payout(ctx context.Context, payout PayoutDetails) (string, error) {
+	release, err := a.limiter.acquire(ctx, a.providerName)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return a.ProviderAdapter.Payout(ctx, payout)
+}
+
+// Cancel implements Canceller, cancellations are not limited
+func (a *limitedAdapter) Cancel(ctx context.Context, externalID string) error {
+	return cancel(ctx, a.ProviderAdapter, externalID)
+}
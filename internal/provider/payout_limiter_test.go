@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowAdapter counts the payouts it is sending at the same time
+type slowAdapter struct {
+	ProviderAdapter
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (a *slowAdapter) Payout(_ context.Context, _ PayoutDetails) (string, error) {
+	current := a.inFlight.Add(1)
+	for {
+		max := a.maxInFlight.Load()
+		if current <= max || a.maxInFlight.CompareAndSwap(max, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	a.inFlight.Add(-1)
+	return "external-id", nil
+}
+
+func TestPayoutLimiter(t *testing.T) {
+	limiter := NewPayoutLimiter(2)
+	hsbc := &slowAdapter{}
+	adcb := &slowAdapter{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for name, adapter := range map[string]*slowAdapter{"HSBC": hsbc, "ADCB": adcb} {
+			wg.Add(1)
+			go func(name string, adapter *slowAdapter) {
+				defer wg.Done()
+				limited := &limitedAdapter{ProviderAdapter: adapter, providerName: name, limiter: limiter}
+				_, err := limited.Payout(context.Background(), PayoutDetails{})
+				assert.NoError(t, err)
+			}(name, adapter)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), hsbc.maxInFlight.Load())
+	assert.Equal(t, int32(2), adcb.maxInFlight.Load(), "each provider has its own limit")
+}
+
+func TestPayoutLimiter_ContextDone(t *testing.T) {
+	limiter := NewPayoutLimiter(1)
+	release, err := limiter.acquire(context.Background(), "HSBC")
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limited := &limitedAdapter{ProviderAdapter: &slowAdapter{}, providerName: "HSBC", limiter: limiter}
+	_, err = limited.Payout(ctx, PayoutDetails{})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
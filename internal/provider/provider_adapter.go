@@ -1,8 +1,98 @@
 package provider
 
-import "context"
+import (
+	"context"
+	"payment-gateway-service/internal/bankaccount"
+	"payment-gateway-service/internal/utils"
+)
 
 // ProviderAdapter is the interface that all provider adapters must implement
 type ProviderAdapter interface {
-	GetDetails(ctx context.Context, amount float64, transactionType, currencyCode string, countryCode string) (string, string, error)
+	GetDetails(ctx context.Context, amount float64, transactionType, currencyCode string, countryCode string, details PaymentDetails) (string, string, error)
+
+	// Payout sends money to a bank account, server to server, and returns the external ID of the payout.
+	// Payouts complete asynchronously: the provider calls back once it settled the payout, or it is polled.
+	Payout(ctx context.Context, payout PayoutDetails) (string, error)
+
+	// PayoutStatus returns the status of a payout: PENDING until the provider settled it, then SUCCESS or FAILED
+	PayoutStatus(ctx context.Context, externalID string) (utils.PaymentStatus, error)
+
+	// PaymentToken returns the reusable token the provider issued for the payment method of a successful
+	// payment created with PaymentDetails.SaveMethod
+	PaymentToken(ctx context.Context, externalID string) (string, error)
+
+	// ChargeToken charges a payment method saved with a token, server to server, and returns the external ID
+	// of the payment and its status: SUCCESS or FAILED, or PENDING until the provider calls back
+	ChargeToken(ctx context.Context, charge TokenChargeDetails) (string, utils.PaymentStatus, error)
+
+	// Capture takes the given amount, up to the authorized amount, of a payment made with manual capture.
+	// The rest of the authorization is released.
+	Capture(ctx context.Context, externalID string, amount float64, currencyCode string) error
+
+	// Void releases the authorization of a payment made with manual capture without taking any of it
+	Void(ctx context.Context, externalID string) error
+}
+
+// Canceller is implemented by the adapters of providers that can be told about cancelled payments.
+// Telling the provider is optional: the payment is cancelled by the gateway all the same.
+type Canceller interface {
+	// Cancel tells the provider that the payment with the external ID will not be completed
+	Cancel(ctx context.Context, externalID string) error
+}
+
+// cancel tells the provider of the adapter about a cancelled payment, if the adapter is a Canceller
+func cancel(ctx context.Context, adapter ProviderAdapter, externalID string) error {
+	canceller, ok := adapter.(Canceller)
+	if !ok {
+		return ErrCancelNotSupported
+	}
+	return canceller.Cancel(ctx, externalID)
+}
+
+// TokenChargeDetails describes the charge of a saved payment method to the provider
+type TokenChargeDetails struct {
+	Amount       float64
+	CurrencyCode string
+	CountryCode  string
+	Reference    string
+	Description  string
+	Token        string
+
+	// ManualCapture only authorizes the charge, which is AUTHORIZED instead of SUCCESS
+	ManualCapture bool
+}
+
+// PayoutDetails describes a payout to the provider
+type PayoutDetails struct {
+	Amount       float64
+	CurrencyCode string
+	CountryCode  string
+	Reference    string
+
+	// IdempotencyKey identifies the payout to the provider, which ignores a payout sent again with the same key
+	IdempotencyKey string
+
+	Beneficiary bankaccount.BankAccount
+}
+
+// PaymentDetails describes a payment to the provider beyond its amount, type, currency and country.
+// Adapters send the fields their provider's API supports and ignore the others.
+type PaymentDetails struct {
+	MerchantReference string
+	Description       string
+	CustomerEmail     string
+	CustomerName      string
+	CustomerLocale    string
+
+	// SaveMethod asks the provider to issue a reusable token for the payment method once the payment succeeded
+	SaveMethod bool
+
+	// ManualCapture asks the provider to only authorize the payment, it is captured or voided later
+	ManualCapture bool
+}
+
+// Credentials authenticate the service with a provider
+type Credentials struct {
+	UserID     string
+	UserSecret string
 }
@@ -14,6 +14,15 @@ type ProviderServiceInterface interface {
 	FindProviderConfig(ctx context.Context, currencyCode, countryCode string) (*ProviderConfiguration, error)
 }
 
+// ProviderAdminServiceInterface defines the routing configuration methods used by the admin API.
+type ProviderAdminServiceInterface interface {
+	ListProviderConfigs(ctx context.Context) ([]ProviderConfiguration, error)
+	UpdateProviderConfig(ctx context.Context, id uint, update *UpdateProviderConfigRequest) (*ProviderConfiguration, error)
+}
+
+// configurationColumns selects a provider configuration with the name and display name of its provider
+const configurationColumns = "provider_configurations.*, payment_providers.name as provider_name, payment_providers.display_name as provider_display_name"
+
 // ProviderService handles operations related to payment providers.
 type ProviderService struct {
 	db *gorm.DB
@@ -51,23 +60,15 @@ func (s *ProviderService) FindProviderConfig(ctx context.Context, currencyCode,
 	utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Attempting to find provider configuration for CurrencyCode: %s, CountryCode: %s", currencyCode, countryCode))
 
 	// Perform the join query safely with parameterized inputs
-	err := s.db.
-		Table("provider_configurations").
-		Joins("JOIN currencies ON currencies.id = provider_configurations.currency_id").
-		Joins("JOIN countries ON countries.id = provider_configurations.country_id").
-		Joins("JOIN payment_providers ON payment_providers.id = provider_configurations.provider_id").
-		Select("provider_configurations.*, payment_providers.name as provider_name").
-		Where("currencies.currency_code = ? AND countries.country_code = ?", currencyCode, countryCode).
-		Order("provider_configurations.priority ASC, provider_configurations.id").
-		First(&providerConfig).Error
+	err := s.routeQuery(currencyCode, countryCode).First(&providerConfig).Error
 
 	// Handle the case where no matching configuration is found
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: No provider configuration found for CurrencyCode: %s, CountryCode: %s", currencyCode, countryCode))
-		} else {
-			utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Error retrieving provider configuration: %v", err))
+			return nil, fmt.Errorf("%w: %w", ErrNoRouteForCurrencyCountry, err)
 		}
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Error retrieving provider configuration: %v", err))
 		return nil, err
 	}
 
@@ -75,5 +76,89 @@ func (s *ProviderService) FindProviderConfig(ctx context.Context, currencyCode,
 	return &providerConfig, nil
 }
 
+// FindProviderConfigs retrieves every provider configuration for a currency code and country code, by priority.
+func (s *ProviderService) FindProviderConfigs(ctx context.Context, currencyCode, countryCode string) ([]ProviderConfiguration, error) {
+	var providerConfigs []ProviderConfiguration
+
+	if err := s.routeQuery(currencyCode, countryCode).Find(&providerConfigs).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Error retrieving provider configurations: %v", err))
+		return nil, err
+	}
+
+	if len(providerConfigs) == 0 {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: No provider configuration found for CurrencyCode: %s, CountryCode: %s", currencyCode, countryCode))
+		return nil, ErrNoRouteForCurrencyCountry
+	}
+
+	return providerConfigs, nil
+}
+
+// routeQuery selects the provider configurations for a currency code and country code, by priority.
+func (s *ProviderService) routeQuery(currencyCode, countryCode string) *gorm.DB {
+	return s.db.
+		Table("provider_configurations").
+		Joins("JOIN currencies ON currencies.id = provider_configurations.currency_id").
+		Joins("JOIN countries ON countries.id = provider_configurations.country_id").
+		Joins("JOIN payment_providers ON payment_providers.id = provider_configurations.provider_id").
+		Select(configurationColumns).
+		Where("currencies.currency_code = ? AND countries.country_code = ?", currencyCode, countryCode).
+		Order("provider_configurations.priority ASC, provider_configurations.id")
+}
+
+// ListProviderConfigs returns every provider configuration ordered by country, currency and priority.
+func (s *ProviderService) ListProviderConfigs(ctx context.Context) ([]ProviderConfiguration, error) {
+	var providerConfigs []ProviderConfiguration
+
+	err := s.db.
+		Table("provider_configurations").
+		Joins("JOIN payment_providers ON payment_providers.id = provider_configurations.provider_id").
+		Select(configurationColumns).
+		Order("provider_configurations.country_id, provider_configurations.currency_id, provider_configurations.priority ASC, provider_configurations.id").
+		Find(&providerConfigs).Error
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Error listing provider configurations: %v", err))
+		return nil, err
+	}
+
+	return providerConfigs, nil
+}
+
+// UpdateProviderConfig changes the routing priority and optionally the base URL and weight of a provider configuration.
+func (s *ProviderService) UpdateProviderConfig(ctx context.Context, id uint, update *UpdateProviderConfigRequest) (*ProviderConfiguration, error) {
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Updating provider configuration %d", id))
+
+	updates := map[string]interface{}{"priority": update.Priority}
+	if update.BaseURL != "" {
+		updates["base_url"] = update.BaseURL
+	}
+	if update.Weight != nil {
+		updates["weight"] = *update.Weight
+	}
+
+	result := s.db.Model(&ProviderConfiguration{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Error updating provider configuration %d: %v", id, result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrProviderConfigNotFound
+	}
+
+	var providerConfig ProviderConfiguration
+	err := s.db.
+		Table("provider_configurations").
+		Joins("JOIN payment_providers ON payment_providers.id = provider_configurations.provider_id").
+		Select(configurationColumns).
+		Where("provider_configurations.id = ?", id).
+		First(&providerConfig).Error
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("ProviderService: Provider configuration %d now has priority %d", id, providerConfig.Priority))
+	return &providerConfig, nil
+}
+
 // Ensure ProviderService implements ProviderServiceInterface.
 var _ ProviderServiceInterface = (*ProviderService)(nil)
+var _ ProviderAdminServiceInterface = (*ProviderService)(nil)
@@ -101,7 +101,7 @@ func TestFindProviderConfig(t *testing.T) {
 	// Set up mock expectations for a successful query
 	sqlRows := sqlmock.NewRows([]string{"currency_id", "country_id", "provider_id", "provider_name"}).
 		AddRow(1, 1, 1, "TestProvider")
-	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name FROM "provider_configurations"`).
+	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name, payment_providers\.display_name as provider_display_name FROM "provider_configurations"`).
 		WillReturnRows(sqlRows)
 
 	// Call the service method
@@ -121,7 +121,7 @@ func TestFindProviderConfig_NotFound(t *testing.T) {
 	providerService := NewProviderService(gormDB)
 
 	// Set up mock expectations for a not found case
-	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name FROM "provider_configurations"`).
+	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name, payment_providers\.display_name as provider_display_name FROM "provider_configurations"`).
 		WillReturnError(gorm.ErrRecordNotFound)
 
 	// Call the service method
@@ -129,6 +129,7 @@ func TestFindProviderConfig_NotFound(t *testing.T) {
 
 	// Assertions
 	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, err, ErrNoRouteForCurrencyCountry)
 	assert.Nil(t, result)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -140,7 +141,7 @@ func TestFindProviderConfig_DBError(t *testing.T) {
 	providerService := NewProviderService(gormDB)
 
 	// Set up mock expectations for a database error
-	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name FROM "provider_configurations"`).
+	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name, payment_providers\.display_name as provider_display_name FROM "provider_configurations"`).
 		WillReturnError(fmt.Errorf("database error"))
 
 	// Call the service method
@@ -151,3 +152,46 @@ func TestFindProviderConfig_DBError(t *testing.T) {
 	assert.Nil(t, result)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestFindProviderConfigs(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerService := NewProviderService(gormDB)
+
+	// Set up mock expectations for two configurations ordered by priority
+	sqlRows := sqlmock.NewRows([]string{"id", "provider_id", "priority", "provider_name"}).
+		AddRow(1, 1, 1, "HSBC").
+		AddRow(2, 2, 2, "ADCB")
+	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name, payment_providers\.display_name as provider_display_name FROM "provider_configurations" .* ORDER BY provider_configurations\.priority ASC, provider_configurations\.id$`).
+		WithArgs("USD", "US").
+		WillReturnRows(sqlRows)
+
+	// Call the service method
+	result, err := providerService.FindProviderConfigs(context.TODO(), "USD", "US")
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "ADCB", result[1].ProviderName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindProviderConfigs_NoRoute(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	providerService := NewProviderService(gormDB)
+
+	// Set up mock expectations for a currency and country without configurations
+	mock.ExpectQuery(`^SELECT provider_configurations\.\*, payment_providers\.name as provider_name, payment_providers\.display_name as provider_display_name FROM "provider_configurations"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// Call the service method
+	result, err := providerService.FindProviderConfigs(context.TODO(), "USD", "AE")
+
+	// Assertions
+	assert.ErrorIs(t, err, ErrNoRouteForCurrencyCountry)
+	assert.Nil(t, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"payment-gateway-service/internal/utils"
+	"sync"
+	"time"
+)
+
+// ProviderStats summarizes the recent calls made to a provider
+type ProviderStats struct {
+	Calls          int
+	SuccessRate    float64
+	AverageLatency time.Duration
+}
+
+type call struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// Stats keeps the outcome and latency of the most recent calls to each provider in process memory,
+// so that routing can favour the providers that currently perform best. Stats only hold per instance.
+type Stats struct {
+	mu     sync.Mutex
+	size   int
+	maxAge time.Duration
+	calls  map[string][]call
+	now    func() time.Time
+}
+
+// NewStats initializes a new Stats keeping at most size calls per provider, none older than maxAge
+func NewStats(size int, maxAge time.Duration) *Stats {
+	return &Stats{size: size, maxAge: maxAge, calls: make(map[string][]call), now: time.Now}
+}
+
+// Record records a call to a provider. Calls rejected by the provider count as successful: the
+// provider answered, the payment was at fault.
+func (s *Stats) Record(providerName string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := append(s.calls[providerName], call{
+		at:      s.now(),
+		latency: latency,
+		failed:  err != nil && !errors.Is(err, ErrProviderRejected),
+	})
+	if len(calls) > s.size {
+		calls = calls[len(calls)-s.size:]
+	}
+	s.calls[providerName] = calls
+}
+
+// Snapshot summarizes the recent calls to a provider
+func (s *Stats) Snapshot(providerName string) ProviderStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stats ProviderStats
+	var succeeded int
+	var totalLatency time.Duration
+	cutoff := s.now().Add(-s.maxAge)
+	for _, c := range s.calls[providerName] {
+		if c.at.Before(cutoff) {
+			continue
+		}
+		stats.Calls++
+		totalLatency += c.latency
+		if !c.failed {
+			succeeded++
+		}
+	}
+
+	if stats.Calls > 0 {
+		stats.SuccessRate = float64(succeeded) / float64(stats.Calls)
+		stats.AverageLatency = totalLatency / time.Duration(stats.Calls)
+	}
+	return stats
+}
+
+// recordingAdapter records the outcome and latency of every call to the adapter it wraps
+type recordingAdapter struct {
+	ProviderAdapter
+	providerName string
+	stats        *Stats
+}
+
+// record records a call to the wrapped adapter that started at startTime
+func (a *recordingAdapter) record(startTime time.Time, err error) {
+	a.stats.Record(a.providerName, time.Since(startTime), err)
+}
+
+// GetDetails implements ProviderAdapter
+func (a *recordingAdapter) GetDetails(ctx context.Context, amount float64, transactionType, currencyCode string, countryCode string, details PaymentDetails) (string, string, error) {
+	startTime := time.Now()
+	url, externalID, err := a.ProviderAdapter.GetDetails(ctx, amount, transactionType, currencyCode, countryCode, details)
+	a.record(startTime, err)
+	return url, externalID, err
+}
+
+// Cancel implements Canceller, calls to adapters that cannot cancel are not recorded
+func (a *recordingAdapter) Cancel(ctx context.Context, externalID string) error {
+	startTime := time.Now()
+	err := cancel(ctx, a.ProviderAdapter, externalID)
+	if errors.Is(err, ErrCancelNotSupported) {
+		return err
+	}
+	a.record(startTime, err)
+	return err
+}
+
+// Payout implements ProviderAdapter
+func (a *recordingAdapter) Payout(ctx context.Context, payout PayoutDetails) (string, error) {
+	startTime := time.Now()
+	externalID, err := a.ProviderAdapter.Payout(ctx, payout)
+	a.record(startTime, err)
+	return externalID, err
+}
+
+// PayoutStatus implements ProviderAdapter
+func (a *recordingAdapter) PayoutStatus(ctx context.Context, externalID string) (utils.PaymentStatus, error) {
+	startTime := time.Now()
+	status, err := a.ProviderAdapter.PayoutStatus(ctx, externalID)
+	a.record(startTime, err)
+	return status, err
+}
+
+// PaymentToken implements ProviderAdapter
+func (a *recordingAdapter) PaymentToken(ctx context.Context, externalID string) (string, error) {
+	startTime := time.Now()
+	token, err := a.ProviderAdapter.PaymentToken(ctx, externalID)
+	a.record(startTime, err)
+	return token, err
+}
+
+// ChargeToken implements ProviderAdapter
+func (a *recordingAdapter) ChargeToken(ctx context.Context, charge TokenChargeDetails) (string, utils.PaymentStatus, error) {
+	startTime := time.Now()
+	externalID, status, err := a.ProviderAdapter.ChargeToken(ctx, charge)
+	a.record(startTime, err)
+	return externalID, status, err
+}
+
+// Capture implements ProviderAdapter
+func (a *recordingAdapter) Capture(ctx context.Context, externalID string, amount float64, currencyCode string) error {
+	startTime := time.Now()
+	err := a.ProviderAdapter.Capture(ctx, externalID, amount, currencyCode)
+	a.record(startTime, err)
+	return err
+}
+
+// Void implements ProviderAdapter
+func (a *recordingAdapter) Void(ctx context.Context, externalID string) error {
+	startTime := time.Now()
+	err := a.ProviderAdapter.Void(ctx, externalID)
+	a.record(startTime, err)
+	return err
+}
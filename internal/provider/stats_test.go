@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"payment-gateway-service/internal/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats_Snapshot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := NewStats(3, time.Minute)
+	stats.now = func() time.Time { return now }
+
+	stats.Record("HSBC", 900*time.Millisecond, nil)
+	stats.Record("HSBC", 100*time.Millisecond, ErrProviderUnavailable)
+	stats.Record("HSBC", 200*time.Millisecond, ErrProviderRejected)
+	stats.Record("HSBC", 300*time.Millisecond, errors.New("connection reset"))
+
+	snapshot := stats.Snapshot("HSBC")
+
+	assert.Equal(t, 3, snapshot.Calls, "only the most recent calls are kept")
+	assert.InDelta(t, 1.0/3, snapshot.SuccessRate, 0.001, "rejections are not provider failures")
+	assert.Equal(t, 200*time.Millisecond, snapshot.AverageLatency)
+	assert.Equal(t, ProviderStats{}, stats.Snapshot("ADCB"))
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, 0, stats.Snapshot("HSBC").Calls, "old calls are ignored")
+}
+
+// unavailableAdapter fails every call as if the provider was down
+type unavailableAdapter struct{}
+
+func (unavailableAdapter) GetDetails(context.Context, float64, string, string, string, PaymentDetails) (string, string, error) {
+	return "", "", ErrProviderUnavailable
+}
+func (unavailableAdapter) Cancel(context.Context, string) error { return ErrProviderUnavailable }
+func (unavailableAdapter) Payout(context.Context, PayoutDetails) (string, error) {
+	return "", ErrProviderUnavailable
+}
+func (unavailableAdapter) PayoutStatus(context.Context, string) (utils.PaymentStatus, error) {
+	return "", ErrProviderUnavailable
+}
+func (unavailableAdapter) PaymentToken(context.Context, string) (string, error) {
+	return "", ErrProviderUnavailable
+}
+func (unavailableAdapter) ChargeToken(context.Context, TokenChargeDetails) (string, utils.PaymentStatus, error) {
+	return "", "", ErrProviderUnavailable
+}
+func (unavailableAdapter) Capture(context.Context, string, float64, string) error {
+	return ErrProviderUnavailable
+}
+func (unavailableAdapter) Void(context.Context, string) error { return ErrProviderUnavailable }
+
+func TestRecordingAdapter_RecordsEveryCall(t *testing.T) {
+	stats := NewStats(20, time.Minute)
+	adapter := &recordingAdapter{ProviderAdapter: unavailableAdapter{}, providerName: "HSBC", stats: stats}
+	ctx := context.Background()
+
+	_, _, _ = adapter.GetDetails(ctx, 100, "DEPOSIT", "USD", "US", PaymentDetails{})
+	_ = adapter.Cancel(ctx, "external-id")
+	_, _ = adapter.Payout(ctx, PayoutDetails{})
+	_, _ = adapter.PayoutStatus(ctx, "external-id")
+	_, _ = adapter.PaymentToken(ctx, "external-id")
+	_, _, _ = adapter.ChargeToken(ctx, TokenChargeDetails{})
+	_ = adapter.Capture(ctx, "external-id", 100, "USD")
+	_ = adapter.Void(ctx, "external-id")
+
+	snapshot := stats.Snapshot("HSBC")
+	assert.Equal(t, 8, snapshot.Calls)
+	assert.Equal(t, float64(0), snapshot.SuccessRate)
+}
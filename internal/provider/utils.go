@@ -0,0 +1,10 @@
+package provider
+
+// UpdateProviderConfigRequest represents the request payload for changing a provider configuration
+type UpdateProviderConfigRequest struct {
+	Priority int    `json:"priority" binding:"required,gte=1"`
+	BaseURL  string `json:"base_url" binding:"omitempty,url"`
+
+	// Weight is the share of traffic among the configurations of the same priority, 0 stops routing to it
+	Weight *int `json:"weight" binding:"omitempty,gte=0,lte=1000"`
+}
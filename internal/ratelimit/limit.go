@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests requests per Per duration, refilled continuously (token bucket).
+// Bursts of up to Requests requests are allowed when the bucket is full.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// IsZero reports whether the limit is unset, in which case nothing is limited
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	for unit, duration := range limitUnits {
+		if l.Per == duration {
+			return fmt.Sprintf("%d/%s", l.Requests, unit)
+		}
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// ratePerSecond returns how many tokens are added to the bucket every second
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+var limitUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimit parses limits of the form "60/m" (s, m and h units) or "100/30s" (any Go duration)
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period>", value)
+	}
+
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", value)
+	}
+
+	per, ok := limitUnits[parts[1]]
+	if !ok {
+		per, err = time.ParseDuration(parts[1])
+		if err != nil || per <= 0 {
+			return Limit{}, fmt.Errorf("invalid period in rate limit %q", value)
+		}
+	}
+
+	return Limit{Requests: requests, Per: per}, nil
+}
+
+// Dimension is what requests are grouped by when they are counted against a limit
+type Dimension string
+
+const (
+	DimensionAPIKey Dimension = "key"
+	DimensionUser   Dimension = "user"
+	DimensionIP     Dimension = "ip"
+)
+
+// Policy holds the limits of one route per dimension. Dimensions without a limit are not limited.
+type Policy map[Dimension]Limit
+
+// ParsePolicy parses a policy of the form "key=60/m,user=10/m,ip=120/m"
+func ParsePolicy(value string) (Policy, error) {
+	policy := Policy{}
+	if strings.TrimSpace(value) == "" {
+		return policy, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rate limit policy entry %q, expected <dimension>=<limit>", entry)
+		}
+
+		dimension := Dimension(strings.TrimSpace(parts[0]))
+		switch dimension {
+		case DimensionAPIKey, DimensionUser, DimensionIP:
+		default:
+			return nil, fmt.Errorf("unknown rate limit dimension %q", dimension)
+		}
+
+		limit, err := ParseLimit(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		policy[dimension] = limit
+	}
+
+	return policy, nil
+}
+
+// Bucket identifies a token bucket and the limit it enforces
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	// Allowed reports whether the bucket had a token. A request is only allowed if all its buckets had one.
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of requests that can be made right away
+	Remaining int
+	// ResetAfter is the time until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// refill returns the tokens in a bucket holding tokens that was last updated elapsed ago
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Requests), tokens+elapsed.Seconds()*limit.ratePerSecond())
+	}
+	return tokens
+}
+
+// take takes a token from each of the refilled buckets holding tokens, but only if every one of them has a
+// token, so that a request denied by one limit does not use up the others. The result of each bucket tells
+// whether it had a token. It returns the tokens left in the buckets and the results.
+func take(limits []Limit, tokens []float64) ([]float64, []Result) {
+	allowed := true
+	for _, t := range tokens {
+		if t < 1 {
+			allowed = false
+		}
+	}
+
+	left := make([]float64, len(tokens))
+	results := make([]Result, len(tokens))
+	for i, limit := range limits {
+		left[i] = tokens[i]
+		if allowed {
+			left[i]--
+		}
+
+		rate := limit.ratePerSecond()
+		results[i] = Result{
+			Allowed:    tokens[i] >= 1,
+			Limit:      limit.Requests,
+			Remaining:  int(math.Floor(left[i])),
+			ResetAfter: secondsToDuration((float64(limit.Requests) - left[i]) / rate),
+		}
+		if tokens[i] < 1 {
+			results[i].RetryAfter = secondsToDuration((1 - tokens[i]) / rate)
+		}
+	}
+	return left, results
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
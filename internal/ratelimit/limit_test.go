@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("60/m")
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 60, Per: time.Minute}, limit)
+
+	limit, err = ParseLimit("5/30s")
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 5, Per: 30 * time.Second}, limit)
+
+	for _, invalid := range []string{"", "60", "x/m", "0/m", "60/fortnight", "60/-1s"} {
+		_, err := ParseLimit(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("key=60/m, user=10/m,ip=120/h")
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{
+		DimensionAPIKey: {Requests: 60, Per: time.Minute},
+		DimensionUser:   {Requests: 10, Per: time.Minute},
+		DimensionIP:     {Requests: 120, Per: time.Hour},
+	}, policy)
+
+	policy, err = ParsePolicy("")
+	assert.NoError(t, err)
+	assert.Empty(t, policy)
+
+	_, err = ParsePolicy("country=1/m")
+	assert.Error(t, err)
+
+	_, err = ParsePolicy("key")
+	assert.Error(t, err)
+}
+
+func TestTake_RefillsOverTime(t *testing.T) {
+	limit := Limit{Requests: 2, Per: 2 * time.Second}
+
+	// Empty bucket, one second later one token was added
+	tokens, results := take([]Limit{limit}, []float64{refill(limit, 0, time.Second)})
+	assert.True(t, results[0].Allowed)
+	assert.InDelta(t, 0, tokens[0], 0.0001)
+
+	// Empty bucket without any refill
+	tokens, results = take([]Limit{limit}, []float64{refill(limit, tokens[0], 0)})
+	assert.False(t, results[0].Allowed)
+	assert.Equal(t, time.Second, results[0].RetryAfter)
+	assert.Equal(t, 2*time.Second, results[0].ResetAfter)
+
+	// The bucket never holds more than the limit
+	_, results = take([]Limit{limit}, []float64{refill(limit, tokens[0], time.Hour)})
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, 1, results[0].Remaining)
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Per: time.Hour}
+
+	for i := 2; i >= 0; i-- {
+		results, err := store.Take(context.TODO(), []Bucket{{Key: "deposit:key:1", Limit: limit}})
+		assert.NoError(t, err)
+		assert.True(t, results[0].Allowed)
+		assert.Equal(t, i, results[0].Remaining)
+	}
+
+	results, err := store.Take(context.TODO(), []Bucket{{Key: "deposit:key:1", Limit: limit}})
+	assert.NoError(t, err)
+	assert.False(t, results[0].Allowed)
+	assert.Greater(t, results[0].RetryAfter, time.Duration(0))
+
+	// Other keys have their own bucket
+	results, err = store.Take(context.TODO(), []Bucket{{Key: "deposit:key:2", Limit: limit}})
+	assert.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+}
+
+func TestMemoryStore_TakeDeniedKeepsTokens(t *testing.T) {
+	store := NewMemoryStore()
+	buckets := []Bucket{
+		{Key: "deposit:key:1", Limit: Limit{Requests: 5, Per: time.Hour}},
+		{Key: "deposit:ip:10.0.0.1", Limit: Limit{Requests: 1, Per: time.Hour}},
+	}
+
+	results, err := store.Take(context.TODO(), buckets)
+	assert.NoError(t, err)
+	assert.True(t, results[0].Allowed && results[1].Allowed)
+	assert.Equal(t, 4, results[0].Remaining)
+
+	// Denied by the IP limit, the key limit keeps its tokens
+	for i := 0; i < 3; i++ {
+		results, err = store.Take(context.TODO(), buckets)
+		assert.NoError(t, err)
+		assert.True(t, results[0].Allowed)
+		assert.False(t, results[1].Allowed)
+		assert.Equal(t, 4, results[0].Remaining)
+	}
+}
+
+func TestMemoryStore_Prune(t *testing.T) {
+	store := NewMemoryStore()
+	_, err := store.Take(context.TODO(), []Bucket{{Key: "read:ip:10.0.0.1", Limit: Limit{Requests: 1, Per: time.Minute}}})
+	assert.NoError(t, err)
+
+	deleted, err := store.Prune(context.TODO(), time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = store.Prune(context.TODO(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
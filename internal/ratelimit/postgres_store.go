@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bucket is a token bucket row of the rate_limit_buckets table
+type bucket struct {
+	Key       string    `gorm:"primaryKey;type:varchar(255)"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+}
+
+func (bucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// PostgresStore is a Store backed by the rate_limit_buckets table, so limits hold across replicas.
+// Buckets are locked row by row, and timestamps come from the database clock.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore initializes a new PostgresStore with the provided database connection.
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take implements Store
+func (s *PostgresStore) Take(ctx context.Context, buckets []Bucket) ([]Result, error) {
+	// Buckets are created and locked in key order, so that concurrent requests cannot deadlock
+	sorted := make([]Bucket, len(buckets))
+	copy(sorted, buckets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	var results []Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var now time.Time
+		if err := tx.Raw("SELECT NOW()").Scan(&now).Error; err != nil {
+			return err
+		}
+
+		// Create full buckets the first time the keys are seen
+		keys := make([]string, len(sorted))
+		rows := make([]bucket, len(sorted))
+		for i, b := range sorted {
+			keys[i] = b.Key
+			rows[i] = bucket{Key: b.Key, Tokens: float64(b.Limit.Requests), UpdatedAt: now}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+
+		var current []bucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key IN ?", keys).
+			Order("key").
+			Find(&current).Error; err != nil {
+			return err
+		}
+		byKey := make(map[string]bucket, len(current))
+		for _, c := range current {
+			byKey[c.Key] = c
+		}
+
+		limits := make([]Limit, len(buckets))
+		tokens := make([]float64, len(buckets))
+		for i, b := range buckets {
+			c, ok := byKey[b.Key]
+			if !ok {
+				return fmt.Errorf("rate limit bucket %s not found", b.Key)
+			}
+			limits[i] = b.Limit
+			tokens[i] = refill(b.Limit, c.Tokens, now.Sub(c.UpdatedAt))
+		}
+
+		var left []float64
+		left, results = take(limits, tokens)
+		for i, b := range buckets {
+			if err := tx.Model(&bucket{}).
+				Where("key = ?", b.Key).
+				Updates(map[string]interface{}{"tokens": left[i], "updated_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return results, err
+}
+
+// Prune implements Store
+func (s *PostgresStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("updated_at < ?", before).Delete(&bucket{})
+	return result.RowsAffected, result.Error
+}
+
+// Ensure PostgresStore implements Store.
+var _ Store = (*PostgresStore)(nil)
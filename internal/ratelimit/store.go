@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the token buckets. Stores shared between replicas make limits hold across all of them.
+type Store interface {
+	// Take takes a token from each of the buckets, creating full buckets if needed, if all of them have
+	// one. The results are in the order of the buckets.
+	Take(ctx context.Context, buckets []Bucket) ([]Result, error)
+
+	// Prune deletes the buckets that have not been used since before the given time. A bucket that was
+	// not used for longer than the period of its limit is full, so deleting it changes nothing.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryStore is a Store kept in process memory. Limits only hold per instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryStore initializes a new MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, buckets []Bucket) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	limits := make([]Limit, len(buckets))
+	tokens := make([]float64, len(buckets))
+	for i, b := range buckets {
+		bucket, exists := s.buckets[b.Key]
+		if !exists {
+			bucket = &memoryBucket{tokens: float64(b.Limit.Requests), updatedAt: now}
+			s.buckets[b.Key] = bucket
+		}
+		limits[i] = b.Limit
+		tokens[i] = refill(b.Limit, bucket.tokens, now.Sub(bucket.updatedAt))
+	}
+
+	left, results := take(limits, tokens)
+	for i, b := range buckets {
+		s.buckets[b.Key].tokens = left[i]
+		s.buckets[b.Key].updatedAt = now
+	}
+	return results, nil
+}
+
+// Prune implements Store
+func (s *MemoryStore) Prune(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range s.buckets {
+		if bucket.updatedAt.Before(before) {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Ensure MemoryStore implements Store.
+var _ Store = (*MemoryStore)(nil)
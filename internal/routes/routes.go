@@ -1,8 +1,22 @@
 package routes
 
 import (
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/batch"
+	"payment-gateway-service/internal/beneficiary"
+	"payment-gateway-service/internal/dispute"
+	"payment-gateway-service/internal/fx"
+	"payment-gateway-service/internal/interaction"
+	"payment-gateway-service/internal/ledger"
+	"payment-gateway-service/internal/merchant"
 	"payment-gateway-service/internal/middleware"
+	"payment-gateway-service/internal/operator"
 	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/ratelimit"
+	"payment-gateway-service/internal/routing"
+	"payment-gateway-service/internal/savedmethod"
+	"payment-gateway-service/internal/subscription"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -10,24 +24,166 @@ import (
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
+// RegisterRoutes registers every route of the service. Merchant-facing routes declare the
+// API key scope they require right next to the handler; routes without a scope are public.
+// Settings that can be reloaded are read from the configuration store on every request.
+func RegisterRoutes(router *gin.Engine, db *gorm.DB, configStore *config.Store, rateLimitStore ratelimit.Store) {
 
 	// Initialize handlers with the correct package paths
-	paymentHandler := payment.NewPaymentHandler(db)
+	paymentHandler := payment.NewPaymentHandler(db, configStore)
+	providerHandler := provider.NewProviderHandler(db)
+	merchantHandler := merchant.NewMerchantHandler(db)
+	routingHandler := routing.NewRoutingHandler(db)
+	fxHandler := fx.NewFXHandler(db, configStore)
+	interactionHandler := interaction.NewInteractionHandler(db)
+	beneficiaryHandler := beneficiary.NewBeneficiaryHandler(db, configStore)
+	savedMethodHandler := savedmethod.NewSavedMethodHandler(db)
+	batchHandler := batch.NewBatchHandler(db, configStore, paymentHandler.Service())
+	subscriptionHandler := subscription.NewSubscriptionHandler(db, configStore, paymentHandler.Service())
+	disputeHandler := dispute.NewDisputeHandler(db, configStore)
+	ledgerHandler := ledger.NewLedgerHandler(db)
+
+	// Merchant API keys authenticate every merchant-facing route
+	merchantSvc := merchant.NewMerchantService(db)
+	authMiddleware := middleware.AuthMiddleware(merchantSvc)
+
+	// Operator keys authenticate the admin routes
+	operatorAuthMiddleware := middleware.OperatorAuthMiddleware(operator.NewOperatorService(db))
+
+	// Keys in hmac mode must sign the requests that create payments
+	signatureMiddleware := middleware.SignatureMiddleware(middleware.NewMemoryNonceCache(), middleware.DefaultSignatureMaxSkew)
+
+	// Providers sign their chargeback notifications with their own secret
+	chargebackSignatureMiddleware := middleware.NotificationSignatureMiddleware(disputeHandler.Service(), middleware.DefaultSignatureMaxSkew)
+
+	// Rate limits per route. Public routes have no API key and are only limited per client IP.
+	depositRateLimit := middleware.RateLimitMiddleware(rateLimitStore, "deposit", currentPolicy(configStore, "deposit"))
+	withdrawalRateLimit := middleware.RateLimitMiddleware(rateLimitStore, "withdrawal", currentPolicy(configStore, "withdrawal"))
+	readRateLimit := middleware.RateLimitMiddleware(rateLimitStore, "read", currentPolicy(configStore, "read"))
+	publicRateLimit := middleware.RateLimitMiddleware(rateLimitStore, "public", currentPolicy(configStore, "public"))
+
+	// Provider callbacks are kept in the provider interactions, like the requests sent to providers
+	callbackInteractions := middleware.InteractionMiddleware(interaction.NewInteractionService(db))
 
 	// Register payment routes with validation middleware
 	paymentRoutes := router.Group("/payment")
 	{
-		paymentRoutes.POST("/deposit", middleware.AuthMiddleware(), middleware.ValidationMiddleware(&payment.PaymentRequest{}), paymentHandler.Deposit)
-		paymentRoutes.POST("/withdrawal", middleware.AuthMiddleware(), middleware.ValidationMiddleware(&payment.PaymentRequest{}), paymentHandler.Withdrawal)
-		paymentRoutes.GET("/callbacks/success", paymentHandler.HandleSuccessCallback)
-		paymentRoutes.GET("/callbacks/success/:external_id", paymentHandler.HandleSuccessCallback)
-		paymentRoutes.GET("/callbacks/failed", paymentHandler.HandleFailedCallback)
-		paymentRoutes.GET("/callbacks/failed/:external_id", paymentHandler.HandleFailedCallback)
+		paymentRoutes.POST("/deposit", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, middleware.ValidationMiddleware(&payment.PaymentRequest{}), depositRateLimit, paymentHandler.Deposit)
+		paymentRoutes.POST("/withdrawal", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsWithdrawal), signatureMiddleware, middleware.ValidationMiddleware(&payment.PaymentRequest{}), withdrawalRateLimit, paymentHandler.Withdrawal)
+		paymentRoutes.POST("/payout", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsWithdrawal), signatureMiddleware, middleware.ValidationMiddleware(&payment.PayoutRequest{}), withdrawalRateLimit, paymentHandler.Payout)
+		paymentRoutes.GET("/callbacks/success", publicRateLimit, callbackInteractions, paymentHandler.HandleSuccessCallback)
+		paymentRoutes.GET("/callbacks/success/:external_id", publicRateLimit, callbackInteractions, paymentHandler.HandleSuccessCallback)
+		paymentRoutes.GET("/callbacks/failed", publicRateLimit, callbackInteractions, paymentHandler.HandleFailedCallback)
+		paymentRoutes.GET("/callbacks/failed/:external_id", publicRateLimit, callbackInteractions, paymentHandler.HandleFailedCallback)
+		paymentRoutes.GET("/callbacks/cancel", publicRateLimit, callbackInteractions, paymentHandler.HandleCancelCallback)
+		paymentRoutes.GET("/callbacks/cancel/:external_id", publicRateLimit, callbackInteractions, paymentHandler.HandleCancelCallback)
+		paymentRoutes.POST("/callbacks/chargeback/:external_id", publicRateLimit, callbackInteractions, chargebackSignatureMiddleware, middleware.ValidationMiddleware(&dispute.ChargebackNotification{}), disputeHandler.HandleChargebackCallback)
+
+		paymentRoutes.GET("/", publicRateLimit, paymentHandler.PaymentStatus)
+		paymentRoutes.GET("/methods", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, paymentHandler.PaymentMethods)
+		paymentRoutes.GET("/reference/:reference", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, paymentHandler.GetPaymentByReference)
+		paymentRoutes.GET("/:id", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, paymentHandler.GetPayment)
+		// Cancelling requires the deposit or withdrawal scope depending on the payment, checked by the handler,
+		// and counts against the deposit limit either way
+		paymentRoutes.POST("/:id/cancel", authMiddleware, signatureMiddleware, depositRateLimit, paymentHandler.CancelPayment)
+		// Only deposits are authorized and captured in two steps
+		paymentRoutes.POST("/:id/capture", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, middleware.ValidationMiddleware(&payment.CaptureRequest{}), depositRateLimit, paymentHandler.CapturePayment)
+		paymentRoutes.POST("/:id/void", authMiddleware, middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, paymentHandler.VoidPayment)
+	}
+
+	// Register the FX routes, a quote locks a rate for the payment created with it, a deposit or a withdrawal
+	fxRoutes := router.Group("/fx")
+	{
+		fxRoutes.POST("/quotes", authMiddleware, middleware.RequireAnyScope(merchant.ScopePaymentsDeposit, merchant.ScopePaymentsWithdrawal), middleware.ValidationMiddleware(&fx.QuoteRequest{}), depositRateLimit, fxHandler.CreateQuote)
+	}
+
+	// Register the beneficiary routes, the bank accounts saved for the payouts of the merchant's users
+	beneficiaryRoutes := router.Group("/beneficiaries", authMiddleware)
+	{
+		beneficiaryRoutes.POST("", middleware.RequireScope(merchant.ScopePaymentsWithdrawal), signatureMiddleware, middleware.ValidationMiddleware(&beneficiary.CreateBeneficiaryRequest{}), withdrawalRateLimit, beneficiaryHandler.Create)
+		beneficiaryRoutes.GET("", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, beneficiaryHandler.List)
+		beneficiaryRoutes.POST("/:id/disable", middleware.RequireScope(merchant.ScopePaymentsWithdrawal), signatureMiddleware, withdrawalRateLimit, beneficiaryHandler.Disable)
+	}
+
+	// Register the saved payment method routes, the methods are saved by the callbacks of deposits made with save_method
+	savedMethodRoutes := router.Group("/saved-methods", authMiddleware)
+	{
+		savedMethodRoutes.GET("", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, savedMethodHandler.List)
+		savedMethodRoutes.POST("/:id/disable", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, savedMethodHandler.Disable)
+	}
 
-		paymentRoutes.GET("/", paymentHandler.PaymentStatus)
+	// Register the payout batch routes, the payouts of a batch are sent in the background
+	payoutRoutes := router.Group("/payouts/batches", authMiddleware)
+	{
+		payoutRoutes.POST("", middleware.RequireScope(merchant.ScopePaymentsWithdrawal), signatureMiddleware, withdrawalRateLimit, batchHandler.Create)
+		payoutRoutes.GET("/:id", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, batchHandler.Get)
+		payoutRoutes.GET("/:id/items", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, batchHandler.ListItems)
+		payoutRoutes.GET("/:id/results.csv", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, batchHandler.Results)
+	}
+
+	// Register the subscription routes, the due subscriptions are charged by the subscriptions run command
+	subscriptionRoutes := router.Group("/subscriptions", authMiddleware)
+	{
+		subscriptionRoutes.POST("/plans", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, middleware.ValidationMiddleware(&subscription.CreatePlanRequest{}), depositRateLimit, subscriptionHandler.CreatePlan)
+		subscriptionRoutes.GET("/plans", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, subscriptionHandler.ListPlans)
+		subscriptionRoutes.POST("", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, middleware.ValidationMiddleware(&subscription.CreateSubscriptionRequest{}), depositRateLimit, subscriptionHandler.Create)
+		subscriptionRoutes.GET("", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, subscriptionHandler.List)
+		subscriptionRoutes.GET("/:id", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, subscriptionHandler.Get)
+		subscriptionRoutes.POST("/:id/pause", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, subscriptionHandler.Pause)
+		subscriptionRoutes.POST("/:id/resume", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, subscriptionHandler.Resume)
+		subscriptionRoutes.POST("/:id/cancel", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, subscriptionHandler.Cancel)
+	}
+
+	// Register the dispute routes, disputes are opened by the chargeback callbacks of providers or by operators
+	disputeRoutes := router.Group("/disputes", authMiddleware)
+	{
+		disputeRoutes.GET("", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, disputeHandler.List)
+		disputeRoutes.GET("/:id", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, disputeHandler.Get)
+		disputeRoutes.POST("/:id/evidence", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, disputeHandler.AddEvidence)
+		disputeRoutes.GET("/:id/evidence/:evidence_id", middleware.RequireScope(merchant.ScopePaymentsRead), readRateLimit, disputeHandler.DownloadEvidence)
+		disputeRoutes.POST("/:id/submit", middleware.RequireScope(merchant.ScopePaymentsDeposit), signatureMiddleware, depositRateLimit, disputeHandler.SubmitEvidence)
+	}
+
+	// Register the hosted checkout pages, the payment ID in the path is the customer's only credential
+	checkoutRoutes := router.Group("/checkout")
+	{
+		checkoutRoutes.GET("/:id", publicRateLimit, paymentHandler.Checkout)
+		checkoutRoutes.POST("/:id", publicRateLimit, paymentHandler.StartCheckout)
+	}
+
+	// Register admin routes, every one of them requires an operator key, which belongs to no merchant
+	adminRoutes := router.Group("/admin", operatorAuthMiddleware)
+	{
+		adminRoutes.GET("/provider-configurations", providerHandler.ListConfigurations)
+		adminRoutes.PUT("/provider-configurations/:id", middleware.ValidationMiddleware(&provider.UpdateProviderConfigRequest{}), providerHandler.UpdateConfiguration)
+
+		adminRoutes.GET("/routing-rules", routingHandler.ListRules)
+		adminRoutes.POST("/routing-rules", middleware.ValidationMiddleware(&routing.CreateRuleRequest{}), routingHandler.CreateRule)
+		adminRoutes.DELETE("/routing-rules/:id", routingHandler.DeleteRule)
+
+		adminRoutes.GET("/payments/:id/interactions", interactionHandler.ListForPayment)
+
+		adminRoutes.POST("/beneficiaries/:id/verify", beneficiaryHandler.Verify)
+
+		adminRoutes.GET("/fx/rates", fxHandler.ListRates)
+		adminRoutes.PUT("/fx/rates", middleware.ValidationMiddleware(&fx.UploadRatesRequest{}), fxHandler.UploadRates)
+
+		adminRoutes.POST("/merchants/:id/api-keys", middleware.ValidationMiddleware(&merchant.CreateAPIKeyRequest{}), merchantHandler.CreateAPIKey)
+		adminRoutes.DELETE("/merchants/:id/api-keys/:key_id", merchantHandler.RevokeAPIKey)
+		adminRoutes.PUT("/merchants/:id/redirect-domains", middleware.ValidationMiddleware(&merchant.UpdateRedirectDomainsRequest{}), merchantHandler.UpdateRedirectDomains)
+		adminRoutes.GET("/merchants/:id/ledger", ledgerHandler.List)
+
+		adminRoutes.POST("/disputes", middleware.ValidationMiddleware(&dispute.CreateDisputeRequest{}), disputeHandler.Create)
+		adminRoutes.POST("/disputes/:id/resolve", middleware.ValidationMiddleware(&dispute.ResolveDisputeRequest{}), disputeHandler.Resolve)
 	}
 
 	// Swagger Route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
+
+// currentPolicy returns a function reading the rate limit policy of a route from the current configuration
+func currentPolicy(configStore *config.Store, route string) func() ratelimit.Policy {
+	return func() ratelimit.Policy {
+		return configStore.Current().RateLimitPolicy(route)
+	}
+}
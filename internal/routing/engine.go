@@ -0,0 +1,147 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/utils"
+	"time"
+)
+
+const (
+	// minCalls is the number of recent calls a provider needs before its live stats affect its weight
+	minCalls = 10
+
+	// latencyTarget is the average latency above which the weight of a provider is reduced proportionally
+	latencyTarget = 2 * time.Second
+)
+
+// RuleSourceInterface defines the method the Engine uses to load the routing rules.
+type RuleSourceInterface interface {
+	FindRules(ctx context.Context, currencyCode, countryCode string) ([]Rule, error)
+}
+
+// StatsInterface defines the method the Engine uses to read the live stats of the providers.
+type StatsInterface interface {
+	Snapshot(providerName string) provider.ProviderStats
+}
+
+// Engine picks the provider configuration of a payment among the eligible ones:
+//  1. the first routing rule, by priority, that matches the payment and names an eligible provider;
+//  2. otherwise a configuration of the highest priority drawn by weight, where the weight is reduced
+//     by the recent success rate and latency of the provider. Priorities with no weight left are
+//     skipped, so lower priorities act as fallbacks.
+type Engine struct {
+	rules RuleSourceInterface
+	stats StatsInterface
+	now   func() time.Time
+	draw  func() float64
+}
+
+// NewEngine initializes a new Engine with the routing rules and the live provider stats. A nil
+// stats means the weights are used as configured.
+func NewEngine(rules RuleSourceInterface, stats StatsInterface) *Engine {
+	return &Engine{rules: rules, stats: stats, now: time.Now, draw: rand.Float64}
+}
+
+// Route picks the provider configuration of a payment among the eligible configurations, ordered by
+// priority, and records the decision.
+func (e *Engine) Route(ctx context.Context, request Request, providerConfigs []provider.ProviderConfiguration) (*provider.ProviderConfiguration, Decision, error) {
+	if len(providerConfigs) == 0 {
+		return nil, Decision{}, provider.ErrNoRouteForCurrencyCountry
+	}
+
+	rules, err := e.rules.FindRules(ctx, request.CurrencyCode, request.CountryCode)
+	if err != nil {
+		return nil, Decision{}, err
+	}
+
+	now := e.now()
+	for _, rule := range rules {
+		if !rule.Matches(request, now) {
+			continue
+		}
+		// A rule naming a provider that is excluded or not configured for the route is skipped
+		for i := range providerConfigs {
+			if providerConfigs[i].ProviderID == rule.ProviderID {
+				decision := NewDecision(StrategyRule, &providerConfigs[i])
+				decision.RuleID = rule.ID
+				utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingEngine: Rule %d routes the payment to %s", rule.ID, decision.Provider))
+				return &providerConfigs[i], decision, nil
+			}
+		}
+	}
+
+	return e.routeByWeight(ctx, providerConfigs)
+}
+
+// routeByWeight draws a configuration by effective weight within the highest priority that has any weight left
+func (e *Engine) routeByWeight(ctx context.Context, providerConfigs []provider.ProviderConfiguration) (*provider.ProviderConfiguration, Decision, error) {
+	candidates := make([]Candidate, len(providerConfigs))
+	for i := range providerConfigs {
+		candidates[i] = e.candidate(&providerConfigs[i])
+	}
+
+	for start := 0; start < len(providerConfigs); {
+		// The configurations of one priority are next to each other
+		end := start
+		var total float64
+		for end < len(providerConfigs) && providerConfigs[end].Priority == providerConfigs[start].Priority {
+			total += candidates[end].EffectiveWeight
+			end++
+		}
+
+		if total > 0 {
+			selected := end - 1
+			target := e.draw() * total
+			for i := start; i < end; i++ {
+				if target < candidates[i].EffectiveWeight {
+					selected = i
+					break
+				}
+				target -= candidates[i].EffectiveWeight
+			}
+
+			decision := NewDecision(StrategyWeighted, &providerConfigs[selected])
+			decision.Candidates = candidates
+			utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingEngine: Weighted routing sends the payment to %s", decision.Provider))
+			return &providerConfigs[selected], decision, nil
+		}
+		start = end
+	}
+
+	decision := NewDecision(StrategyPriority, &providerConfigs[0])
+	decision.Candidates = candidates
+	utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingEngine: No configuration has a weight left, the payment goes to %s", decision.Provider))
+	return &providerConfigs[0], decision, nil
+}
+
+// candidate weights a configuration by the live stats of its provider
+func (e *Engine) candidate(providerConfig *provider.ProviderConfiguration) Candidate {
+	candidate := Candidate{
+		ProviderConfigurationID: providerConfig.ID,
+		Provider:                providerConfig.ProviderName,
+		Priority:                providerConfig.Priority,
+		Weight:                  providerConfig.Weight,
+		EffectiveWeight:         float64(providerConfig.Weight),
+	}
+	if e.stats == nil {
+		return candidate
+	}
+
+	stats := e.stats.Snapshot(providerConfig.ProviderName)
+	candidate.Calls = stats.Calls
+	if stats.Calls < minCalls {
+		return candidate
+	}
+
+	successRate := stats.SuccessRate
+	candidate.SuccessRate = &successRate
+	candidate.AverageLatencyMs = stats.AverageLatency.Milliseconds()
+	candidate.EffectiveWeight *= successRate
+	if stats.AverageLatency > latencyTarget {
+		candidate.EffectiveWeight *= float64(latencyTarget) / float64(stats.AverageLatency)
+	}
+	return candidate
+}
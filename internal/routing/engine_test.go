@@ -0,0 +1,144 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedRules is a RuleSourceInterface returning the same rules for every route
+type fixedRules []Rule
+
+func (r fixedRules) FindRules(context.Context, string, string) ([]Rule, error) {
+	return r, nil
+}
+
+// fixedStats is a StatsInterface returning the same stats for every call
+type fixedStats map[string]provider.ProviderStats
+
+func (s fixedStats) Snapshot(providerName string) provider.ProviderStats {
+	return s[providerName]
+}
+
+// aedConfigs are the provider configurations of a route, by priority
+var aedConfigs = []provider.ProviderConfiguration{
+	{ID: 1, ProviderID: 1, ProviderName: "HSBC", Priority: 1, Weight: 3},
+	{ID: 2, ProviderID: 2, ProviderName: "ADCB", Priority: 1, Weight: 1},
+	{ID: 3, ProviderID: 3, ProviderName: "CITI", Priority: 2, Weight: 1},
+}
+
+var aedRequest = Request{CurrencyCode: "AED", CountryCode: "AE", PaymentType: utils.PaymentTypeDeposit, Amount: 500}
+
+func newTestEngine(rules fixedRules, stats StatsInterface, draw float64) *Engine {
+	engine := NewEngine(rules, stats)
+	engine.now = func() time.Time { return time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC) }
+	engine.draw = func() float64 { return draw }
+	return engine
+}
+
+func TestRule_Matches(t *testing.T) {
+	minAmount := 10000.0
+	now := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		request Request
+		want    bool
+	}{
+		{name: "no conditions", rule: Rule{}, request: aedRequest, want: true},
+		{name: "amount below band", rule: Rule{MinAmount: &minAmount}, request: aedRequest, want: false},
+		{name: "amount in band", rule: Rule{MinAmount: &minAmount}, request: Request{Amount: 10000}, want: true},
+		{name: "other payment type", rule: Rule{PaymentType: "WITHDRAWAL"}, request: aedRequest, want: false},
+		{name: "other currency", rule: Rule{CurrencyCode: "USD"}, request: aedRequest, want: false},
+		{name: "segment", rule: Rule{UserSegment: "VIP"}, request: Request{UserSegment: "vip"}, want: true},
+		{name: "no segment", rule: Rule{UserSegment: "vip"}, request: aedRequest, want: false},
+		{name: "window wrapping midnight", rule: Rule{ActiveFrom: "22:00", ActiveUntil: "06:00"}, request: aedRequest, want: true},
+		{name: "outside window", rule: Rule{ActiveFrom: "09:00", ActiveUntil: "17:00"}, request: aedRequest, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule.Matches(tt.request, now))
+		})
+	}
+}
+
+func TestRoute_Rule(t *testing.T) {
+	rules := fixedRules{
+		{ID: 7, ProviderID: 9, Priority: 1},                            // provider not eligible, skipped
+		{ID: 8, ProviderID: 2, Priority: 2, PaymentType: "WITHDRAWAL"}, // does not match
+		{ID: 9, ProviderID: 2, Priority: 3},
+	}
+
+	providerConfig, decision, err := newTestEngine(rules, nil, 0).Route(context.TODO(), aedRequest, aedConfigs)
+
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), providerConfig.ID)
+	assert.Equal(t, StrategyRule, decision.Strategy)
+	assert.Equal(t, uint(9), decision.RuleID)
+}
+
+func TestRoute_Weighted(t *testing.T) {
+	// HSBC has 3 of the 4 weight units of the first priority
+	providerConfig, decision, err := newTestEngine(nil, nil, 0.7).Route(context.TODO(), aedRequest, aedConfigs)
+
+	require.NoError(t, err)
+	assert.Equal(t, "HSBC", providerConfig.ProviderName)
+	assert.Equal(t, StrategyWeighted, decision.Strategy)
+	assert.Len(t, decision.Candidates, 3)
+
+	providerConfig, _, err = newTestEngine(nil, nil, 0.8).Route(context.TODO(), aedRequest, aedConfigs)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ADCB", providerConfig.ProviderName)
+}
+
+func TestRoute_LiveStats(t *testing.T) {
+	stats := fixedStats{
+		"HSBC": {Calls: 20, SuccessRate: 0.1, AverageLatency: 8 * time.Second},
+		"ADCB": {Calls: 20, SuccessRate: 1, AverageLatency: 300 * time.Millisecond},
+	}
+
+	providerConfig, decision, err := newTestEngine(nil, stats, 0.5).Route(context.TODO(), aedRequest, aedConfigs)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ADCB", providerConfig.ProviderName, "a failing, slow provider loses its share")
+	assert.InDelta(t, 3*0.1*0.25, decision.Candidates[0].EffectiveWeight, 0.001)
+	assert.InDelta(t, 1, decision.Candidates[1].EffectiveWeight, 0.001)
+}
+
+func TestRoute_FallsBackToLowerPriority(t *testing.T) {
+	stats := fixedStats{
+		"HSBC": {Calls: 20, SuccessRate: 0},
+		"ADCB": {Calls: 20, SuccessRate: 0},
+	}
+
+	providerConfig, decision, err := newTestEngine(nil, stats, 0.5).Route(context.TODO(), aedRequest, aedConfigs)
+
+	require.NoError(t, err)
+	assert.Equal(t, "CITI", providerConfig.ProviderName)
+	assert.Equal(t, StrategyWeighted, decision.Strategy)
+}
+
+func TestRoute_NoWeightLeft(t *testing.T) {
+	configs := []provider.ProviderConfiguration{{ID: 1, ProviderName: "HSBC", Priority: 1}, {ID: 2, ProviderName: "ADCB", Priority: 2}}
+
+	providerConfig, decision, err := newTestEngine(nil, nil, 0.5).Route(context.TODO(), aedRequest, configs)
+
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), providerConfig.ID)
+	assert.Equal(t, StrategyPriority, decision.Strategy)
+}
+
+func TestRoute_NoConfigurations(t *testing.T) {
+	_, _, err := newTestEngine(nil, nil, 0).Route(context.TODO(), aedRequest, nil)
+
+	assert.ErrorIs(t, err, provider.ErrNoRouteForCurrencyCountry)
+}
@@ -0,0 +1,17 @@
+package routing
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrRuleNotFound is returned when no routing rule matches the lookup
+	ErrRuleNotFound = utils.NewAPIError(http.StatusNotFound, "routing_rule_not_found", "Routing rule not found")
+
+	// ErrUnknownProvider is returned when a routing rule names a provider that does not exist
+	ErrUnknownProvider = utils.NewAPIError(http.StatusUnprocessableEntity, "unknown_provider", "Unknown payment provider")
+
+	// ErrInvalidAmountBand is returned when the minimum amount of a routing rule is not below its maximum amount
+	ErrInvalidAmountBand = utils.NewAPIError(http.StatusBadRequest, "invalid_amount_band", "Minimum amount must be below the maximum amount")
+)
@@ -0,0 +1,98 @@
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"payment-gateway-service/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RoutingHandler handles the routing rule admin requests
+type RoutingHandler struct {
+	service RoutingServiceInterface
+}
+
+// NewRoutingHandler initializes a new RoutingHandler
+func NewRoutingHandler(db *gorm.DB) *RoutingHandler {
+	return &RoutingHandler{service: NewRoutingService(db)}
+}
+
+// ListRules lists the routing rules
+// @Summary List routing rules
+// @Description Lists the routing rules by priority. The first rule matching a payment sends it to its provider, before the provider configuration weights are considered.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Success 200 {object} utils.APIResponse{data=[]Rule} "Routing rules"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /admin/routing-rules [get]
+func (h *RoutingHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Routing rules", rules)
+}
+
+// CreateRule creates a routing rule
+// @Summary Create a routing rule
+// @Description Creates a rule sending the payments that match its currency, country, payment type, amount band, user segment and UTC time window to a provider.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param validatedBody body CreateRuleRequest true "Routing rule"
+// @Success 201 {object} utils.APIResponse{data=Rule} "Created routing rule"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 422 {object} utils.APIResponse "Unknown provider"
+// @Router /admin/routing-rules [post]
+func (h *RoutingHandler) CreateRule(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CreateRuleRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	rule, err := h.service.CreateRule(c, request)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Routing rule created", rule)
+}
+
+// DeleteRule deletes a routing rule
+// @Summary Delete a routing rule
+// @Description Deletes a routing rule. Payments created afterwards are no longer routed by it.
+// @Tags admin
+// @Produce json
+// @Param X-OPERATOR-TOKEN header string true "Operator API key"
+// @Param id path int true "Routing rule ID"
+// @Success 200 {object} utils.APIResponse "Routing rule deleted"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Routing rule not found"
+// @Router /admin/routing-rules/{id} [delete]
+func (h *RoutingHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(ErrRuleNotFound)
+		return
+	}
+
+	utils.LogWithRequestID(c, fmt.Sprintf("Deleting routing rule %d", id))
+
+	if err := h.service.DeleteRule(c, uint(id)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Routing rule deleted", nil)
+}
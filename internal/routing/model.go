@@ -0,0 +1,131 @@
+package routing
+
+import (
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/utils"
+	"strings"
+	"time"
+)
+
+// Rule sends the payments it matches to a provider. Empty conditions match every payment, the
+// amount band includes MinAmount and excludes MaxAmount, and the time window is a UTC time of
+// day that may wrap around midnight.
+type Rule struct {
+	ID           uint              `gorm:"primaryKey" json:"id"`
+	CurrencyCode string            `gorm:"type:varchar(3);not null;default:''" json:"currency_code"`
+	CountryCode  string            `gorm:"type:varchar(2);not null;default:''" json:"country_code"`
+	PaymentType  string            `gorm:"type:varchar(20);not null;default:''" json:"payment_type"`
+	MinAmount    *float64          `gorm:"type:numeric(12,2)" json:"min_amount"`
+	MaxAmount    *float64          `gorm:"type:numeric(12,2)" json:"max_amount"`
+	UserSegment  string            `gorm:"type:varchar(50);not null;default:''" json:"user_segment"`
+	ActiveFrom   string            `gorm:"type:varchar(5);not null;default:''" json:"active_from"`
+	ActiveUntil  string            `gorm:"type:varchar(5);not null;default:''" json:"active_until"`
+	ProviderID   uint              `gorm:"not null" json:"provider_id"`
+	Provider     provider.Provider `gorm:"foreignKey:ProviderID" json:"provider"`
+	Priority     int               `gorm:"not null;check:priority >= 1" json:"priority"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+func (Rule) TableName() string {
+	return "routing_rules"
+}
+
+// Matches reports whether the rule applies to a payment at a point in time
+func (r *Rule) Matches(request Request, now time.Time) bool {
+	switch {
+	case r.CurrencyCode != "" && r.CurrencyCode != request.CurrencyCode:
+		return false
+	case r.CountryCode != "" && r.CountryCode != request.CountryCode:
+		return false
+	case r.PaymentType != "" && r.PaymentType != string(request.PaymentType):
+		return false
+	case r.MinAmount != nil && request.Amount < *r.MinAmount:
+		return false
+	case r.MaxAmount != nil && request.Amount >= *r.MaxAmount:
+		return false
+	case r.UserSegment != "" && !strings.EqualFold(r.UserSegment, request.UserSegment):
+		return false
+	}
+	return r.activeAt(now)
+}
+
+// activeAt reports whether a point in time falls within the time window of the rule
+func (r *Rule) activeAt(now time.Time) bool {
+	if r.ActiveFrom == "" || r.ActiveUntil == "" {
+		return true
+	}
+	from, errFrom := time.Parse(timeOfDayLayout, r.ActiveFrom)
+	until, errUntil := time.Parse(timeOfDayLayout, r.ActiveUntil)
+	if errFrom != nil || errUntil != nil {
+		return false
+	}
+
+	minute := now.UTC().Hour()*60 + now.UTC().Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := until.Hour()*60 + until.Minute()
+	if start <= end {
+		return start <= minute && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// timeOfDayLayout is the layout of the time window of a rule
+const timeOfDayLayout = "15:04"
+
+// Request describes the payment being routed
+type Request struct {
+	CurrencyCode string
+	CountryCode  string
+	PaymentType  utils.PaymentType
+	Amount       float64
+	UserSegment  string
+}
+
+// Strategy is how the provider of a payment was chosen
+type Strategy string
+
+const (
+	// StrategyPreferred means the merchant asked for the provider
+	StrategyPreferred Strategy = "preferred"
+	// StrategyRule means a routing rule matched the payment
+	StrategyRule Strategy = "rule"
+	// StrategyWeighted means the provider was drawn by weight among the configurations of the highest priority
+	StrategyWeighted Strategy = "weighted"
+	// StrategyPriority means no configuration had a weight left and the highest priority one was used
+	StrategyPriority Strategy = "priority"
+	// StrategyCheckout means the customer picked the provider on the hosted checkout
+	StrategyCheckout Strategy = "checkout"
+	// StrategySavedMethod means the payment charged a saved payment method, which only its provider can charge
+	StrategySavedMethod Strategy = "saved_method"
+)
+
+// Decision records how the provider of a payment was chosen
+type Decision struct {
+	Strategy                Strategy    `json:"strategy,omitempty"`
+	RuleID                  uint        `json:"rule_id,omitempty"`
+	Provider                string      `json:"provider,omitempty"`
+	ProviderConfigurationID uint        `json:"provider_configuration_id,omitempty"`
+	Candidates              []Candidate `json:"candidates,omitempty"`
+}
+
+// Candidate is a provider configuration considered by the weighted routing and the figures it was weighted with
+type Candidate struct {
+	ProviderConfigurationID uint     `json:"provider_configuration_id"`
+	Provider                string   `json:"provider"`
+	Priority                int      `json:"priority"`
+	Weight                  int      `json:"weight"`
+	Calls                   int      `json:"calls"`
+	SuccessRate             *float64 `json:"success_rate,omitempty"`
+	AverageLatencyMs        int64    `json:"average_latency_ms,omitempty"`
+	EffectiveWeight         float64  `json:"effective_weight"`
+}
+
+// NewDecision records that a provider configuration was chosen with a strategy that involved no other candidates
+func NewDecision(strategy Strategy, providerConfig *provider.ProviderConfiguration) Decision {
+	return Decision{
+		Strategy:                strategy,
+		Provider:                providerConfig.ProviderName,
+		ProviderConfigurationID: providerConfig.ID,
+	}
+}
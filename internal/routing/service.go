@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/provider"
+	"payment-gateway-service/internal/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// RoutingServiceInterface defines the routing rule methods used by the admin API.
+type RoutingServiceInterface interface {
+	ListRules(ctx context.Context) ([]Rule, error)
+	CreateRule(ctx context.Context, request *CreateRuleRequest) (*Rule, error)
+	DeleteRule(ctx context.Context, id uint) error
+}
+
+// RoutingService handles operations related to routing rules.
+type RoutingService struct {
+	db *gorm.DB
+}
+
+// NewRoutingService initializes a new RoutingService with the provided database connection.
+func NewRoutingService(db *gorm.DB) *RoutingService {
+	return &RoutingService{db: db}
+}
+
+// FindRules retrieves the routing rules that can apply to a currency code and country code, by priority.
+func (s *RoutingService) FindRules(ctx context.Context, currencyCode, countryCode string) ([]Rule, error) {
+	var rules []Rule
+
+	err := s.db.WithContext(ctx).
+		Where("currency_code IN (?, '') AND country_code IN (?, '')", currencyCode, countryCode).
+		Order("priority ASC, id").
+		Find(&rules).Error
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingService: Error retrieving routing rules: %v", err))
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ListRules returns every routing rule with its provider, by priority.
+func (s *RoutingService) ListRules(ctx context.Context) ([]Rule, error) {
+	var rules []Rule
+
+	if err := s.db.WithContext(ctx).Preload("Provider").Order("priority ASC, id").Find(&rules).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingService: Error listing routing rules: %v", err))
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// CreateRule creates a routing rule for the named provider.
+func (s *RoutingService) CreateRule(ctx context.Context, request *CreateRuleRequest) (*Rule, error) {
+	if request.MinAmount != nil && request.MaxAmount != nil && *request.MinAmount >= *request.MaxAmount {
+		return nil, ErrInvalidAmountBand
+	}
+
+	var p provider.Provider
+	if err := s.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", request.Provider).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, request.Provider)
+		}
+		return nil, err
+	}
+
+	rule := &Rule{
+		CurrencyCode: strings.ToUpper(request.CurrencyCode),
+		CountryCode:  strings.ToUpper(request.CountryCode),
+		PaymentType:  request.PaymentType,
+		MinAmount:    request.MinAmount,
+		MaxAmount:    request.MaxAmount,
+		UserSegment:  request.UserSegment,
+		ActiveFrom:   request.ActiveFrom,
+		ActiveUntil:  request.ActiveUntil,
+		ProviderID:   p.ID,
+		Provider:     p,
+		Priority:     request.Priority,
+	}
+	if err := s.db.WithContext(ctx).Omit("Provider").Create(rule).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingService: Failed to create routing rule: %v", err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingService: Created routing rule %d for provider %s", rule.ID, p.Name))
+	return rule, nil
+}
+
+// DeleteRule deletes a routing rule.
+func (s *RoutingService) DeleteRule(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).Delete(&Rule{}, id)
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingService: Failed to delete routing rule %d: %v", id, result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRuleNotFound
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("RoutingService: Deleted routing rule %d", id))
+	return nil
+}
+
+// Ensure RoutingService implements RuleSourceInterface and RoutingServiceInterface.
+var _ RuleSourceInterface = (*RoutingService)(nil)
+var _ RoutingServiceInterface = (*RoutingService)(nil)
@@ -0,0 +1,97 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+func TestFindRules(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	routingService := NewRoutingService(gormDB)
+
+	sqlRows := sqlmock.NewRows([]string{"id", "currency_code", "min_amount", "provider_id", "priority"}).
+		AddRow(1, "AED", 10000, 2, 1).
+		AddRow(2, "", nil, 1, 2)
+	mock.ExpectQuery(`^SELECT \* FROM "routing_rules" WHERE currency_code IN \(\$1, ''\) AND country_code IN \(\$2, ''\) ORDER BY priority ASC, id$`).
+		WithArgs("AED", "AE").
+		WillReturnRows(sqlRows)
+
+	rules, err := routingService.FindRules(context.TODO(), "AED", "AE")
+
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, 10000.0, *rules[0].MinAmount)
+	assert.Nil(t, rules[1].MinAmount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateRule_InvalidAmountBand(t *testing.T) {
+	routingService := NewRoutingService(nil)
+	minAmount, maxAmount := 500.0, 100.0
+
+	rule, err := routingService.CreateRule(context.TODO(), &CreateRuleRequest{Provider: "ADCB", Priority: 1, MinAmount: &minAmount, MaxAmount: &maxAmount})
+
+	assert.ErrorIs(t, err, ErrInvalidAmountBand)
+	assert.Nil(t, rule)
+}
+
+func TestCreateRule_UnknownProvider(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	routingService := NewRoutingService(gormDB)
+
+	mock.ExpectQuery(`^SELECT \* FROM "payment_providers" WHERE LOWER\(name\) = LOWER\(\$1\)`).
+		WithArgs("CITI", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	rule, err := routingService.CreateRule(context.TODO(), &CreateRuleRequest{Provider: "CITI", Priority: 1})
+
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+	assert.Nil(t, rule)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteRule_NotFound(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	routingService := NewRoutingService(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "routing_rules" WHERE "routing_rules"\."id" = \$1$`).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := routingService.DeleteRule(context.TODO(), 42)
+
+	assert.ErrorIs(t, err, ErrRuleNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,17 @@
+package routing
+
+// CreateRuleRequest represents the request payload for creating a routing rule. Omitted conditions match every payment.
+type CreateRuleRequest struct {
+	Provider     string   `json:"provider" binding:"required,max=255"`
+	Priority     int      `json:"priority" binding:"required,gte=1"`
+	CurrencyCode string   `json:"currency_code" binding:"omitempty,len=3"`
+	CountryCode  string   `json:"country_code" binding:"omitempty,len=2"`
+	PaymentType  string   `json:"payment_type" binding:"omitempty,oneof=DEPOSIT WITHDRAWAL"`
+	MinAmount    *float64 `json:"min_amount" binding:"omitempty,gte=0"`
+	MaxAmount    *float64 `json:"max_amount" binding:"omitempty,gt=0"`
+	UserSegment  string   `json:"user_segment" binding:"omitempty,max=50"`
+
+	// ActiveFrom and ActiveUntil limit the rule to a UTC time of day window, such as 22:00 to 06:00
+	ActiveFrom  string `json:"active_from" binding:"required_with=ActiveUntil,omitempty,datetime=15:04"`
+	ActiveUntil string `json:"active_until" binding:"required_with=ActiveFrom,omitempty,datetime=15:04"`
+}
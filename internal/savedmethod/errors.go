@@ -0,0 +1,17 @@
+package savedmethod
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrSavedMethodNotFound is returned when no saved method of the merchant, or of the user, has the ID
+	ErrSavedMethodNotFound = utils.NewAPIError(http.StatusNotFound, "saved_method_not_found", "Saved payment method not found")
+
+	// ErrSavedMethodDisabled is returned when charging a disabled saved method
+	ErrSavedMethodDisabled = utils.NewAPIError(http.StatusUnprocessableEntity, "saved_method_disabled", "Saved payment method is disabled")
+
+	// ErrTokenOfAnotherUser is returned when a provider token is saved again for another user or merchant than the one it was saved for
+	ErrTokenOfAnotherUser = utils.NewAPIError(http.StatusConflict, "token_of_another_user", "The payment method is saved for another user")
+)
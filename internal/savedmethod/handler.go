@@ -0,0 +1,70 @@
+package savedmethod
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SavedMethodHandler handles the saved payment method requests
+type SavedMethodHandler struct {
+	service SavedMethodServiceInterface
+}
+
+// NewSavedMethodHandler initializes a new SavedMethodHandler. Methods are saved by the payment
+// callbacks, not by the handler.
+func NewSavedMethodHandler(db *gorm.DB) *SavedMethodHandler {
+	return &SavedMethodHandler{service: NewSavedMethodService(db)}
+}
+
+// List lists the saved methods of a user
+// @Summary List the saved payment methods of a user
+// @Description Lists the payment methods a user of the authenticated merchant saved with deposits made with save_method, newest first. The provider tokens are never returned.
+// @Tags saved-methods
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param user_id query int true "User ID"
+// @Success 200 {object} utils.APIResponse{data=[]SavedMethod} "Saved methods"
+// @Failure 400 {object} utils.APIResponse "Missing user ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Router /saved-methods [get]
+func (h *SavedMethodHandler) List(c *gin.Context) {
+	var query ListSavedMethodsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", map[string][]string{"validation": {err.Error()}})
+		return
+	}
+
+	methods, err := h.service.List(c, query.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Saved methods", methods)
+}
+
+// Disable disables a saved method
+// @Summary Disable a saved payment method
+// @Description Disables a saved payment method of the authenticated merchant for good, it can no longer be charged.
+// @Tags saved-methods
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Saved method ID"
+// @Success 200 {object} utils.APIResponse{data=SavedMethod} "Disabled saved method"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Saved method not found"
+// @Router /saved-methods/{id}/disable [post]
+func (h *SavedMethodHandler) Disable(c *gin.Context) {
+	method, err := h.service.Disable(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Saved method disabled", method)
+}
@@ -0,0 +1,41 @@
+package savedmethod
+
+import (
+	"payment-gateway-service/internal/provider"
+	"time"
+)
+
+// Status is the status of a saved payment method
+type Status string
+
+const (
+	// StatusActive methods can be charged
+	StatusActive Status = "ACTIVE"
+	// StatusDisabled methods were disabled by the merchant and can no longer be charged
+	StatusDisabled Status = "DISABLED"
+)
+
+// SavedMethod is a payment method a user of a merchant saved with a provider, identified by the reusable
+// token the provider issued once a payment made with it succeeded. Only that provider can charge it. The
+// token is useless without the gateway's provider credentials and is never returned.
+type SavedMethod struct {
+	ID         string            `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MerchantID uint              `gorm:"not null" json:"merchant_id"`
+	UserID     int               `gorm:"not null" json:"user_id"`
+	ProviderID uint              `gorm:"not null" json:"provider_id"`
+	Provider   provider.Provider `gorm:"foreignKey:ProviderID" json:"provider"`
+	Token      string            `gorm:"type:varchar(255);not null" json:"-"`
+
+	// SourcePaymentID is the payment the user saved the method with
+	SourcePaymentID string `gorm:"type:uuid;not null" json:"source_payment_id"`
+
+	Status     Status     `gorm:"type:varchar(20);not null;default:ACTIVE" json:"status"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ListSavedMethodsQuery is the user to list the saved methods of
+type ListSavedMethodsQuery struct {
+	UserID int `form:"user_id" binding:"required"`
+}
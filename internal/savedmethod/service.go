@@ -0,0 +1,139 @@
+package savedmethod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SavedMethodServiceInterface defines the methods that the SavedMethodService must implement.
+type SavedMethodServiceInterface interface {
+	Save(ctx context.Context, method *SavedMethod) (*SavedMethod, error)
+	List(ctx context.Context, userID int) ([]SavedMethod, error)
+	Disable(ctx context.Context, id string) (*SavedMethod, error)
+	MethodForCharge(ctx context.Context, id string, userID int) (*SavedMethod, error)
+}
+
+// SavedMethodService stores the payment methods the users of merchants saved with providers.
+type SavedMethodService struct {
+	db *gorm.DB
+}
+
+// NewSavedMethodService initializes a new SavedMethodService
+func NewSavedMethodService(db *gorm.DB) *SavedMethodService {
+	return &SavedMethodService{db: db}
+}
+
+var _ SavedMethodServiceInterface = (*SavedMethodService)(nil)
+
+// Save stores an ACTIVE method issued by a provider. A token the provider issued before is not stored
+// twice: the method already saved with it for the same user of the merchant is returned instead, and a
+// token saved for another user or merchant is refused.
+func (s *SavedMethodService) Save(ctx context.Context, method *SavedMethod) (*SavedMethod, error) {
+	method.Status = StatusActive
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(method)
+	if result.Error != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Failed to save method of user %d: %v", method.UserID, result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var existing SavedMethod
+		if err := s.db.WithContext(ctx).Where("provider_id = ? AND token = ?", method.ProviderID, method.Token).First(&existing).Error; err != nil {
+			return nil, err
+		}
+		if existing.MerchantID != method.MerchantID || existing.UserID != method.UserID {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Token of provider %d is saved as method %s of another user", method.ProviderID, existing.ID))
+			return nil, ErrTokenOfAnotherUser
+		}
+		return &existing, nil
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Method %s saved for user %d", method.ID, method.UserID))
+	return method, nil
+}
+
+// List lists the saved methods of a user of the authenticated merchant, newest first
+func (s *SavedMethodService) List(ctx context.Context, userID int) ([]SavedMethod, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []SavedMethod
+	if err := s.db.WithContext(ctx).Preload("Provider").
+		Where("merchant_id = ? AND user_id = ?", merchantID, userID).
+		Order("created_at DESC").
+		Find(&methods).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Failed to list methods of user %d: %v", userID, err))
+		return nil, err
+	}
+	return methods, nil
+}
+
+// Disable marks a saved method of the authenticated merchant as DISABLED for good. Disabling a disabled
+// method has no effect.
+func (s *SavedMethodService) Disable(ctx context.Context, id string) (*SavedMethod, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSavedMethodNotFound
+	}
+
+	var method SavedMethod
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND merchant_id = ?", id, merchantID).First(&method).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSavedMethodNotFound
+			}
+			return err
+		}
+		if method.Status == StatusDisabled {
+			return nil
+		}
+
+		now := time.Now()
+		method.Status = StatusDisabled
+		method.DisabledAt = &now
+		return tx.Save(&method).Error
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Failed to disable method %s: %v", id, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Method %s is %s", id, method.Status))
+	return &method, nil
+}
+
+// MethodForCharge returns an ACTIVE saved method of a user of the authenticated merchant, to charge it
+func (s *SavedMethodService) MethodForCharge(ctx context.Context, id string, userID int) (*SavedMethod, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSavedMethodNotFound
+	}
+
+	var method SavedMethod
+	if err := s.db.WithContext(ctx).Where("id = ? AND merchant_id = ? AND user_id = ?", id, merchantID, userID).First(&method).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSavedMethodNotFound
+		}
+		return nil, err
+	}
+
+	if method.Status != StatusActive {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SavedMethodService: Charge of method %s refused (status: %s)", id, method.Status))
+		return nil, ErrSavedMethodDisabled
+	}
+	return &method, nil
+}
@@ -0,0 +1,180 @@
+package savedmethod
+
+import (
+	"context"
+	"testing"
+
+	"payment-gateway-service/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB and service
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, mock, func() {
+		db.Close()
+	}
+}
+
+// merchantCtx carries the authenticated merchant the way the auth middleware sets it
+var merchantCtx = context.WithValue(context.TODO(), utils.ContextKeyMerchantID, uint(1))
+
+const savedMethodID = "5b7e2c1a-9d3f-4e8b-a6c2-1f0d9e8c7b6a"
+
+const sourcePaymentID = "8d1c2b1e-4f5a-4c3b-9a7e-2f6d5c4b3a21"
+
+const chargeQuery = `^SELECT \* FROM "saved_methods" WHERE id = \$1 AND merchant_id = \$2 AND user_id = \$3 ORDER BY "saved_methods"."id" LIMIT \$4$`
+
+func TestSave_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "saved_methods" .* ON CONFLICT DO NOTHING RETURNING "id"$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(savedMethodID))
+	mock.ExpectCommit()
+
+	service := NewSavedMethodService(gormDB)
+
+	method, err := service.Save(context.TODO(), &SavedMethod{MerchantID: 1, UserID: 7, ProviderID: 2, Token: "tok_1", SourcePaymentID: sourcePaymentID})
+
+	require.NoError(t, err)
+	assert.Equal(t, savedMethodID, method.ID)
+	assert.Equal(t, StatusActive, method.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSave_TokenAlreadySaved(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The provider issued the same token for another payment with the method
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "saved_methods" .* ON CONFLICT DO NOTHING RETURNING "id"$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^SELECT \* FROM "saved_methods" WHERE provider_id = \$1 AND token = \$2 ORDER BY "saved_methods"."id" LIMIT \$3$`).
+		WithArgs(2, "tok_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "user_id", "provider_id", "token"}).AddRow(savedMethodID, 1, 7, 2, "tok_1"))
+
+	service := NewSavedMethodService(gormDB)
+
+	method, err := service.Save(context.TODO(), &SavedMethod{MerchantID: 1, UserID: 7, ProviderID: 2, Token: "tok_1", SourcePaymentID: sourcePaymentID})
+
+	require.NoError(t, err)
+	assert.Equal(t, savedMethodID, method.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSave_TokenOfAnotherUser(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	// The token was saved for user 9, whose method must not be handed to user 7
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^INSERT INTO "saved_methods" .* ON CONFLICT DO NOTHING RETURNING "id"$`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^SELECT \* FROM "saved_methods" WHERE provider_id = \$1 AND token = \$2 ORDER BY "saved_methods"."id" LIMIT \$3$`).
+		WithArgs(2, "tok_1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "user_id", "provider_id", "token"}).AddRow(savedMethodID, 1, 9, 2, "tok_1"))
+
+	service := NewSavedMethodService(gormDB)
+
+	method, err := service.Save(context.TODO(), &SavedMethod{MerchantID: 1, UserID: 7, ProviderID: 2, Token: "tok_1", SourcePaymentID: sourcePaymentID})
+
+	assert.Nil(t, method)
+	assert.ErrorIs(t, err, ErrTokenOfAnotherUser)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDisable_Success(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "saved_methods" WHERE id = \$1 AND merchant_id = \$2 ORDER BY "saved_methods"."id" LIMIT \$3 FOR UPDATE$`).
+		WithArgs(savedMethodID, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "status"}).AddRow(savedMethodID, 1, "ACTIVE"))
+	mock.ExpectExec(`^UPDATE "saved_methods" SET .*"status"=\$6,.* WHERE "id" = \$10$`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	service := NewSavedMethodService(gormDB)
+
+	method, err := service.Disable(merchantCtx, savedMethodID)
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusDisabled, method.Status)
+	assert.NotNil(t, method.DisabledAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMethodForCharge(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		wantErr error
+	}{
+		{name: "active", status: "ACTIVE"},
+		{name: "disabled", status: "DISABLED", wantErr: ErrSavedMethodDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock, teardown := setupTest(t)
+			defer teardown()
+
+			mock.ExpectQuery(chargeQuery).
+				WithArgs(savedMethodID, 1, 7, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "user_id", "provider_id", "token", "status"}).
+					AddRow(savedMethodID, 1, 7, 2, "tok_1", tt.status))
+
+			service := NewSavedMethodService(gormDB)
+
+			method, err := service.MethodForCharge(merchantCtx, savedMethodID, 7)
+
+			if tt.wantErr != nil {
+				assert.Nil(t, method)
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "tok_1", method.Token)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMethodForCharge_OtherUser(t *testing.T) {
+	gormDB, mock, teardown := setupTest(t)
+	defer teardown()
+
+	mock.ExpectQuery(chargeQuery).
+		WithArgs(savedMethodID, 1, 8, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	service := NewSavedMethodService(gormDB)
+
+	method, err := service.MethodForCharge(merchantCtx, savedMethodID, 8)
+
+	assert.Nil(t, method)
+	assert.ErrorIs(t, err, ErrSavedMethodNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
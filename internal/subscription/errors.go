@@ -0,0 +1,20 @@
+package subscription
+
+import (
+	"net/http"
+	"payment-gateway-service/internal/utils"
+)
+
+var (
+	// ErrPlanNotFound is returned when no plan of the merchant has the ID
+	ErrPlanNotFound = utils.NewAPIError(http.StatusNotFound, "plan_not_found", "Plan not found")
+
+	// ErrSubscriptionNotFound is returned when no subscription of the merchant has the ID
+	ErrSubscriptionNotFound = utils.NewAPIError(http.StatusNotFound, "subscription_not_found", "Subscription not found")
+
+	// ErrStartAtInPast is returned when a subscription is created to start in the past, whose periods would all be charged at once
+	ErrStartAtInPast = utils.NewAPIError(http.StatusUnprocessableEntity, "start_at_in_past", "The subscription cannot start in the past")
+
+	// ErrInvalidTransition is returned when a cancelled subscription is paused or resumed
+	ErrInvalidTransition = utils.NewAPIError(http.StatusConflict, "invalid_transition", "Subscription is cancelled")
+)
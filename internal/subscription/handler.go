@@ -0,0 +1,226 @@
+package subscription
+
+import (
+	"net/http"
+	"payment-gateway-service/config"
+	"payment-gateway-service/internal/savedmethod"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SubscriptionHandler handles the plan and subscription requests
+type SubscriptionHandler struct {
+	service SubscriptionServiceInterface
+}
+
+// NewSubscriptionHandler initializes a new SubscriptionHandler. The charges of subscriptions are
+// created with payments by the subscriptions run command, not by the handler.
+func NewSubscriptionHandler(db *gorm.DB, configStore *config.Store, payments PaymentServiceInterface) *SubscriptionHandler {
+	return &SubscriptionHandler{service: NewSubscriptionService(db, payments, savedmethod.NewSavedMethodService(db), configStore.Current().SubscriptionRetryDelays())}
+}
+
+// CreatePlan creates a plan
+// @Summary Create a plan
+// @Description Creates a plan billing an amount every interval_count intervals of a day, week, month or year, every interval by default. Monthly and yearly periods keep the day of the month the subscription started on, or the last day of shorter months.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param validatedBody body CreatePlanRequest true "Plan"
+// @Success 201 {object} utils.APIResponse{data=Plan} "Created plan"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Router /subscriptions/plans [post]
+func (h *SubscriptionHandler) CreatePlan(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CreatePlanRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	plan, err := h.service.CreatePlan(c, request)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Plan created", plan)
+}
+
+// ListPlans lists the plans
+// @Summary List the plans
+// @Description Lists the plans of the authenticated merchant, newest first.
+// @Tags subscriptions
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Success 200 {object} utils.APIResponse{data=[]Plan} "Plans"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Router /subscriptions/plans [get]
+func (h *SubscriptionHandler) ListPlans(c *gin.Context) {
+	plans, err := h.service.ListPlans(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Plans", plans)
+}
+
+// Create subscribes a user to a plan
+// @Summary Create a subscription
+// @Description Subscribes a user to a plan of the authenticated merchant from start_at, now by default. Each period is charged when it starts with a deposit of the plan's amount, whose checkout URL is recorded on the charge. A failed charge is retried on the configured retry schedule, during which the subscription is PAST_DUE, and the subscription is CANCELLED when the last retry fails.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param validatedBody body CreateSubscriptionRequest true "Subscription"
+// @Success 201 {object} utils.APIResponse{data=Subscription} "Created subscription"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Plan not found"
+// @Failure 422 {object} utils.APIResponse "Start in the past"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /subscriptions [post]
+func (h *SubscriptionHandler) Create(c *gin.Context) {
+	req, _ := c.Get("validatedBody")
+	request, ok := req.(*CreateSubscriptionRequest)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "Invalid request", nil)
+		return
+	}
+
+	subscription, err := h.service.Create(c, request)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Subscription created", subscription)
+}
+
+// List lists the subscriptions of a user
+// @Summary List the subscriptions of a user
+// @Description Lists the subscriptions of a user of the authenticated merchant with their plan, newest first.
+// @Tags subscriptions
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param user_id query int true "User ID"
+// @Success 200 {object} utils.APIResponse{data=[]Subscription} "Subscriptions"
+// @Failure 400 {object} utils.APIResponse "Missing user ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Router /subscriptions [get]
+func (h *SubscriptionHandler) List(c *gin.Context) {
+	var query ListSubscriptionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.ErrCodeValidationFailed, "Validation failed", map[string][]string{"validation": {err.Error()}})
+		return
+	}
+
+	subscriptions, err := h.service.List(c, query.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscriptions", subscriptions)
+}
+
+// Get returns a subscription
+// @Summary Get a subscription
+// @Description Returns a subscription of the authenticated merchant with its plan and its charges, newest first.
+// @Tags subscriptions
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} utils.APIResponse{data=Subscription} "Subscription"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:read"
+// @Failure 404 {object} utils.APIResponse "Subscription not found"
+// @Router /subscriptions/{id} [get]
+func (h *SubscriptionHandler) Get(c *gin.Context) {
+	subscription, err := h.service.Find(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscription", subscription)
+}
+
+// Pause pauses a subscription
+// @Summary Pause a subscription
+// @Description Stops charging a subscription of the authenticated merchant until it is resumed. A charge in progress still completes. Pausing a paused subscription has no effect.
+// @Tags subscriptions
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} utils.APIResponse{data=Subscription} "Paused subscription"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Subscription not found"
+// @Failure 409 {object} utils.APIResponse "Subscription is cancelled"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	subscription, err := h.service.Pause(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscription paused", subscription)
+}
+
+// Resume resumes a subscription
+// @Summary Resume a subscription
+// @Description Charges a paused subscription of the authenticated merchant again from the first period starting after now. The periods that started while it was paused are not charged. Resuming an active subscription has no effect.
+// @Tags subscriptions
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} utils.APIResponse{data=Subscription} "Resumed subscription"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Subscription not found"
+// @Failure 409 {object} utils.APIResponse "Subscription is cancelled"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	subscription, err := h.service.Resume(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscription resumed", subscription)
+}
+
+// Cancel cancels a subscription
+// @Summary Cancel a subscription
+// @Description Stops charging a subscription of the authenticated merchant for good. A charge in progress still completes.
+// @Tags subscriptions
+// @Produce json
+// @Param X-AUTH-TOKEN header string true "Merchant API key"
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} utils.APIResponse{data=Subscription} "Cancelled subscription"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Missing scope payments:deposit"
+// @Failure 404 {object} utils.APIResponse "Subscription not found"
+// @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	subscription, err := h.service.Cancel(c, c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscription cancelled", subscription)
+}
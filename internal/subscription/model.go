@@ -0,0 +1,238 @@
+package subscription
+
+import (
+	"payment-gateway-service/internal/payment"
+	"time"
+)
+
+// Interval is the unit of the billing period of a plan
+type Interval string
+
+// Intervals of plans
+const (
+	IntervalDay   Interval = "DAY"
+	IntervalWeek  Interval = "WEEK"
+	IntervalMonth Interval = "MONTH"
+	IntervalYear  Interval = "YEAR"
+)
+
+// Status is the status of a subscription
+type Status string
+
+const (
+	// StatusActive subscriptions are charged at the start of each period
+	StatusActive Status = "ACTIVE"
+	// StatusPastDue subscriptions failed their last charge and are retried on the retry schedule
+	StatusPastDue Status = "PAST_DUE"
+	// StatusPaused subscriptions are not charged until they are resumed
+	StatusPaused Status = "PAUSED"
+	// StatusCancelled subscriptions are no longer charged, cancelled by the merchant or after their last retry failed
+	StatusCancelled Status = "CANCELLED"
+)
+
+// ChargeStatus is the status of a charge of a subscription
+type ChargeStatus string
+
+const (
+	// ChargeStatusPending charges wait for their payment to complete
+	ChargeStatusPending ChargeStatus = "PENDING"
+	// ChargeStatusSucceeded charges paid their period
+	ChargeStatusSucceeded ChargeStatus = "SUCCEEDED"
+	// ChargeStatusFailed charges could not be created or their payment did not succeed
+	ChargeStatusFailed ChargeStatus = "FAILED"
+)
+
+// Plan is what a merchant bills its subscribers: an amount every IntervalCount intervals
+type Plan struct {
+	ID            string    `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MerchantID    uint      `gorm:"not null" json:"merchant_id"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Amount        float64   `gorm:"type:numeric(12,2);not null" json:"amount"`
+	CurrencyCode  string    `gorm:"type:varchar(3);not null" json:"currency_code"`
+	Interval      Interval  `gorm:"type:varchar(10);not null" json:"interval"`
+	IntervalCount int       `gorm:"not null" json:"interval_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (Plan) TableName() string {
+	return "subscription_plans"
+}
+
+// Subscription bills a user of a merchant on a plan, from StartAt. Period n starts at
+// Plan.PeriodStart(StartAt, n) and NextPeriod is the first period not paid nor skipped.
+type Subscription struct {
+	ID          string           `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	MerchantID  uint             `gorm:"not null" json:"merchant_id"`
+	PlanID      string           `gorm:"type:uuid;not null" json:"plan_id"`
+	Plan        Plan             `gorm:"foreignKey:PlanID" json:"plan"`
+	UserID      int              `gorm:"not null" json:"user_id"`
+	CountryCode string           `gorm:"type:varchar(2);not null" json:"country_code"`
+	Customer    payment.Customer `gorm:"embedded;embeddedPrefix:customer_" json:"customer"`
+	Status      Status           `gorm:"type:varchar(20);not null" json:"status"`
+	StartAt     time.Time        `gorm:"not null" json:"start_at"`
+	NextPeriod  int              `gorm:"not null" json:"next_period"`
+
+	// NextChargeAt is the start of the next period, or the next retry of a PAST_DUE subscription.
+	// FailedAttempts counts the failed charges of the period since its last success.
+	NextChargeAt   time.Time `gorm:"not null" json:"next_charge_at"`
+	FailedAttempts int       `gorm:"not null" json:"failed_attempts"`
+
+	// PendingPaymentID is the payment of the charge in progress, the subscription is not charged again until it completes
+	PendingPaymentID *string `gorm:"type:uuid" json:"pending_payment_id,omitempty"`
+
+	// SavedMethodID is the saved payment method the subscription is charged with, with no redirect. Without
+	// one each charge records a checkout URL the customer pays at.
+	SavedMethodID *string `gorm:"type:uuid" json:"saved_method_id,omitempty"`
+
+	PausedAt    *time.Time `json:"paused_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Charges []Charge `gorm:"foreignKey:SubscriptionID" json:"charges,omitempty"`
+}
+
+// Charge is an attempt to pay a period of a subscription
+type Charge struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	SubscriptionID string       `gorm:"type:uuid;not null" json:"subscription_id"`
+	Period         int          `gorm:"not null" json:"period"`
+	Attempt        int          `gorm:"not null" json:"attempt"`
+	PaymentID      *string      `gorm:"type:uuid" json:"payment_id,omitempty"`
+	CheckoutURL    string       `gorm:"type:text;not null" json:"checkout_url,omitempty"`
+	Status         ChargeStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Error          string       `gorm:"type:text;not null" json:"error,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func (Charge) TableName() string {
+	return "subscription_charges"
+}
+
+// CreatePlanRequest creates a plan billing Amount every IntervalCount intervals, every interval by default
+type CreatePlanRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Amount        float64  `json:"amount" binding:"required,gt=1"`
+	CurrencyCode  string   `json:"currency_code" binding:"required,len=3"`
+	Interval      Interval `json:"interval" binding:"required,oneof=DAY WEEK MONTH YEAR"`
+	IntervalCount int      `json:"interval_count" binding:"omitempty,min=1,max=365"`
+}
+
+// CreateSubscriptionRequest subscribes a user to a plan, from StartAt or now. The payments of the
+// subscription are made in CountryCode by the customer.
+type CreateSubscriptionRequest struct {
+	PlanID      string           `json:"plan_id" binding:"required,uuid"`
+	UserID      int              `json:"user_id" binding:"required"`
+	CountryCode string           `json:"country_code" binding:"required,len=2"`
+	StartAt     *time.Time       `json:"start_at"`
+	Customer    payment.Customer `json:"customer"`
+
+	// SavedMethodID charges the subscription with a saved payment method of the user, with no redirect
+	SavedMethodID string `json:"saved_method_id" binding:"omitempty,uuid"`
+}
+
+// GetUserID returns the user the subscription is for, for per-user rate limiting
+func (r *CreateSubscriptionRequest) GetUserID() int {
+	return r.UserID
+}
+
+// ListSubscriptionsQuery is the user to list the subscriptions of
+type ListSubscriptionsQuery struct {
+	UserID int `form:"user_id" binding:"required"`
+}
+
+// PeriodStart returns the start of period n of a subscription started at start. Monthly and yearly
+// periods keep the day of the month of start, or the last day of shorter months.
+func (p *Plan) PeriodStart(start time.Time, n int) time.Time {
+	count := n * p.IntervalCount
+	switch p.Interval {
+	case IntervalDay:
+		return start.AddDate(0, 0, count)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7*count)
+	case IntervalYear:
+		return addMonths(start, 12*count)
+	default:
+		return addMonths(start, count)
+	}
+}
+
+// addMonths adds months to t, clamping the day to the last day of the resulting month
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// chargeSucceeded moves a subscription to its next period once a charge paid the current one
+func (s *Subscription) chargeSucceeded() {
+	s.NextPeriod++
+	s.FailedAttempts = 0
+	s.NextChargeAt = s.Plan.PeriodStart(s.StartAt, s.NextPeriod)
+	if s.Status == StatusPastDue {
+		s.Status = StatusActive
+	}
+}
+
+// chargeFailed schedules the next retry of a subscription after a failed charge, or cancels it once
+// the retries are exhausted. Failures do not change paused and cancelled subscriptions.
+func (s *Subscription) chargeFailed(now time.Time, retrySchedule []time.Duration) {
+	if s.Status != StatusActive && s.Status != StatusPastDue {
+		return
+	}
+
+	s.FailedAttempts++
+	if s.FailedAttempts > len(retrySchedule) {
+		s.Status = StatusCancelled
+		s.CancelledAt = &now
+		return
+	}
+	s.Status = StatusPastDue
+	s.NextChargeAt = now.Add(retrySchedule[s.FailedAttempts-1])
+}
+
+// pause stops charging an active or past due subscription
+func (s *Subscription) pause(now time.Time) error {
+	switch s.Status {
+	case StatusPaused:
+		return nil
+	case StatusCancelled:
+		return ErrInvalidTransition
+	}
+	s.Status = StatusPaused
+	s.PausedAt = &now
+	return nil
+}
+
+// resume charges a paused subscription again from the first period starting after now. The periods
+// that started while it was paused, and a period left unpaid when it was paused, are not charged.
+func (s *Subscription) resume(now time.Time) error {
+	switch s.Status {
+	case StatusActive, StatusPastDue:
+		return nil
+	case StatusCancelled:
+		return ErrInvalidTransition
+	}
+	s.Status = StatusActive
+	s.PausedAt = nil
+	s.FailedAttempts = 0
+	for !s.Plan.PeriodStart(s.StartAt, s.NextPeriod).After(now) {
+		s.NextPeriod++
+	}
+	s.NextChargeAt = s.Plan.PeriodStart(s.StartAt, s.NextPeriod)
+	return nil
+}
+
+// cancel stops charging a subscription for good
+func (s *Subscription) cancel(now time.Time) {
+	if s.Status == StatusCancelled {
+		return
+	}
+	s.Status = StatusCancelled
+	s.CancelledAt = &now
+}
@@ -0,0 +1,116 @@
+package subscription
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodStart(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		plan     Plan
+		period   int
+		expected time.Time
+	}{
+		{"first period", Plan{Interval: IntervalMonth, IntervalCount: 1}, 0, start},
+		{"daily", Plan{Interval: IntervalDay, IntervalCount: 1}, 3, time.Date(2026, time.February, 3, 9, 30, 0, 0, time.UTC)},
+		{"every two weeks", Plan{Interval: IntervalWeek, IntervalCount: 2}, 1, time.Date(2026, time.February, 14, 9, 30, 0, 0, time.UTC)},
+		{"monthly into a shorter month", Plan{Interval: IntervalMonth, IntervalCount: 1}, 1, time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC)},
+		{"monthly after a shorter month", Plan{Interval: IntervalMonth, IntervalCount: 1}, 2, time.Date(2026, time.March, 31, 9, 30, 0, 0, time.UTC)},
+		{"quarterly", Plan{Interval: IntervalMonth, IntervalCount: 3}, 1, time.Date(2026, time.April, 30, 9, 30, 0, 0, time.UTC)},
+		{"yearly", Plan{Interval: IntervalYear, IntervalCount: 1}, 2, time.Date(2028, time.January, 31, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.plan.PeriodStart(start, tt.period))
+		})
+	}
+}
+
+func TestPeriodStart_LeapDay(t *testing.T) {
+	plan := Plan{Interval: IntervalYear, IntervalCount: 1}
+	start := time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2029, time.February, 28, 0, 0, 0, 0, time.UTC), plan.PeriodStart(start, 1))
+	assert.Equal(t, time.Date(2032, time.February, 29, 0, 0, 0, 0, time.UTC), plan.PeriodStart(start, 4))
+}
+
+func TestChargeFailed_RetriesThenCancels(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	schedule := []time.Duration{24 * time.Hour, 72 * time.Hour}
+	subscription := Subscription{Status: StatusActive, NextChargeAt: now}
+
+	subscription.chargeFailed(now, schedule)
+	assert.Equal(t, StatusPastDue, subscription.Status)
+	assert.Equal(t, 1, subscription.FailedAttempts)
+	assert.Equal(t, now.Add(24*time.Hour), subscription.NextChargeAt)
+
+	subscription.chargeFailed(now, schedule)
+	assert.Equal(t, StatusPastDue, subscription.Status)
+	assert.Equal(t, now.Add(72*time.Hour), subscription.NextChargeAt)
+
+	subscription.chargeFailed(now, schedule)
+	assert.Equal(t, StatusCancelled, subscription.Status)
+	assert.Equal(t, &now, subscription.CancelledAt)
+}
+
+func TestChargeFailed_PausedUnchanged(t *testing.T) {
+	now := time.Now()
+	subscription := Subscription{Status: StatusPaused}
+
+	subscription.chargeFailed(now, nil)
+
+	assert.Equal(t, StatusPaused, subscription.Status)
+	assert.Zero(t, subscription.FailedAttempts)
+}
+
+func TestChargeSucceeded_RecoversPastDue(t *testing.T) {
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	subscription := Subscription{
+		Plan:           Plan{Interval: IntervalMonth, IntervalCount: 1},
+		Status:         StatusPastDue,
+		StartAt:        start,
+		NextPeriod:     1,
+		FailedAttempts: 2,
+	}
+
+	subscription.chargeSucceeded()
+
+	assert.Equal(t, StatusActive, subscription.Status)
+	assert.Equal(t, 2, subscription.NextPeriod)
+	assert.Zero(t, subscription.FailedAttempts)
+	assert.Equal(t, time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC), subscription.NextChargeAt)
+}
+
+func TestResume_SkipsPausedPeriods(t *testing.T) {
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, time.April, 2, 0, 0, 0, 0, time.UTC)
+	subscription := Subscription{
+		Plan:           Plan{Interval: IntervalMonth, IntervalCount: 1},
+		Status:         StatusPaused,
+		StartAt:        start,
+		NextPeriod:     1,
+		FailedAttempts: 1,
+		PausedAt:       &start,
+	}
+
+	assert.NoError(t, subscription.resume(now))
+
+	assert.Equal(t, StatusActive, subscription.Status)
+	assert.Nil(t, subscription.PausedAt)
+	assert.Zero(t, subscription.FailedAttempts)
+	assert.Equal(t, 3, subscription.NextPeriod, "the periods of February and March are skipped")
+	assert.Equal(t, time.Date(2026, time.April, 15, 0, 0, 0, 0, time.UTC), subscription.NextChargeAt)
+}
+
+func TestTransitions_Cancelled(t *testing.T) {
+	subscription := Subscription{Status: StatusCancelled}
+
+	assert.ErrorIs(t, subscription.pause(time.Now()), ErrInvalidTransition)
+	assert.ErrorIs(t, subscription.resume(time.Now()), ErrInvalidTransition)
+}
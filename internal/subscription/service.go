@@ -0,0 +1,518 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/savedmethod"
+	"payment-gateway-service/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubscriptionServiceInterface defines the methods that the SubscriptionService must implement.
+type SubscriptionServiceInterface interface {
+	CreatePlan(ctx context.Context, request *CreatePlanRequest) (*Plan, error)
+	ListPlans(ctx context.Context) ([]Plan, error)
+	Create(ctx context.Context, request *CreateSubscriptionRequest) (*Subscription, error)
+	List(ctx context.Context, userID int) ([]Subscription, error)
+	Find(ctx context.Context, id string) (*Subscription, error)
+	Pause(ctx context.Context, id string) (*Subscription, error)
+	Resume(ctx context.Context, id string) (*Subscription, error)
+	Cancel(ctx context.Context, id string) (*Subscription, error)
+	Run(ctx context.Context, now time.Time) ([]Charge, error)
+}
+
+// PaymentServiceInterface defines the methods of the PaymentService used to charge subscriptions.
+type PaymentServiceInterface interface {
+	CreatePayment(ctx context.Context, paymentRequest *payment.PaymentRequest, paymentType utils.PaymentType) (string, error)
+	FindPaymentByID(ctx context.Context, id string) (*payment.Payment, error)
+	FindPaymentByReference(ctx context.Context, reference string) (*payment.Payment, error)
+}
+
+// SavedMethodServiceInterface defines the method of the SavedMethodService used to check the saved method of subscriptions.
+type SavedMethodServiceInterface interface {
+	MethodForCharge(ctx context.Context, id string, userID int) (*savedmethod.SavedMethod, error)
+}
+
+// SubscriptionService stores plans and subscriptions and charges the subscriptions when they are due.
+type SubscriptionService struct {
+	db            *gorm.DB
+	payments      PaymentServiceInterface
+	savedMethods  SavedMethodServiceInterface
+	retrySchedule []time.Duration
+}
+
+// NewSubscriptionService initializes a new SubscriptionService. Failed charges are retried after each
+// delay of retrySchedule in turn, and the subscription is cancelled once they are exhausted. Only Run
+// uses payments and retrySchedule, and only Create uses savedMethods.
+func NewSubscriptionService(db *gorm.DB, payments PaymentServiceInterface, savedMethods SavedMethodServiceInterface, retrySchedule []time.Duration) *SubscriptionService {
+	return &SubscriptionService{db: db, payments: payments, savedMethods: savedMethods, retrySchedule: retrySchedule}
+}
+
+var _ SubscriptionServiceInterface = (*SubscriptionService)(nil)
+
+// CreatePlan creates a plan of the authenticated merchant
+func (s *SubscriptionService) CreatePlan(ctx context.Context, request *CreatePlanRequest) (*Plan, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		MerchantID:    merchantID,
+		Name:          request.Name,
+		Amount:        request.Amount,
+		CurrencyCode:  strings.ToUpper(request.CurrencyCode),
+		Interval:      request.Interval,
+		IntervalCount: max(request.IntervalCount, 1),
+	}
+	if err := s.db.WithContext(ctx).Create(plan).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to save plan: %v", err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Plan %s created", plan.ID))
+	return plan, nil
+}
+
+// ListPlans lists the plans of the authenticated merchant, newest first
+func (s *SubscriptionService) ListPlans(ctx context.Context) ([]Plan, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []Plan
+	if err := s.db.WithContext(ctx).Where("merchant_id = ?", merchantID).Order("created_at DESC").Find(&plans).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to list plans: %v", err))
+		return nil, err
+	}
+	return plans, nil
+}
+
+// Create subscribes a user of the authenticated merchant to one of its plans, from now or a later start.
+// The first period is charged at the start of the subscription.
+func (s *SubscriptionService) Create(ctx context.Context, request *CreateSubscriptionRequest) (*Subscription, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	startAt := time.Now()
+	if request.StartAt != nil {
+		// Every period since a past start would be due at once
+		if request.StartAt.Before(startAt) {
+			return nil, ErrStartAtInPast
+		}
+		startAt = *request.StartAt
+	}
+
+	var plan Plan
+	if err := s.db.WithContext(ctx).Where("id = ? AND merchant_id = ?", request.PlanID, merchantID).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlanNotFound
+		}
+		return nil, err
+	}
+
+	var savedMethodID *string
+	if request.SavedMethodID != "" {
+		method, err := s.savedMethods.MethodForCharge(ctx, request.SavedMethodID, request.UserID)
+		if err != nil {
+			return nil, err
+		}
+		savedMethodID = &method.ID
+	}
+
+	subscription := &Subscription{
+		MerchantID:   merchantID,
+		PlanID:       plan.ID,
+		UserID:       request.UserID,
+		CountryCode:  strings.ToUpper(request.CountryCode),
+		Customer:     request.Customer,
+		Status:       StatusActive,
+		StartAt:      startAt,
+		NextChargeAt: startAt,
+
+		SavedMethodID: savedMethodID,
+	}
+	// The plan is returned with the subscription but already exists
+	if err := s.db.WithContext(ctx).Omit("Plan").Create(subscription).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to save subscription: %v", err))
+		return nil, err
+	}
+	subscription.Plan = plan
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Subscription %s created for user %d", subscription.ID, subscription.UserID))
+	return subscription, nil
+}
+
+// List lists the subscriptions of a user of the authenticated merchant, newest first
+func (s *SubscriptionService) List(ctx context.Context, userID int) ([]Subscription, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptions []Subscription
+	if err := s.db.WithContext(ctx).Preload("Plan").
+		Where("merchant_id = ? AND user_id = ?", merchantID, userID).
+		Order("created_at DESC").
+		Find(&subscriptions).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to list subscriptions of user %d: %v", userID, err))
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// Find finds a subscription of the authenticated merchant with its charges, newest first
+func (s *SubscriptionService) Find(ctx context.Context, id string) (*Subscription, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	var subscription Subscription
+	if err := s.db.WithContext(ctx).Preload("Plan").
+		Preload("Charges", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Where("id = ? AND merchant_id = ?", id, merchantID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// Pause stops charging a subscription of the authenticated merchant until it is resumed. A charge in
+// progress still completes.
+func (s *SubscriptionService) Pause(ctx context.Context, id string) (*Subscription, error) {
+	return s.update(ctx, id, func(subscription *Subscription) error {
+		return subscription.pause(time.Now())
+	})
+}
+
+// Resume charges a paused subscription of the authenticated merchant again, from the next period
+func (s *SubscriptionService) Resume(ctx context.Context, id string) (*Subscription, error) {
+	return s.update(ctx, id, func(subscription *Subscription) error {
+		return subscription.resume(time.Now())
+	})
+}
+
+// Cancel stops charging a subscription of the authenticated merchant for good. A charge in progress
+// still completes.
+func (s *SubscriptionService) Cancel(ctx context.Context, id string) (*Subscription, error) {
+	return s.update(ctx, id, func(subscription *Subscription) error {
+		subscription.cancel(time.Now())
+		return nil
+	})
+}
+
+// update applies change to a subscription of the authenticated merchant and saves it
+func (s *SubscriptionService) update(ctx context.Context, id string, change func(*Subscription) error) (*Subscription, error) {
+	merchantID, err := utils.RequireMerchantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	var subscription Subscription
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND merchant_id = ?", id, merchantID).
+			First(&subscription).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSubscriptionNotFound
+			}
+			return err
+		}
+		if err := tx.Where("id = ?", subscription.PlanID).First(&subscription.Plan).Error; err != nil {
+			return err
+		}
+
+		if err := change(&subscription); err != nil {
+			return err
+		}
+		return tx.Omit("Plan").Save(&subscription).Error
+	})
+	if err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to update subscription %s: %v", id, err))
+		return nil, err
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Subscription %s is %s", id, subscription.Status))
+	return &subscription, nil
+}
+
+// Run settles the charges whose payment completed and then charges the subscriptions due at now,
+// and returns the charges created or settled. Subscriptions are locked while they are charged, so
+// concurrent runs skip each other's subscriptions.
+func (s *SubscriptionService) Run(ctx context.Context, now time.Time) ([]Charge, error) {
+	var pending []string
+	if err := s.db.WithContext(ctx).Model(&Subscription{}).
+		Where("pending_payment_id IS NOT NULL").
+		Order("next_charge_at").
+		Pluck("id", &pending).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to list the pending charges: %v", err))
+		return nil, err
+	}
+
+	var charges []Charge
+	for _, id := range pending {
+		charge, err := s.settle(ctx, id)
+		if err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to settle the charge of subscription %s: %v", id, err))
+			continue
+		}
+		if charge != nil {
+			charges = append(charges, *charge)
+		}
+	}
+
+	var due []string
+	if err := s.db.WithContext(ctx).Model(&Subscription{}).
+		Where("status IN ? AND pending_payment_id IS NULL AND next_charge_at <= ?", []Status{StatusActive, StatusPastDue}, now).
+		Order("next_charge_at").
+		Pluck("id", &due).Error; err != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to list the due subscriptions: %v", err))
+		return charges, err
+	}
+
+	for _, id := range due {
+		charge, err := s.charge(ctx, id, now)
+		if err != nil {
+			utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Failed to charge subscription %s: %v", id, err))
+			continue
+		}
+		if charge != nil {
+			charges = append(charges, *charge)
+		}
+	}
+
+	utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: %d charge(s) created or settled", len(charges)))
+	return charges, nil
+}
+
+// lock loads a subscription with its plan for update within tx, or nil when another run holds it or
+// it no longer matches condition
+func lock(tx *gorm.DB, id string, condition string, args ...interface{}) (*Subscription, error) {
+	var subscriptions []Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("id = ?", id).Where(condition, args...).
+		Limit(1).
+		Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+	subscription := &subscriptions[0]
+	if err := tx.Where("id = ?", subscription.PlanID).First(&subscription.Plan).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// settle completes the pending charge of a subscription once its payment succeeded or failed, and
+// returns nil while it is still in progress
+func (s *SubscriptionService) settle(ctx context.Context, id string) (*Charge, error) {
+	var settled *Charge
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		subscription, err := lock(tx, id, "pending_payment_id IS NOT NULL")
+		if err != nil || subscription == nil {
+			return err
+		}
+
+		merchantCtx := context.WithValue(ctx, utils.ContextKeyMerchantID, subscription.MerchantID)
+		payment, err := s.payments.FindPaymentByID(merchantCtx, *subscription.PendingPaymentID)
+		if err != nil {
+			return err
+		}
+
+		var charge Charge
+		if err := tx.Where("subscription_id = ? AND payment_id = ?", id, payment.ID).First(&charge).Error; err != nil {
+			return err
+		}
+
+		if !s.complete(subscription, &charge, payment.Status, time.Now()) {
+			return nil
+		}
+		subscription.PendingPaymentID = nil
+
+		if err := tx.Save(&charge).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Plan", "Charges").Save(subscription).Error; err != nil {
+			return err
+		}
+		settled = &charge
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if settled != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Charge of period %d of subscription %s %s", settled.Period, id, settled.Status))
+	}
+	return settled, nil
+}
+
+// complete records the result of the payment of a charge on the charge and its subscription, and returns
+// false while the payment is not complete
+func (s *SubscriptionService) complete(subscription *Subscription, charge *Charge, status utils.PaymentStatus, now time.Time) bool {
+	switch status {
+	case utils.PaymentStatusSuccess:
+		charge.Status = ChargeStatusSucceeded
+		subscription.chargeSucceeded()
+	case utils.PaymentStatusFailed, utils.PaymentStatusExpired, utils.PaymentStatusCancelled:
+		charge.Status = ChargeStatusFailed
+		charge.Error = fmt.Sprintf("payment %s", strings.ToLower(string(status)))
+		subscription.chargeFailed(now, s.retrySchedule)
+	default:
+		return false
+	}
+	return true
+}
+
+// charge creates the payment of the next period of a due subscription and records its charge, and
+// returns nil when the subscription is no longer due or another run recorded the charge. The charge is
+// recorded PENDING before its payment is created, so that no transaction is held open while the provider
+// answers, and the result of the payment is recorded afterwards.
+func (s *SubscriptionService) charge(ctx context.Context, id string, now time.Time) (*Charge, error) {
+	subscription, charge, err := s.startCharge(ctx, id, now)
+	if err != nil || charge == nil {
+		return nil, err
+	}
+
+	merchantCtx := context.WithValue(ctx, utils.ContextKeyMerchantID, subscription.MerchantID)
+	payment, paymentErr := s.createPayment(merchantCtx, subscription, charge)
+
+	var recorded *Charge
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		subscription, err := lock(tx, id, "pending_payment_id IS NULL")
+		if err != nil || subscription == nil {
+			return err
+		}
+
+		// The charge is left as it is once a run recorded its payment
+		var charges []Charge
+		if err := tx.Where("id = ? AND status = ? AND payment_id IS NULL", charge.ID, ChargeStatusPending).
+			Limit(1).
+			Find(&charges).Error; err != nil {
+			return err
+		}
+		if len(charges) == 0 {
+			return nil
+		}
+		pending := &charges[0]
+
+		if paymentErr != nil {
+			pending.Status = ChargeStatusFailed
+			pending.Error = paymentErr.Error()
+			subscription.chargeFailed(now, s.retrySchedule)
+		} else {
+			pending.PaymentID = &payment.ID
+			pending.CheckoutURL = payment.CheckoutURL
+			// The payment charging a saved method may be complete already
+			if !s.complete(subscription, pending, payment.Status, now) {
+				subscription.PendingPaymentID = &payment.ID
+			}
+		}
+
+		if err := tx.Save(pending).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Plan", "Charges").Save(subscription).Error; err != nil {
+			return err
+		}
+		recorded = pending
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if recorded != nil {
+		utils.LogWithRequestID(ctx, fmt.Sprintf("SubscriptionService: Charge of period %d of subscription %s %s", recorded.Period, id, recorded.Status))
+	}
+	return recorded, nil
+}
+
+// startCharge records the PENDING charge of the next period of a due subscription, or finds the one an
+// interrupted run recorded, and returns nil when the subscription is no longer due
+func (s *SubscriptionService) startCharge(ctx context.Context, id string, now time.Time) (*Subscription, *Charge, error) {
+	var subscription *Subscription
+	var charge *Charge
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		subscription, err = lock(tx, id, "status IN ? AND pending_payment_id IS NULL AND next_charge_at <= ?",
+			[]Status{StatusActive, StatusPastDue}, now)
+		if err != nil || subscription == nil {
+			return err
+		}
+
+		var charges []Charge
+		if err := tx.Where("subscription_id = ? AND period = ? AND attempt = ?", id, subscription.NextPeriod, subscription.FailedAttempts+1).
+			Limit(1).
+			Find(&charges).Error; err != nil {
+			return err
+		}
+		if len(charges) > 0 {
+			charge = &charges[0]
+			if charge.Status != ChargeStatusPending || charge.PaymentID != nil {
+				return fmt.Errorf("charge %d of period %d was already recorded", charge.ID, charge.Period)
+			}
+			return nil
+		}
+
+		charge = &Charge{
+			SubscriptionID: id,
+			Period:         subscription.NextPeriod,
+			Attempt:        subscription.FailedAttempts + 1,
+			Status:         ChargeStatusPending,
+		}
+		return tx.Create(charge).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return subscription, charge, nil
+}
+
+// createPayment creates the deposit of a charge. Its merchant reference identifies the attempt, so a
+// payment created by an interrupted run is found instead of created twice.
+func (s *SubscriptionService) createPayment(ctx context.Context, subscription *Subscription, charge *Charge) (*payment.Payment, error) {
+	reference := fmt.Sprintf("sub-%s-%d-%d", subscription.ID, charge.Period, charge.Attempt)
+	request := &payment.PaymentRequest{
+		UserID:            subscription.UserID,
+		Amount:            subscription.Plan.Amount,
+		CurrencyCode:      subscription.Plan.CurrencyCode,
+		CountryCode:       subscription.CountryCode,
+		MerchantReference: reference,
+		Description: fmt.Sprintf("%s from %s", subscription.Plan.Name,
+			subscription.Plan.PeriodStart(subscription.StartAt, charge.Period).Format("2006-01-02")),
+		Metadata: map[string]interface{}{"subscription_id": subscription.ID, "period": charge.Period},
+		Customer: subscription.Customer,
+	}
+	if subscription.SavedMethodID != nil {
+		request.SavedMethodID = *subscription.SavedMethodID
+	}
+
+	if _, err := s.payments.CreatePayment(ctx, request, utils.PaymentTypeDeposit); err != nil && !errors.Is(err, payment.ErrDuplicateMerchantReference) {
+		return nil, err
+	}
+	return s.payments.FindPaymentByReference(ctx, reference)
+}
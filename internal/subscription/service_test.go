@@ -0,0 +1,380 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payment-gateway-service/internal/payment"
+	"payment-gateway-service/internal/savedmethod"
+	"payment-gateway-service/internal/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Helper function to create a mock GORM DB
+func setupTest(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when initializing gorm", err)
+	}
+
+	return gormDB, sqlMock, func() {
+		db.Close()
+	}
+}
+
+// MockPaymentService is a mock implementation of the PaymentServiceInterface
+type MockPaymentService struct {
+	mock.Mock
+}
+
+func (m *MockPaymentService) CreatePayment(ctx context.Context, paymentRequest *payment.PaymentRequest, paymentType utils.PaymentType) (string, error) {
+	args := m.Called(ctx, paymentRequest, paymentType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPaymentService) FindPaymentByID(ctx context.Context, id string) (*payment.Payment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*payment.Payment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPaymentService) FindPaymentByReference(ctx context.Context, reference string) (*payment.Payment, error) {
+	args := m.Called(ctx, reference)
+	if args.Get(0) != nil {
+		return args.Get(0).(*payment.Payment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// MockSavedMethodService is a mock implementation of the SavedMethodServiceInterface
+type MockSavedMethodService struct {
+	mock.Mock
+}
+
+func (m *MockSavedMethodService) MethodForCharge(ctx context.Context, id string, userID int) (*savedmethod.SavedMethod, error) {
+	args := m.Called(ctx, id, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*savedmethod.SavedMethod), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// merchantCtx carries the authenticated merchant the way the auth middleware sets it
+var merchantCtx = context.WithValue(context.TODO(), utils.ContextKeyMerchantID, uint(1))
+
+const (
+	planID         = "0b6f3c2a-8d1e-4f7a-9b5c-3e2d1a0f9c8b"
+	subscriptionID = "7c4e1a9b-2d3f-4e5a-8b6c-9d0e1f2a3b4c"
+	paymentID      = "9b2f6a4e-1c3d-4e5f-8a7b-6c5d4e3f2a1b"
+)
+
+var subscriptionColumns = []string{"id", "merchant_id", "plan_id", "user_id", "country_code", "customer_email", "status", "start_at", "next_period", "next_charge_at", "failed_attempts", "pending_payment_id"}
+
+// expectLockedSubscription expects a run to lock a monthly subscription in status, started on January
+// 15th, and to load its plan
+func expectLockedSubscription(sqlMock sqlmock.Sqlmock, status Status, nextPeriod, failedAttempts int, pendingPaymentID interface{}) {
+	start := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscriptions" WHERE id = \$1 AND .* LIMIT \$\d+ FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows(subscriptionColumns).
+			AddRow(subscriptionID, 1, planID, 7, "GB", "jane@example.com", status, start, nextPeriod, start.AddDate(0, nextPeriod, 0), failedAttempts, pendingPaymentID))
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscription_plans" WHERE id = \$1`).
+		WithArgs(planID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "merchant_id", "name", "amount", "currency_code", "interval", "interval_count"}).
+			AddRow(planID, 1, "Premium", 9.99, "GBP", IntervalMonth, 1))
+}
+
+// chargeOfAttemptQuery finds the charge a run recorded for the next attempt of a subscription
+const chargeOfAttemptQuery = `^SELECT \* FROM "subscription_charges" WHERE subscription_id = \$1 AND period = \$2 AND attempt = \$3 LIMIT \$4$`
+
+// pendingChargeQuery finds a charge whose payment was not recorded yet
+const pendingChargeQuery = `^SELECT \* FROM "subscription_charges" WHERE id = \$1 AND status = \$2 AND payment_id IS NULL LIMIT \$3$`
+
+var chargeColumns = []string{"id", "subscription_id", "period", "attempt", "payment_id", "status"}
+
+func TestCreatePlan_DefaultsToEveryInterval(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewSubscriptionService(db, nil, nil, nil)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`^INSERT INTO "subscription_plans"`).
+		WithArgs(1, "Premium", 9.99, "GBP", IntervalMonth, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(planID))
+	sqlMock.ExpectCommit()
+
+	plan, err := service.CreatePlan(merchantCtx, &CreatePlanRequest{Name: "Premium", Amount: 9.99, CurrencyCode: "gbp", Interval: IntervalMonth})
+
+	require.NoError(t, err)
+	assert.Equal(t, planID, plan.ID)
+	assert.Equal(t, 1, plan.IntervalCount)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_PlanOfAnotherMerchant(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewSubscriptionService(db, nil, nil, nil)
+
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscription_plans" WHERE id = \$1 AND merchant_id = \$2`).
+		WithArgs(planID, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	subscription, err := service.Create(merchantCtx, &CreateSubscriptionRequest{PlanID: planID, UserID: 7, CountryCode: "GB"})
+
+	assert.Nil(t, subscription)
+	assert.ErrorIs(t, err, ErrPlanNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_StartAtInPast(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewSubscriptionService(db, nil, nil, nil)
+
+	startAt := time.Now().Add(-24 * time.Hour)
+	subscription, err := service.Create(merchantCtx, &CreateSubscriptionRequest{PlanID: planID, UserID: 7, CountryCode: "GB", StartAt: &startAt})
+
+	assert.Nil(t, subscription)
+	assert.ErrorIs(t, err, ErrStartAtInPast)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPause_NoMerchant(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewSubscriptionService(db, nil, nil, nil)
+
+	_, err := service.Pause(context.TODO(), subscriptionID)
+
+	assert.ErrorIs(t, err, utils.ErrUnauthorized)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCreate_DisabledSavedMethod(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	savedMethods := new(MockSavedMethodService)
+	service := NewSubscriptionService(db, nil, savedMethods, nil)
+
+	const savedMethodID = "5b7e2c1a-9d3f-4e8b-a6c2-1f0d9e8c7b6a"
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscription_plans" WHERE id = \$1 AND merchant_id = \$2`).
+		WithArgs(planID, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(planID))
+	savedMethods.On("MethodForCharge", merchantCtx, savedMethodID, 7).Return(nil, savedmethod.ErrSavedMethodDisabled)
+
+	subscription, err := service.Create(merchantCtx, &CreateSubscriptionRequest{PlanID: planID, UserID: 7, CountryCode: "GB", SavedMethodID: savedMethodID})
+
+	assert.Nil(t, subscription)
+	assert.ErrorIs(t, err, savedmethod.ErrSavedMethodDisabled)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPause_Cancelled(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	service := NewSubscriptionService(db, nil, nil, nil)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscriptions" WHERE id = \$1 AND merchant_id = \$2 .* FOR UPDATE`).
+		WithArgs(subscriptionID, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "plan_id", "status"}).AddRow(subscriptionID, planID, StatusCancelled))
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscription_plans" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(planID))
+	sqlMock.ExpectRollback()
+
+	subscription, err := service.Pause(merchantCtx, subscriptionID)
+
+	assert.Nil(t, subscription)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRun_ChargesDueSubscription(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payments := new(MockPaymentService)
+	service := NewSubscriptionService(db, payments, nil, []time.Duration{24 * time.Hour})
+	now := time.Date(2026, time.February, 15, 6, 0, 0, 0, time.UTC)
+
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE pending_payment_id IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE status IN \(\$1,\$2\) AND pending_payment_id IS NULL AND next_charge_at <= \$3`).
+		WithArgs(StatusActive, StatusPastDue, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(subscriptionID))
+	// The PENDING charge is recorded before the payment is created
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusActive, 1, 0, nil)
+	sqlMock.ExpectQuery(chargeOfAttemptQuery).WithArgs(subscriptionID, 1, 1, 1).WillReturnRows(sqlmock.NewRows(chargeColumns))
+	sqlMock.ExpectQuery(`^INSERT INTO "subscription_charges"`).
+		WithArgs(subscriptionID, 1, 1, nil, "", ChargeStatusPending, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	sqlMock.ExpectCommit()
+
+	payments.On("CreatePayment", mock.Anything, mock.MatchedBy(func(request *payment.PaymentRequest) bool {
+		return request.MerchantReference == "sub-"+subscriptionID+"-1-1" && request.Amount == 9.99 && request.CurrencyCode == "GBP" &&
+			request.UserID == 7 && request.Description == "Premium from 2026-02-15" && request.Customer.Email == "jane@example.com"
+	}), utils.PaymentTypeDeposit).Return("https://checkout.example.com/1", nil)
+	payments.On("FindPaymentByReference", mock.MatchedBy(func(ctx context.Context) bool {
+		merchantID, _ := utils.MerchantIDFromContext(ctx)
+		return merchantID == 1
+	}), "sub-"+subscriptionID+"-1-1").Return(&payment.Payment{ID: paymentID, CheckoutURL: "https://checkout.example.com/1"}, nil)
+
+	// The payment is then recorded on the charge and the subscription
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusActive, 1, 0, nil)
+	sqlMock.ExpectQuery(pendingChargeQuery).WithArgs(1, ChargeStatusPending, 1).
+		WillReturnRows(sqlmock.NewRows(chargeColumns).AddRow(1, subscriptionID, 1, 1, nil, ChargeStatusPending))
+	sqlMock.ExpectExec(`^UPDATE "subscription_charges" SET "subscription_id"=\$1,"period"=\$2,"attempt"=\$3,"payment_id"=\$4,"checkout_url"=\$5,"status"=\$6,`).
+		WithArgs(subscriptionID, 1, 1, paymentID, "https://checkout.example.com/1", ChargeStatusPending, "", sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`^UPDATE "subscriptions" SET .*"pending_payment_id"=\$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	charges, err := service.Run(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, ChargeStatusPending, charges[0].Status)
+	assert.Equal(t, paymentID, *charges[0].PaymentID)
+	payments.AssertExpectations(t)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRun_FailedChargeIsRetried(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payments := new(MockPaymentService)
+	service := NewSubscriptionService(db, payments, nil, []time.Duration{24 * time.Hour})
+	now := time.Date(2026, time.February, 15, 6, 0, 0, 0, time.UTC)
+
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE pending_payment_id IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE status IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(subscriptionID))
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusActive, 1, 0, nil)
+	sqlMock.ExpectQuery(chargeOfAttemptQuery).WithArgs(subscriptionID, 1, 1, 1).WillReturnRows(sqlmock.NewRows(chargeColumns))
+	sqlMock.ExpectQuery(`^INSERT INTO "subscription_charges"`).
+		WithArgs(subscriptionID, 1, 1, nil, "", ChargeStatusPending, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	sqlMock.ExpectCommit()
+
+	payments.On("CreatePayment", mock.Anything, mock.Anything, utils.PaymentTypeDeposit).Return("", errors.New("no provider available"))
+
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusActive, 1, 0, nil)
+	sqlMock.ExpectQuery(pendingChargeQuery).WithArgs(1, ChargeStatusPending, 1).
+		WillReturnRows(sqlmock.NewRows(chargeColumns).AddRow(1, subscriptionID, 1, 1, nil, ChargeStatusPending))
+	sqlMock.ExpectExec(`^UPDATE "subscription_charges" SET`).
+		WithArgs(subscriptionID, 1, 1, nil, "", ChargeStatusFailed, "no provider available", sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`^UPDATE "subscriptions" SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	charges, err := service.Run(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, ChargeStatusFailed, charges[0].Status)
+	assert.Equal(t, "no provider available", charges[0].Error)
+	payments.AssertNotCalled(t, "FindPaymentByReference", mock.Anything, mock.Anything)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRun_ResumesInterruptedCharge(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payments := new(MockPaymentService)
+	service := NewSubscriptionService(db, payments, nil, []time.Duration{24 * time.Hour})
+	now := time.Date(2026, time.February, 15, 6, 0, 0, 0, time.UTC)
+
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE pending_payment_id IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE status IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(subscriptionID))
+
+	// An interrupted run recorded the charge and created its payment, which is found by its reference
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusActive, 1, 0, nil)
+	sqlMock.ExpectQuery(chargeOfAttemptQuery).WithArgs(subscriptionID, 1, 1, 1).
+		WillReturnRows(sqlmock.NewRows(chargeColumns).AddRow(3, subscriptionID, 1, 1, nil, ChargeStatusPending))
+	sqlMock.ExpectCommit()
+
+	payments.On("CreatePayment", mock.Anything, mock.Anything, utils.PaymentTypeDeposit).Return("", payment.ErrDuplicateMerchantReference)
+	payments.On("FindPaymentByReference", mock.Anything, "sub-"+subscriptionID+"-1-1").
+		Return(&payment.Payment{ID: paymentID, Status: utils.PaymentStatusPending, CheckoutURL: "https://checkout.example.com/1"}, nil)
+
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusActive, 1, 0, nil)
+	sqlMock.ExpectQuery(pendingChargeQuery).WithArgs(3, ChargeStatusPending, 1).
+		WillReturnRows(sqlmock.NewRows(chargeColumns).AddRow(3, subscriptionID, 1, 1, nil, ChargeStatusPending))
+	sqlMock.ExpectExec(`^UPDATE "subscription_charges" SET`).
+		WithArgs(subscriptionID, 1, 1, paymentID, "https://checkout.example.com/1", ChargeStatusPending, "", sqlmock.AnyArg(), sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`^UPDATE "subscriptions" SET .*"pending_payment_id"=\$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	charges, err := service.Run(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, uint(3), charges[0].ID)
+	assert.Equal(t, paymentID, *charges[0].PaymentID)
+	payments.AssertExpectations(t)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRun_SettlesSucceededCharge(t *testing.T) {
+	db, sqlMock, teardown := setupTest(t)
+	defer teardown()
+	payments := new(MockPaymentService)
+	service := NewSubscriptionService(db, payments, nil, nil)
+	now := time.Date(2026, time.February, 15, 6, 30, 0, 0, time.UTC)
+
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE pending_payment_id IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(subscriptionID))
+	sqlMock.ExpectBegin()
+	expectLockedSubscription(sqlMock, StatusPastDue, 1, 1, paymentID)
+
+	payments.On("FindPaymentByID", mock.Anything, paymentID).Return(&payment.Payment{ID: paymentID, Status: utils.PaymentStatusSuccess}, nil)
+
+	sqlMock.ExpectQuery(`^SELECT \* FROM "subscription_charges" WHERE subscription_id = \$1 AND payment_id = \$2`).
+		WithArgs(subscriptionID, paymentID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "period", "attempt", "payment_id", "status"}).
+			AddRow(2, subscriptionID, 1, 2, paymentID, ChargeStatusPending))
+	sqlMock.ExpectExec(`^UPDATE "subscription_charges" SET .*"status"=\$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`^UPDATE "subscriptions" SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectQuery(`^SELECT "id" FROM "subscriptions" WHERE status IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	charges, err := service.Run(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, ChargeStatusSucceeded, charges[0].Status)
+	payments.AssertExpectations(t)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "context"
+
+// Context keys set by the authentication middlewares. They are plain strings so they can be
+// read both from the gin context and from the context handed down to the services.
+const (
+	ContextKeyMerchantID    = "MerchantID"
+	ContextKeyAPIKeyID      = "APIKeyID"
+	ContextKeyScopes        = "Scopes"
+	ContextKeyOperatorKeyID = "OperatorKeyID"
+)
+
+// MerchantIDFromContext returns the ID of the authenticated merchant stored in the context.
+func MerchantIDFromContext(ctx context.Context) (uint, bool) {
+	merchantID, ok := ctx.Value(ContextKeyMerchantID).(uint)
+	return merchantID, ok && merchantID != 0
+}
+
+// RequireMerchantID returns the ID of the authenticated merchant stored in the context, or ErrUnauthorized
+// when there is none, so that nothing is ever created for merchant 0.
+func RequireMerchantID(ctx context.Context) (uint, error) {
+	merchantID, ok := MerchantIDFromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthorized
+	}
+	return merchantID, nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "net/http"
+
+// Stable machine-readable error codes returned in APIResponse.Code
+const (
+	ErrCodeBadRequest        = "bad_request"
+	ErrCodeValidationFailed  = "validation_failed"
+	ErrCodeUnauthorized      = "unauthorized"
+	ErrCodeInsufficientScope = "insufficient_scope"
+	ErrCodeNotFound          = "not_found"
+	ErrCodeRateLimited       = "rate_limited"
+	ErrCodeInternal          = "internal_error"
+)
+
+// APIError is a domain error that knows how it should be presented to API clients.
+// Handlers attach it (or an error wrapping it) to the gin context and the error
+// middleware turns it into an APIResponse with the given status and code.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+// NewAPIError creates a new APIError
+func NewAPIError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// ErrInternal is used for any error that does not carry its own APIError
+var ErrInternal = NewAPIError(http.StatusInternalServerError, ErrCodeInternal, "Internal server error")
+
+// ErrUnauthorized is returned by services that require an authenticated merchant when there is none
+var ErrUnauthorized = NewAPIError(http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
@@ -11,23 +11,25 @@ import (
 // APIResponse is the structure for all API responses
 type APIResponse struct {
 	Status  string              `json:"status"`
+	Code    string              `json:"code,omitempty"`
 	Message string              `json:"message,omitempty"`
 	Errors  map[string][]string `json:"errors,omitempty"`
 	Data    interface{}         `json:"data,omitempty"`
 }
 
-// ErrorResponse sends a JSON error response with a specific status code and aborts the request
-func ErrorResponse(c *gin.Context, statusCode int, message string, errors map[string][]string) {
+// ErrorResponse sends a JSON error response with a specific status code and error code and aborts the request
+func ErrorResponse(c *gin.Context, statusCode int, code string, message string, errors map[string][]string) {
 	requestID := c.GetString("RequestID")
 
 	// Prepare error details as JSON string for logging
 	errorDetails, _ := json.Marshal(errors)
 
-	logMessage := fmt.Sprintf("Request ID: %s - Sending error response: %s, Errors: %s", requestID, message, string(errorDetails))
+	logMessage := fmt.Sprintf("Request ID: %s - Sending error response: %s (%s), Errors: %s", requestID, message, code, string(errorDetails))
 	log.Println(logMessage)
 
 	c.JSON(statusCode, APIResponse{
 		Status:  "error",
+		Code:    code,
 		Message: message,
 		Errors:  errors,
 	})
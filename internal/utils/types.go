@@ -16,6 +16,14 @@ const (
 	PaymentStatusPending     PaymentStatus = "PENDING"
 	PaymentStatusSuccess     PaymentStatus = "SUCCESS"
 	PaymentStatusFailed      PaymentStatus = "FAILED"
+	PaymentStatusExpired     PaymentStatus = "EXPIRED"
+	PaymentStatusCancelled   PaymentStatus = "CANCELLED"
+
+	// Deposits made with manual capture are AUTHORIZED once the provider reserved the funds,
+	// then CAPTURED or VOIDED by the merchant
+	PaymentStatusAuthorized PaymentStatus = "AUTHORIZED"
+	PaymentStatusCaptured   PaymentStatus = "CAPTURED"
+	PaymentStatusVoided     PaymentStatus = "VOIDED"
 )
 
 // Define the error for invalid transaction type
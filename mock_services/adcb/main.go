@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,6 +20,10 @@ type PaymentRequest struct {
 	PaymentType string   `xml:"PaymentType"`
 	Currency    string   `xml:"Currency"`
 	Country     string   `xml:"Country"`
+	Reference   string   `xml:"Reference"`
+	Description string   `xml:"Description"`
+	SaveMethod  bool     `xml:"SaveMethod"`
+	CaptureMode string   `xml:"CaptureMode"`
 }
 
 // PaymentResponse represents the structure of the payment response
@@ -25,6 +33,120 @@ type PaymentResponse struct {
 	ExternalID string   `xml:"ExternalID"`
 }
 
+// CancelRequest represents the structure of the cancel request
+type CancelRequest struct {
+	XMLName    xml.Name `xml:"CancelRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// CancelResponse represents the structure of the cancel response
+type CancelResponse struct {
+	XMLName    xml.Name `xml:"CancelResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// PayoutRequest represents the structure of the payout request
+type PayoutRequest struct {
+	XMLName       xml.Name `xml:"PayoutRequest"`
+	Amount        float64  `xml:"Amount"`
+	Currency      string   `xml:"Currency"`
+	Country       string   `xml:"Country"`
+	Reference     string   `xml:"Reference"`
+	RequestID     string   `xml:"RequestID"`
+	HolderName    string   `xml:"Beneficiary>HolderName"`
+	IBAN          string   `xml:"Beneficiary>IBAN"`
+	AccountNumber string   `xml:"Beneficiary>AccountNumber"`
+	BankCode      string   `xml:"Beneficiary>BankCode"`
+}
+
+// PayoutStatusRequest represents the structure of the payout status request
+type PayoutStatusRequest struct {
+	XMLName    xml.Name `xml:"PayoutStatusRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// PayoutResponse represents the structure of the payout and payout status responses
+type PayoutResponse struct {
+	XMLName    xml.Name `xml:"PayoutResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// payoutDelay is the time a payout stays pending before it is settled
+const payoutDelay = 5 * time.Second
+
+// payouts holds the status of the payouts received, by external ID
+var payouts sync.Map
+
+// payoutRequests holds the external ID of the payouts received, by request ID, so a payout sent twice is paid once
+var payoutRequests sync.Map
+
+// TokenRequest represents the structure of the payment token request
+type TokenRequest struct {
+	XMLName    xml.Name `xml:"TokenRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// TokenResponse represents the structure of the payment token response
+type TokenResponse struct {
+	XMLName xml.Name `xml:"TokenResponse"`
+	Token   string   `xml:"Token"`
+}
+
+// ChargeRequest represents the structure of the charge request of a token
+type ChargeRequest struct {
+	XMLName     xml.Name `xml:"ChargeRequest"`
+	Amount      float64  `xml:"Amount"`
+	Currency    string   `xml:"Currency"`
+	Country     string   `xml:"Country"`
+	Reference   string   `xml:"Reference"`
+	Description string   `xml:"Description"`
+	Token       string   `xml:"Token"`
+	CaptureMode string   `xml:"CaptureMode"`
+}
+
+// ChargeResponse represents the structure of the charge response
+type ChargeResponse struct {
+	XMLName    xml.Name `xml:"ChargeResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// chargeLimit is the amount above which charges of tokens are declined
+const chargeLimit = 5000
+
+// paymentTokens holds the tokens issued for the payments made with SaveMethod, by external ID
+var paymentTokens sync.Map
+
+// tokens holds the tokens issued
+var tokens sync.Map
+
+// CaptureRequest represents the structure of the capture request of an authorized payment
+type CaptureRequest struct {
+	XMLName    xml.Name `xml:"CaptureRequest"`
+	ExternalID string   `xml:"ExternalID"`
+	Amount     float64  `xml:"Amount"`
+	Currency   string   `xml:"Currency"`
+}
+
+// VoidRequest represents the structure of the void request of an authorized payment
+type VoidRequest struct {
+	XMLName    xml.Name `xml:"VoidRequest"`
+	ExternalID string   `xml:"ExternalID"`
+}
+
+// AuthorizationResponse represents the structure of the capture and void responses
+type AuthorizationResponse struct {
+	XMLName    xml.Name `xml:"AuthorizationResponse"`
+	ExternalID string   `xml:"ExternalID"`
+	Status     string   `xml:"Status"`
+}
+
+// authorizations holds the amount authorized for the payments and charges made with manual capture, by
+// external ID, until they are captured or voided
+var authorizations sync.Map
+
 // CallbackRequest represents the structure of the callback request
 type CallbackRequest struct {
 	XMLName    xml.Name `xml:"CallbackRequest"`
@@ -34,7 +156,14 @@ type CallbackRequest struct {
 
 func main() {
 	http.HandleFunc("/adcb/payment", handleADCMPayment)
+	http.HandleFunc("/adcb/payment/cancel", handleADCBCancel)
+	http.HandleFunc("/adcb/payment/token", handleADCBPaymentToken)
+	http.HandleFunc("/adcb/payment/capture", handleADCBCapture)
+	http.HandleFunc("/adcb/payment/void", handleADCBVoid)
+	http.HandleFunc("/adcb/charge", handleADCBCharge)
 	http.HandleFunc("/adcb/callback", handleADCBCallback)
+	http.HandleFunc("/adcb/payout", handleADCBPayout)
+	http.HandleFunc("/adcb/payout/status", handleADCBPayoutStatus)
 	log.Println("ADCB Mock Service running on port 8082")
 	log.Fatal(http.ListenAndServe(":8082", nil))
 }
@@ -66,6 +195,16 @@ func handleADCMPayment(w http.ResponseWriter, r *http.Request) {
 	// Simulate generating a URL for the payment
 	paymentURL := fmt.Sprintf("http://localhost:8082/adcb/callback?external_id=%s", externalID)
 
+	// The payments of this mock always succeed, the token of the method is issued right away
+	if paymentRequest.SaveMethod {
+		token := "tok_" + uuid.New().String()
+		tokens.Store(token, true)
+		paymentTokens.Store(externalID, token)
+	}
+	if paymentRequest.CaptureMode == "MANUAL" {
+		authorizations.Store(externalID, paymentRequest.Amount)
+	}
+
 	// Respond with the payment URL and external ID
 	response := PaymentResponse{
 		URL:        paymentURL,
@@ -82,6 +221,7 @@ func handleADCMPayment(w http.ResponseWriter, r *http.Request) {
 	w.Write(responseXML)
 
 	log.Printf("Payment request received: User ID: %s, Amount: %.2f, PaymentType: %s, Currency: %s, Country: %s", userID, paymentRequest.Amount, paymentRequest.PaymentType, paymentRequest.Currency, paymentRequest.Country)
+	log.Printf("Reference: %q, Description: %q", paymentRequest.Reference, paymentRequest.Description)
 	log.Printf("Generated payment URL: %s and External ID: %s", paymentURL, externalID)
 }
 
@@ -99,3 +239,295 @@ func handleADCBCallback(w http.ResponseWriter, r *http.Request) {
 	//Redirect
 	http.Redirect(w, r, callbackURL, http.StatusFound)
 }
+
+// handleADCBCancel handles the cancel request of a payment for ADCB
+func handleADCBCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var cancelRequest CancelRequest
+	if err := xml.NewDecoder(r.Body).Decode(&cancelRequest); err != nil || cancelRequest.ExternalID == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	responseXML, err := xml.MarshalIndent(CancelResponse{ExternalID: cancelRequest.ExternalID, Status: "CANCELLED"}, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(responseXML)
+
+	log.Printf("Cancel request received for External ID: %s", cancelRequest.ExternalID)
+}
+
+// handleADCBPaymentToken returns the token issued for a payment made with SaveMethod
+func handleADCBPaymentToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var tokenRequest TokenRequest
+	if err := xml.NewDecoder(r.Body).Decode(&tokenRequest); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	token, ok := paymentTokens.Load(tokenRequest.ExternalID)
+	if !ok {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	responseXML, err := xml.MarshalIndent(TokenResponse{Token: token.(string)}, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(responseXML)
+
+	log.Printf("Token request received for External ID: %s", tokenRequest.ExternalID)
+}
+
+// handleADCBCharge charges a token right away, with no redirect. Charges above chargeLimit are declined.
+func handleADCBCharge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var chargeRequest ChargeRequest
+	if err := xml.NewDecoder(r.Body).Decode(&chargeRequest); err != nil || chargeRequest.Token == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if _, ok := tokens.Load(chargeRequest.Token); !ok {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	externalID := uuid.New().String()
+	status := "APPROVED"
+	if chargeRequest.Amount > chargeLimit {
+		status = "DECLINED"
+	} else if chargeRequest.CaptureMode == "MANUAL" {
+		status = "AUTHORIZED"
+		authorizations.Store(externalID, chargeRequest.Amount)
+	}
+
+	responseXML, err := xml.MarshalIndent(ChargeResponse{ExternalID: externalID, Status: status}, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(responseXML)
+
+	log.Printf("Charge request received: Amount: %.2f, Currency: %s, Country: %s, Reference: %q, External ID: %s, Status: %s", chargeRequest.Amount, chargeRequest.Currency, chargeRequest.Country, chargeRequest.Reference, externalID, status)
+}
+
+// handleADCBCapture captures up to the authorized amount of a payment made with manual capture
+func handleADCBCapture(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var captureRequest CaptureRequest
+	if err := xml.NewDecoder(r.Body).Decode(&captureRequest); err != nil || captureRequest.ExternalID == "" || captureRequest.Amount <= 0 {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// An authorization is captured or voided once
+	authorized, ok := authorizations.LoadAndDelete(captureRequest.ExternalID)
+	if !ok {
+		http.Error(w, "Authorization not found", http.StatusNotFound)
+		return
+	}
+	if captureRequest.Amount > authorized.(float64) {
+		authorizations.Store(captureRequest.ExternalID, authorized)
+		http.Error(w, "Capture amount exceeds the authorized amount", http.StatusUnprocessableEntity)
+		return
+	}
+
+	writeAuthorizationResponse(w, AuthorizationResponse{ExternalID: captureRequest.ExternalID, Status: "CAPTURED"})
+
+	log.Printf("Capture request received: External ID: %s, Amount: %.2f of %.2f %s", captureRequest.ExternalID, captureRequest.Amount, authorized.(float64), captureRequest.Currency)
+}
+
+// handleADCBVoid releases the authorization of a payment made with manual capture
+func handleADCBVoid(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var voidRequest VoidRequest
+	if err := xml.NewDecoder(r.Body).Decode(&voidRequest); err != nil || voidRequest.ExternalID == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := authorizations.LoadAndDelete(voidRequest.ExternalID); !ok {
+		http.Error(w, "Authorization not found", http.StatusNotFound)
+		return
+	}
+
+	writeAuthorizationResponse(w, AuthorizationResponse{ExternalID: voidRequest.ExternalID, Status: "VOIDED"})
+
+	log.Printf("Void request received for External ID: %s", voidRequest.ExternalID)
+}
+
+// writeAuthorizationResponse writes a capture or void response as XML
+func writeAuthorizationResponse(w http.ResponseWriter, response AuthorizationResponse) {
+	responseXML, err := xml.MarshalIndent(response, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(responseXML)
+}
+
+// handleADCBPayout accepts a payout and settles it in the background. Payouts to accounts ending in 0000 are rejected.
+func handleADCBPayout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var payoutRequest PayoutRequest
+	if err := xml.NewDecoder(r.Body).Decode(&payoutRequest); err != nil || payoutRequest.HolderName == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	externalID := uuid.New().String()
+	if payoutRequest.RequestID != "" {
+		if existing, loaded := payoutRequests.LoadOrStore(payoutRequest.RequestID, externalID); loaded {
+			writePayoutResponse(w, PayoutResponse{ExternalID: existing.(string), Status: "PENDING"})
+			log.Printf("Payout request %s received again, External ID: %s", payoutRequest.RequestID, existing)
+			return
+		}
+	}
+	payouts.Store(externalID, "PENDING")
+
+	status := "PAID"
+	if strings.HasSuffix(payoutRequest.IBAN+payoutRequest.AccountNumber, "0000") {
+		status = "REJECTED"
+	}
+	go settlePayout(externalID, status)
+
+	writePayoutResponse(w, PayoutResponse{ExternalID: externalID, Status: "PENDING"})
+
+	log.Printf("Payout request received: Amount: %.2f, Currency: %s, Country: %s, Reference: %q, External ID: %s", payoutRequest.Amount, payoutRequest.Currency, payoutRequest.Country, payoutRequest.Reference, externalID)
+}
+
+// handleADCBPayoutStatus returns the status of a payout
+func handleADCBPayoutStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var statusRequest PayoutStatusRequest
+	if err := xml.NewDecoder(r.Body).Decode(&statusRequest); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := payouts.Load(statusRequest.ExternalID)
+	if !ok {
+		http.Error(w, "Payout not found", http.StatusNotFound)
+		return
+	}
+
+	writePayoutResponse(w, PayoutResponse{ExternalID: statusRequest.ExternalID, Status: status.(string)})
+}
+
+// writePayoutResponse writes a payout response as XML
+func writePayoutResponse(w http.ResponseWriter, response PayoutResponse) {
+	responseXML, err := xml.MarshalIndent(response, "", "  ")
+	if err != nil {
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(responseXML)
+}
+
+// settlePayout settles a payout after a delay and notifies the payment service of the result
+func settlePayout(externalID, status string) {
+	time.Sleep(payoutDelay)
+	payouts.Store(externalID, status)
+
+	result := "success"
+	if status == "REJECTED" {
+		result = "failed"
+	}
+	callbackURL := fmt.Sprintf("%s/payment/callbacks/%s/%s", gatewayURL(), result, externalID)
+
+	// The payment service answers callbacks with a redirect meant for the customer, which is not followed
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(callbackURL)
+	if err != nil {
+		log.Printf("Payout callback for External ID %s failed: %v", externalID, err)
+		return
+	}
+	resp.Body.Close()
+	log.Printf("Payout %s settled as %s, callback answered with status %d", externalID, status, resp.StatusCode)
+}
+
+// gatewayURL is the base URL of the payment service, configurable with GATEWAY_URL
+func gatewayURL() string {
+	if url := os.Getenv("GATEWAY_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "http://localhost:8080"
+}
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -15,6 +19,15 @@ type PaymentRequest struct {
 	PaymentType string  `json:"payment_type"`
 	Currency    string  `json:"currency"`
 	Country     string  `json:"country"`
+	Reference   string  `json:"reference"`
+	Description string  `json:"description"`
+	SaveMethod  bool    `json:"save_method"`
+	CaptureMode string  `json:"capture_mode"`
+	Customer    struct {
+		Email  string `json:"email"`
+		Name   string `json:"name"`
+		Locale string `json:"locale"`
+	} `json:"customer"`
 }
 
 // PaymentResponse represents the structure of the payment response
@@ -23,6 +36,83 @@ type PaymentResponse struct {
 	ExternalID string `json:"external_id"`
 }
 
+// CancelRequest represents the structure of the cancel request
+type CancelRequest struct {
+	ExternalID string `json:"external_id"`
+}
+
+// PayoutRequest represents the structure of the payout request
+type PayoutRequest struct {
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Country     string  `json:"country"`
+	Reference   string  `json:"reference"`
+	RequestID   string  `json:"request_id"`
+	Beneficiary struct {
+		HolderName    string `json:"holder_name"`
+		IBAN          string `json:"iban"`
+		AccountNumber string `json:"account_number"`
+		BankCode      string `json:"bank_code"`
+	} `json:"beneficiary"`
+}
+
+// PayoutResponse represents the structure of the payout and payout status responses
+type PayoutResponse struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+// payoutDelay is the time a payout stays pending before it is settled
+const payoutDelay = 5 * time.Second
+
+// payouts holds the status of the payouts received, by external ID
+var payouts sync.Map
+
+// payoutRequests holds the external ID of the payouts received, by request ID, so a payout sent twice is paid once
+var payoutRequests sync.Map
+
+// TokenResponse represents the structure of the payment token response
+type TokenResponse struct {
+	Token string `json:"token"`
+}
+
+// ChargeRequest represents the structure of the charge request of a token
+type ChargeRequest struct {
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Country     string  `json:"country"`
+	Reference   string  `json:"reference"`
+	Description string  `json:"description"`
+	Token       string  `json:"token"`
+	CaptureMode string  `json:"capture_mode"`
+}
+
+// ChargeResponse represents the structure of the charge response
+type ChargeResponse struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+// chargeLimit is the amount above which charges of tokens are declined
+const chargeLimit = 5000
+
+// paymentTokens holds the tokens issued for the payments made with save_method, by external ID
+var paymentTokens sync.Map
+
+// tokens holds the tokens issued
+var tokens sync.Map
+
+// CaptureRequest represents the structure of the capture request of an authorized payment
+type CaptureRequest struct {
+	ExternalID string  `json:"external_id"`
+	Amount     float64 `json:"amount"`
+	Currency   string  `json:"currency"`
+}
+
+// authorizations holds the amount authorized for the payments and charges made with manual capture, by
+// external ID, until they are captured or voided
+var authorizations sync.Map
+
 // CallbackRequest represents the structure of the callback request
 type CallbackRequest struct {
 	ExternalID string `json:"external_id"`
@@ -31,7 +121,14 @@ type CallbackRequest struct {
 
 func main() {
 	http.HandleFunc("/hsbc/payment", handleHSBCPayment)
+	http.HandleFunc("/hsbc/payment/cancel", handleHSBCCancel)
+	http.HandleFunc("/hsbc/payment/token", handleHSBCPaymentToken)
+	http.HandleFunc("/hsbc/payment/capture", handleHSBCCapture)
+	http.HandleFunc("/hsbc/payment/void", handleHSBCVoid)
+	http.HandleFunc("/hsbc/charge", handleHSBCCharge)
 	http.HandleFunc("/hsbc/callback", handleHSBCCallback)
+	http.HandleFunc("/hsbc/payout", handleHSBCPayout)
+	http.HandleFunc("/hsbc/payout/status", handleHSBCPayoutStatus)
 	log.Println("HSBC Mock Service running on port 8081")
 	log.Fatal(http.ListenAndServe(":8081", nil))
 }
@@ -63,6 +160,16 @@ func handleHSBCPayment(w http.ResponseWriter, r *http.Request) {
 	// Simulate generating a URL for the payment
 	paymentURL := fmt.Sprintf("http://localhost:8081/hsbc/callback?external_id=%s", externalID)
 
+	// The payments of this mock always succeed, the token of the method is issued right away
+	if paymentRequest.SaveMethod {
+		token := "tok_" + uuid.New().String()
+		tokens.Store(token, true)
+		paymentTokens.Store(externalID, token)
+	}
+	if paymentRequest.CaptureMode == "manual" {
+		authorizations.Store(externalID, paymentRequest.Amount)
+	}
+
 	// Respond with the payment URL and external ID
 	response := PaymentResponse{
 		URL:        paymentURL,
@@ -73,6 +180,7 @@ func handleHSBCPayment(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 
 	log.Printf("Payment request received: User ID: %s, Amount: %.2f, PaymentType: %s, Currency: %s, Country: %s", userID, paymentRequest.Amount, paymentRequest.PaymentType, paymentRequest.Currency, paymentRequest.Country)
+	log.Printf("Reference: %q, Description: %q, Customer: %+v", paymentRequest.Reference, paymentRequest.Description, paymentRequest.Customer)
 	log.Printf("Generated payment URL: %s and External ID: %s", paymentURL, externalID)
 }
 
@@ -90,3 +198,238 @@ func handleHSBCCallback(w http.ResponseWriter, r *http.Request) {
 	//Redirect
 	http.Redirect(w, r, callbackURL, http.StatusFound)
 }
+
+// handleHSBCCancel handles the cancel request of a payment
+func handleHSBCCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var cancelRequest CancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&cancelRequest); err != nil || cancelRequest.ExternalID == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"external_id": cancelRequest.ExternalID, "status": "CANCELLED"})
+
+	log.Printf("Cancel request received for External ID: %s", cancelRequest.ExternalID)
+}
+
+// handleHSBCPaymentToken returns the token issued for a payment made with save_method
+func handleHSBCPaymentToken(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	externalID := r.URL.Query().Get("external_id")
+	token, ok := paymentTokens.Load(externalID)
+	if !ok {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TokenResponse{Token: token.(string)})
+
+	log.Printf("Token request received for External ID: %s", externalID)
+}
+
+// handleHSBCCharge charges a token right away, with no redirect. Charges above chargeLimit are declined.
+func handleHSBCCharge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var chargeRequest ChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&chargeRequest); err != nil || chargeRequest.Token == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if _, ok := tokens.Load(chargeRequest.Token); !ok {
+		http.Error(w, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	externalID := uuid.New().String()
+	status := "COMPLETED"
+	if chargeRequest.Amount > chargeLimit {
+		status = "DECLINED"
+	} else if chargeRequest.CaptureMode == "manual" {
+		status = "AUTHORIZED"
+		authorizations.Store(externalID, chargeRequest.Amount)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChargeResponse{ExternalID: externalID, Status: status})
+
+	log.Printf("Charge request received: Amount: %.2f, Currency: %s, Country: %s, Reference: %q, External ID: %s, Status: %s", chargeRequest.Amount, chargeRequest.Currency, chargeRequest.Country, chargeRequest.Reference, externalID, status)
+}
+
+// handleHSBCCapture captures up to the authorized amount of a payment made with manual capture
+func handleHSBCCapture(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var captureRequest CaptureRequest
+	if err := json.NewDecoder(r.Body).Decode(&captureRequest); err != nil || captureRequest.ExternalID == "" || captureRequest.Amount <= 0 {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// An authorization is captured or voided once
+	authorized, ok := authorizations.LoadAndDelete(captureRequest.ExternalID)
+	if !ok {
+		http.Error(w, "Authorization not found", http.StatusNotFound)
+		return
+	}
+	if captureRequest.Amount > authorized.(float64) {
+		authorizations.Store(captureRequest.ExternalID, authorized)
+		http.Error(w, "Capture amount exceeds the authorized amount", http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"external_id": captureRequest.ExternalID, "status": "CAPTURED"})
+
+	log.Printf("Capture request received: External ID: %s, Amount: %.2f of %.2f %s", captureRequest.ExternalID, captureRequest.Amount, authorized.(float64), captureRequest.Currency)
+}
+
+// handleHSBCVoid releases the authorization of a payment made with manual capture
+func handleHSBCVoid(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var voidRequest CancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&voidRequest); err != nil || voidRequest.ExternalID == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := authorizations.LoadAndDelete(voidRequest.ExternalID); !ok {
+		http.Error(w, "Authorization not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"external_id": voidRequest.ExternalID, "status": "VOIDED"})
+
+	log.Printf("Void request received for External ID: %s", voidRequest.ExternalID)
+}
+
+// handleHSBCPayout accepts a payout and settles it in the background. Payouts to accounts ending in 0000 fail.
+func handleHSBCPayout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	var payoutRequest PayoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&payoutRequest); err != nil || payoutRequest.Beneficiary.HolderName == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	externalID := uuid.New().String()
+	if payoutRequest.RequestID != "" {
+		if existing, loaded := payoutRequests.LoadOrStore(payoutRequest.RequestID, externalID); loaded {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(PayoutResponse{ExternalID: existing.(string), Status: "PENDING"})
+			log.Printf("Payout request %s received again, External ID: %s", payoutRequest.RequestID, existing)
+			return
+		}
+	}
+	payouts.Store(externalID, "PENDING")
+
+	status := "COMPLETED"
+	if strings.HasSuffix(payoutRequest.Beneficiary.IBAN+payoutRequest.Beneficiary.AccountNumber, "0000") {
+		status = "FAILED"
+	}
+	go settlePayout(externalID, status)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PayoutResponse{ExternalID: externalID, Status: "PENDING"})
+
+	log.Printf("Payout request received: Amount: %.2f, Currency: %s, Country: %s, Reference: %q, External ID: %s", payoutRequest.Amount, payoutRequest.Currency, payoutRequest.Country, payoutRequest.Reference, externalID)
+}
+
+// handleHSBCPayoutStatus returns the status of a payout
+func handleHSBCPayoutStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("user_id") == "" || r.Header.Get("user_secret") == "" {
+		http.Error(w, "Missing required headers: user_id or user_secret", http.StatusBadRequest)
+		return
+	}
+
+	externalID := r.URL.Query().Get("external_id")
+	status, ok := payouts.Load(externalID)
+	if !ok {
+		http.Error(w, "Payout not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PayoutResponse{ExternalID: externalID, Status: status.(string)})
+}
+
+// settlePayout settles a payout after a delay and notifies the payment service of the result
+func settlePayout(externalID, status string) {
+	time.Sleep(payoutDelay)
+	payouts.Store(externalID, status)
+
+	result := "success"
+	if status == "FAILED" {
+		result = "failed"
+	}
+	callbackURL := fmt.Sprintf("%s/payment/callbacks/%s/%s", gatewayURL(), result, externalID)
+
+	// The payment service answers callbacks with a redirect meant for the customer, which is not followed
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(callbackURL)
+	if err != nil {
+		log.Printf("Payout callback for External ID %s failed: %v", externalID, err)
+		return
+	}
+	resp.Body.Close()
+	log.Printf("Payout %s settled as %s, callback answered with status %d", externalID, status, resp.StatusCode)
+}
+
+// gatewayURL is the base URL of the payment service, configurable with GATEWAY_URL
+func gatewayURL() string {
+	if url := os.Getenv("GATEWAY_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "http://localhost:8080"
+}